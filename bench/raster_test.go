@@ -0,0 +1,66 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bench has benchmarks of rendering performance -- see bench.md for
+// results and notes.
+package bench
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/goki/gi/gi"
+)
+
+// renderPlot renders a data-heavy plot, with many long polylines, filled
+// areas and markers, into rs
+func renderPlot(rs *gi.RenderState) {
+	pc := &rs.Paint
+	sz := rs.Image.Bounds().Size()
+	w, h := float32(sz.X), float32(sz.Y)
+	rnd := rand.New(rand.NewSource(1))
+	pc.FillStyle.Color.SetColor(color.White)
+	pc.DrawRectangle(rs, 0, 0, w, h)
+	pc.Fill(rs)
+	for s := 0; s < 8; s++ {
+		pc.FillStyle.Color.SetColor(color.RGBA{uint8(30 * s), 100, uint8(255 - 30*s), 60})
+		pc.StrokeStyle.Color.SetColor(color.RGBA{uint8(30 * s), 50, uint8(255 - 30*s), 255})
+		pc.StrokeStyle.Width.Dots = 2
+		pc.MoveTo(rs, 0, h)
+		for i := 0; i <= 2000; i++ {
+			x := w * float32(i) / 2000
+			y := h * (0.5 + 0.3*float32(math.Sin(float64(i)*0.01+float64(s))) + 0.05*rnd.Float32())
+			pc.LineTo(rs, x, y)
+		}
+		pc.LineTo(rs, w, h)
+		pc.ClosePath(rs)
+		pc.FillPreserve(rs)
+		pc.Stroke(rs)
+	}
+	pc.FillStyle.Color.SetColor(color.RGBA{200, 0, 0, 128})
+	for i := 0; i < 2000; i++ {
+		pc.DrawCircle(rs, rnd.Float32()*w, rnd.Float32()*h, 4)
+	}
+	pc.Fill(rs)
+}
+
+// benchRaster benchmarks rendering the plot with given tile size (0 = serial)
+func benchRaster(b *testing.B, tileSize int) {
+	img := image.NewRGBA(image.Rect(0, 0, 1920, 1080))
+	rs := &gi.RenderState{}
+	rs.Init(1920, 1080, img)
+	rs.TileSize = tileSize
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		renderPlot(rs)
+	}
+}
+
+func BenchmarkRasterSerial(b *testing.B)   { benchRaster(b, 0) }
+func BenchmarkRasterTiled64(b *testing.B)  { benchRaster(b, 64) }
+func BenchmarkRasterTiled128(b *testing.B) { benchRaster(b, 128) }
+func BenchmarkRasterTiled256(b *testing.B) { benchRaster(b, 256) }
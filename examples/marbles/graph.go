@@ -12,6 +12,7 @@ import (
 	"io/ioutil"
 	"log"
 	"math"
+	"time"
 
 	"github.com/Knetic/govaluate"
 	"github.com/chewxy/math32"
@@ -123,7 +124,7 @@ func (gr *Graph) Graph() {
 
 // Run runs the marbles for NSteps
 func (gr *Graph) Run() {
-	go RunMarbles()
+	RunMarbles()
 }
 
 // Stop stops the marbles
@@ -469,14 +470,25 @@ func UpdateMarbles() {
 
 var Stop = false
 
+// RunStep is the current step while running
+var RunStep = 0
+
+// RunMarbles runs the marbles for NSteps, one step per animation frame
 func RunMarbles() {
 	Stop = false
-	for i := 0; i < Gr.Params.NSteps; i++ {
-		UpdateMarbles()
-		if Stop {
-			break
-		}
+	RunStep = 0
+	Vp.Win.RequestAnimationFrame(RunMarblesFrame)
+}
+
+// RunMarblesFrame updates the marbles for one animation frame, requesting
+// the next frame until done
+func RunMarblesFrame(now time.Time) {
+	if Stop || RunStep >= Gr.Params.NSteps {
+		return
 	}
+	UpdateMarbles()
+	RunStep++
+	Vp.Win.RequestAnimationFrame(RunMarblesFrame)
 }
 
 var functions = map[string]govaluate.ExpressionFunction{
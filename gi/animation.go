@@ -0,0 +1,798 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+	"github.com/srwiley/rasterx"
+)
+
+// animation.go implements CSS transitions and keyframe animations of style
+// properties: https://www.w3.org/TR/css-transitions-1/ and
+// https://www.w3.org/TR/css-animations-1/ -- the transition property of
+// the style for the new state determines how a state change is animated,
+// and the animation property runs a named @keyframes animation, driven by
+// the window FrameClock.  Colors, units values (sizes) and float values
+// (e.g., opacity) are interpolated -- other properties snap to the new value.
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   Timing functions
+
+// TimingFunc is a CSS easing (timing) function, mapping the fraction of time
+// elapsed (0-1) to the fraction of the change in value
+type TimingFunc func(t float32) float32
+
+// standard named timing functions
+var (
+	TimingLinear    = TimingFunc(func(t float32) float32 { return t })
+	TimingEase      = CubicBezier(0.25, 0.1, 0.25, 1)
+	TimingEaseIn    = CubicBezier(0.42, 0, 1, 1)
+	TimingEaseOut   = CubicBezier(0, 0, 0.58, 1)
+	TimingEaseInOut = CubicBezier(0.42, 0, 0.58, 1)
+	TimingStepStart = Steps(1, true)
+	TimingStepEnd   = Steps(1, false)
+)
+
+// TimingFuncs are the named timing functions, by their CSS names
+var TimingFuncs = map[string]TimingFunc{
+	"linear":      TimingLinear,
+	"ease":        TimingEase,
+	"ease-in":     TimingEaseIn,
+	"ease-out":    TimingEaseOut,
+	"ease-in-out": TimingEaseInOut,
+	"step-start":  TimingStepStart,
+	"step-end":    TimingStepEnd,
+}
+
+// CubicBezier returns a cubic-bezier timing function with given control
+// points, with the end points fixed at 0,0 and 1,1
+func CubicBezier(x1, y1, x2, y2 float32) TimingFunc {
+	bez := func(t, p1, p2 float32) float32 {
+		mt := 1 - t
+		return 3*mt*mt*t*p1 + 3*mt*t*t*p2 + t*t*t
+	}
+	return func(t float32) float32 {
+		if t <= 0 || t >= 1 {
+			return t
+		}
+		// x is monotonic in the bezier parameter, so bisection always works
+		lo, hi := float32(0), float32(1)
+		u := t
+		for i := 0; i < 24; i++ {
+			x := bez(u, x1, x2)
+			if math.Abs(float64(x-t)) < 1.0e-5 {
+				break
+			}
+			if x < t {
+				lo = u
+			} else {
+				hi = u
+			}
+			u = 0.5 * (lo + hi)
+		}
+		return bez(u, y1, y2)
+	}
+}
+
+// Steps returns a steps timing function with given number of steps --
+// if jumpStart is true, the first jump happens at the start (step-start),
+// else at the end of each step (step-end)
+func Steps(n int, jumpStart bool) TimingFunc {
+	if n < 1 {
+		n = 1
+	}
+	return func(t float32) float32 {
+		if t >= 1 {
+			return 1
+		}
+		st := float32(math.Floor(float64(t * float32(n))))
+		if jumpStart {
+			st++
+		}
+		return Min32(st/float32(n), 1)
+	}
+}
+
+// ParseTimingFunc parses a CSS timing function: one of the named functions,
+// cubic-bezier(x1, y1, x2, y2) or steps(n[, start|end])
+func ParseTimingFunc(str string) (TimingFunc, error) {
+	str = strings.ToLower(strings.TrimSpace(str))
+	if tf, ok := TimingFuncs[str]; ok {
+		return tf, nil
+	}
+	op := strings.Index(str, "(")
+	if op < 0 || !strings.HasSuffix(str, ")") {
+		return nil, fmt.Errorf("gi.ParseTimingFunc: unknown timing function: %v", str)
+	}
+	fn := str[:op]
+	args := strings.Split(str[op+1:len(str)-1], ",")
+	for i := range args {
+		args[i] = strings.TrimSpace(args[i])
+	}
+	switch fn {
+	case "cubic-bezier":
+		if len(args) != 4 {
+			return nil, fmt.Errorf("gi.ParseTimingFunc: cubic-bezier needs 4 args: %v", str)
+		}
+		var pts [4]float32
+		for i, a := range args {
+			f, err := strconv.ParseFloat(a, 32)
+			if err != nil {
+				return nil, fmt.Errorf("gi.ParseTimingFunc: %v: %v", str, err)
+			}
+			pts[i] = float32(f)
+		}
+		return CubicBezier(pts[0], pts[1], pts[2], pts[3]), nil
+	case "steps":
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("gi.ParseTimingFunc: %v: %v", str, err)
+		}
+		start := len(args) > 1 && (args[1] == "start" || args[1] == "jump-start")
+		return Steps(n, start), nil
+	}
+	return nil, fmt.Errorf("gi.ParseTimingFunc: unknown timing function: %v", str)
+}
+
+// ParseCSSDuration parses a CSS time value, e.g., 200ms or .5s
+func ParseCSSDuration(str string) (time.Duration, error) {
+	str = strings.ToLower(strings.TrimSpace(str))
+	mult := float64(time.Second)
+	switch {
+	case strings.HasSuffix(str, "ms"):
+		mult = float64(time.Millisecond)
+		str = str[:len(str)-2]
+	case strings.HasSuffix(str, "s"):
+		str = str[:len(str)-1]
+	default:
+		if str != "0" {
+			return 0, fmt.Errorf("gi.ParseCSSDuration: time value must have s or ms units: %v", str)
+		}
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, fmt.Errorf("gi.ParseCSSDuration: %v", err)
+	}
+	return time.Duration(f * mult), nil
+}
+
+// splitCSSList splits a CSS value list at given separator (',' or ' ')
+// outside of any parentheses -- empty items are dropped
+func splitCSSList(str string, sep rune) []string {
+	var items []string
+	depth := 0
+	st := 0
+	add := func(it string) {
+		if it = strings.TrimSpace(it); it != "" {
+			items = append(items, it)
+		}
+	}
+	for i, r := range str {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case depth == 0 && (r == sep || (sep == ' ' && unicode.IsSpace(r))):
+			add(str[st:i])
+			st = i + 1
+		}
+	}
+	add(str[st:])
+	return items
+}
+
+// isCSSTime returns true if the string starts like a time value
+func isCSSTime(str string) bool {
+	return len(str) > 0 && (str[0] == '.' || (str[0] >= '0' && str[0] <= '9'))
+}
+
+// isCSSLength returns true if the string starts like a length value, which
+// can also be a calc() expression
+func isCSSLength(str string) bool {
+	return isCSSTime(str) || units.IsCalc(str)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   Transition
+
+// Transition specifies how changes in one property (or all of them) are
+// animated, from the CSS transition property
+type Transition struct {
+	Prop     string        `desc:"property to animate -- all for all properties -- also matches sub-properties, e.g., border matches border-color"`
+	Duration time.Duration `desc:"duration of the transition"`
+	Delay    time.Duration `desc:"delay before the transition starts"`
+	Timing   TimingFunc    `desc:"timing function -- ease by default"`
+}
+
+// Pos returns the position (0-1) of the transition after given elapsed
+// time, and whether it is done
+func (tr *Transition) Pos(el time.Duration) (float32, bool) {
+	el -= tr.Delay
+	if el < 0 {
+		return 0, false
+	}
+	if el >= tr.Duration {
+		return 1, true
+	}
+	return tr.Timing(float32(el) / float32(tr.Duration)), false
+}
+
+// transCache caches parsed transition lists by their property string
+var transCache sync.Map
+
+// ParseTransitions parses a CSS transition property value: a comma-separated
+// list of: property duration [timing-function] [delay] -- results are
+// cached, so they must not be modified
+func ParseTransitions(str string) ([]Transition, error) {
+	if tri, ok := transCache.Load(str); ok {
+		return tri.([]Transition), nil
+	}
+	var trs []Transition
+	for _, it := range splitCSSList(str, ',') {
+		tr := Transition{Prop: "all", Timing: TimingEase}
+		ntimes := 0
+		for _, tok := range splitCSSList(it, ' ') {
+			switch {
+			case isCSSTime(tok):
+				d, err := ParseCSSDuration(tok)
+				if err != nil {
+					return nil, err
+				}
+				if ntimes == 0 {
+					tr.Duration = d
+				} else {
+					tr.Delay = d
+				}
+				ntimes++
+			default:
+				if tf, err := ParseTimingFunc(tok); err == nil {
+					tr.Timing = tf
+				} else {
+					tr.Prop = strings.ToLower(tok)
+				}
+			}
+		}
+		if tr.Prop == "none" {
+			continue
+		}
+		trs = append(trs, tr)
+	}
+	transCache.Store(str, trs)
+	return trs, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   Animation and KeyFrames
+
+// AnimDirections are the CSS animation directions
+type AnimDirections int32
+
+const (
+	// AnimNormal plays each cycle forward
+	AnimNormal AnimDirections = iota
+
+	// AnimReverse plays each cycle backward
+	AnimReverse
+
+	// AnimAlternate plays cycles alternately forward and backward
+	AnimAlternate
+
+	// AnimAlternateReverse plays cycles alternately backward and forward
+	AnimAlternateReverse
+
+	AnimDirectionsN
+)
+
+var animDirNames = map[string]AnimDirections{
+	"normal":            AnimNormal,
+	"reverse":           AnimReverse,
+	"alternate":         AnimAlternate,
+	"alternate-reverse": AnimAlternateReverse,
+}
+
+// Animation is a keyframe animation, from the CSS animation property
+type Animation struct {
+	Name       string         `desc:"name of the @keyframes to run"`
+	Duration   time.Duration  `desc:"duration of one cycle"`
+	Delay      time.Duration  `desc:"delay before the animation starts"`
+	Timing     TimingFunc     `desc:"timing function applied between each pair of keyframes -- ease by default"`
+	Iterations float32        `desc:"number of cycles -- negative for infinite"`
+	Direction  AnimDirections `desc:"direction of the cycles"`
+}
+
+// ParseAnimation parses a CSS animation property value: name duration
+// [timing-function] [delay] [iteration-count|infinite] [direction] -- only
+// a single animation is supported
+func ParseAnimation(str string) (*Animation, error) {
+	an := &Animation{Timing: TimingEase, Iterations: 1}
+	ntimes := 0
+	for _, tok := range splitCSSList(str, ' ') {
+		ltok := strings.ToLower(tok)
+		if dir, ok := animDirNames[ltok]; ok {
+			an.Direction = dir
+			continue
+		}
+		switch {
+		case ltok == "infinite":
+			an.Iterations = -1
+		case isCSSTime(ltok) && strings.HasSuffix(ltok, "s"):
+			d, err := ParseCSSDuration(ltok)
+			if err != nil {
+				return nil, err
+			}
+			if ntimes == 0 {
+				an.Duration = d
+			} else {
+				an.Delay = d
+			}
+			ntimes++
+		case isCSSTime(ltok):
+			f, err := strconv.ParseFloat(ltok, 32)
+			if err != nil {
+				return nil, fmt.Errorf("gi.ParseAnimation: %v: %v", str, err)
+			}
+			an.Iterations = float32(f)
+		default:
+			if tf, err := ParseTimingFunc(ltok); err == nil {
+				an.Timing = tf
+			} else {
+				an.Name = tok
+			}
+		}
+	}
+	if an.Name == "" || an.Name == "none" {
+		return nil, fmt.Errorf("gi.ParseAnimation: no keyframes name in: %v", str)
+	}
+	return an, nil
+}
+
+// Progress returns the keyframe position (0-1) of the animation after given
+// elapsed time, whether the animation has started (after the delay), and
+// whether it is done -- the style is not affected before it starts or after
+// it is done
+func (an *Animation) Progress(el time.Duration) (pos float32, started, done bool) {
+	el -= an.Delay
+	if el < 0 {
+		return 0, false, false
+	}
+	if an.Duration <= 0 {
+		return 1, true, true
+	}
+	cyc := float64(el) / float64(an.Duration)
+	if an.Iterations >= 0 && cyc >= float64(an.Iterations) {
+		return 1, true, true
+	}
+	ncyc := math.Floor(cyc)
+	pos = float32(cyc - ncyc)
+	odd := int(ncyc)%2 == 1
+	switch an.Direction {
+	case AnimReverse:
+		pos = 1 - pos
+	case AnimAlternate:
+		if odd {
+			pos = 1 - pos
+		}
+	case AnimAlternateReverse:
+		if !odd {
+			pos = 1 - pos
+		}
+	}
+	return pos, true, false
+}
+
+// KeyFrame is one keyframe of a @keyframes animation: the style properties
+// at given offset (0-1) in the cycle
+type KeyFrame struct {
+	Offset float32
+	Props  ki.Props
+}
+
+var (
+	keyFrames   = map[string][]KeyFrame{}
+	keyFramesMu sync.RWMutex
+)
+
+// ParseKeyFrameOffset parses a keyframe selector: from, to, or a percentage
+func ParseKeyFrameOffset(sel string) (float32, error) {
+	sel = strings.ToLower(strings.TrimSpace(sel))
+	switch sel {
+	case "from":
+		return 0, nil
+	case "to":
+		return 1, nil
+	}
+	if !strings.HasSuffix(sel, "%") {
+		return 0, fmt.Errorf("gi.ParseKeyFrameOffset: keyframe selector must be from, to or a percentage: %v", sel)
+	}
+	f, err := strconv.ParseFloat(sel[:len(sel)-1], 32)
+	if err != nil {
+		return 0, fmt.Errorf("gi.ParseKeyFrameOffset: %v", err)
+	}
+	return InRange32(float32(f)/100, 0, 1), nil
+}
+
+// AddKeyFrames registers the keyframes for an animation with given name,
+// replacing any existing ones -- frames has the keyframe selectors (from, to,
+// or percentages, optionally comma-separated) as keys, and the style
+// properties at each keyframe as ki.Props values -- StyleSheet registers
+// any @keyframes rules automatically.
+func AddKeyFrames(name string, frames ki.Props) {
+	var kfs []KeyFrame
+	for sel, pv := range frames {
+		props, ok := pv.(ki.Props)
+		if !ok {
+			log.Printf("gi.AddKeyFrames: %v keyframe %v properties are not ki.Props: %T\n", name, sel, pv)
+			continue
+		}
+		for _, s := range strings.Split(sel, ",") {
+			off, err := ParseKeyFrameOffset(s)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			kfs = append(kfs, KeyFrame{Offset: off, Props: props})
+		}
+	}
+	sort.SliceStable(kfs, func(i, j int) bool { return kfs[i].Offset < kfs[j].Offset })
+	// implicit from and to keyframes use the element's own style
+	if len(kfs) == 0 || kfs[0].Offset > 0 {
+		kfs = append([]KeyFrame{{Offset: 0}}, kfs...)
+	}
+	if kfs[len(kfs)-1].Offset < 1 {
+		kfs = append(kfs, KeyFrame{Offset: 1})
+	}
+	keyFramesMu.Lock()
+	keyFrames[name] = kfs
+	keyFramesMu.Unlock()
+}
+
+// KeyFramesByName returns the keyframes registered under given name, sorted
+// by offset, and false if not found
+func KeyFramesByName(name string) ([]KeyFrame, bool) {
+	keyFramesMu.RLock()
+	defer keyFramesMu.RUnlock()
+	kfs, ok := keyFrames[name]
+	return kfs, ok
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   Style interpolation
+
+// interpField is a style field that can be interpolated, with all of its
+// property names
+type interpField struct {
+	fld  *StyledField
+	tags []string
+}
+
+var (
+	interpFields     []interpField
+	interpFieldsOnce sync.Once
+)
+
+// initInterpFields collects the interpolatable fields from StyleFields
+func initInterpFields() {
+	byOff := map[uintptr]int{}
+	tags := make([]string, 0, len(StyleFields.Fields))
+	for tag := range StyleFields.Fields {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags) // deterministic order
+	for _, tag := range tags {
+		fld := StyleFields.Fields[tag]
+		npt := kit.NonPtrType(fld.Field.Type)
+		if !(npt == KiT_Color || npt == KiT_ColorSpec || npt.Name() == "Value" || npt.Kind() == reflect.Float32) {
+			continue
+		}
+		if idx, ok := byOff[fld.NetOff]; ok {
+			interpFields[idx].tags = append(interpFields[idx].tags, tag)
+			continue
+		}
+		byOff[fld.NetOff] = len(interpFields)
+		interpFields = append(interpFields, interpField{fld: fld, tags: []string{tag}})
+	}
+}
+
+// matches returns true if the field is affected by given property name
+func (ifl *interpField) matches(prop string) bool {
+	if prop == "all" {
+		return true
+	}
+	for _, tag := range ifl.tags {
+		if tag == prop || (strings.HasPrefix(tag, prop) && (tag[len(prop)] == '-' || tag[len(prop)] == '.')) {
+			return true
+		}
+	}
+	return false
+}
+
+// Interpolate sets the fields of this style affected by given property name
+// (all for all of them) to the values interpolated between the from and to
+// styles at position pos (0-1) -- this style is typically a copy of to.
+// Colors (including matching gradients), units values and float values are
+// interpolated -- other fields are not changed.
+func (s *Style) Interpolate(from, to *Style, pos float32, prop string) {
+	interpFieldsOnce.Do(initInterpFields)
+	sp := reflect.ValueOf(s).Pointer()
+	fp := reflect.ValueOf(from).Pointer()
+	tp := reflect.ValueOf(to).Pointer()
+	for i := range interpFields {
+		ifl := &interpFields[i]
+		if !ifl.matches(prop) {
+			continue
+		}
+		fld := ifl.fld
+		switch sv := fld.FieldIface(sp).(type) {
+		case *Color:
+			*sv = interpColor(fld.FieldIface(fp).(*Color), fld.FieldIface(tp).(*Color), pos)
+		case *ColorSpec:
+			interpColorSpec(sv, fld.FieldIface(fp).(*ColorSpec), fld.FieldIface(tp).(*ColorSpec), pos)
+		case *units.Value:
+			fv := fld.FieldIface(fp).(*units.Value)
+			tv := fld.FieldIface(tp).(*units.Value)
+			sv.Un = tv.Un
+			sv.Val = fv.Val + pos*(tv.Val-fv.Val)
+			sv.Dots = fv.Dots + pos*(tv.Dots-fv.Dots)
+			if fv.Calc != nil || tv.Calc != nil { // calc() values are interpolated in dots
+				sv.Calc = nil
+				sv.Val, sv.Un = sv.Dots, units.Dot
+			}
+		case *float32:
+			fv := *(fld.FieldIface(fp).(*float32))
+			*sv = fv + pos*(*(fld.FieldIface(tp).(*float32))-fv)
+		}
+	}
+}
+
+// interpColor interpolates between colors, in alpha-premultiplied space as
+// in CSS, so a nil (fully transparent) color fades in or out cleanly
+func interpColor(from, to *Color, pos float32) Color {
+	lerp := func(f, t uint8) uint8 {
+		return uint8(InRange32(float32(f)+pos*(float32(t)-float32(f))+0.5, 0, 255))
+	}
+	return Color{lerp(from.R, to.R), lerp(from.G, to.G), lerp(from.B, to.B), lerp(from.A, to.A)}
+}
+
+// interpColorSpec sets cs to the interpolation between solid colors or
+// gradients with the same number of stops -- a solid color is interpolated
+// with a gradient as a gradient of that uniform color -- otherwise (e.g., for
+// patterns) cs is not changed
+func interpColorSpec(cs, from, to *ColorSpec, pos float32) {
+	if from.Source == TiledPattern || to.Source == TiledPattern {
+		return
+	}
+	fsolid := from.Source == SolidColor || from.Gradient == nil
+	tsolid := to.Source == SolidColor || to.Gradient == nil
+	if fsolid && tsolid {
+		cs.Color = interpColor(&from.Color, &to.Color, pos)
+		return
+	}
+	fg, tg := from.Gradient, to.Gradient
+	switch {
+	case fsolid:
+		fg = uniformGradient(tg, from.Color)
+	case tsolid:
+		tg = uniformGradient(fg, to.Color)
+		cs.Source = from.Source
+	}
+	if len(fg.Stops) != len(tg.Stops) {
+		return
+	}
+	gr := &rasterx.Gradient{}
+	CopyGradient(gr, tg)
+	for i := range gr.Stops {
+		fs := &fg.Stops[i]
+		ts := &tg.Stops[i]
+		var fc, tc Color
+		fc.SetColor(fs.StopColor)
+		tc.SetColor(ts.StopColor)
+		gr.Stops[i].StopColor = interpColor(&fc, &tc, pos)
+		gr.Stops[i].Offset = fs.Offset + float64(pos)*(ts.Offset-fs.Offset)
+		gr.Stops[i].Opacity = fs.Opacity + float64(pos)*(ts.Opacity-fs.Opacity)
+	}
+	cs.Gradient = gr
+}
+
+// uniformGradient returns a copy of given gradient with all the stops set
+// to given color
+func uniformGradient(gr *rasterx.Gradient, clr Color) *rasterx.Gradient {
+	ug := &rasterx.Gradient{}
+	CopyGradient(ug, gr)
+	for i := range ug.Stops {
+		ug.Stops[i].StopColor = clr
+		ug.Stops[i].Opacity = 1
+	}
+	return ug
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   StyleAnimator
+
+// StyleAnimator manages the transitions between the state styles of a widget
+// (e.g., hover, focus) according to their transition property, and any
+// keyframe animation in their animation property -- widgets call Update
+// whenever they set their current style from a state style.
+type StyleAnimator struct {
+	init      bool
+	state     int
+	from      Style
+	start     time.Time
+	trans     []Transition
+	anim      *Animation
+	animKey   string
+	animState int
+	animStart time.Time
+	frames    []Style
+	kfs       []KeyFrame
+	frameID   int
+	win       *Window
+}
+
+// Reset stops any transition or animation, so the next Update sets the
+// style directly
+func (sa *StyleAnimator) Reset() {
+	sa.cancelFrame()
+	sa.init = false
+	sa.trans = nil
+	sa.anim = nil
+	sa.animKey = ""
+	sa.frames = nil
+}
+
+// IsAnimating returns true if a transition or animation is in progress
+func (sa *StyleAnimator) IsAnimating() bool {
+	return sa.trans != nil || sa.anim != nil
+}
+
+// Update sets the current style cur of given widget for the target style of
+// given state: when the state changes, a transition starts from the current
+// style according to the transition property of the target style, and any
+// animation in the target style is applied on top.  Returns true if a
+// transition or animation is in progress, in which case an animation frame
+// has been requested to update the widget again.
+func (sa *StyleAnimator) Update(wb *WidgetBase, state int, to, cur *Style) bool {
+	now := time.Now()
+	win := wb.ParentWindow()
+	if !sa.init || win == nil {
+		sa.init = win != nil
+		sa.state = state
+		sa.trans = nil
+	} else if state != sa.state {
+		sa.state = state
+		sa.trans = nil
+		if to.Transition != "" {
+			trs, err := ParseTransitions(to.Transition)
+			if err != nil {
+				log.Println(err)
+			} else if len(trs) > 0 {
+				sa.from = *cur
+				sa.trans = trs
+				sa.start = now
+			}
+		}
+	}
+	*cur = *to
+	active := false
+	if sa.trans != nil {
+		done := true
+		el := now.Sub(sa.start)
+		for i := range sa.trans {
+			tr := &sa.trans[i]
+			pos, fin := tr.Pos(el)
+			if !fin {
+				done = false
+			}
+			cur.Interpolate(&sa.from, to, pos, tr.Prop)
+		}
+		if done {
+			sa.trans = nil
+		} else {
+			active = true
+		}
+	}
+	if win != nil && sa.animate(wb, now, state, to, cur) {
+		active = true
+	}
+	if active {
+		sa.requestFrame(wb, win)
+	}
+	return active
+}
+
+// animate applies the animation property of the target style to cur,
+// returning true if it is in progress
+func (sa *StyleAnimator) animate(wb *WidgetBase, now time.Time, state int, to, cur *Style) bool {
+	if to.Animation != sa.animKey {
+		sa.animKey = to.Animation
+		sa.anim = nil
+		sa.frames = nil
+		if to.Animation != "" && to.Animation != "none" {
+			an, err := ParseAnimation(to.Animation)
+			if err != nil {
+				log.Println(err)
+			} else if kfs, ok := KeyFramesByName(an.Name); ok {
+				sa.anim = an
+				sa.kfs = kfs
+				sa.animStart = now
+			} else {
+				log.Printf("gi.StyleAnimator: @keyframes not found: %v for node: %v\n", an.Name, wb.PathUnique())
+			}
+		}
+	}
+	if sa.anim == nil {
+		return false
+	}
+	if sa.frames == nil || sa.animState != state {
+		sa.animState = state
+		sa.frames = make([]Style, len(sa.kfs))
+		for i, kf := range sa.kfs {
+			sa.frames[i] = *to
+			if kf.Props != nil {
+				sa.frames[i].SetStyleProps(nil, kf.Props, wb.Viewport)
+				sa.frames[i].ToDots()
+			}
+		}
+	}
+	pos, started, done := sa.anim.Progress(now.Sub(sa.animStart))
+	if done {
+		sa.anim = nil
+		sa.frames = nil
+		return false
+	}
+	if !started {
+		return true
+	}
+	kfs := sa.kfs
+	i := 0
+	for i < len(kfs)-2 && pos >= kfs[i+1].Offset {
+		i++
+	}
+	k0, k1 := &kfs[i], &kfs[i+1]
+	t := float32(1)
+	if k1.Offset > k0.Offset {
+		t = sa.anim.Timing(InRange32((pos-k0.Offset)/(k1.Offset-k0.Offset), 0, 1))
+	}
+	for _, kp := range []ki.Props{k0.Props, k1.Props} {
+		for prop := range kp {
+			cur.Interpolate(&sa.frames[i], &sa.frames[i+1], t, prop)
+		}
+	}
+	return true
+}
+
+// requestFrame requests an animation frame to update the widget, if not
+// already requested
+func (sa *StyleAnimator) requestFrame(wb *WidgetBase, win *Window) {
+	if sa.frameID != 0 {
+		return
+	}
+	sa.win = win
+	sa.frameID = win.RequestAnimationFrame(func(now time.Time) {
+		sa.frameID = 0
+		wb.UpdateSig()
+	})
+}
+
+// cancelFrame cancels any pending animation frame
+func (sa *StyleAnimator) cancelFrame() {
+	if sa.frameID != 0 && sa.win != nil {
+		sa.win.CancelAnimationFrame(sa.frameID)
+	}
+	sa.frameID = 0
+}
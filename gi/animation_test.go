@@ -0,0 +1,60 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/gitest"
+	"github.com/goki/ki"
+)
+
+func TestHoverTransition(t *testing.T) {
+	var but *gi.Button
+	au := gitest.NewWindowAuto(t, "transition", 200, 100, func(mfr *gi.Frame) {
+		but = mfr.AddNewChild(gi.KiT_Button, "but").(*gi.Button)
+		but.SetText("Hover")
+		but.SetProp("transition", "background-color 300ms linear")
+		but.SetProp(":active", ki.Props{"background-color": "#0000ff"})
+		but.SetProp(":focus", ki.Props{"background-color": "#0000ff"})
+		but.SetProp(":hover", ki.Props{"background-color": "#ff0000"})
+	})
+	defer au.Close()
+	win := au.Win
+
+	// sample the background color in each frame, from the event loop
+	var cols []gi.Color
+	done := make(chan struct{})
+	var sample func(now time.Time)
+	sample = func(now time.Time) {
+		cols = append(cols, but.Sty.Font.BgColor.Color)
+		if len(cols) > 1 && !but.StyleAnim.IsAnimating() {
+			close(done)
+			return
+		}
+		win.RequestAnimationFrame(sample)
+	}
+	au.MoveTo(au.Center(but))
+	win.RequestAnimationFrame(sample)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("hover transition did not finish: %v\n", cols)
+	}
+	mid := false
+	for _, c := range cols {
+		if c.R > 0 && c.R < 255 && c.B > 0 && c.B < 255 {
+			mid = true
+		}
+	}
+	if !mid {
+		t.Errorf("no intermediate colors in transition: %v\n", cols)
+	}
+	if c := cols[len(cols)-1]; c != (gi.Color{R: 255, A: 255}) {
+		t.Errorf("final hover color: %v\n", c)
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"image/color"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/draw"
+)
+
+// background.go implements CSS background images:
+// https://www.w3.org/TR/css-backgrounds-3/#backgrounds -- the image is
+// rendered over the background-color, which is in FontStyle.BgColor.  Each
+// tile of the image (loaded from a file, or rendered from a gradient) is
+// cached at the size it is rendered at, so re-rendering only costs the
+// compositing of the tiles.
+
+// BackgroundStyle has style parameters for background images
+type BackgroundStyle struct {
+	Image    string            `xml:"image" desc:"prop: background-image = image to render over the background color: url(file) or the file name of an image, or a linear-gradient(..) or radial-gradient(..) -- none for no image"`
+	Size     string            `xml:"size" desc:"prop: background-size = size of the image: auto (natural size of an image file, or the whole box for a gradient), cover, contain, or width [height] as lengths or percents of the box, where auto keeps the aspect ratio of an image"`
+	Position string            `xml:"position" desc:"prop: background-position = position of the image in the box: x [y] as keywords (left, center, right, top, bottom), lengths, or percents, which align the same point of the image and the box -- default is top left"`
+	Repeat   BackgroundRepeats `xml:"repeat" desc:"prop: background-repeat = how the image is tiled to fill the box"`
+}
+
+// HasImage returns true if there is a background image
+func (b *BackgroundStyle) HasImage() bool {
+	return b.Image != "" && b.Image != "none"
+}
+
+// BackgroundRepeats determine how a background image is tiled
+type BackgroundRepeats int32
+
+const (
+	// BgRepeat tiles the image in both directions
+	BgRepeat BackgroundRepeats = iota
+
+	// BgRepeatX tiles the image horizontally
+	BgRepeatX
+
+	// BgRepeatY tiles the image vertically
+	BgRepeatY
+
+	// BgNoRepeat renders one image
+	BgNoRepeat
+
+	BackgroundRepeatsN
+)
+
+//go:generate stringer -type=BackgroundRepeats
+
+var KiT_BackgroundRepeats = kit.Enums.AddEnumAltLower(BackgroundRepeatsN, false, StylePropProps, "Bg")
+
+func (ev BackgroundRepeats) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *BackgroundRepeats) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// SetBackgroundPost sets the background-repeat from its CSS name in props
+// (e.g., repeat-x), which is not the same as the enum name
+func (s *Style) SetBackgroundPost(props ki.Props) {
+	if str, ok := props["background-repeat"].(string); ok {
+		rp := strings.Replace(strings.TrimSpace(str), "-", "", -1)
+		kit.Enums.SetAnyEnumIfaceFromString(&s.Background.Repeat, rp)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   Rendering
+
+// RenderBackgroundImage renders the background image in given style, if any,
+// positioned in and clipped to the box at given position and size, with the
+// corners rounded by the border radii
+func (wb *WidgetBase) RenderBackgroundImage(st *Style, pos, sz Vec2D) {
+	if !st.Background.HasImage() {
+		return
+	}
+	RenderBackground(&wb.Viewport.Render, st, pos, sz, wb.Viewport)
+}
+
+// RenderBackground renders the background image in given style into given
+// render state -- see WidgetBase.RenderBackgroundImage
+func RenderBackground(rs *RenderState, st *Style, pos, sz Vec2D, vp *Viewport2D) {
+	bg := &st.Background
+	area := RectFromPosSizeMax(pos, sz)
+	clip := area.Intersect(rs.Bounds)
+	if clip.Empty() {
+		return
+	}
+	isImg := !strings.Contains(bg.Image, "-gradient")
+	var src image.Image
+	var nsz Vec2D // natural size
+	if isImg {
+		src = backgroundImage(bg.Image)
+		if src == nil {
+			return
+		}
+		nsz = NewVec2DFmPoint(src.Bounds().Size())
+	} else {
+		nsz = sz
+	}
+	tsz := bg.tileSize(sz, nsz, isImg, &st.UnContext)
+	tw, th := int(tsz.X+0.5), int(tsz.Y+0.5)
+	if tw <= 0 || th <= 0 {
+		return
+	}
+	tile := backgroundTile(bg.Image, src, tw, th, vp)
+	xs, ys := bg.positions()
+	x0 := pos.X + bgOffset(xs, sz.X-float32(tw), &st.UnContext)
+	y0 := pos.Y + bgOffset(ys, sz.Y-float32(th), &st.UnContext)
+	if bg.Repeat == BgRepeat || bg.Repeat == BgRepeatX {
+		x0 -= float32(math.Ceil(float64((x0-pos.X)/float32(tw)))) * float32(tw)
+	}
+	if bg.Repeat == BgRepeat || bg.Repeat == BgRepeatY {
+		y0 -= float32(math.Ceil(float64((y0-pos.Y)/float32(th)))) * float32(th)
+	}
+	mask := backgroundMask(st, pos, sz, clip)
+	org := image.Point{int(math.Floor(float64(x0 + 0.5))), int(math.Floor(float64(y0 + 0.5)))}
+	dst := rs.Image
+	if rs.Recorder != nil { // draw into a separate image to record it
+		dst = image.NewRGBA(clip)
+	}
+	for ty := org.Y; ty < clip.Max.Y; ty += th {
+		for tx := org.X; tx < clip.Max.X; tx += tw {
+			tr := image.Rect(tx, ty, tx+tw, ty+th).Intersect(clip)
+			if !tr.Empty() {
+				if mask != nil {
+					draw.DrawMask(dst, tr, tile, tr.Min.Sub(image.Point{tx, ty}), mask, tr.Min, draw.Over)
+				} else {
+					draw.Draw(dst, tr, tile, tr.Min.Sub(image.Point{tx, ty}), draw.Over)
+				}
+			}
+			if bg.Repeat != BgRepeat && bg.Repeat != BgRepeatX {
+				break
+			}
+		}
+		if bg.Repeat != BgRepeat && bg.Repeat != BgRepeatY {
+			break
+		}
+	}
+	if dst != rs.Image {
+		draw.Draw(rs.Image, clip, dst, clip.Min, draw.Over)
+		rs.RecordImage(dst, Identity2D())
+	}
+}
+
+// tileSize returns the size of one tile of the background image, given the
+// size of the box and the natural size of the image
+func (b *BackgroundStyle) tileSize(sz, nsz Vec2D, isImg bool, uc *units.Context) Vec2D {
+	size := strings.TrimSpace(b.Size)
+	switch size {
+	case "", "auto", "auto auto":
+		return nsz
+	case "cover", "contain":
+		if !isImg || nsz.X == 0 || nsz.Y == 0 {
+			return sz
+		}
+		sc := Max32(sz.X/nsz.X, sz.Y/nsz.Y)
+		if size == "contain" {
+			sc = Min32(sz.X/nsz.X, sz.Y/nsz.Y)
+		}
+		return nsz.MulVal(sc)
+	}
+	toks := strings.Fields(size)
+	if len(toks) == 1 {
+		toks = append(toks, "auto")
+	}
+	w, wok := bgLength(toks[0], sz.X, uc)
+	h, hok := bgLength(toks[1], sz.Y, uc)
+	switch {
+	case wok && hok:
+		return NewVec2D(w, h)
+	case !wok && !hok:
+		return nsz
+	case !isImg || nsz.X == 0 || nsz.Y == 0:
+		if !wok {
+			w = sz.X
+		} else {
+			h = sz.Y
+		}
+		return NewVec2D(w, h)
+	case !hok:
+		return NewVec2D(w, w*nsz.Y/nsz.X)
+	default:
+		return NewVec2D(h*nsz.X/nsz.Y, h)
+	}
+}
+
+// positions returns the x and y components of the background position
+func (b *BackgroundStyle) positions() (xs, ys string) {
+	toks := strings.Fields(b.Position)
+	switch len(toks) {
+	case 0:
+		return "0%", "0%"
+	case 1:
+		if toks[0] == "top" || toks[0] == "bottom" {
+			return "center", toks[0]
+		}
+		return toks[0], "center"
+	}
+	if toks[0] == "top" || toks[0] == "bottom" || toks[1] == "left" || toks[1] == "right" {
+		return toks[1], toks[0]
+	}
+	return toks[0], toks[1]
+}
+
+// bgOffset returns the offset for a background position component, where
+// free is the box size minus the tile size
+func bgOffset(str string, free float32, uc *units.Context) float32 {
+	switch str {
+	case "left", "top":
+		return 0
+	case "center":
+		return 0.5 * free
+	case "right", "bottom":
+		return free
+	}
+	if strings.HasSuffix(str, "%") {
+		pct, _ := strconv.ParseFloat(strings.TrimSuffix(str, "%"), 32)
+		return free * float32(pct) / 100
+	}
+	v, _ := bgLength(str, 0, uc)
+	return v
+}
+
+// bgLength returns a length in dots, for a length or a percent of ref --
+// returns false for auto
+func bgLength(str string, ref float32, uc *units.Context) (float32, bool) {
+	if str == "auto" {
+		return 0, false
+	}
+	if strings.HasSuffix(str, "%") {
+		pct, _ := strconv.ParseFloat(strings.TrimSuffix(str, "%"), 32)
+		return ref * float32(pct) / 100, true
+	}
+	ruc := *uc
+	ruc.ElW = ref // for percents in calc()
+	v := units.StringToValue(str)
+	return v.ToDots(&ruc), true
+}
+
+// backgroundMask returns the mask for rendering within the box, for the
+// rounded corners and opacity of the style -- nil if not needed
+func backgroundMask(st *Style, pos, sz Vec2D, clip image.Rectangle) *image.Alpha {
+	rad := st.BorderSides.Radii()
+	round := rad[0] > 0 || rad[1] > 0 || rad[2] > 0 || rad[3] > 0
+	op := InRange32(st.Font.Opacity, 0, 1)
+	if !round && op >= 1 {
+		return nil
+	}
+	mask := image.NewAlpha(clip)
+	for y := clip.Min.Y; y < clip.Max.Y; y++ {
+		py := float32(y) + 0.5
+		for x := clip.Min.X; x < clip.Max.X; x++ {
+			a := op
+			if round {
+				a *= coverage(roundRectCornersDist(float32(x)+0.5, py, pos.X, pos.Y, sz.X, sz.Y, rad))
+			}
+			mask.Pix[mask.PixOffset(x, y)] = uint8(a*255 + 0.5)
+		}
+	}
+	return mask
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   Images and tiles
+
+// backgroundURL returns the file name from a url(..) background image, or
+// the image string itself if it is not a url
+func backgroundURL(img string) string {
+	img = strings.TrimSpace(img)
+	if strings.HasPrefix(img, "url(") {
+		img = strings.TrimSuffix(strings.TrimPrefix(img, "url("), ")")
+		img = strings.Trim(strings.TrimSpace(img), `"'`)
+	}
+	return img
+}
+
+var (
+	bgImages   = map[string]image.Image{}
+	bgImagesMu sync.Mutex
+)
+
+// backgroundImage returns the image for a background image url or file name,
+// loading it the first time -- images that fail to load are logged once,
+// and return nil
+func backgroundImage(img string) image.Image {
+	fn := backgroundURL(img)
+	bgImagesMu.Lock()
+	defer bgImagesMu.Unlock()
+	if im, ok := bgImages[fn]; ok {
+		return im
+	}
+	im, err := OpenImage(fn)
+	if err != nil {
+		log.Printf("gi.RenderBackground: could not open background image: %v\n", err)
+		im = nil
+	}
+	bgImages[fn] = im
+	return im
+}
+
+// bgTileKey is a background image or gradient at a given tile size
+type bgTileKey struct {
+	img  string
+	w, h int
+}
+
+// BgTileCacheMax is the maximum number of background image tiles to keep in
+// the cache
+var BgTileCacheMax = 64
+
+var (
+	bgTiles   = map[bgTileKey]*image.RGBA{}
+	bgTilesMu sync.Mutex
+)
+
+// backgroundTile returns the cached tile for a background image (src) or
+// gradient at given size, creating it if needed
+func backgroundTile(img string, src image.Image, w, h int, vp *Viewport2D) *image.RGBA {
+	key := bgTileKey{img: img, w: w, h: h}
+	bgTilesMu.Lock()
+	tile, ok := bgTiles[key]
+	bgTilesMu.Unlock()
+	if ok {
+		return tile
+	}
+	tile = image.NewRGBA(image.Rect(0, 0, w, h))
+	if src != nil {
+		draw.CatmullRom.Scale(tile, tile.Bounds(), src, src.Bounds(), draw.Src, nil)
+	} else {
+		var cs ColorSpec
+		cs.SetString(img, vp)
+		switch cf := cs.RenderColor(1, tile.Bounds(), Identity2D()).(type) {
+		case rasterx.ColorFunc:
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					tile.Set(x, y, cf(x, y))
+				}
+			}
+		case color.Color:
+			draw.Draw(tile, tile.Bounds(), &image.Uniform{cf}, image.ZP, draw.Src)
+		}
+	}
+	bgTilesMu.Lock()
+	if len(bgTiles) >= BgTileCacheMax {
+		bgTiles = map[bgTileKey]*image.RGBA{}
+	}
+	bgTiles[key] = tile
+	bgTilesMu.Unlock()
+	return tile
+}
@@ -0,0 +1,475 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"log"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+	"github.com/srwiley/rasterx"
+	"github.com/srwiley/scanFT"
+)
+
+// blend.go implements the compositing of groups of painting: everything
+// painted between RenderState.PushLayer and PopLayer is rendered into an
+// offscreen layer image, which is then composited into the image below it
+// as a whole, with a group opacity, a blend mode and a Porter-Duff
+// compositing operator -- so that overlapping elements of a semi-transparent
+// group do not show through each other.  The blend modes are those of the
+// CSS mix-blend-mode property: https://www.w3.org/TR/compositing-1/ -- layers
+// are always isolated, i.e., the elements in a layer blend only with each
+// other, and the layer as a whole blends with what is below it.
+
+// BlendModes are the ways of mixing the colors of a layer with the colors
+// below it, from the CSS mix-blend-mode property
+type BlendModes int32
+
+const (
+	// BlendNormal uses the colors of the layer
+	BlendNormal BlendModes = iota
+
+	// BlendMultiply multiplies the colors, which always darkens
+	BlendMultiply
+
+	// BlendScreen multiplies the complements of the colors, which always lightens
+	BlendScreen
+
+	// BlendOverlay multiplies or screens the colors, depending on the color below
+	BlendOverlay
+
+	// BlendDarken uses the darker of the colors
+	BlendDarken
+
+	// BlendLighten uses the lighter of the colors
+	BlendLighten
+
+	// BlendColorDodge brightens the color below to reflect the layer color
+	BlendColorDodge
+
+	// BlendColorBurn darkens the color below to reflect the layer color
+	BlendColorBurn
+
+	// BlendHardLight multiplies or screens the colors, depending on the layer color
+	BlendHardLight
+
+	// BlendSoftLight darkens or lightens the colors, depending on the layer color
+	BlendSoftLight
+
+	// BlendDifference subtracts the darker of the colors from the lighter
+	BlendDifference
+
+	// BlendExclusion is like BlendDifference, with lower contrast
+	BlendExclusion
+
+	// BlendHue uses the hue of the layer, with the saturation and luminosity below
+	BlendHue
+
+	// BlendSaturation uses the saturation of the layer, with the hue and luminosity below
+	BlendSaturation
+
+	// BlendColor uses the hue and saturation of the layer, with the luminosity below
+	BlendColor
+
+	// BlendLuminosity uses the luminosity of the layer, with the hue and saturation below
+	BlendLuminosity
+
+	BlendModesN
+)
+
+//go:generate stringer -type=BlendModes
+
+var KiT_BlendModes = kit.Enums.AddEnumAltLower(BlendModesN, false, StylePropProps, "Blend")
+
+func (ev BlendModes) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *BlendModes) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// BlendModeNames are the CSS names of the blend modes, as used in the
+// mix-blend-mode property
+var BlendModeNames = [BlendModesN]string{"normal", "multiply", "screen", "overlay", "darken", "lighten", "color-dodge", "color-burn", "hard-light", "soft-light", "difference", "exclusion", "hue", "saturation", "color", "luminosity"}
+
+// CSSName returns the CSS name of the blend mode
+func (bm BlendModes) CSSName() string {
+	if bm < 0 || bm >= BlendModesN {
+		return "normal"
+	}
+	return BlendModeNames[bm]
+}
+
+// SetBlendPost sets the mix-blend-mode from its CSS name in props (e.g.,
+// color-dodge), which is not the same as the enum name
+func (fs *FontStyle) SetBlendPost(props ki.Props) {
+	str, ok := props["mix-blend-mode"].(string)
+	if !ok {
+		return
+	}
+	for i, nm := range BlendModeNames {
+		if nm == str {
+			fs.Blend = BlendModes(i)
+			return
+		}
+	}
+}
+
+// CompositeOps are the Porter-Duff operators for compositing a layer (the
+// source) with the image below it (the destination), within the bounds of
+// the layer
+type CompositeOps int32
+
+const (
+	// CompSrcOver draws the source over the destination -- the usual
+	CompSrcOver CompositeOps = iota
+
+	// CompSrc replaces the destination with the source
+	CompSrc
+
+	// CompSrcIn shows the source only where the destination is
+	CompSrcIn
+
+	// CompSrcOut shows the source only where the destination is not
+	CompSrcOut
+
+	// CompSrcAtop draws the source over the destination, only where the destination is
+	CompSrcAtop
+
+	// CompDst keeps the destination, ignoring the source
+	CompDst
+
+	// CompDstOver draws the destination over the source
+	CompDstOver
+
+	// CompDstIn shows the destination only where the source is
+	CompDstIn
+
+	// CompDstOut shows the destination only where the source is not
+	CompDstOut
+
+	// CompDstAtop draws the destination over the source, only where the source is
+	CompDstAtop
+
+	// CompXor shows the source and destination where they do not overlap
+	CompXor
+
+	// CompClear clears the destination
+	CompClear
+
+	// CompLighter adds the source and destination
+	CompLighter
+
+	CompositeOpsN
+)
+
+//go:generate stringer -type=CompositeOps
+
+var KiT_CompositeOps = kit.Enums.AddEnumAltLower(CompositeOpsN, false, StylePropProps, "Comp")
+
+func (ev CompositeOps) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *CompositeOps) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// Factors returns the Porter-Duff factors of the source and destination for
+// the operator, given the source and destination alpha
+func (co CompositeOps) Factors(sa, da float32) (fs, fd float32) {
+	switch co {
+	case CompSrc:
+		return 1, 0
+	case CompSrcIn:
+		return da, 0
+	case CompSrcOut:
+		return 1 - da, 0
+	case CompSrcAtop:
+		return da, 1 - sa
+	case CompDst:
+		return 0, 1
+	case CompDstOver:
+		return 1 - da, 1
+	case CompDstIn:
+		return 0, sa
+	case CompDstOut:
+		return 0, 1 - sa
+	case CompDstAtop:
+		return 1 - da, sa
+	case CompXor:
+		return 1 - da, 1 - sa
+	case CompClear:
+		return 0, 0
+	case CompLighter:
+		return 1, 1
+	}
+	return 1, 1 - sa
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   Layers
+
+// renderLayer is a layer pushed by PushLayer, with the render state it
+// replaced
+type renderLayer struct {
+	buf      *layerBuf
+	bounds   image.Rectangle
+	opacity  float32
+	blend    BlendModes
+	comp     CompositeOps
+	image    *image.RGBA
+	raster   *rasterx.Dasher
+	scanner  *scanFT.ScannerFT
+	traster  *rasterx.Dasher
+	tscanner *TileScanner
+	recorder *PaintRecorder
+}
+
+// layerBuf is an offscreen image for a layer, with its rasterizer -- these
+// are kept for re-use, as layers are typically pushed at every render
+type layerBuf struct {
+	image   *image.RGBA
+	raster  *rasterx.Dasher
+	scanner *scanFT.ScannerFT
+}
+
+// PushLayer starts rendering into a new transparent offscreen layer, which
+// is composited into the current image when PopLayer is called, within given
+// bounds, with given group opacity, blend mode and compositing operator --
+// all painting until then, including that of sub-viewports, goes into the
+// layer.  Protects within render mutex lock.
+func (rs *RenderState) PushLayer(bounds image.Rectangle, opacity float32, blend BlendModes, comp CompositeOps) {
+	rs.RenderMu.Lock()
+	defer rs.RenderMu.Unlock()
+
+	ib := rs.Image.Bounds()
+	ly := renderLayer{bounds: bounds.Intersect(ib), opacity: opacity, blend: blend, comp: comp, image: rs.Image, raster: rs.Raster, scanner: rs.Scanner, traster: rs.TileRaster, tscanner: rs.TileScanner, recorder: rs.Recorder}
+	var lb *layerBuf
+	if n := len(rs.layerBufs); n > 0 && rs.layerBufs[n-1].image.Bounds() == ib {
+		lb = rs.layerBufs[n-1]
+		rs.layerBufs = rs.layerBufs[:n-1]
+		draw.Draw(lb.image, ly.bounds, &image.Uniform{color.Transparent}, image.ZP, draw.Src)
+	} else {
+		lb = &layerBuf{image: image.NewRGBA(ib)}
+		lb.scanner = scanFT.NewScannerFT(ib.Dx(), ib.Dy(), scanFT.NewRGBAPainter(lb.image))
+		lb.raster = rasterx.NewDasher(ib.Dx(), ib.Dy(), lb.scanner)
+	}
+	ly.buf = lb
+	rs.layers = append(rs.layers, ly)
+	rs.Image = lb.image
+	rs.Raster = lb.raster
+	rs.Scanner = lb.scanner
+	rs.TileRaster = nil
+	rs.TileScanner = nil
+	if rs.Recorder != nil {
+		rs.Recorder = &PaintRecorder{}
+	}
+}
+
+// PopLayer composites the layer started by the last PushLayer into the image
+// below it, and restores rendering into that image.  Protects within render
+// mutex lock.
+func (rs *RenderState) PopLayer() {
+	rs.RenderMu.Lock()
+	defer rs.RenderMu.Unlock()
+
+	sz := len(rs.layers)
+	if sz == 0 {
+		log.Printf("gi.RenderState PopLayer: stack is empty -- programmer error\n")
+		return
+	}
+	ly := rs.layers[sz-1]
+	rs.layers = rs.layers[:sz-1]
+	CompositeLayer(ly.image, ly.buf.image, ly.bounds, ly.opacity, ly.blend, ly.comp)
+	if ly.recorder != nil && len(rs.Recorder.Ops) > 0 {
+		ly.recorder.Add(&PaintLayerOp{Ops: rs.Recorder.Ops, Opacity: ly.opacity, Blend: ly.blend, Comp: ly.comp, Clip: ly.bounds})
+	}
+	rs.Image = ly.image
+	rs.Raster = ly.raster
+	rs.Scanner = ly.scanner
+	rs.TileRaster = ly.traster
+	rs.TileScanner = ly.tscanner
+	rs.Recorder = ly.recorder
+	rs.layerBufs = append(rs.layerBufs, ly.buf)
+}
+
+// CompositeLayer composites the src image into the dst image, within given
+// bounds, with given opacity applied to src, blending its colors with those
+// of dst using given blend mode, and compositing with given operator
+func CompositeLayer(dst, src *image.RGBA, bounds image.Rectangle, opacity float32, blend BlendModes, comp CompositeOps) {
+	bounds = bounds.Intersect(dst.Bounds()).Intersect(src.Bounds())
+	if bounds.Empty() {
+		return
+	}
+	if blend == BlendNormal && comp == CompSrcOver {
+		if opacity >= 1 {
+			draw.Draw(dst, bounds, src, bounds.Min, draw.Over)
+		} else {
+			mask := &image.Uniform{color.Alpha{uint8(InRange32(opacity, 0, 1)*255 + 0.5)}}
+			draw.DrawMask(dst, bounds, src, bounds.Min, mask, image.ZP, draw.Over)
+		}
+		return
+	}
+	opacity = InRange32(opacity, 0, 1)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		si := src.PixOffset(bounds.Min.X, y)
+		di := dst.PixOffset(bounds.Min.X, y)
+		for x := bounds.Min.X; x < bounds.Max.X; x, si, di = x+1, si+4, di+4 {
+			sp := src.Pix[si : si+4 : si+4]
+			dp := dst.Pix[di : di+4 : di+4]
+			sa := float32(sp[3]) / 255 * opacity
+			da := float32(dp[3]) / 255
+			if sa == 0 && (comp == CompSrcOver || comp == CompDstOver || comp == CompSrcAtop || comp == CompDstOut || comp == CompXor || comp == CompLighter) {
+				continue // no change
+			}
+			var s, d [3]float32 // premultiplied
+			for c := 0; c < 3; c++ {
+				s[c] = float32(sp[c]) / 255 * opacity
+				d[c] = float32(dp[c]) / 255
+			}
+			if blend != BlendNormal && sa > 0 && da > 0 {
+				var cs, cb [3]float32 // un-premultiplied
+				for c := 0; c < 3; c++ {
+					cs[c] = s[c] / sa
+					cb[c] = d[c] / da
+				}
+				bc := blend.Blend(cb, cs)
+				for c := 0; c < 3; c++ {
+					s[c] = (1-da)*s[c] + sa*da*bc[c]
+				}
+			}
+			fs, fd := comp.Factors(sa, da)
+			for c := 0; c < 3; c++ {
+				dp[c] = uint8(InRange32(s[c]*fs+d[c]*fd, 0, 1)*255 + 0.5)
+			}
+			dp[3] = uint8(InRange32(sa*fs+da*fd, 0, 1)*255 + 0.5)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   Blend functions
+
+// Blend returns the blended color of backdrop color cb (below) and source
+// color cs (the layer), for the blend mode -- colors are not premultiplied,
+// with components in the 0-1 range
+func (bm BlendModes) Blend(cb, cs [3]float32) [3]float32 {
+	switch bm {
+	case BlendHue:
+		return blendSetLum(blendSetSat(cs, blendSat(cb)), blendLum(cb))
+	case BlendSaturation:
+		return blendSetLum(blendSetSat(cb, blendSat(cs)), blendLum(cb))
+	case BlendColor:
+		return blendSetLum(cs, blendLum(cb))
+	case BlendLuminosity:
+		return blendSetLum(cb, blendLum(cs))
+	}
+	var r [3]float32
+	for c := 0; c < 3; c++ {
+		r[c] = bm.blendSep(cb[c], cs[c])
+	}
+	return r
+}
+
+// blendSep returns the blend of one component, for the separable blend modes
+func (bm BlendModes) blendSep(cb, cs float32) float32 {
+	switch bm {
+	case BlendMultiply:
+		return cb * cs
+	case BlendScreen:
+		return cb + cs - cb*cs
+	case BlendOverlay:
+		return BlendHardLight.blendSep(cs, cb)
+	case BlendDarken:
+		return Min32(cb, cs)
+	case BlendLighten:
+		return Max32(cb, cs)
+	case BlendColorDodge:
+		switch {
+		case cb == 0:
+			return 0
+		case cs >= 1:
+			return 1
+		}
+		return Min32(1, cb/(1-cs))
+	case BlendColorBurn:
+		switch {
+		case cb >= 1:
+			return 1
+		case cs == 0:
+			return 0
+		}
+		return 1 - Min32(1, (1-cb)/cs)
+	case BlendHardLight:
+		if cs <= 0.5 {
+			return cb * 2 * cs
+		}
+		return BlendScreen.blendSep(cb, 2*cs-1)
+	case BlendSoftLight:
+		if cs <= 0.5 {
+			return cb - (1-2*cs)*cb*(1-cb)
+		}
+		var d float32
+		if cb <= 0.25 {
+			d = ((16*cb-12)*cb + 4) * cb
+		} else {
+			d = math32.Sqrt(cb)
+		}
+		return cb + (2*cs-1)*(d-cb)
+	case BlendDifference:
+		return math32.Abs(cb - cs)
+	case BlendExclusion:
+		return cb + cs - 2*cb*cs
+	}
+	return cs
+}
+
+// blendLum returns the luminosity of a color, for the non-separable blend modes
+func blendLum(c [3]float32) float32 {
+	return 0.3*c[0] + 0.59*c[1] + 0.11*c[2]
+}
+
+// blendSetLum returns the color with its luminosity set to l, clipped to the
+// range of colors
+func blendSetLum(c [3]float32, l float32) [3]float32 {
+	d := l - blendLum(c)
+	c[0] += d
+	c[1] += d
+	c[2] += d
+	l = blendLum(c)
+	n := Min32(c[0], Min32(c[1], c[2]))
+	x := Max32(c[0], Max32(c[1], c[2]))
+	for i := 0; i < 3; i++ {
+		if n < 0 {
+			c[i] = l + (c[i]-l)*l/(l-n)
+		}
+		if x > 1 {
+			c[i] = l + (c[i]-l)*(1-l)/(x-l)
+		}
+	}
+	return c
+}
+
+// blendSat returns the saturation of a color
+func blendSat(c [3]float32) float32 {
+	return Max32(c[0], Max32(c[1], c[2])) - Min32(c[0], Min32(c[1], c[2]))
+}
+
+// blendSetSat returns the color with its saturation set to s
+func blendSetSat(c [3]float32, s float32) [3]float32 {
+	mx, mn := 0, 0
+	for i := 1; i < 3; i++ {
+		if c[i] > c[mx] {
+			mx = i
+		}
+		if c[i] < c[mn] {
+			mn = i
+		}
+	}
+	if mx == mn {
+		return [3]float32{}
+	}
+	md := 3 - mx - mn
+	var r [3]float32
+	r[md] = (c[md] - c[mn]) * s / (c[mx] - c[mn])
+	r[mx] = s
+	return r
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// renderLayerScene renders two overlapping opaque red squares, in a layer
+// with given opacity and blend mode, over a gray background
+func renderLayerScene(opacity float32, blend BlendModes) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{128, 128, 128, 255}}, image.ZP, draw.Src)
+	rs := &RenderState{}
+	rs.Init(40, 20, img)
+	rs.Bounds = img.Bounds()
+	pc := &rs.Paint
+	pc.StrokeStyle.SetColor(nil)
+	pc.FillStyle.Color.SetColor(color.RGBA{255, 0, 0, 255})
+	rs.PushLayer(rs.Bounds, opacity, blend, CompSrcOver)
+	pc.DrawRectangle(rs, 0, 0, 20, 20)
+	pc.Fill(rs)
+	pc.DrawRectangle(rs, 10, 0, 20, 20)
+	pc.Fill(rs)
+	rs.PopLayer()
+	return img
+}
+
+func TestLayerOpacity(t *testing.T) {
+	img := renderLayerScene(0.5, BlendNormal)
+	single := img.RGBAAt(5, 10)
+	overlap := img.RGBAAt(15, 10)
+	if single != overlap {
+		t.Errorf("overlapping shapes in a layer should not double up: single %v, overlap %v\n", single, overlap)
+	}
+	if single.R < 188 || single.R > 194 || single.G < 62 || single.G > 66 {
+		t.Errorf("layer opacity not applied: %v\n", single)
+	}
+	if bg := img.RGBAAt(35, 10); bg != (color.RGBA{128, 128, 128, 255}) {
+		t.Errorf("background outside of the shapes changed: %v\n", bg)
+	}
+}
+
+func TestLayerBlend(t *testing.T) {
+	img := renderLayerScene(1, BlendMultiply)
+	if c := img.RGBAAt(15, 10); c.R != 128 || c.G != 0 || c.B != 0 || c.A != 255 {
+		t.Errorf("multiply blend: got %v, expected {128 0 0 255}\n", c)
+	}
+	img = renderLayerScene(1, BlendScreen)
+	if c := img.RGBAAt(15, 10); c.R != 255 || c.G != 128 || c.B != 128 {
+		t.Errorf("screen blend: got %v, expected {255 128 128 255}\n", c)
+	}
+	if c := img.RGBAAt(35, 10); c != (color.RGBA{128, 128, 128, 255}) {
+		t.Errorf("background outside of the shapes changed: %v\n", c)
+	}
+}
+
+func TestCompositeOps(t *testing.T) {
+	for _, tst := range []struct {
+		op     CompositeOps
+		expect color.RGBA
+	}{
+		{CompSrcOver, color.RGBA{255, 0, 0, 255}},
+		{CompDstOver, color.RGBA{0, 0, 128, 255}},
+		{CompSrcIn, color.RGBA{255, 0, 0, 255}},
+		{CompDstOut, color.RGBA{0, 0, 0, 0}},
+		{CompXor, color.RGBA{0, 0, 0, 0}},
+		{CompClear, color.RGBA{0, 0, 0, 0}},
+	} {
+		dst := image.NewRGBA(image.Rect(0, 0, 4, 4))
+		draw.Draw(dst, dst.Bounds(), &image.Uniform{color.RGBA{0, 0, 128, 255}}, image.ZP, draw.Src)
+		src := image.NewRGBA(dst.Bounds())
+		draw.Draw(src, src.Bounds(), &image.Uniform{color.RGBA{255, 0, 0, 255}}, image.ZP, draw.Src)
+		CompositeLayer(dst, src, dst.Bounds(), 1, BlendNormal, tst.op)
+		if c := dst.RGBAAt(1, 1); c != tst.expect {
+			t.Errorf("composite op %v: got %v, expected %v\n", tst.op, c, tst.expect)
+		}
+	}
+}
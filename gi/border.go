@@ -0,0 +1,552 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"math"
+	"strings"
+
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// border.go implements per-side borders and per-corner radii:
+// https://www.w3.org/TR/css-backgrounds-3/#borders -- Style.Border holds the
+// shorthand values for all sides (border-width etc), and Style.BorderSides
+// the resolved values for each side and corner, which are used for
+// rendering.
+
+// BoxCorners specifies the corners of a box, in CSS order, for per-corner
+// properties (e.g., border radius)
+type BoxCorners int32
+
+const (
+	BoxTopLeft BoxCorners = iota
+	BoxTopRight
+	BoxBottomRight
+	BoxBottomLeft
+	BoxCornersN
+)
+
+//go:generate stringer -type=BoxCorners
+
+var KiT_BoxCorners = kit.Enums.AddEnumAltLower(BoxCornersN, false, StylePropProps, "Box")
+
+func (ev BoxCorners) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *BoxCorners) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// BoxSideNames are the CSS names of the sides, as used in property names
+var BoxSideNames = [BoxN]string{"top", "right", "bottom", "left"}
+
+// BoxCornerNames are the CSS names of the corners, as used in property names
+var BoxCornerNames = [BoxCornersN]string{"top-left", "top-right", "bottom-right", "bottom-left"}
+
+// BorderSidesStyle has the border style, width and color for each side of
+// the box, and the radius of each corner -- these are set from the
+// per-side properties (e.g., border-top-width, border-top-left-radius), and
+// from the shorthand properties for all sides (border, border-width etc),
+// which can have 1-4 values for the different sides, as in CSS.
+type BorderSidesStyle struct {
+	TopStyle          BorderDrawStyle `xml:"border-top-style" desc:"prop: border-top-style = how to draw the top border"`
+	RightStyle        BorderDrawStyle `xml:"border-right-style" desc:"prop: border-right-style = how to draw the right border"`
+	BottomStyle       BorderDrawStyle `xml:"border-bottom-style" desc:"prop: border-bottom-style = how to draw the bottom border"`
+	LeftStyle         BorderDrawStyle `xml:"border-left-style" desc:"prop: border-left-style = how to draw the left border"`
+	TopWidth          units.Value     `xml:"border-top-width" desc:"prop: border-top-width = width of the top border"`
+	RightWidth        units.Value     `xml:"border-right-width" desc:"prop: border-right-width = width of the right border"`
+	BottomWidth       units.Value     `xml:"border-bottom-width" desc:"prop: border-bottom-width = width of the bottom border"`
+	LeftWidth         units.Value     `xml:"border-left-width" desc:"prop: border-left-width = width of the left border"`
+	TopColor          Color           `xml:"border-top-color" desc:"prop: border-top-color = color of the top border"`
+	RightColor        Color           `xml:"border-right-color" desc:"prop: border-right-color = color of the right border"`
+	BottomColor       Color           `xml:"border-bottom-color" desc:"prop: border-bottom-color = color of the bottom border"`
+	LeftColor         Color           `xml:"border-left-color" desc:"prop: border-left-color = color of the left border"`
+	TopLeftRadius     units.Value     `xml:"border-top-left-radius" desc:"prop: border-top-left-radius = rounding of the top-left corner"`
+	TopRightRadius    units.Value     `xml:"border-top-right-radius" desc:"prop: border-top-right-radius = rounding of the top-right corner"`
+	BottomRightRadius units.Value     `xml:"border-bottom-right-radius" desc:"prop: border-bottom-right-radius = rounding of the bottom-right corner"`
+	BottomLeftRadius  units.Value     `xml:"border-bottom-left-radius" desc:"prop: border-bottom-left-radius = rounding of the bottom-left corner"`
+}
+
+// SideStyle returns the border style for given side
+func (bs *BorderSidesStyle) SideStyle(sd BoxSides) *BorderDrawStyle {
+	switch sd {
+	case BoxTop:
+		return &bs.TopStyle
+	case BoxRight:
+		return &bs.RightStyle
+	case BoxBottom:
+		return &bs.BottomStyle
+	default:
+		return &bs.LeftStyle
+	}
+}
+
+// SideWidth returns the border width for given side
+func (bs *BorderSidesStyle) SideWidth(sd BoxSides) *units.Value {
+	switch sd {
+	case BoxTop:
+		return &bs.TopWidth
+	case BoxRight:
+		return &bs.RightWidth
+	case BoxBottom:
+		return &bs.BottomWidth
+	default:
+		return &bs.LeftWidth
+	}
+}
+
+// SideColor returns the border color for given side
+func (bs *BorderSidesStyle) SideColor(sd BoxSides) *Color {
+	switch sd {
+	case BoxTop:
+		return &bs.TopColor
+	case BoxRight:
+		return &bs.RightColor
+	case BoxBottom:
+		return &bs.BottomColor
+	default:
+		return &bs.LeftColor
+	}
+}
+
+// CornerRadius returns the border radius for given corner
+func (bs *BorderSidesStyle) CornerRadius(cr BoxCorners) *units.Value {
+	switch cr {
+	case BoxTopLeft:
+		return &bs.TopLeftRadius
+	case BoxTopRight:
+		return &bs.TopRightRadius
+	case BoxBottomRight:
+		return &bs.BottomRightRadius
+	default:
+		return &bs.BottomLeftRadius
+	}
+}
+
+// SetFromBorder sets all the sides and corners from given border style
+func (bs *BorderSidesStyle) SetFromBorder(b *BorderStyle) {
+	for sd := BoxTop; sd < BoxN; sd++ {
+		*bs.SideStyle(sd) = b.Style
+		*bs.SideWidth(sd) = b.Width
+		*bs.SideColor(sd) = b.Color
+	}
+	for cr := BoxTopLeft; cr < BoxCornersN; cr++ {
+		*bs.CornerRadius(cr) = b.Radius
+	}
+}
+
+// Widths returns the widths of the sides in dots -- 0 for sides with
+// border style none or hidden
+func (bs *BorderSidesStyle) Widths() [BoxN]float32 {
+	var wd [BoxN]float32
+	for sd := BoxTop; sd < BoxN; sd++ {
+		if st := *bs.SideStyle(sd); st != BorderNone && st != BorderHidden {
+			wd[sd] = bs.SideWidth(sd).Dots
+		}
+	}
+	return wd
+}
+
+// MaxWidth returns the maximum width of the sides in dots
+func (bs *BorderSidesStyle) MaxWidth() float32 {
+	wd := bs.Widths()
+	return Max32(Max32(wd[BoxTop], wd[BoxRight]), Max32(wd[BoxBottom], wd[BoxLeft]))
+}
+
+// Radii returns the corner radii in dots
+func (bs *BorderSidesStyle) Radii() [BoxCornersN]float32 {
+	var rad [BoxCornersN]float32
+	for cr := BoxTopLeft; cr < BoxCornersN; cr++ {
+		rad[cr] = bs.CornerRadius(cr).Dots
+	}
+	return rad
+}
+
+// UniformRadius returns the corner radius in dots, and true if all corners
+// have the same radius
+func (bs *BorderSidesStyle) UniformRadius() (float32, bool) {
+	rad := bs.Radii()
+	return rad[0], rad[0] == rad[1] && rad[0] == rad[2] && rad[0] == rad[3]
+}
+
+// IsUniform returns true if all the sides have the same style, width and
+// color
+func (bs *BorderSidesStyle) IsUniform() bool {
+	for sd := BoxRight; sd < BoxN; sd++ {
+		if *bs.SideStyle(sd) != bs.TopStyle || bs.SideWidth(sd).Dots != bs.TopWidth.Dots || *bs.SideColor(sd) != bs.TopColor {
+			return false
+		}
+	}
+	return true
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   Border properties
+
+// sideValueIndex maps the sides (or corners) to the index of the value to
+// use from a list of 1-4 values, as in CSS
+var sideValueIndex = [5][4]int{{}, {0, 0, 0, 0}, {0, 1, 0, 1}, {0, 1, 2, 1}, {0, 1, 2, 3}}
+
+// multiValues returns the space-separated values in a property value if
+// it is a string with 2-4 values, else nil
+func multiValues(pv interface{}) []string {
+	str, ok := pv.(string)
+	if !ok {
+		return nil
+	}
+	vals := splitCSSList(str, ' ')
+	if len(vals) < 2 || len(vals) > 4 {
+		return nil
+	}
+	return vals
+}
+
+// borderDrawStyleFromString sets the border style from its lower-case CSS
+// name, returning false if it is not a valid name
+func borderDrawStyleFromString(str string, st *BorderDrawStyle) bool {
+	for bs := BorderSolid; bs < BorderN; bs++ {
+		if str == strings.ToLower(strings.TrimPrefix(bs.String(), "Border")) {
+			*st = bs
+			return true
+		}
+	}
+	return false
+}
+
+// parseBorderShorthand sets the width, style and color of given border from
+// a border shorthand value, e.g., 1px solid red -- returns which were set
+func parseBorderShorthand(str string, b *BorderStyle, vp *Viewport2D) (width, style, color bool) {
+	for _, tok := range splitCSSList(str, ' ') {
+		ltok := strings.ToLower(tok)
+		switch {
+		case isCSSLength(ltok):
+			b.Width = units.StringToValue(ltok)
+			width = true
+		case ltok == "thin" || ltok == "medium" || ltok == "thick":
+			b.Width = units.NewValue(map[string]float32{"thin": 1, "medium": 3, "thick": 5}[ltok], units.Px)
+			width = true
+		case borderDrawStyleFromString(ltok, &b.Style):
+			style = true
+		default:
+			if b.Color.SetStringStyle(tok, nil, vp) == nil {
+				color = true
+			}
+		}
+	}
+	return
+}
+
+// SetBorderPost sets the per-side border values in BorderSides from the
+// shorthand border properties in props, which apply to all sides unless a
+// more specific property for the side is also present: border-top-width >
+// border-top > border-width > border.  Called after the styled fields have
+// been set from the props, so Border has the (first) shorthand values.
+func (s *Style) SetBorderPost(props ki.Props, vp *Viewport2D) {
+	if len(props) == 0 {
+		return
+	}
+	bs := &s.BorderSides
+	has := func(key string) bool {
+		_, ok := props[key]
+		return ok
+	}
+	// explicit returns true if the side value is set more specifically
+	explicit := func(sd BoxSides, comp string) bool {
+		nm := "border-" + BoxSideNames[sd]
+		return has(nm+"-"+comp) || has(nm)
+	}
+	if str, ok := props["border"].(string); ok {
+		w, st, c := parseBorderShorthand(str, &s.Border, vp)
+		for sd := BoxTop; sd < BoxN; sd++ {
+			if w && !explicit(sd, "width") && !has("border-width") {
+				*bs.SideWidth(sd) = s.Border.Width
+			}
+			if st && !explicit(sd, "style") && !has("border-style") {
+				*bs.SideStyle(sd) = s.Border.Style
+			}
+			if c && !explicit(sd, "color") && !has("border-color") {
+				*bs.SideColor(sd) = s.Border.Color
+			}
+		}
+	}
+	if pv, ok := props["border-width"]; ok {
+		vals := multiValues(pv)
+		var uvs []units.Value
+		for _, v := range vals {
+			uvs = append(uvs, units.StringToValue(v))
+		}
+		if len(uvs) > 0 {
+			s.Border.Width = uvs[0]
+		}
+		for sd := BoxTop; sd < BoxN; sd++ {
+			if explicit(sd, "width") {
+				continue
+			}
+			if len(uvs) > 0 {
+				*bs.SideWidth(sd) = uvs[sideValueIndex[len(uvs)][sd]]
+			} else {
+				*bs.SideWidth(sd) = s.Border.Width
+			}
+		}
+	}
+	if pv, ok := props["border-style"]; ok {
+		vals := multiValues(pv)
+		var sts []BorderDrawStyle
+		for _, v := range vals {
+			var st BorderDrawStyle
+			borderDrawStyleFromString(strings.ToLower(v), &st)
+			sts = append(sts, st)
+		}
+		if len(sts) > 0 {
+			s.Border.Style = sts[0]
+		}
+		for sd := BoxTop; sd < BoxN; sd++ {
+			if explicit(sd, "style") {
+				continue
+			}
+			if len(sts) > 0 {
+				*bs.SideStyle(sd) = sts[sideValueIndex[len(sts)][sd]]
+			} else {
+				*bs.SideStyle(sd) = s.Border.Style
+			}
+		}
+	}
+	if pv, ok := props["border-color"]; ok {
+		vals := multiValues(pv)
+		var cls []Color
+		for _, v := range vals {
+			var c Color
+			c.SetStringStyle(v, nil, vp)
+			cls = append(cls, c)
+		}
+		if len(cls) > 0 {
+			s.Border.Color = cls[0]
+		}
+		for sd := BoxTop; sd < BoxN; sd++ {
+			if explicit(sd, "color") {
+				continue
+			}
+			if len(cls) > 0 {
+				*bs.SideColor(sd) = cls[sideValueIndex[len(cls)][sd]]
+			} else {
+				*bs.SideColor(sd) = s.Border.Color
+			}
+		}
+	}
+	for sd := BoxTop; sd < BoxN; sd++ {
+		nm := "border-" + BoxSideNames[sd]
+		if str, ok := props[nm].(string); ok {
+			b := BorderStyle{Style: *bs.SideStyle(sd), Width: *bs.SideWidth(sd), Color: *bs.SideColor(sd)}
+			parseBorderShorthand(str, &b, vp)
+			if !has(nm + "-width") {
+				*bs.SideWidth(sd) = b.Width
+			}
+			if !has(nm + "-style") {
+				*bs.SideStyle(sd) = b.Style
+			}
+			if !has(nm + "-color") {
+				*bs.SideColor(sd) = b.Color
+			}
+		}
+	}
+	if pv, ok := props["border-radius"]; ok {
+		vals := multiValues(pv)
+		var uvs []units.Value
+		for _, v := range vals {
+			uvs = append(uvs, units.StringToValue(v))
+		}
+		if len(uvs) > 0 {
+			s.Border.Radius = uvs[0]
+		}
+		for cr := BoxTopLeft; cr < BoxCornersN; cr++ {
+			if has("border-" + BoxCornerNames[cr] + "-radius") {
+				continue
+			}
+			if len(uvs) > 0 {
+				*bs.CornerRadius(cr) = uvs[sideValueIndex[len(uvs)][cr]]
+			} else {
+				*bs.CornerRadius(cr) = s.Border.Radius
+			}
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   Rendering
+
+// RenderBorder renders the border in given style for the border box at
+// given position and size (i.e., the outside edge of the border) -- uniform
+// solid borders are stroked as a path, and all others are rendered directly
+// per pixel, with each side in its own style, width and color, mitered at
+// the corners, and clipped to the rounded corners.
+func (wb *WidgetBase) RenderBorder(st *Style, pos, sz Vec2D) {
+	rs := &wb.Viewport.Render
+	pc := &rs.Paint
+	bs := &st.BorderSides
+	if bs.IsUniform() && (bs.TopStyle == BorderSolid || bs.TopWidth.Dots == 0) {
+		bw := bs.TopWidth.Dots
+		pc.StrokeStyle.SetColor(&bs.TopColor)
+		pc.StrokeStyle.Width = bs.TopWidth
+		pos = pos.AddVal(0.5 * bw)
+		sz = sz.SubVal(bw)
+		pc.FillStyle.SetColor(nil)
+		if rad, ok := bs.UniformRadius(); ok {
+			wb.RenderBoxImpl(pos, sz, rad)
+			return
+		}
+		rad := bs.Radii()
+		pc.DrawRoundedRectangleCorners(rs, pos.X, pos.Y, sz.X, sz.Y, rad)
+		pc.FillStrokeClear(rs)
+		return
+	}
+	RenderBorderSides(rs, bs, pos, sz)
+}
+
+// borderSideColors returns the colors for the outer and inner halves of a
+// border side with given style -- the 3D styles use darker colors for the
+// shaded sides
+func borderSideColors(sd BoxSides, st BorderDrawStyle, clr Color) [2]Color {
+	dark := clr.Darker(50)
+	light := clr.Lighter(50)
+	topLeft := sd == BoxTop || sd == BoxLeft
+	shade := func(lit bool) Color {
+		if lit {
+			return light
+		}
+		return dark
+	}
+	switch st {
+	case BorderInset:
+		c := shade(!topLeft)
+		return [2]Color{c, c}
+	case BorderOutset:
+		c := shade(topLeft)
+		return [2]Color{c, c}
+	case BorderGroove:
+		return [2]Color{shade(!topLeft), shade(topLeft)}
+	case BorderRidge:
+		return [2]Color{shade(topLeft), shade(!topLeft)}
+	}
+	return [2]Color{clr, clr}
+}
+
+// RenderBorderSides renders a border with independent sides and corner
+// radii, per pixel, for the border box at given position and size
+func RenderBorderSides(rs *RenderState, bs *BorderSidesStyle, pos, sz Vec2D) {
+	wd := bs.Widths()
+	rad := bs.Radii()
+	var cols [BoxN][2]Color
+	var sts [BoxN]BorderDrawStyle
+	for sd := BoxTop; sd < BoxN; sd++ {
+		sts[sd] = *bs.SideStyle(sd)
+		cols[sd] = borderSideColors(sd, sts[sd], *bs.SideColor(sd))
+	}
+	ipos := NewVec2D(pos.X+wd[BoxLeft], pos.Y+wd[BoxTop])
+	isz := NewVec2D(sz.X-wd[BoxLeft]-wd[BoxRight], sz.Y-wd[BoxTop]-wd[BoxBottom])
+	irad := [BoxCornersN]float32{
+		Max32(rad[BoxTopLeft]-Max32(wd[BoxTop], wd[BoxLeft]), 0),
+		Max32(rad[BoxTopRight]-Max32(wd[BoxTop], wd[BoxRight]), 0),
+		Max32(rad[BoxBottomRight]-Max32(wd[BoxBottom], wd[BoxRight]), 0),
+		Max32(rad[BoxBottomLeft]-Max32(wd[BoxBottom], wd[BoxLeft]), 0),
+	}
+	maxr := Max32(Max32(irad[0], irad[1]), Max32(irad[2], irad[3])) + 1
+	r := image.Rect(int(math.Floor(float64(pos.X))), int(math.Floor(float64(pos.Y))), int(math.Ceil(float64(pos.X+sz.X))), int(math.Ceil(float64(pos.Y+sz.Y)))).Intersect(rs.Bounds)
+	img := rs.Image
+	// the interior, which has no border, is skipped
+	skip0, skip1 := int(ipos.X+maxr), int(ipos.X+isz.X-maxr)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		py := float32(y) + 0.5
+		inrow := py > ipos.Y+maxr && py < ipos.Y+isz.Y-maxr
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if inrow && x > skip0 && x < skip1 {
+				x = skip1
+			}
+			px := float32(x) + 0.5
+			a := coverage(roundRectCornersDist(px, py, pos.X, pos.Y, sz.X, sz.Y, rad))
+			if a == 0 {
+				continue
+			}
+			if isz.X > 0 && isz.Y > 0 {
+				a *= 1 - coverage(roundRectCornersDist(px, py, ipos.X, ipos.Y, isz.X, isz.Y, irad))
+			}
+			if a == 0 {
+				continue
+			}
+			// the side is the one we are proportionally furthest into,
+			// which miters the corners between the outer and inner corners
+			dists := [BoxN]float32{py - pos.Y, pos.X + sz.X - px, pos.Y + sz.Y - py, px - pos.X}
+			sd := BoxSides(-1)
+			frac := float32(math.MaxFloat32)
+			for s := BoxTop; s < BoxN; s++ {
+				if wd[s] <= 0 {
+					continue
+				}
+				if f := dists[s] / wd[s]; f < frac {
+					sd, frac = s, f
+				}
+			}
+			if sd < 0 {
+				continue
+			}
+			frac = InRange32(frac, 0, 1)
+			along := px - pos.X
+			if sd == BoxLeft || sd == BoxRight {
+				along = py - pos.Y
+			}
+			w := wd[sd]
+			switch sts[sd] {
+			case BorderDouble:
+				if frac > 1.0/3.0 && frac < 2.0/3.0 {
+					continue
+				}
+			case BorderDotted:
+				if float32(math.Mod(float64(along), float64(2*w))) >= w {
+					continue
+				}
+			case BorderDashed:
+				if float32(math.Mod(float64(along), float64(5*w))) >= 3*w {
+					continue
+				}
+			}
+			c := cols[sd][0]
+			if frac >= 0.5 {
+				c = cols[sd][1]
+			}
+			blendPixel(img, x, y, c, a)
+		}
+	}
+}
+
+// blendPixel composites color c with coverage a over the pixel at x, y
+func blendPixel(img *image.RGBA, x, y int, c Color, a float32) {
+	i := img.PixOffset(x, y)
+	p := img.Pix[i : i+4 : i+4]
+	sa := float32(c.A) * a
+	ia := 1 - sa/255
+	p[0] = uint8(float32(c.R)*a + float32(p[0])*ia + 0.5)
+	p[1] = uint8(float32(c.G)*a + float32(p[1])*ia + 0.5)
+	p[2] = uint8(float32(c.B)*a + float32(p[2])*ia + 0.5)
+	p[3] = uint8(sa + float32(p[3])*ia + 0.5)
+}
+
+// roundRectCornersDist returns the signed distance from point px, py to the
+// edge of the rectangle at x, y of size w, h, with the corners rounded by
+// given radii (top-left, top-right, bottom-right, bottom-left) -- negative
+// inside
+func roundRectCornersDist(px, py, x, y, w, h float32, rad [BoxCornersN]float32) float32 {
+	cx, cy := x+0.5*w, y+0.5*h
+	var r float32
+	switch {
+	case px < cx && py < cy:
+		r = rad[BoxTopLeft]
+	case py < cy:
+		r = rad[BoxTopRight]
+	case px >= cx:
+		r = rad[BoxBottomRight]
+	default:
+		r = rad[BoxBottomLeft]
+	}
+	return roundRectDist(px, py, x, y, w, h, r)
+}
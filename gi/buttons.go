@@ -30,6 +30,7 @@ type ButtonBase struct {
 	Shortcut     key.Chord            `xml:"shortcut" desc:"optional shortcut keyboard chord to trigger this action -- always window-wide in scope, and should generally not conflict other shortcuts (a log message will be emitted if so).  Shortcuts are processed after all other processing of keyboard input.  Use Command for Control / Meta (Mac Command key) per platform.  These are only set automatically for Menu items, NOT for items in ToolBar or buttons somewhere, but the tooltip for buttons will show the shortcut if set."`
 	StateStyles  [ButtonStatesN]Style `json:"-" xml:"-" desc:"styles for different states of the button, one for each state -- everything inherits from the base Style which is styled first according to the user-set styles, and then subsequent style settings can override that"`
 	State        ButtonStates         `json:"-" xml:"-" desc:"current state of the button based on gui interaction"`
+	StyleAnim    StyleAnimator        `json:"-" xml:"-" view:"-" desc:"animates the transitions between state styles, and any keyframe animations"`
 	ButtonSig    ki.Signal            `json:"-" xml:"-" view:"-" desc:"signal for button -- see ButtonSignals for the types"`
 	Menu         Menu                 `desc:"the menu items for this menu -- typically add Action elements for menus, along with separators"`
 	MakeMenuFunc MakeMenuFunc         `json:"-" xml:"-" view:"-" desc:"set this to make a menu on demand -- if set then this button acts like a menu button"`
@@ -218,7 +219,7 @@ func (bb *ButtonBase) SetButtonState(state ButtonStates) bool {
 		}
 	}
 	bb.State = state
-	bb.Sty = bb.StateStyles[state]
+	bb.StyleAnim.Update(bb.AsWidget(), int(state), &bb.StateStyles[state], &bb.Sty)
 	if prev != bb.State {
 		bb.SetFullReRenderIconLabel() // needs full rerender to update text, icon
 		return true
@@ -247,9 +248,9 @@ func (bb *ButtonBase) UpdateButtonStyle() bool {
 			bb.State = ButtonActive
 		}
 	}
-	bb.Sty = bb.StateStyles[bb.State]
+	anim := bb.StyleAnim.Update(bb.AsWidget(), int(bb.State), &bb.StateStyles[bb.State], &bb.Sty)
 	bb.This().(ButtonWidget).ConfigPartsIfNeeded()
-	if prev != bb.State {
+	if prev != bb.State || anim {
 		bb.SetFullReRenderIconLabel() // needs full rerender
 		return true
 	}
@@ -578,6 +579,7 @@ func (bb *ButtonBase) StyleButton() {
 				bb.StateStyles[i].SetStyleProps(pst, stclsp, bb.Viewport)
 			}
 		}
+		bb.StyleCSS(&bb.StateStyles[i], ButtonSelectors[i])
 		bb.StateStyles[i].CopyUnitContext(&bb.Sty.UnContext)
 	}
 }
@@ -586,6 +588,7 @@ func (bb *ButtonBase) Style2D() {
 	bb.StyleButton()
 	bb.LayData.SetFromStyle(&bb.Sty.Layout) // also does reset
 	bb.This().(ButtonWidget).ConfigParts()
+	bb.StyleAnim.Reset()
 	bb.SetButtonState(ButtonActive) // initial default
 	if bb.Menu != nil {
 		bb.Menu.SetShortcuts(bb.ParentWindow())
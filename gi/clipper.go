@@ -5,6 +5,8 @@
 package gi
 
 import (
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/clip"
 	"github.com/goki/gi/oswin/dnd"
 	"github.com/goki/gi/oswin/mimedata"
 )
@@ -28,6 +30,17 @@ type Clipper interface {
 	Paste()
 }
 
+// PrimaryClip returns the PRIMARY selection clipboard for given window,
+// which holds the most recently selected text and is pasted with the middle
+// mouse button -- returns nil on platforms that do not have one (only X11
+// does).  Text widgets write their selection to it automatically.
+func PrimaryClip(win *Window) clip.Board {
+	if win == nil || win.OSWin == nil {
+		return nil
+	}
+	return clip.Primary(oswin.TheApp.ClipBoard(win.OSWin))
+}
+
 // DragNDropper is the interface for standard drag-n-drop actions
 // Types can use this interface to support extensible DND functionality
 // used in all relevant valueview types in giv package (e.g., TreeView)
@@ -26,9 +26,10 @@ import (
 // ColorSpec fully specifies the color for rendering -- used in FillStyle and
 // StrokeStyle
 type ColorSpec struct {
-	Source   ColorSources      `desc:"source of color (solid, gradient)"`
+	Source   ColorSources      `desc:"source of color (solid, gradient, pattern)"`
 	Color    Color             `desc:"color for solid color source"`
 	Gradient *rasterx.Gradient `desc:"gradient parameters for gradient color source"`
+	Pattern  *Pattern          `desc:"pattern for the tiled pattern color source -- shared, not copied, by copies of the spec"`
 }
 
 var KiT_ColorSpec = kit.Types.AddType(&ColorSpec{}, nil)
@@ -42,6 +43,7 @@ const (
 	SolidColor ColorSources = iota
 	LinearGradient
 	RadialGradient
+	TiledPattern
 	ColorSourcesN
 )
 
@@ -63,10 +65,13 @@ const (
 	GradientPointsN
 )
 
-// IsNil tests for nil solid or gradient colors
+// IsNil tests for nil solid or gradient colors, or patterns
 func (cs *ColorSpec) IsNil() bool {
-	if cs.Source == SolidColor {
+	switch cs.Source {
+	case SolidColor:
 		return cs.Color.IsNil()
+	case TiledPattern:
+		return cs.Pattern == nil
 	}
 	return cs.Gradient == nil
 }
@@ -85,6 +90,7 @@ func (cs *ColorSpec) SetColor(cl color.Color) {
 	cs.Color.SetColor(cl)
 	cs.Source = SolidColor
 	cs.Gradient = nil
+	cs.Pattern = nil
 }
 
 // Copy copies a gradient, making new copies of the stops instead of
@@ -131,8 +137,12 @@ func CopyGradient(dst, src *rasterx.Gradient) {
 }
 
 // RenderColor gets the color for rendering, applying opacity and bounds for
-// gradients
+// gradients -- patterns are transparent here, as they are painted with the
+// tile returned by PreparePattern for each element
 func (cs *ColorSpec) RenderColor(opacity float32, bounds image.Rectangle, xform Matrix2D) interface{} {
+	if cs.Source == TiledPattern && cs.Pattern != nil {
+		return color.Transparent
+	}
 	if cs.Source == SolidColor || cs.Gradient == nil {
 		return rasterx.ApplyOpacity(cs.Color, float64(opacity))
 	} else {
@@ -37,12 +37,15 @@ func XMLAttr(name string, attrs []xml.Attr) string {
 
 // SetString sets the color spec from a standard CSS-formatted string -- see
 // https://www.w3schools.com/css/css3_gradients.asp -- see UnmarshalXML for
-// XML-based version
+// XML-based version.  url(#name) refers to a named gradient or pattern
+// element, or a pattern registered with AddPattern, and url(file) tiles an
+// image file as a pattern.
 func (cs *ColorSpec) SetString(clrstr string, vp *Viewport2D) bool {
 	clrstr = strings.TrimSpace(clrstr)
 	if strings.HasPrefix(clrstr, "url(") {
-		val := clrstr[4:]
-		val = strings.TrimPrefix(strings.TrimSuffix(val, ")"), "#")
+		val := strings.Trim(strings.TrimSpace(strings.TrimSuffix(clrstr[4:], ")")), `"'`)
+		isID := strings.HasPrefix(val, "#")
+		val = strings.TrimPrefix(val, "#")
 		if vp != nil {
 			ne := vp.CurStyleNodeNamedEl(val)
 			if ne != nil {
@@ -50,17 +53,32 @@ func (cs *ColorSpec) SetString(clrstr string, vp *Viewport2D) bool {
 					*cs = grad.Grad
 					return true
 				}
+				if pn, ok := ne.(Patterner); ok {
+					cs.SetPattern(pn.Pattern())
+					return true
+				}
+			}
+		}
+		if pt, ok := PatternByName(val); ok {
+			cs.SetPattern(pt)
+			return true
+		}
+		if !isID { // image file, registered as a pattern for re-use
+			if img := backgroundImage(val); img != nil {
+				pt := NewImagePattern(img)
+				AddPattern(val, pt)
+				cs.SetPattern(pt)
+				return true
 			}
 		}
 		fmt.Printf("gi.Color Warning: Not able to find url: %v\n", val)
-		cs.Gradient = nil
-		cs.Source = SolidColor
-		cs.Color.SetColor(color.Black)
+		cs.SetColor(color.Black)
 		return false
 	}
 	clrstr = strings.ToLower(clrstr)
 	grad := "-gradient"
 	if gidx := strings.Index(clrstr, grad); gidx > 0 {
+		cs.Pattern = nil
 		gtyp := clrstr[:gidx]
 		rmdr := clrstr[gidx+len(grad):]
 		pidx := strings.IndexByte(rmdr, '(')
@@ -92,6 +110,7 @@ func (cs *ColorSpec) SetString(clrstr string, vp *Viewport2D) bool {
 		FixGradientStops(cs.Gradient)
 	} else {
 		cs.Gradient = nil
+		cs.Pattern = nil
 		cs.Source = SolidColor
 		cs.Color.SetString(clrstr, nil)
 	}
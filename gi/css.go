@@ -6,6 +6,7 @@ package gi
 
 import (
 	"log"
+	"strings"
 
 	"github.com/aymerick/douceur/css"
 	"github.com/aymerick/douceur/parser"
@@ -36,8 +37,17 @@ func (ss *StyleSheet) ParseString(str string) error {
 	return nil
 }
 
+// CSSOrderKey is the key for the source order of the declarations of each
+// selector in the properties returned by StyleSheet.CSSProps, as a
+// map[string]int from property name to the index of the rule that set it --
+// it is used by MatchCSS to apply declarations of equal specificity in the
+// order they appear in the style sheet, and is ignored in styling because
+// it starts with _
+const CSSOrderKey = "_css-order"
+
 // CSSProps returns the properties for each of the rules in this style sheet,
-// suitable for setting the CSS value of a node -- returns nil if empty sheet
+// suitable for setting the CSS value of a node -- returns nil if empty sheet.
+// The source order of each declaration is recorded under CSSOrderKey.
 func (ss *StyleSheet) CSSProps() ki.Props {
 	if ss.Sheet == nil {
 		return nil
@@ -47,21 +57,72 @@ func (ss *StyleSheet) CSSProps() ki.Props {
 		return nil
 	}
 	pr := make(ki.Props, sz)
+	order := 0
 	for _, r := range ss.Sheet.Rules {
 		if r.Kind == css.AtRule {
-			continue // not supported
-		}
-		nd := len(r.Declarations)
-		if nd == 0 {
-			continue
-		}
-		for _, sel := range r.Selectors {
-			sp := make(ki.Props, nd)
-			for _, de := range r.Declarations {
-				sp[de.Property] = de.Value
+			switch r.Name {
+			case "@keyframes":
+				ss.AddKeyFrames(r)
+			case "@media":
+				key := "@media " + strings.TrimSpace(r.Prelude)
+				mp, ok := pr[key].(ki.Props)
+				if !ok {
+					mp = make(ki.Props, len(r.Rules))
+					pr[key] = mp
+				}
+				for _, mr := range r.Rules {
+					addRuleProps(mp, mr, &order)
+				}
 			}
-			pr[sel] = sp
+			continue // others not supported
 		}
+		addRuleProps(pr, r, &order)
 	}
 	return pr
 }
+
+// addRuleProps adds the declarations of given rule to the props for each
+// of its selectors in pr, recording the source order of the rule, which is
+// then incremented -- a selector can be repeated in later rules, so the
+// order is recorded for each declaration
+func addRuleProps(pr ki.Props, r *css.Rule, order *int) {
+	nd := len(r.Declarations)
+	if nd == 0 {
+		return
+	}
+	ord := *order
+	*order++
+	for _, sel := range r.Selectors {
+		sp, ok := pr[sel].(ki.Props) // later rules for the same selector add to it
+		if !ok {
+			sp = make(ki.Props, nd)
+			pr[sel] = sp
+		}
+		ords, ok := sp[CSSOrderKey].(map[string]int)
+		if !ok {
+			ords = make(map[string]int, nd)
+			sp[CSSOrderKey] = ords
+		}
+		for _, de := range r.Declarations {
+			sp[de.Property] = de.Value
+			ords[de.Property] = ord
+		}
+	}
+}
+
+// AddKeyFrames registers the keyframes in given @keyframes rule, for use in
+// the animation style property
+func (ss *StyleSheet) AddKeyFrames(r *css.Rule) {
+	name := strings.TrimSpace(r.Prelude)
+	frames := make(ki.Props, len(r.Rules))
+	for _, kr := range r.Rules {
+		kp := make(ki.Props, len(kr.Declarations))
+		for _, de := range kr.Declarations {
+			kp[de.Property] = de.Value
+		}
+		for _, sel := range kr.Selectors {
+			frames[sel] = kp
+		}
+	}
+	AddKeyFrames(name, frames)
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"strings"
+
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+)
+
+// cssvars.go implements CSS custom properties:
+// https://www.w3.org/TR/css-variables-1/ -- properties with names starting
+// with -- (e.g., "--accent": "#39f") are set on a style and inherited by the
+// styles of all the children, and they are used in other property values as
+// var(--accent) or var(--accent, fallback), in ki.Props as well as parsed
+// StyleSheet's.  Lengths can also be calc() expressions (see units.Calc),
+// which can use var() too, e.g., calc(var(--gap) * 2).
+
+// CSSVars are the values of CSS custom properties, by name including the
+// leading -- -- the map is shared with the styles that inherit it, so it is
+// never modified, only copied with any new values (see SetFmProps)
+type CSSVars map[string]string
+
+// IsCSSVar returns true if the property name is a custom property: --name
+func IsCSSVar(key string) bool {
+	return strings.HasPrefix(key, "--")
+}
+
+// SetFmProps returns the vars with any custom properties set in props added
+// -- a copy is made if there are any, otherwise the vars are returned as-is.
+// var() references in the values are resolved when they are set, including
+// references to the other vars set in props, whatever their order.
+func (cv CSSVars) SetFmProps(props ki.Props) CSSVars {
+	var keys []string
+	for key := range props {
+		if IsCSSVar(key) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return cv
+	}
+	nv := make(CSSVars, len(cv)+len(keys))
+	for k, v := range cv {
+		nv[k] = v
+	}
+	for _, key := range keys {
+		nv[key] = strings.TrimSpace(kit.ToString(props[key]))
+	}
+	// each pass resolves one more level of references among the new vars --
+	// any left after as many passes as vars are in a cycle
+	for pass := 0; pass < len(keys); pass++ {
+		changed := false
+		for _, key := range keys {
+			str := nv[key]
+			if !strings.Contains(str, "var(") {
+				continue
+			}
+			if rstr, ok := nv.Resolve(str); ok && rstr != str {
+				nv[key] = rstr
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	return nv
+}
+
+// Inherit returns the vars to use for a child style that already has given
+// vars (e.g., from its default style): the parent vars (these), with any
+// of the child vars added
+func (cv CSSVars) Inherit(child CSSVars) CSSVars {
+	if len(child) == 0 {
+		return cv
+	}
+	if len(cv) == 0 {
+		return child
+	}
+	nv := make(CSSVars, len(cv)+len(child))
+	for k, v := range cv {
+		nv[k] = v
+	}
+	for k, v := range child {
+		nv[k] = v
+	}
+	return nv
+}
+
+// Resolve returns the string with all var() references replaced by the
+// values of the vars, or the fallback values if not set -- returns false if
+// a var is not set and has no fallback, in which case the property is
+// invalid and should not be set
+func (cv CSSVars) Resolve(str string) (string, bool) {
+	vi := strings.Index(str, "var(")
+	if vi < 0 {
+		return str, true
+	}
+	depth := 0
+	end := -1
+	for i := vi + 3; i < len(str); i++ {
+		if str[i] == '(' {
+			depth++
+		} else if str[i] == ')' {
+			depth--
+			if depth == 0 {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		return str, false
+	}
+	args := str[vi+4 : end]
+	name, fb := args, ""
+	hasFb := false
+	if ci := strings.IndexByte(args, ','); ci >= 0 {
+		name, fb, hasFb = args[:ci], strings.TrimSpace(args[ci+1:]), true
+	}
+	val, ok := cv[strings.TrimSpace(name)]
+	if !ok {
+		if !hasFb {
+			return str, false
+		}
+		if val, ok = cv.Resolve(fb); !ok {
+			return str, false
+		}
+	}
+	rest, ok := cv.Resolve(str[end+1:])
+	return str[:vi] + val + rest, ok
+}
+
+// ResolveProps returns the props with all var() references in string
+// values resolved (see Resolve), dropping the properties that are invalid
+// as a result -- a copy is made if there are any, otherwise the props are
+// returned as-is
+func (cv CSSVars) ResolveProps(props ki.Props) ki.Props {
+	var np ki.Props
+	for key, val := range props {
+		str, ok := val.(string)
+		if !ok || IsCSSVar(key) || !strings.Contains(str, "var(") {
+			continue
+		}
+		if np == nil {
+			np = make(ki.Props, len(props))
+			for k, v := range props {
+				np[k] = v
+			}
+		}
+		if rstr, ok := cv.Resolve(str); ok {
+			np[key] = rstr
+		} else {
+			delete(np, key)
+		}
+	}
+	if np == nil {
+		return props
+	}
+	return np
+}
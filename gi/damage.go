@@ -0,0 +1,208 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"image"
+)
+
+// damage.go implements the tracking of damaged (updated) regions of the
+// window: uploads of viewport regions to the window texture during a frame
+// (see FrameClock) are merged and done at the end of the frame, and only
+// the regions of the texture updated since the last publish are copied to
+// the window by Publish, when the window preserves its contents between
+// publishes.
+
+// MaxDamageRects is the maximum number of separate rectangles in a
+// DamageRegion -- when there would be more, they are merged into their
+// bounding box
+var MaxDamageRects = 16
+
+// DamageRegion is a set of damaged rectangles, which are merged as they are
+// added whenever the merged rectangle does not cover much more than the
+// separate ones
+type DamageRegion struct {
+	Rects []image.Rectangle `desc:"the damaged rectangles -- non-overlapping after merging, except where merging would cover too much undamaged area"`
+}
+
+// rectArea returns the area of the rectangle
+func rectArea(r image.Rectangle) int {
+	return r.Dx() * r.Dy()
+}
+
+// Add adds a damaged rectangle to the region, merging it with any of the
+// existing rectangles where the merged rectangle covers no more undamaged
+// area than the overlap between them (so adjacent aligned rectangles are
+// always merged)
+func (dr *DamageRegion) Add(r image.Rectangle) {
+	if r.Empty() {
+		return
+	}
+	for merged := true; merged; {
+		merged = false
+		for i, er := range dr.Rects {
+			if r.In(er) {
+				return
+			}
+			u := er.Union(r)
+			if rectArea(u) <= rectArea(er)+rectArea(r) {
+				r = u
+				dr.Rects = append(dr.Rects[:i], dr.Rects[i+1:]...)
+				merged = true
+				break
+			}
+		}
+	}
+	dr.Rects = append(dr.Rects, r)
+	if len(dr.Rects) > MaxDamageRects {
+		dr.Rects = []image.Rectangle{dr.Bounds()}
+	}
+}
+
+// IsEmpty returns true if there is no damage
+func (dr *DamageRegion) IsEmpty() bool {
+	return len(dr.Rects) == 0
+}
+
+// Bounds returns the bounding box of all the damage
+func (dr *DamageRegion) Bounds() image.Rectangle {
+	var bb image.Rectangle
+	for _, r := range dr.Rects {
+		bb = bb.Union(r)
+	}
+	return bb
+}
+
+// Area returns the total area of the damaged rectangles
+func (dr *DamageRegion) Area() int {
+	a := 0
+	for _, r := range dr.Rects {
+		a += rectArea(r)
+	}
+	return a
+}
+
+// Reset clears all the damage
+func (dr *DamageRegion) Reset() {
+	dr.Rects = dr.Rects[:0]
+}
+
+// DamageStats are statistics on the uploading and publishing of damaged
+// regions of a window -- reset them to measure a given set of updates
+type DamageStats struct {
+	Uploads         int `desc:"number of viewport regions uploaded to the window texture"`
+	UploadPixels    int `desc:"total number of pixels uploaded to the window texture"`
+	Publishes       int `desc:"number of times the window was published"`
+	FullPublishes   int `desc:"number of publishes that copied the entire window texture, because the window contents were not preserved, or the overlay was active"`
+	PublishPixels   int `desc:"total number of pixels copied from the window texture to the window by publishes"`
+	DeferredUploads int `desc:"number of uploads that were deferred to the end of a frame, where they are merged with the others in the frame"`
+}
+
+// String returns a summary of the stats
+func (ds *DamageStats) String() string {
+	return fmt.Sprintf("Uploads: %v (%v deferred), %v pixels  Publishes: %v (%v full), %v pixels", ds.Uploads, ds.DeferredUploads, ds.UploadPixels, ds.Publishes, ds.FullPublishes, ds.PublishPixels)
+}
+
+// ResetDamageStats resets the DamageStats, under UpMu, so they can be safely
+// reset while the window is being updated
+func (w *Window) ResetDamageStats() {
+	w.UpMu.Lock()
+	w.DamageStats = DamageStats{}
+	w.UpMu.Unlock()
+}
+
+// DamageStatsSnapshot returns a copy of the current DamageStats, taken under
+// UpMu, so they can be safely read while the window is being updated
+func (w *Window) DamageStatsSnapshot() DamageStats {
+	w.UpMu.Lock()
+	defer w.UpMu.Unlock()
+	return w.DamageStats
+}
+
+// vpDamage is a pending upload of damaged regions of a viewport
+type vpDamage struct {
+	off image.Point // offset from viewport to window coordinates
+	dmg DamageRegion
+}
+
+// uploadRegion uploads region vpBBox of viewport vp to winBBox in the window
+// texture, recording the damage -- must be called under UpMu
+func (w *Window) uploadRegion(vp *Viewport2D, vpBBox, winBBox image.Rectangle) {
+	w.WinTex.Upload(winBBox.Min, vp.OSImage, vpBBox)
+	w.Damage.Add(winBBox)
+	w.DamageStats.Uploads++
+	w.DamageStats.UploadPixels += rectArea(vpBBox)
+}
+
+// deferUpload records region vpBBox of viewport vp, at winBBox in the
+// window, to be uploaded at the next Publish, if we are in a frame or one
+// is scheduled, so that all the updates in the frame are merged -- returns
+// false if not, in which case it should be uploaded now -- must be called
+// under UpMu
+func (w *Window) deferUpload(vp *Viewport2D, vpBBox, winBBox image.Rectangle) bool {
+	if !w.FrameClock.isPending() {
+		return false
+	}
+	if w.pendUploads == nil {
+		w.pendUploads = make(map[*Viewport2D]*vpDamage)
+	}
+	pd, ok := w.pendUploads[vp]
+	if !ok {
+		pd = &vpDamage{off: winBBox.Min.Sub(vpBBox.Min)}
+		w.pendUploads[vp] = pd
+	}
+	pd.dmg.Add(vpBBox)
+	w.DamageStats.DeferredUploads++
+	return true
+}
+
+// uploadPending uploads all the pending deferred uploads, with the main
+// viewport first and then the popups in stacking order -- must be called
+// under UpMu
+func (w *Window) uploadPending() {
+	if len(w.pendUploads) == 0 {
+		return
+	}
+	upVp := func(vp *Viewport2D) {
+		pd, ok := w.pendUploads[vp]
+		if !ok {
+			return
+		}
+		delete(w.pendUploads, vp)
+		if vp.OSImage == nil {
+			return
+		}
+		for _, r := range pd.dmg.Rects {
+			r = r.Intersect(vp.OSImage.Bounds())
+			w.uploadRegion(vp, r, r.Add(pd.off))
+		}
+	}
+	upVp(w.Viewport)
+	w.PopMu.RLock()
+	for _, pop := range w.PopupStack {
+		if gii, _ := KiToNode2D(pop); gii != nil {
+			upVp(gii.AsViewport2D())
+		}
+	}
+	if gii, _ := KiToNode2D(w.Popup); gii != nil {
+		upVp(gii.AsViewport2D())
+	}
+	w.PopMu.RUnlock()
+	for vp := range w.pendUploads { // others are popups that have been closed
+		delete(w.pendUploads, vp)
+	}
+}
+
+// DamageAll marks the entire window as damaged, so that the next Publish
+// copies all of it -- e.g., when the window contents have been lost
+func (w *Window) DamageAll() {
+	w.UpMu.Lock()
+	if w.WinTex != nil {
+		w.Damage.Add(w.WinTex.Bounds())
+	}
+	w.publishFull = true
+	w.UpMu.Unlock()
+}
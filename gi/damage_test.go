@@ -0,0 +1,61 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/gitest"
+	"github.com/goki/gi/oswin/driver/offscreen"
+)
+
+func TestDamagePublish(t *testing.T) {
+	var but *gi.Button
+	au := gitest.NewWindowAuto(t, "damage", 200, 100, func(mfr *gi.Frame) {
+		for i := 0; i < 2; i++ {
+			but = mfr.AddNewChild(gi.KiT_Button, fmt.Sprintf("but%d", i)).(*gi.Button)
+			but.SetText("Click")
+		}
+	})
+	defer au.Close()
+	win := au.Win
+
+	update := func() { // re-renders just the last button
+		updt := but.UpdateStart()
+		but.SetProp("background-color", "#f00")
+		but.UpdateEnd(updt)
+	}
+	win.ResetDamageStats()
+	update()
+	au.Wait()
+	st := win.DamageStatsSnapshot()
+	if st.Publishes == 0 || st.FullPublishes != 0 || st.PublishPixels > st.UploadPixels || st.UploadPixels >= 200*100 {
+		t.Errorf("button update not published as a partial update: %v\n", st.String())
+	}
+	if diff := gitest.Compare(offscreen.WindowImage(win.OSWin), win.Viewport.Pixels, 0); diff.NPixels != 0 {
+		t.Errorf("partially published window differs from viewport in %v pixels\n", diff.NPixels)
+	}
+
+	// updates during a frame are merged and uploaded at the end of it
+	done := make(chan struct{})
+	win.ResetDamageStats()
+	win.RequestAnimationFrame(func(now time.Time) {
+		update()
+		update()
+		close(done)
+	})
+	<-done
+	au.Wait()
+	st = win.DamageStatsSnapshot()
+	if st.DeferredUploads < 2 || st.Uploads != 1 || st.Publishes != 1 {
+		t.Errorf("frame updates not merged: %v\n", st.String())
+	}
+	if diff := gitest.Compare(offscreen.WindowImage(win.OSWin), win.Viewport.Pixels, 0); diff.NPixels != 0 {
+		t.Errorf("window differs from viewport after frame in %v pixels\n", diff.NPixels)
+	}
+}
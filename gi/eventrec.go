@@ -0,0 +1,320 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/dnd"
+	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/mouse"
+	"github.com/goki/gi/oswin/window"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  EventRecording
+
+// EventRecVersion is the current version of the EventRecording file format --
+// recordings with a different version cannot be replayed.  Version 2 saves
+// the event types by name instead of number.
+const EventRecVersion = 2
+
+// EventReplayTimeout is the maximum amount of time to wait for the window
+// event loop to process each replayed event, before giving up
+var EventReplayTimeout = 10 * time.Second
+
+// RecordedEvent is one event in an EventRecording
+type RecordedEvent struct {
+	Type  oswin.EventType `desc:"type of the event, saved by name -- determines how Event is decoded"`
+	Time  time.Duration   `desc:"time of the event relative to the start of the recording"`
+	Size  image.Point     `desc:"for WindowResizeEvent, the size of the window after the resize"`
+	Event json.RawMessage `desc:"the event itself, in JSON format"`
+}
+
+// Decode returns a new event of the appropriate type decoded from the
+// recorded JSON data
+func (re *RecordedEvent) Decode() (oswin.Event, error) {
+	var ev oswin.Event
+	switch re.Type {
+	case oswin.MouseEvent:
+		ev = &mouse.Event{}
+	case oswin.MouseMoveEvent:
+		ev = &mouse.MoveEvent{}
+	case oswin.MouseDragEvent:
+		ev = &mouse.DragEvent{}
+	case oswin.MouseScrollEvent:
+		ev = &mouse.ScrollEvent{}
+	case oswin.KeyEvent:
+		ev = &key.Event{}
+	case oswin.KeyChordEvent:
+		ev = &key.ChordEvent{}
+	case oswin.IMEEvent:
+		ev = &key.IMEEvent{}
+	case oswin.WindowResizeEvent:
+		ev = &window.Event{}
+	case oswin.DNDEvent:
+		ev = &dnd.Event{}
+	case oswin.DNDMoveEvent:
+		ev = &dnd.MoveEvent{}
+	default:
+		return nil, fmt.Errorf("gi.RecordedEvent: event type %v cannot be replayed", re.Type)
+	}
+	if err := json.Unmarshal(re.Event, ev); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// IsRecordableEvent returns true if given event type is recorded by an
+// EventRecording -- these are the raw input events coming from the OS, from
+// which all of the other events are generated within the Window.
+func IsRecordableEvent(et oswin.EventType) bool {
+	switch et {
+	case oswin.MouseEvent, oswin.MouseMoveEvent, oswin.MouseDragEvent, oswin.MouseScrollEvent,
+		oswin.KeyEvent, oswin.KeyChordEvent, oswin.IMEEvent, oswin.WindowResizeEvent,
+		oswin.DNDEvent, oswin.DNDMoveEvent:
+		return true
+	}
+	return false
+}
+
+// EventRecording is a record of the raw input events received by a Window,
+// which can be saved to a file and replayed later (see Window
+// StartEventRecording, ReplayEvents), e.g., to turn a bug report into a
+// reproducible regression test.
+type EventRecording struct {
+	Version    int             `desc:"version of the recording format -- see EventRecVersion"`
+	Window     string          `desc:"name of the window that was recorded"`
+	Size       image.Point     `desc:"size of the window at the start of recording"`
+	LogicalDPI float32         `desc:"logical DPI of the window at the start of recording"`
+	Events     []RecordedEvent `desc:"the recorded events, in order"`
+	start      time.Time
+	mu         sync.Mutex
+}
+
+// NewEventRecording returns a new EventRecording starting now, for given
+// window
+func NewEventRecording(w *Window) *EventRecording {
+	rec := &EventRecording{Version: EventRecVersion, Window: w.Nm, start: time.Now()}
+	if w.OSWin != nil {
+		rec.Size = w.OSWin.Size()
+		rec.LogicalDPI = w.OSWin.LogicalDPI()
+	}
+	return rec
+}
+
+// Record records given event, if it is of a recordable type -- winSz is the
+// current window size, used for resize events
+func (rec *EventRecording) Record(evi oswin.Event, winSz image.Point) {
+	et := evi.Type()
+	if !IsRecordableEvent(et) {
+		return
+	}
+	var b []byte
+	var err error
+	switch ev := evi.(type) {
+	case *dnd.Event: // source, target are not saveable, and only internal anyway
+		ce := *ev
+		ce.Source, ce.Target = nil, nil
+		b, err = json.Marshal(&ce)
+	case *dnd.MoveEvent:
+		ce := *ev
+		ce.Source, ce.Target = nil, nil
+		b, err = json.Marshal(&ce)
+	default:
+		b, err = json.Marshal(evi)
+	}
+	if err != nil {
+		log.Printf("gi.EventRecording: could not record event: %v err: %v\n", evi.String(), err)
+		return
+	}
+	re := RecordedEvent{Type: et, Event: b}
+	if et == oswin.WindowResizeEvent {
+		re.Size = winSz
+	}
+	rec.mu.Lock()
+	if rec.start.IsZero() {
+		rec.start = evi.Time()
+	}
+	re.Time = evi.Time().Sub(rec.start)
+	if re.Time < 0 {
+		re.Time = 0
+	}
+	rec.Events = append(rec.Events, re)
+	rec.mu.Unlock()
+}
+
+// OpenJSON opens a recording from a JSON-formatted file.
+func (rec *EventRecording) OpenJSON(filename FileName) error {
+	b, err := ioutil.ReadFile(string(filename))
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.Events = nil
+	err = json.Unmarshal(b, rec)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	if rec.Version != EventRecVersion {
+		err = fmt.Errorf("gi.EventRecording: file: %v has version: %v which is not the current version: %v", filename, rec.Version, EventRecVersion)
+		log.Println(err)
+	}
+	return err
+}
+
+// SaveJSON saves the recording to a JSON-formatted file.
+func (rec *EventRecording) SaveJSON(filename FileName) error {
+	rec.mu.Lock()
+	b, err := json.MarshalIndent(rec, "", "  ")
+	rec.mu.Unlock()
+	if err != nil {
+		log.Println(err) // unlikely
+		return err
+	}
+	err = ioutil.WriteFile(string(filename), b, 0644)
+	if err != nil {
+		log.Println(err)
+	}
+	return err
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Window record / replay
+
+// eventSync is a private event used by WaitEvents to synchronize with the
+// event loop -- done is closed when the event loop receives it, at which
+// point all prior events have been fully processed, after calling fun if
+// it is set (see RunOnEventLoop)
+type eventSync struct {
+	oswin.EventBase
+	fun  func()
+	done chan struct{}
+}
+
+func (ev *eventSync) Type() oswin.EventType { return oswin.CustomEventType }
+func (ev *eventSync) HasPos() bool          { return false }
+func (ev *eventSync) Pos() image.Point      { return image.ZP }
+func (ev *eventSync) OnFocus() bool         { return false }
+func (ev *eventSync) String() string        { return "event replay sync" }
+
+// StartEventRecording starts recording the raw input events received by
+// this window, returning the new recording (which is also in EventRec).
+func (w *Window) StartEventRecording() *EventRecording {
+	rec := NewEventRecording(w)
+	w.EventRecMu.Lock()
+	w.EventRec = rec
+	w.EventRecMu.Unlock()
+	return rec
+}
+
+// StopEventRecording stops recording events, returning the recording (nil
+// if not recording).
+func (w *Window) StopEventRecording() *EventRecording {
+	w.EventRecMu.Lock()
+	rec := w.EventRec
+	w.EventRec = nil
+	w.EventRecMu.Unlock()
+	return rec
+}
+
+// IsEventRecording returns true if events are currently being recorded
+func (w *Window) IsEventRecording() bool {
+	w.EventRecMu.Lock()
+	defer w.EventRecMu.Unlock()
+	return w.EventRec != nil
+}
+
+// recordEvent records given event if recording is active
+func (w *Window) recordEvent(evi oswin.Event) {
+	w.EventRecMu.Lock()
+	rec := w.EventRec
+	w.EventRecMu.Unlock()
+	if rec != nil {
+		rec.Record(evi, w.OSWin.Size())
+	}
+}
+
+// ReplayEvents sends the events in given recording to this window, in
+// order, waiting for each event to be fully processed by the event loop
+// before sending the next one, so the results are deterministic.  Speed
+// determines the timing: 1 replays with the original timing, 2 at twice the
+// original speed, etc, and <= 0 sends each event as soon as the prior one
+// has been processed -- note that drag and hover events depend on elapsed
+// time, so they are only reproduced faithfully with the original timing.
+// Resize events are replayed by resizing the OS window.  Blocks until done,
+// so it must be called from a different goroutine than the event loop.
+func (w *Window) ReplayEvents(rec *EventRecording, speed float64) error {
+	if rec.Version != EventRecVersion {
+		return fmt.Errorf("gi.Window ReplayEvents: recording version: %v is not the current version: %v", rec.Version, EventRecVersion)
+	}
+	rec.mu.Lock()
+	evs := make([]RecordedEvent, len(rec.Events))
+	copy(evs, rec.Events)
+	rec.mu.Unlock()
+
+	start := time.Now()
+	for i := range evs {
+		re := &evs[i]
+		ev, err := re.Decode()
+		if err != nil {
+			return err
+		}
+		if speed > 0 {
+			due := start.Add(time.Duration(float64(re.Time) / speed))
+			if d := time.Until(due); d > 0 {
+				time.Sleep(d)
+			}
+		}
+		if w.IsClosed() {
+			return fmt.Errorf("gi.Window ReplayEvents: window: %v closed during replay", w.Nm)
+		}
+		if re.Type == oswin.WindowResizeEvent {
+			w.OSWin.SetSize(re.Size) // generates its own resize event
+		} else {
+			ev.Init()
+			w.OSWin.Send(ev)
+		}
+		if err := w.WaitEvents(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WaitEvents waits until the event loop has processed all of the events
+// sent to the window so far, returning an error if that takes longer than
+// EventReplayTimeout.  The event loop must be running, in a different
+// goroutine.
+func (w *Window) WaitEvents() error {
+	return w.RunOnEventLoop(nil)
+}
+
+// RunOnEventLoop calls given function on the event loop goroutine, once all
+// of the events sent to the window so far have been processed, and waits
+// for it to return -- the widget tree is updated by the event loop, so this
+// is how other goroutines can safely inspect it while the event loop is
+// running.  Returns an error if that takes longer than EventReplayTimeout.
+func (w *Window) RunOnEventLoop(fun func()) error {
+	se := &eventSync{fun: fun, done: make(chan struct{})}
+	se.Init()
+	w.OSWin.Send(se)
+	select {
+	case <-se.done:
+		return nil
+	case <-time.After(EventReplayTimeout):
+		return fmt.Errorf("gi.Window RunOnEventLoop: timed out waiting for window: %v to process events", w.Nm)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"bytes"
+	"image"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/mouse"
+	"github.com/goki/gi/oswin/window"
+)
+
+func TestEventRecording(t *testing.T) {
+	rec := &EventRecording{Version: EventRecVersion}
+	me := &mouse.Event{Where: image.Point{10, 20}, Button: mouse.Left, Action: mouse.Press}
+	me.Init()
+	ke := &key.ChordEvent{}
+	ke.Rune = 'x'
+	ke.Action = key.Press
+	ke.Init()
+	we := &window.Event{Action: window.Resize}
+	we.Init()
+	pe := &window.Event{Action: window.Paint} // not recordable
+	pe.Init()
+	for _, ev := range []oswin.Event{me, ke, we, pe} {
+		rec.Record(ev, image.Point{300, 200})
+	}
+	if len(rec.Events) != 3 {
+		t.Fatalf("recorded %v events != 3\n", len(rec.Events))
+	}
+
+	fn := FileName(filepath.Join(t.TempDir(), "events.json"))
+	if err := rec.SaveJSON(fn); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(string(fn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte(`"Type": "MouseEvent"`)) {
+		t.Errorf("event type not saved by name:\n%s\n", b)
+	}
+	nrec := &EventRecording{}
+	if err := nrec.OpenJSON(fn); err != nil {
+		t.Fatal(err)
+	}
+	if len(nrec.Events) != 3 {
+		t.Fatalf("opened %v events != 3\n", len(nrec.Events))
+	}
+	ev, err := nrec.Events[0].Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dme, ok := ev.(*mouse.Event); !ok || dme.Where != me.Where || dme.Button != me.Button {
+		t.Errorf("decoded mouse event: %v != %v\n", ev, me)
+	}
+	ev, err = nrec.Events[1].Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dke, ok := ev.(*key.ChordEvent); !ok || dke.Rune != 'x' {
+		t.Errorf("decoded key chord event: %v != %v\n", ev, ke)
+	}
+	if nrec.Events[2].Size != (image.Point{300, 200}) {
+		t.Errorf("resize event size: %v != (300,200)\n", nrec.Events[2].Size)
+	}
+
+	bad := bytes.Replace(b, []byte(`"Type": "MouseEvent"`), []byte(`"Type": "NoSuchEvent"`), 1)
+	if err := ioutil.WriteFile(string(fn), bad, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := nrec.OpenJSON(fn); err == nil {
+		t.Errorf("unknown event type name was not rejected\n")
+	}
+}
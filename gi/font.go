@@ -57,6 +57,7 @@ type FontStyle struct {
 	Color    Color           `xml:"color" inherit:"true" desc:"prop: color = text color -- also defines the currentColor variable value"`
 	BgColor  ColorSpec       `xml:"background-color" desc:"prop: background-color = background color -- not inherited, transparent by default"`
 	Opacity  float32         `xml:"opacity" desc:"prop: opacity = alpha value to apply to all elements"`
+	Blend    BlendModes      `xml:"mix-blend-mode" desc:"prop: mix-blend-mode = how the colors of the element, rendered as a whole with its opacity, blend with the colors below it -- not inherited -- see PushLayer"`
 	Size     units.Value     `xml:"font-size" desc:"prop: font-size = size of font to render -- convert to points when getting font to use"`
 	Family   string          `xml:"font-family" inherit:"true" desc:"prop: font-family = font family -- ordered list of comma-separated names from more general to more specific to use -- use split on , to parse"`
 	Style    FontStyles      `xml:"font-style" inherit:"true" desc:"prop: font-style = style -- normal, italic, etc"`
@@ -100,6 +101,7 @@ func (fs *FontStyle) SetStylePost(props ki.Props) {
 			}
 		}
 	}
+	fs.SetBlendPost(props)
 }
 
 // InheritFields from parent: Manual inheriting of values is much faster than
@@ -543,6 +545,29 @@ var FontWeightNames = []string{"Normal", "Thin", "ExtraLight", "Light", "Medium"
 // one)
 var FontWeightNameVals = []FontWeights{WeightNormal, WeightThin, WeightExtraLight, WeightLight, WeightMedium, WeightSemiBold, WeightExtraBold, WeightBold, WeightBlack}
 
+// FontWeightCSSVals maps the weight enums to the standard CSS numeric
+// weights, e.g., for writing SVG
+var FontWeightCSSVals = map[FontWeights]int{
+	Weight100:        100,
+	WeightThin:       100,
+	Weight200:        200,
+	WeightExtraLight: 200,
+	Weight300:        300,
+	WeightLight:      300,
+	Weight400:        400,
+	WeightNormal:     400,
+	Weight500:        500,
+	WeightMedium:     500,
+	Weight600:        600,
+	WeightSemiBold:   600,
+	Weight700:        700,
+	WeightBold:       700,
+	Weight800:        800,
+	WeightExtraBold:  800,
+	Weight900:        900,
+	WeightBlack:      900,
+}
+
 // FontWeightToNameMap maps all the style enums to canonical regularized font names
 var FontWeightToNameMap = map[FontWeights]string{
 	Weight100:        "Thin",
@@ -796,6 +821,44 @@ func (fl *FontLib) DeleteFont(fontnm string) {
 	}
 }
 
+// FaceInfo returns the regularized font name and integer dots size of given
+// font face, if it was loaded by the library (see Font) -- returns false if
+// not found
+func (fl *FontLib) FaceInfo(face font.Face) (fontnm string, size int, ok bool) {
+	loadFontMu.RLock()
+	defer loadFontMu.RUnlock()
+	for nm, facemap := range fl.Faces {
+		for sz, f := range facemap {
+			if f != face {
+				continue
+			}
+			for _, fi := range fl.FontInfo {
+				if strings.ToLower(fi.Name) == nm {
+					return fi.Name, sz, true
+				}
+			}
+			return nm, sz, true
+		}
+	}
+	return "", 0, false
+}
+
+// FontData returns the raw data of the font file for given font name (case
+// insensitive), e.g., for embedding the font in a document
+func (fl *FontLib) FontData(fontnm string) ([]byte, error) {
+	fl.Init()
+	loadFontMu.RLock()
+	path, ok := fl.FontsAvail[strings.ToLower(fontnm)]
+	loadFontMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("gi.FontLib: Font named: %v not found in list of available fonts", fontnm)
+	}
+	if gf, has := GoFonts[path]; has {
+		return gf.ttf, nil
+	}
+	return ioutil.ReadFile(path)
+}
+
 // OpenAllFonts attempts to load all fonts that were found -- call this before
 // displaying the font chooser to eliminate any bad fonts.
 func (fl *FontLib) OpenAllFonts(size int) {
@@ -62,35 +62,25 @@ func (fr *Frame) FrameStdRender() {
 	pc.FillBox(rs, pos, sz, &st.Font.BgColor)
 
 	rad := st.Border.Radius.Dots
-	pos = pos.AddVal(st.Layout.Margin.Dots).SubVal(0.5 * st.Border.Width.Dots)
-	sz = sz.SubVal(2.0 * st.Layout.Margin.Dots).AddVal(st.Border.Width.Dots)
-
-	// then any shadow -- todo: optimize!
-	if st.BoxShadow.HasShadow() {
-		spos := pos.Add(Vec2D{st.BoxShadow.HOffset.Dots, st.BoxShadow.VOffset.Dots})
-		pc.StrokeStyle.SetColor(nil)
-		pc.FillStyle.SetColor(&st.BoxShadow.Color)
-		if rad == 0.0 {
-			pc.DrawRectangle(rs, spos.X, spos.Y, sz.X, sz.Y)
-		} else {
-			pc.DrawRoundedRectangle(rs, spos.X, spos.Y, sz.X, sz.Y, rad)
-		}
-		pc.FillStrokeClear(rs)
+	bw := st.Border.Width.Dots
+	pos = pos.AddVal(st.Layout.Margin.Dots).SubVal(0.5 * bw)
+	sz = sz.SubVal(2.0 * st.Layout.Margin.Dots).AddVal(bw)
+
+	// then any shadow
+	if st.HasBoxShadow() {
+		fr.RenderBoxShadows(st, pos, sz, rad, false)
 	}
+	fr.RenderBackgroundImage(st, pos.SubVal(0.5*bw), sz.AddVal(bw))
 
 	if fr.Lay == LayoutGrid && fr.Stripes != NoStripes {
 		fr.RenderStripes()
 	}
 
-	pc.FillStyle.SetColor(nil)
-	pc.StrokeStyle.SetColor(&st.Border.Color)
-	pc.StrokeStyle.Width = st.Border.Width
-	if rad == 0.0 {
-		pc.DrawRectangle(rs, pos.X, pos.Y, sz.X, sz.Y)
-	} else {
-		pc.DrawRoundedRectangle(rs, pos.X, pos.Y, sz.X, sz.Y, rad)
+	if st.HasBoxShadow() {
+		fr.RenderBoxShadows(st, pos.AddVal(bw), sz.SubVal(2*bw), Max32(rad-bw, 0), true)
 	}
-	pc.FillStrokeClear(rs)
+
+	fr.RenderBorder(st, pos.SubVal(0.5*bw), sz.AddVal(bw))
 	rs.Unlock()
 }
 
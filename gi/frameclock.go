@@ -0,0 +1,247 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"sync"
+	"time"
+
+	"github.com/goki/gi/oswin"
+)
+
+// FrameRate is the default target number of frames per second for the
+// window FrameClock -- the refresh rate of the window's screen is used
+// instead when it is known.
+var FrameRate = 60
+
+// FrameStats are the frame timing statistics for a window FrameClock --
+// averages are running averages over roughly the last 10 frames.
+type FrameStats struct {
+	Frames      int           `desc:"number of frames that have been run"`
+	Dropped     int           `desc:"number of frame intervals that were missed because a frame or other event processing took too long"`
+	Last        time.Time     `desc:"start time of the last frame"`
+	Interval    time.Duration `desc:"actual interval between the starts of the last two frames"`
+	AvgInterval time.Duration `desc:"running average interval between frames"`
+	Dur         time.Duration `desc:"time taken by the last frame, for callbacks and publishing"`
+	AvgDur      time.Duration `desc:"running average time taken by frames"`
+	MaxDur      time.Duration `desc:"maximum time taken by any frame"`
+}
+
+// FPS returns the average frames per second, from AvgInterval
+func (fs *FrameStats) FPS() float32 {
+	if fs.AvgInterval == 0 {
+		return 0
+	}
+	return float32(time.Second) / float32(fs.AvgInterval)
+}
+
+// update updates the stats for a frame that started at st and took dur,
+// given the target interval between frames -- chained is true if the frame
+// was requested during the previous frame, so the interval is meaningful
+func (fs *FrameStats) update(st time.Time, dur, target time.Duration, chained bool) {
+	if chained && fs.Frames > 0 {
+		fs.Interval = st.Sub(fs.Last)
+		if fs.AvgInterval == 0 {
+			fs.AvgInterval = fs.Interval
+		} else {
+			fs.AvgInterval += (fs.Interval - fs.AvgInterval) / 10
+		}
+		if target > 0 && fs.Interval > target+target/2 {
+			fs.Dropped += int(fs.Interval/target) - 1
+		}
+	}
+	fs.Frames++
+	fs.Last = st
+	fs.Dur = dur
+	if fs.AvgDur == 0 {
+		fs.AvgDur = dur
+	} else {
+		fs.AvgDur += (dur - fs.AvgDur) / 10
+	}
+	if dur > fs.MaxDur {
+		fs.MaxDur = dur
+	}
+}
+
+// frameCallback is one requested animation frame callback
+type frameCallback struct {
+	id  int
+	fun func(now time.Time)
+}
+
+// FrameClock drives animation for a Window: callbacks requested with
+// Window.RequestAnimationFrame are all called at the start of the next
+// frame, in the window event loop, and while frames are pending, all the
+// window publishing triggered by updates (from the callbacks or anywhere
+// else) is coalesced into a single publish at the end of the frame.
+type FrameClock struct {
+	Interval    time.Duration `desc:"target interval between frames -- if 0, the screen refresh rate or FrameRate is used -- use SetInterval once the event loop is running"`
+	Stats       FrameStats    `desc:"frame timing statistics"`
+	callbacks   []frameCallback
+	nextID      int
+	start       time.Time
+	scheduled   bool
+	chained     bool
+	inFrame     bool
+	needPublish bool
+	mu          sync.Mutex
+}
+
+// frameTick is a private event that triggers a frame in the event loop
+type frameTick struct {
+	oswin.EventBase
+}
+
+func (ev *frameTick) Type() oswin.EventType { return oswin.CustomEventType }
+func (ev *frameTick) HasPos() bool          { return false }
+func (ev *frameTick) Pos() image.Point      { return image.ZP }
+func (ev *frameTick) OnFocus() bool         { return false }
+func (ev *frameTick) String() string        { return "frame tick" }
+
+// SetInterval sets the target interval between frames -- 0 uses the screen
+// refresh rate or FrameRate
+func (fc *FrameClock) SetInterval(iv time.Duration) {
+	fc.mu.Lock()
+	fc.Interval = iv
+	fc.mu.Unlock()
+}
+
+// FrameInterval returns the target interval between frames for this window
+func (w *Window) FrameInterval() time.Duration {
+	fc := &w.FrameClock
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return w.frameInterval()
+}
+
+// frameInterval returns the target interval between frames -- FrameClock.mu
+// must be locked
+func (w *Window) frameInterval() time.Duration {
+	if w.FrameClock.Interval > 0 {
+		return w.FrameClock.Interval
+	}
+	if sc := w.OSWin.Screen(); sc != nil && sc.RefreshRate > 0 {
+		return time.Duration(float32(time.Second) / sc.RefreshRate)
+	}
+	return time.Second / time.Duration(FrameRate)
+}
+
+// RequestAnimationFrame requests that given function be called at the start
+// of the next frame, in the window event loop, with the start time of the
+// frame -- the function must request another frame to continue animating.
+// All the updates made during the frame are published together at the end
+// of the frame.  Returns an id that can be passed to CancelAnimationFrame.
+func (w *Window) RequestAnimationFrame(fun func(now time.Time)) int {
+	fc := &w.FrameClock
+	fc.mu.Lock()
+	fc.nextID++
+	id := fc.nextID
+	fc.callbacks = append(fc.callbacks, frameCallback{id: id, fun: fun})
+	w.scheduleFrame()
+	fc.mu.Unlock()
+	return id
+}
+
+// CancelAnimationFrame cancels the pending animation frame callback with
+// given id, as returned by RequestAnimationFrame -- returns false if not
+// found (e.g., it was already called).
+func (w *Window) CancelAnimationFrame(id int) bool {
+	fc := &w.FrameClock
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	for i, cb := range fc.callbacks {
+		if cb.id == id {
+			fc.callbacks = append(fc.callbacks[:i], fc.callbacks[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// FrameStats returns a copy of the current frame timing statistics
+func (w *Window) FrameStats() FrameStats {
+	fc := &w.FrameClock
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.Stats
+}
+
+// scheduleFrame schedules the next frame tick at the frame interval after
+// the start of the last frame, if not already scheduled -- FrameClock.mu
+// must be locked
+func (w *Window) scheduleFrame() {
+	fc := &w.FrameClock
+	if fc.scheduled {
+		return
+	}
+	fc.scheduled = true
+	fc.chained = fc.inFrame
+	delay := time.Until(fc.start.Add(w.frameInterval()))
+	if delay < 0 {
+		delay = 0
+	}
+	time.AfterFunc(delay, func() {
+		if w.IsClosed() {
+			return
+		}
+		ev := &frameTick{}
+		ev.Init()
+		w.OSWin.Send(ev)
+	})
+}
+
+// isPending returns true if we are in a frame or one is scheduled
+func (fc *FrameClock) isPending() bool {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.inFrame || fc.scheduled
+}
+
+// deferPublish returns true if the window publish should be deferred to
+// the end of a frame, because we are in a frame or one is scheduled, and
+// records that a publish is needed
+func (w *Window) deferPublish() bool {
+	fc := &w.FrameClock
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if !fc.inFrame && !fc.scheduled {
+		return false
+	}
+	fc.needPublish = true
+	return true
+}
+
+// runFrame runs one frame, called by the event loop: calls all the pending
+// animation frame callbacks, and then publishes the window if needed
+func (w *Window) runFrame() {
+	fc := &w.FrameClock
+	st := time.Now()
+	fc.mu.Lock()
+	cbs := fc.callbacks
+	fc.callbacks = nil
+	chained := fc.chained
+	fc.start = st
+	fc.scheduled = false
+	fc.inFrame = true
+	fc.mu.Unlock()
+
+	for _, cb := range cbs {
+		cb.fun(st)
+	}
+
+	fc.mu.Lock()
+	fc.inFrame = false
+	pub := fc.needPublish
+	fc.needPublish = false
+	fc.mu.Unlock()
+	if pub {
+		w.Publish()
+	}
+
+	fc.mu.Lock()
+	fc.Stats.update(st, time.Now().Sub(st), w.frameInterval(), chained)
+	fc.mu.Unlock()
+}
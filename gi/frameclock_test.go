@@ -0,0 +1,59 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/gitest"
+)
+
+func TestAnimationFrame(t *testing.T) {
+	var lbl *gi.Label
+	au := gitest.NewWindowAuto(t, "anim", 200, 100, func(mfr *gi.Frame) {
+		lbl = addLabel(mfr, "lbl", "0")
+	})
+	defer au.Close()
+	win := au.Win
+	win.FrameClock.SetInterval(5 * time.Millisecond)
+
+	done := make(chan struct{})
+	var times []time.Time
+	var frame func(now time.Time)
+	frame = func(now time.Time) {
+		times = append(times, now)
+		lbl.SetText(fmt.Sprintf("%d", len(times)))
+		if len(times) == 3 {
+			close(done)
+			return
+		}
+		win.RequestAnimationFrame(frame)
+	}
+	win.RequestAnimationFrame(frame)
+	cid := win.RequestAnimationFrame(func(now time.Time) {
+		t.Errorf("canceled animation frame callback was called\n")
+	})
+	if !win.CancelAnimationFrame(cid) {
+		t.Errorf("CancelAnimationFrame did not find callback: %v\n", cid)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("animation frames not run: %v\n", len(times))
+	}
+	au.Wait()
+	for i := 1; i < len(times); i++ {
+		if times[i].Sub(times[i-1]) < win.FrameInterval() {
+			t.Errorf("frame %v started too soon after previous: %v\n", i, times[i].Sub(times[i-1]))
+		}
+	}
+	if st := win.FrameStats(); st.Frames != 3 || st.AvgInterval == 0 {
+		t.Errorf("frame stats: %+v\n", st)
+	}
+	au.AssertText("#lbl", "3")
+}
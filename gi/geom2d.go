@@ -639,6 +639,18 @@ func (a Matrix2D) Skew(x, y float32) Matrix2D {
 	return Skew2D(x, y).Multiply(a)
 }
 
+// Inverse returns the inverse of the matrix, which maps transformed points
+// back to the original points -- the matrix must be invertible
+func (a Matrix2D) Inverse() Matrix2D {
+	det := a.XX*a.YY - a.XY*a.YX
+	return Matrix2D{
+		a.YY / det, -a.YX / det,
+		-a.XY / det, a.XX / det,
+		(a.XY*a.Y0 - a.YY*a.X0) / det,
+		(a.YX*a.X0 - a.XX*a.Y0) / det,
+	}
+}
+
 func (a Matrix2D) ToRasterx() rasterx.Matrix2D {
 	return rasterx.Matrix2D{float64(a.XX), float64(a.YX), float64(a.XY), float64(a.YY), float64(a.X0), float64(a.Y0)}
 }
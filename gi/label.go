@@ -330,6 +330,7 @@ func (lb *Label) StyleLabel() {
 	for i := 0; i < int(LabelStatesN); i++ {
 		lb.StateStyles[i].CopyFrom(&lb.Sty)
 		lb.StateStyles[i].SetStyleProps(pst, lb.StyleProps(LabelSelectors[i]), lb.Viewport)
+		lb.StyleCSS(&lb.StateStyles[i], LabelSelectors[i])
 		lb.StateStyles[i].CopyUnitContext(&lb.Sty.UnContext)
 	}
 }
@@ -1892,6 +1892,7 @@ func (ly *Layout) Render2D() {
 		if ly.ScrollsOff {
 			ly.ManageOverflow()
 		}
+		ly.RenderBackground()
 		ly.RenderScrolls()
 		ly.Render2DChildren()
 		ly.PopBounds()
@@ -1901,6 +1902,21 @@ func (ly *Layout) Render2D() {
 	}
 }
 
+// RenderBackground renders the background image of the layout, if any --
+// layouts do not otherwise render a box (see Frame)
+func (ly *Layout) RenderBackground() {
+	st := &ly.Sty
+	if !st.Background.HasImage() {
+		return
+	}
+	rs := &ly.Viewport.Render
+	rs.Lock()
+	pos := ly.LayData.AllocPos.AddVal(st.Layout.Margin.Dots)
+	sz := ly.LayData.AllocSize.AddVal(-2.0 * st.Layout.Margin.Dots)
+	ly.RenderBackgroundImage(st, pos, sz)
+	rs.Unlock()
+}
+
 func (ly *Layout) ConnectEvents2D() {
 	if ly.HasAnyScroll() {
 		ly.LayoutScrollEvents()
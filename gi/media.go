@@ -0,0 +1,280 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goki/gi/units"
+	"github.com/goki/ki/kit"
+)
+
+// media.go implements CSS @media queries:
+// https://www.w3.org/TR/mediaqueries-4/ -- in CSS props, the rules in an
+// @media block are under a key of "@media " + the query, e.g.,
+// "@media (max-width: 400px)": ki.Props{"button": ki.Props{..}}, and they
+// apply (after the other rules of the same specificity) when the query
+// matches the window of the node being styled.
+
+// ColorSchemes are the light or dark color schemes that users can prefer,
+// for the prefers-color-scheme media feature
+type ColorSchemes int32
+
+const (
+	// ColorSchemeLight is a light color scheme: dark text on light backgrounds
+	ColorSchemeLight ColorSchemes = iota
+
+	// ColorSchemeDark is a dark color scheme: light text on dark backgrounds
+	ColorSchemeDark
+
+	ColorSchemesN
+)
+
+//go:generate stringer -type=ColorSchemes
+
+var KiT_ColorSchemes = kit.Enums.AddEnumAltLower(ColorSchemesN, false, StylePropProps, "ColorScheme")
+
+func (ev ColorSchemes) MarshalJSON() ([]byte, error)  { return kit.EnumMarshalJSON(ev) }
+func (ev *ColorSchemes) UnmarshalJSON(b []byte) error { return kit.EnumUnmarshalJSON(ev, b) }
+
+// MediaFeature is one (feature: value) or (feature op value) condition of a
+// media query
+type MediaFeature struct {
+	Name string `desc:"feature name, without any min- or max- prefix, e.g., width"`
+	Op   string `desc:"comparison of the media value with Val: one of = < <= > >= -- min- and max- prefixes are >= and <= -- empty to test that the feature is non-zero"`
+	Val  string `desc:"value to compare with"`
+}
+
+// MediaQuery is one query in a media query list: [not | only] type, and
+// features, all of which must match
+type MediaQuery struct {
+	Not      bool           `desc:"negate the result of the query"`
+	Type     string         `desc:"media type: all, screen or print -- print never matches windows"`
+	Features []MediaFeature `desc:"features, all of which must match"`
+}
+
+// MediaQueries is a comma-separated list of media queries, which matches
+// if any of the queries match
+type MediaQueries []MediaQuery
+
+// mediaQueries caches the parsed media query lists
+var mediaQueries sync.Map
+
+// ParseMediaQueries parses a media query list, e.g., screen and
+// (min-width: 600px), (prefers-color-scheme: dark) -- parsed lists are
+// cached
+func ParseMediaQueries(str string) (MediaQueries, error) {
+	str = strings.TrimSpace(str)
+	if mqi, ok := mediaQueries.Load(str); ok {
+		return mqi.(MediaQueries), nil
+	}
+	var mqs MediaQueries
+	for _, qs := range splitCSSList(str, ',') {
+		mq, err := parseMediaQuery(qs)
+		if err != nil {
+			return nil, err
+		}
+		mqs = append(mqs, mq)
+	}
+	if len(mqs) == 0 {
+		mqs = MediaQueries{{Type: "all"}}
+	}
+	mediaQueries.Store(str, mqs)
+	return mqs, nil
+}
+
+// parseMediaQuery parses one media query in a list
+func parseMediaQuery(str string) (MediaQuery, error) {
+	mq := MediaQuery{Type: "all"}
+	toks := splitCSSList(strings.ToLower(str), ' ')
+	needAnd := false
+	for i, tok := range toks {
+		switch {
+		case tok == "and":
+			if !needAnd {
+				return mq, fmt.Errorf("gi.ParseMediaQueries: misplaced and in query: %v", str)
+			}
+			needAnd = false
+		case needAnd:
+			return mq, fmt.Errorf("gi.ParseMediaQueries: missing and before: %v in query: %v", tok, str)
+		case strings.HasPrefix(tok, "("):
+			mf, err := parseMediaFeature(tok)
+			if err != nil {
+				return mq, err
+			}
+			mq.Features = append(mq.Features, mf)
+			needAnd = true
+		case i == 0 && (tok == "not" || tok == "only"):
+			mq.Not = tok == "not"
+		case tok == "all" || tok == "screen" || tok == "print":
+			mq.Type = tok
+			needAnd = true
+		default:
+			return mq, fmt.Errorf("gi.ParseMediaQueries: unknown media type: %v in query: %v", tok, str)
+		}
+	}
+	return mq, nil
+}
+
+// parseMediaFeature parses a (feature: value) or (feature op value)
+func parseMediaFeature(str string) (MediaFeature, error) {
+	var mf MediaFeature
+	if !strings.HasSuffix(str, ")") {
+		return mf, fmt.Errorf("gi.ParseMediaQueries: missing ) in feature: %v", str)
+	}
+	str = strings.TrimSpace(str[1 : len(str)-1])
+	if ci := strings.IndexByte(str, ':'); ci >= 0 {
+		mf.Name = strings.TrimSpace(str[:ci])
+		mf.Val = strings.TrimSpace(str[ci+1:])
+		mf.Op = "="
+		switch {
+		case strings.HasPrefix(mf.Name, "min-"):
+			mf.Name, mf.Op = mf.Name[4:], ">="
+		case strings.HasPrefix(mf.Name, "max-"):
+			mf.Name, mf.Op = mf.Name[4:], "<="
+		}
+		return mf, nil
+	}
+	for _, op := range []string{"<=", ">=", "<", ">", "="} {
+		if oi := strings.Index(str, op); oi >= 0 {
+			mf.Name = strings.TrimSpace(str[:oi])
+			mf.Op = op
+			mf.Val = strings.TrimSpace(str[oi+len(op):])
+			return mf, nil
+		}
+	}
+	mf.Name = str
+	return mf, nil
+}
+
+// MediaViewport returns the viewport that media queries are evaluated
+// against for nodes in given viewport: the main viewport of its window
+func MediaViewport(vp *Viewport2D) *Viewport2D {
+	if vp != nil && vp.Win != nil && vp.Win.Viewport != nil {
+		return vp.Win.Viewport
+	}
+	return vp
+}
+
+// Match returns true if any of the queries match given viewport (see
+// MediaViewport)
+func (mqs MediaQueries) Match(vp *Viewport2D) bool {
+	for i := range mqs {
+		if mqs[i].Match(vp) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns true if the query matches given viewport
+func (mq *MediaQuery) Match(vp *Viewport2D) bool {
+	match := mq.Type != "print"
+	for i := range mq.Features {
+		if !match {
+			break
+		}
+		match = mq.Features[i].Match(vp)
+	}
+	return match != mq.Not
+}
+
+// Match returns true if the feature matches given viewport -- sizes are
+// those of the viewport, and resolution is the logical DPI of its window
+func (mf *MediaFeature) Match(vp *Viewport2D) bool {
+	var uc units.Context
+	uc.Defaults()
+	var sz Vec2D
+	if vp != nil {
+		sz = NewVec2DFmPoint(vp.Geom.Size)
+		if vp.Win != nil {
+			uc.DPI = vp.Win.LogicalDPI()
+		}
+	}
+	uc.SetSizes(sz.X, sz.Y, sz.X, sz.Y)
+	var mv, cv float32 // media value and value to compare with
+	switch mf.Name {
+	case "width", "height":
+		mv = sz.X
+		if mf.Name == "height" {
+			mv = sz.Y
+		}
+		if mf.Val != "" {
+			v := units.StringToValue(mf.Val)
+			v.ToDots(&uc)
+			cv = v.Dots
+		}
+	case "aspect-ratio":
+		if sz.Y > 0 {
+			mv = sz.X / sz.Y
+		}
+		cv = parseRatio(mf.Val)
+	case "orientation":
+		if mf.Val == "landscape" {
+			return sz.X > sz.Y
+		}
+		return mf.Val == "portrait" && sz.Y >= sz.X
+	case "resolution":
+		mv = uc.DPI
+		cv = parseResolution(mf.Val)
+	case "prefers-color-scheme":
+		if mf.Val == "" {
+			return true
+		}
+		return mf.Val == strings.ToLower(strings.TrimPrefix(Prefs.ColorScheme.String(), "ColorScheme"))
+	case "color":
+		mv = 8
+		cv = parseRatio(mf.Val)
+	default:
+		return false
+	}
+	switch mf.Op {
+	case "":
+		return mv != 0
+	case "=":
+		return mv == cv
+	case "<":
+		return mv < cv
+	case "<=":
+		return mv <= cv
+	case ">":
+		return mv > cv
+	case ">=":
+		return mv >= cv
+	}
+	return false
+}
+
+// parseRatio parses a ratio value of the form w/h or a number
+func parseRatio(str string) float32 {
+	if si := strings.IndexByte(str, '/'); si >= 0 {
+		w, _ := strconv.ParseFloat(strings.TrimSpace(str[:si]), 32)
+		h, _ := strconv.ParseFloat(strings.TrimSpace(str[si+1:]), 32)
+		if h == 0 {
+			return 0
+		}
+		return float32(w / h)
+	}
+	v, _ := strconv.ParseFloat(strings.TrimSpace(str), 32)
+	return float32(v)
+}
+
+// parseResolution parses a resolution value in dpi, dpcm, dppx or x
+// (dppx) into dpi, where 1dppx is 96dpi as in CSS
+func parseResolution(str string) float32 {
+	for _, un := range []struct {
+		suf string
+		fac float32
+	}{{"dpcm", 2.54}, {"dppx", 96}, {"dpi", 1}, {"x", 96}} {
+		if strings.HasSuffix(str, un.suf) {
+			v, _ := strconv.ParseFloat(strings.TrimSuffix(str, un.suf), 32)
+			return float32(v) * un.fac
+		}
+	}
+	return 0
+}
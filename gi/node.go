@@ -7,6 +7,7 @@ package gi
 import (
 	"image"
 	"log"
+	"strings"
 
 	"github.com/goki/ki"
 	"github.com/goki/ki/kit"
@@ -295,6 +296,16 @@ func (nb *NodeBase) AddClass(class string) {
 	}
 }
 
+// HasClass returns true if node has given CSS class name (case insensitive)
+func (nb *NodeBase) HasClass(class string) bool {
+	for _, cl := range strings.Fields(nb.Class) {
+		if strings.EqualFold(cl, class) {
+			return true
+		}
+	}
+	return false
+}
+
 // StyleProps returns a property that contains another map of properties for a
 // given styling selector, such as :normal :active :hover etc -- the
 // convention is to prefix this selector with a : and use lower-case names, so
@@ -23,6 +23,7 @@ import (
 	"github.com/srwiley/scanFT"
 	"golang.org/x/image/draw"
 	"golang.org/x/image/math/f64"
+	"golang.org/x/image/math/fixed"
 )
 
 /*
@@ -69,6 +70,7 @@ type Paint struct {
 	TextStyle   TextStyle    `desc:"font also has global opacity setting, along with generic color, background-color settings, which can be copied into stroke / fill as needed"`
 	VecEff      VectorEffect `xml:"vector-effect" desc:"prop: vector-effect = various rendering special effects settings"`
 	XForm       Matrix2D     `xml:"transform" desc:"prop: transform = our additions to transform -- pushed to render state"`
+	Vars        CSSVars      `xml:"-" desc:"CSS custom properties (--name: value) set on this paint or inherited from the parent paint, for var() references in property values -- see CSSVars"`
 	dotsSet     bool
 	lastUnCtxt  units.Context
 }
@@ -118,6 +120,7 @@ func (pc *Paint) CopyStyleFrom(cp *Paint) {
 	pc.FontStyle = cp.FontStyle
 	pc.TextStyle = cp.TextStyle
 	pc.VecEff = cp.VecEff
+	pc.Vars = cp.Vars
 }
 
 // InheritFields from parent: Manual inheriting of values is much faster than
@@ -125,6 +128,7 @@ func (pc *Paint) CopyStyleFrom(cp *Paint) {
 func (pc *Paint) InheritFields(par *Paint) {
 	pc.FontStyle.InheritFields(&par.FontStyle)
 	pc.TextStyle.InheritFields(&par.TextStyle)
+	pc.Vars = par.Vars.Inherit(pc.Vars)
 }
 
 // SetStyleProps sets paint values based on given property map (name: value
@@ -135,6 +139,8 @@ func (pc *Paint) SetStyleProps(par *Paint, props ki.Props, vp *Viewport2D) {
 		// PaintFields.Inherit(pc, par) // very slow..
 		pc.InheritFields(par)
 	}
+	pc.Vars = pc.Vars.SetFmProps(props)
+	props = pc.Vars.ResolveProps(props)
 	PaintFields.Style(pc, par, props, vp)
 	pc.StrokeStyle.SetStylePost(props)
 	pc.FillStyle.SetStylePost(props)
@@ -242,6 +248,12 @@ type RenderState struct {
 	PaintBack      Paint             `desc:"backup of paint -- don't need a full stack but sometimes safer to backup and restore"`
 	RenderMu       sync.Mutex        `desc:"mutex for overall rendering"`
 	RasterMu       sync.Mutex        `desc:"mutex for final rasterx rendering -- only one at a time"`
+	TileSize       int               `desc:"if > 0, fills and strokes are rasterized in square tiles of this size (in pixels), concurrently, instead of all in the one Scanner -- faster for large, complex paths, especially with multiple cores -- the result is the same"`
+	TileRaster     *rasterx.Dasher   `view:"-" desc:"rasterizer for tiled rendering (see TileSize), using TileScanner"`
+	TileScanner    *TileScanner      `view:"-" desc:"scanner for tiled rendering (see TileSize)"`
+	Recorder       *PaintRecorder    `view:"-" desc:"if set, everything painted is also recorded here, as vector paint ops -- see Viewport2D.RecordRender"`
+	layers         []renderLayer     // stack of layers -- see PushLayer
+	layerBufs      []*layerBuf       // layer images available for re-use
 }
 
 // Init initializes RenderState -- must be called whenever image size changes
@@ -387,6 +399,40 @@ func (pc *Paint) TransformPoint(rs *RenderState, x, y float32) Vec2D {
 	return Vec2D{tx, ty}
 }
 
+// pathBounds returns the bounding box of the points of a path
+func pathBounds(p rasterx.Path) image.Rectangle {
+	var r fixed.Rectangle26_6
+	first := true
+	add := func(x, y fixed.Int26_6) {
+		if first {
+			r.Min, r.Max = fixed.Point26_6{X: x, Y: y}, fixed.Point26_6{X: x, Y: y}
+			first = false
+			return
+		}
+		r = r.Union(fixed.Rectangle26_6{Min: fixed.Point26_6{X: x, Y: y}, Max: fixed.Point26_6{X: x, Y: y}})
+	}
+	for i := 0; i < len(p); {
+		n := 0
+		switch rasterx.PathCommand(p[i]) {
+		case rasterx.PathMoveTo, rasterx.PathLineTo:
+			n = 1
+		case rasterx.PathQuadTo:
+			n = 2
+		case rasterx.PathCubicTo:
+			n = 3
+		case rasterx.PathClose:
+		default:
+			i = len(p)
+			continue
+		}
+		for j := 0; j < n; j++ {
+			add(p[i+1+2*j], p[i+2+2*j])
+		}
+		i += 1 + 2*n
+	}
+	return image.Rect(r.Min.X.Floor(), r.Min.Y.Floor(), r.Max.X.Ceil(), r.Max.Y.Ceil())
+}
+
 // BoundingBox computes the bounding box for an element in pixel int
 // coordinates, applying current transform
 func (pc *Paint) BoundingBox(rs *RenderState, minX, minY, maxX, maxY float32) image.Rectangle {
@@ -549,9 +595,11 @@ func (pc *Paint) StrokeWidth(rs *RenderState) float32 {
 
 func (pc *Paint) stroke(rs *RenderState) {
 	pr := prof.Start("Paint.stroke")
+	pp := pc.StrokeStyle.Color.PreparePattern(rs)
 
 	rs.RasterMu.Lock()
 	defer rs.RasterMu.Unlock()
+	rd := rs.rasterizer()
 
 	dash := pc.StrokeStyle.Dashes
 	if dash != nil {
@@ -570,45 +618,59 @@ func (pc *Paint) stroke(rs *RenderState) {
 		}
 	}
 
-	rs.Raster.SetStroke(
+	rd.SetStroke(
 		Float32ToFixed(pc.StrokeWidth(rs)),
 		Float32ToFixed(pc.StrokeStyle.MiterLimit),
 		pc.capfunc(), nil, nil, pc.joinmode(), // todo: supports leading / trailing caps, and "gaps"
 		dash, 0)
-	rs.Scanner.SetClip(rs.Bounds)
-	rs.Path.AddTo(rs.Raster)
-	fbox := rs.Raster.Scanner.GetPathExtent()
+	rd.Scanner.SetClip(rs.Bounds)
+	rs.Path.AddTo(rd)
+	fbox := rd.Scanner.GetPathExtent()
 	// fmt.Printf("node: %v fbox: %v\n", g.Nm, fbox)
 	rs.LastRenderBBox = image.Rectangle{Min: image.Point{fbox.Min.X.Floor(), fbox.Min.Y.Floor()},
 		Max: image.Point{fbox.Max.X.Ceil(), fbox.Max.Y.Ceil()}}
-	rs.Raster.SetColor(pc.StrokeStyle.Color.RenderColor(pc.FontStyle.Opacity*pc.StrokeStyle.Opacity, rs.LastRenderBBox, rs.XForm))
-	rs.Raster.Draw()
-	rs.Raster.Clear()
+	if pp != nil {
+		rd.SetColor(pp.colorFunc(pc.FontStyle.Opacity * pc.StrokeStyle.Opacity))
+	} else {
+		rd.SetColor(pc.StrokeStyle.Color.RenderColor(pc.FontStyle.Opacity*pc.StrokeStyle.Opacity, rs.LastRenderBBox, rs.XForm))
+	}
+	rd.Draw()
+	rd.Clear()
+	if rs.Recorder != nil {
+		pc.recordPath(rs, true, dash, pp)
+	}
 
 	pr.End()
 }
 
 func (pc *Paint) fill(rs *RenderState) {
 	pr := prof.Start("Paint.fill")
+	pp := pc.FillStyle.Color.PreparePattern(rs)
 
 	rs.RasterMu.Lock()
 	defer rs.RasterMu.Unlock()
+	rd := rs.rasterizer()
 
-	rf := &rs.Raster.Filler
+	rf := &rd.Filler
 	rf.SetWinding(pc.FillStyle.Rule == FillRuleNonZero)
-	rs.Scanner.SetClip(rs.Bounds)
+	rf.SetClip(rs.Bounds)
 	rs.Path.AddTo(rf)
-	fbox := rs.Scanner.GetPathExtent()
+	fbox := rf.GetPathExtent()
 	// fmt.Printf("node: %v fbox: %v\n", g.Nm, fbox)
 	rs.LastRenderBBox = image.Rectangle{Min: image.Point{fbox.Min.X.Floor(), fbox.Min.Y.Floor()},
 		Max: image.Point{fbox.Max.X.Ceil(), fbox.Max.Y.Ceil()}}
-	if pc.FillStyle.Color.Source == RadialGradient {
+	if pp != nil {
+		rf.SetColor(pp.colorFunc(pc.FontStyle.Opacity * pc.FillStyle.Opacity))
+	} else if pc.FillStyle.Color.Source == RadialGradient {
 		rf.SetColor(pc.FillStyle.Color.RenderColor(pc.FontStyle.Opacity*pc.FillStyle.Opacity, rs.LastRenderBBox, rs.XForm))
 	} else {
 		rf.SetColor(pc.FillStyle.Color.RenderColor(pc.FontStyle.Opacity*pc.FillStyle.Opacity, rs.LastRenderBBox, rs.XForm))
 	}
 	rf.Draw()
 	rf.Clear()
+	if rs.Recorder != nil {
+		pc.recordPath(rs, false, nil, pp)
+	}
 
 	pr.End()
 }
@@ -647,6 +709,9 @@ func (pc *Paint) FillBox(rs *RenderState, pos, size Vec2D, clr *ColorSpec) {
 	if clr.Source == SolidColor {
 		b := rs.Bounds.Intersect(RectFromPosSizeMax(pos, size))
 		draw.Draw(rs.Image, b, &image.Uniform{clr.Color}, image.ZP, draw.Src)
+		if rs.Recorder != nil {
+			rs.recordRect(b, clr.Color)
+		}
 	} else {
 		pc.FillStyle.SetColorSpec(clr)
 		pc.DrawRectangle(rs, pos.X, pos.Y, size.X, size.Y)
@@ -658,6 +723,9 @@ func (pc *Paint) FillBox(rs *RenderState, pos, size Vec2D, clr *ColorSpec) {
 func (pc *Paint) FillBoxColor(rs *RenderState, pos, size Vec2D, clr color.Color) {
 	b := rs.Bounds.Intersect(RectFromPosSizeMax(pos, size))
 	draw.Draw(rs.Image, b, &image.Uniform{clr}, image.ZP, draw.Src)
+	if rs.Recorder != nil {
+		rs.recordRect(b, clr)
+	}
 }
 
 // ClipPreserve updates the clipping region by intersecting the current
@@ -717,6 +785,9 @@ func (pc *Paint) ResetClip(rs *RenderState) {
 func (pc *Paint) Clear(rs *RenderState) {
 	src := image.NewUniform(&pc.FillStyle.Color.Color)
 	draw.Draw(rs.Image, rs.Image.Bounds(), src, image.ZP, draw.Src)
+	if rs.Recorder != nil {
+		rs.recordRect(rs.Image.Bounds(), &pc.FillStyle.Color.Color)
+	}
 }
 
 // SetPixel sets the color of the specified pixel using the current stroke color.
@@ -787,6 +858,30 @@ func (pc *Paint) DrawRoundedRectangle(rs *RenderState, x, y, w, h, r float32) {
 	pc.ClosePath(rs)
 }
 
+// DrawRoundedRectangleCorners draws a rectangle with a different radius
+// for each corner: top-left, top-right, bottom-right, bottom-left
+func (pc *Paint) DrawRoundedRectangleCorners(rs *RenderState, x, y, w, h float32, r [4]float32) {
+	pc.NewSubPath(rs)
+	pc.MoveTo(rs, x+r[0], y)
+	pc.LineTo(rs, x+w-r[1], y)
+	if r[1] > 0 {
+		pc.DrawArc(rs, x+w-r[1], y+r[1], r[1], Radians(270), Radians(360))
+	}
+	pc.LineTo(rs, x+w, y+h-r[2])
+	if r[2] > 0 {
+		pc.DrawArc(rs, x+w-r[2], y+h-r[2], r[2], Radians(0), Radians(90))
+	}
+	pc.LineTo(rs, x+r[3], y+h)
+	if r[3] > 0 {
+		pc.DrawArc(rs, x+r[3], y+h-r[3], r[3], Radians(90), Radians(180))
+	}
+	pc.LineTo(rs, x, y+r[0])
+	if r[0] > 0 {
+		pc.DrawArc(rs, x+r[0], y+r[0], r[0], Radians(180), Radians(270))
+	}
+	pc.ClosePath(rs)
+}
+
 // DrawEllipticalArc draws arc between angle1 and angle2 along an ellipse,
 // using quadratic bezier curves -- centers of ellipse are at cx, cy with
 // radii rx, ry -- see DrawEllipticalArcPath for a version compatible with SVG
@@ -1002,6 +1097,9 @@ func (pc *Paint) DrawImageAnchored(rs *RenderState, fmIm image.Image, x, y int,
 			DstMaskP: image.ZP,
 		})
 	}
+	if rs.Recorder != nil {
+		rs.RecordImage(cloneImage(fmIm), m)
+	}
 }
 
 //////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,277 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/chewxy/math32"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// paintrec.go implements the recording of everything painted into a
+// RenderState as a list of vector paint operations (PaintOp's): filled and
+// stroked paths, text and images, which can then be written out as a vector
+// graphics document (see svgenc.go).  Set the Recorder on a RenderState to
+// record its painting, in addition to the usual rasterization -- any
+// sub-viewports rendered into a recorded viewport are recorded as groups of
+// their own ops, or as images if they were not re-rendered.  See
+// Viewport2D.RecordRender, EncodeSVG.
+
+// PaintOp is one recorded paint operation: a *PaintPathOp, *PaintTextOp,
+// *PaintImageOp, *PaintGroupOp or *PaintLayerOp -- all coordinates are in the device (pixel)
+// coordinates of the recorded image, with any transforms already applied
+type PaintOp interface {
+	// ClipBox returns the rectangle that the painting is restricted to
+	ClipBox() image.Rectangle
+}
+
+// PaintPathOp is a recorded fill or stroke of a path
+type PaintPathOp struct {
+	Path       rasterx.Path      `desc:"the path, in device coordinates"`
+	Stroke     bool              `desc:"if true, the path is stroked, otherwise it is filled"`
+	Color      color.NRGBA       `desc:"color of a solid fill or stroke, including opacity"`
+	Gradient   *rasterx.Gradient `desc:"gradient, if not a solid color -- for user-space gradients, the gradient matrix includes the transform of the path into device coordinates"`
+	Pattern    *PaintPattern     `desc:"pattern, if painted with a pattern -- its tile includes the opacity"`
+	Opacity    float32           `desc:"overall opacity of the gradient"`
+	Rule       FillRule          `desc:"fill rule for fills"`
+	Width      float32           `desc:"stroke width, in device dots"`
+	MiterLimit float32           `desc:"stroke miter limit"`
+	Cap        LineCap           `desc:"stroke line cap"`
+	Join       LineJoin          `desc:"stroke line join"`
+	Dashes     []float64         `desc:"stroke dash lengths, in device dots -- nil for a solid line"`
+	Clip       image.Rectangle   `desc:"rectangle the painting is restricted to"`
+}
+
+// ClipBox returns the rectangle that the painting is restricted to
+func (op *PaintPathOp) ClipBox() image.Rectangle {
+	return op.Clip
+}
+
+// PaintPattern is a tile image that is repeated in a grid, transformed into
+// device coordinates: the pattern of a PaintPathOp, and the tile of a
+// Pattern prepared for painting an element (see ColorSpec.PreparePattern)
+type PaintPattern struct {
+	Image  *image.RGBA       `desc:"the tile, already rendered at the resolution of the device"`
+	XForm  Matrix2D          `desc:"transform from the pixel coordinates of the tile to device coordinates"`
+	Repeat BackgroundRepeats `desc:"how the tile is repeated -- BgRepeat tiles the plane"`
+}
+
+// PaintRune is one rendered rune of a PaintTextOp
+type PaintRune struct {
+	Rune   rune        `desc:"the rune"`
+	Pos    Vec2D       `desc:"position of the lower-left baseline rendering point of the rune"`
+	Face   font.Face   `desc:"font face the rune is rendered in, including its size"`
+	Color  color.NRGBA `desc:"color of the rune"`
+	RotRad float32     `desc:"rotation in radians around Pos"`
+	ScaleX float32     `desc:"scaling of the X dimension, 0 = no scaling"`
+}
+
+// PaintTextOp is a recorded rendering of a span of text
+type PaintTextOp struct {
+	Runes []PaintRune     `desc:"the rendered runes -- non-printing and fully clipped runes are not included"`
+	Clip  image.Rectangle `desc:"rectangle the painting is restricted to"`
+}
+
+// ClipBox returns the rectangle that the painting is restricted to
+func (op *PaintTextOp) ClipBox() image.Rectangle {
+	return op.Clip
+}
+
+// PaintImageOp is a recorded drawing of an image
+type PaintImageOp struct {
+	Image image.Image     `desc:"the image -- must not be modified after it is recorded"`
+	XForm Matrix2D        `desc:"transform from the pixel coordinates of the image (within its Bounds) to device coordinates"`
+	Clip  image.Rectangle `desc:"rectangle the painting is restricted to"`
+}
+
+// ClipBox returns the rectangle that the painting is restricted to
+func (op *PaintImageOp) ClipBox() image.Rectangle {
+	return op.Clip
+}
+
+// PaintGroupOp is a recorded group of ops, e.g., from the rendering of a
+// sub-viewport
+type PaintGroupOp struct {
+	Ops   []PaintOp       `desc:"the ops in the group, in its own coordinates"`
+	XForm Matrix2D        `desc:"transform from the group coordinates to the enclosing coordinates -- a translation for sub-viewports, but can also scale, e.g., for printing"`
+	Clip  image.Rectangle `desc:"rectangle the painting is restricted to, in the enclosing coordinates"`
+}
+
+// ClipBox returns the rectangle that the painting is restricted to
+func (op *PaintGroupOp) ClipBox() image.Rectangle {
+	return op.Clip
+}
+
+// InnerBounds returns the bounding box in the group coordinates of the given
+// rectangle in the enclosing coordinates
+func (op *PaintGroupOp) InnerBounds(r image.Rectangle) image.Rectangle {
+	inv := op.XForm.Inverse()
+	var min, max Vec2D
+	for i, c := range [4]Vec2D{{float32(r.Min.X), float32(r.Min.Y)}, {float32(r.Max.X), float32(r.Min.Y)},
+		{float32(r.Min.X), float32(r.Max.Y)}, {float32(r.Max.X), float32(r.Max.Y)}} {
+		p := inv.TransformPointVec2D(c)
+		if i == 0 {
+			min, max = p, p
+		} else {
+			min.SetMin(p)
+			max.SetMax(p)
+		}
+	}
+	return image.Rect(int(math32.Floor(min.X)), int(math32.Floor(min.Y)), int(math32.Ceil(max.X)), int(math32.Ceil(max.Y)))
+}
+
+// PaintLayerOp is a recorded layer of ops, composited as a whole into the
+// painting below it (see RenderState.PushLayer) -- vector output supports
+// the group opacity and blend mode, but only the CompSrcOver compositing
+// operator
+type PaintLayerOp struct {
+	Ops     []PaintOp       `desc:"the ops in the layer"`
+	Opacity float32         `desc:"group opacity of the layer"`
+	Blend   BlendModes      `desc:"blend mode of the layer"`
+	Comp    CompositeOps    `desc:"compositing operator of the layer"`
+	Clip    image.Rectangle `desc:"rectangle the compositing is restricted to"`
+}
+
+// ClipBox returns the rectangle that the painting is restricted to
+func (op *PaintLayerOp) ClipBox() image.Rectangle {
+	return op.Clip
+}
+
+// PaintRecorder records the painting done into a RenderState, as a list of
+// PaintOp's, when set as its Recorder
+type PaintRecorder struct {
+	Ops []PaintOp `desc:"the recorded ops, in painting order"`
+	mu  sync.Mutex
+}
+
+// Add adds an op to the recording
+func (pr *PaintRecorder) Add(op PaintOp) {
+	pr.mu.Lock()
+	pr.Ops = append(pr.Ops, op)
+	pr.mu.Unlock()
+}
+
+// Reset clears all the recorded ops
+func (pr *PaintRecorder) Reset() {
+	pr.mu.Lock()
+	pr.Ops = nil
+	pr.mu.Unlock()
+}
+
+// recordPath records the fill or stroke of the current path, with given
+// device-scaled dashes for strokes
+func (pc *Paint) recordPath(rs *RenderState, stroke bool, dash []float64, pp *PaintPattern) {
+	op := &PaintPathOp{Stroke: stroke, Clip: rs.Bounds}
+	op.Path = make(rasterx.Path, len(rs.Path))
+	copy(op.Path, rs.Path)
+	cs := &pc.FillStyle.Color
+	op.Opacity = pc.FontStyle.Opacity * pc.FillStyle.Opacity
+	if stroke {
+		cs = &pc.StrokeStyle.Color
+		op.Opacity = pc.FontStyle.Opacity * pc.StrokeStyle.Opacity
+		op.Width = pc.StrokeWidth(rs)
+		op.MiterLimit = pc.StrokeStyle.MiterLimit
+		op.Cap = pc.StrokeStyle.Cap
+		op.Join = pc.StrokeStyle.Join
+		if dash != nil {
+			op.Dashes = make([]float64, len(dash))
+			copy(op.Dashes, dash)
+		}
+	} else {
+		op.Rule = pc.FillStyle.Rule
+	}
+	switch {
+	case cs.Source == TiledPattern:
+		if pp == nil {
+			return
+		}
+		op.Pattern = pp.withOpacity(op.Opacity)
+	case cs.Source == SolidColor || cs.Gradient == nil:
+		op.Color = rasterx.ApplyOpacity(cs.Color, float64(op.Opacity))
+	default:
+		op.Gradient = &rasterx.Gradient{}
+		CopyGradient(op.Gradient, cs.Gradient)
+		op.Gradient.IsRadial = cs.Source == RadialGradient
+		if op.Gradient.Units == rasterx.UserSpaceOnUse {
+			op.Gradient.Matrix = rs.XForm.ToRasterx().Mult(op.Gradient.Matrix)
+		}
+	}
+	rs.Recorder.Add(op)
+}
+
+// recordRect records a fill of the given rectangle with given uniform color
+// -- the rectangle is already clipped as needed (it can extend outside of
+// the Bounds, for Clear)
+func (rs *RenderState) recordRect(r image.Rectangle, clr color.Color) {
+	op := &PaintPathOp{Clip: rs.Bounds.Union(r)}
+	op.Color = color.NRGBAModel.Convert(clr).(color.NRGBA)
+	op.Opacity = 1
+	op.Path.Start(fixed.P(r.Min.X, r.Min.Y))
+	op.Path.Line(fixed.P(r.Max.X, r.Min.Y))
+	op.Path.Line(fixed.P(r.Max.X, r.Max.Y))
+	op.Path.Line(fixed.P(r.Min.X, r.Max.Y))
+	op.Path.Stop(true)
+	rs.Recorder.Add(op)
+}
+
+// RecordImage records the drawing of given image, with given transform from
+// its pixel coordinates to device coordinates, if painting is being
+// recorded -- this is needed for anything drawn directly into the Image,
+// which should then be drawn into a separate image to record it (see
+// RenderBoxShadow for an example).  The image must not be modified after it
+// is recorded.
+func (rs *RenderState) RecordImage(img image.Image, xf Matrix2D) {
+	if rs.Recorder == nil {
+		return
+	}
+	rs.Recorder.Add(&PaintImageOp{Image: img, XForm: xf, Clip: rs.Bounds})
+}
+
+// cloneImage returns a copy of the image, e.g., for recording an image that
+// may be modified later
+func cloneImage(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	cp := image.NewRGBA(b)
+	draw.Draw(cp, b, img, b.Min, draw.Src)
+	return cp
+}
+
+// IsRecording returns true if the painting of this viewport is being
+// recorded, or will be when it is next rendered, as a sub-viewport of a
+// recorded viewport -- rendering of cached content should be redone when
+// recording, to record it as vector graphics
+func (vp *Viewport2D) IsRecording() bool {
+	if vp.Render.Recorder != nil {
+		return true
+	}
+	return vp.Viewport != nil && !vp.IsPopup() && vp.Viewport.Render.Recorder != nil
+}
+
+// recordIntoParent records the drawing of our image into the parent
+// recording, at given rect in the parent, from given starting point in our
+// image -- as a group of our recorded ops, if we were re-rendered and
+// recorded, and otherwise as an image of our pixels
+func (vp *Viewport2D) recordIntoParent(pr *PaintRecorder, r image.Rectangle, sp image.Point) {
+	rec := vp.Render.Recorder
+	vp.Render.Recorder = nil
+	off := r.Min.Sub(sp)
+	if rec != nil && len(rec.Ops) > 0 {
+		pr.Add(&PaintGroupOp{Ops: rec.Ops, XForm: Translate2D(float32(off.X), float32(off.Y)), Clip: r})
+		return
+	}
+	if vp.Pixels == nil {
+		return
+	}
+	sr := r.Sub(off)
+	img := image.NewRGBA(sr)
+	draw.Draw(img, sr, vp.Pixels, sr.Min, draw.Src)
+	pr.Add(&PaintImageOp{Image: img, XForm: Translate2D(float32(off.X), float32(off.Y)), Clip: r})
+}
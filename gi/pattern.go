@@ -0,0 +1,300 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"image/color"
+	"sync"
+
+	"github.com/chewxy/math32"
+	"github.com/srwiley/rasterx"
+	"github.com/srwiley/scanFT"
+	"golang.org/x/image/draw"
+)
+
+// pattern.go implements pattern paint servers, as in the SVG pattern element
+// https://www.w3.org/TR/SVG11/pservers.html#Patterns -- a ColorSpec with the
+// TiledPattern Source fills or strokes by tiling the plane with an image, or
+// with a drawing.  The tile is rendered at the resolution of the device, and
+// cached until its size in pixels changes.  Patterns can be referenced from
+// CSS color properties (e.g., fill, background-color) as url(#name), for
+// patterns registered with AddPattern, or for elements that are Patterner's
+// (e.g., svg pattern elements), and url(file) tiles an image file.
+
+// Pattern is a paint server that tiles the plane with an image or a drawing
+// -- use NewImagePattern, NewDrawPattern or NewHatchPattern to create one,
+// and ColorSpec.SetPattern to paint with it
+type Pattern struct {
+	Image   image.Image           `desc:"image to tile, if there is no Drawer -- it is scaled to the tile Size, or tiled at its size in pixels (as user units) if Size is zero"`
+	Drawer  PatternDrawer         `desc:"draws the contents of one tile, if not an Image"`
+	Units   rasterx.GradientUnits `desc:"units of Pos and Size: UserSpaceOnUse, or ObjectBoundingBox for fractions of the bounding box of the element painted -- the contents of the tile are always in user units"`
+	Pos     Vec2D                 `desc:"position of the origin of the tiles"`
+	Size    Vec2D                 `desc:"size of one tile"`
+	XForm   Matrix2D              `desc:"additional transform of the tiles, e.g., to rotate a hatching -- the SVG patternTransform -- zero is the identity"`
+	Repeat  BackgroundRepeats     `desc:"how the tile is repeated -- BgRepeat tiles the plane"`
+	tile    *image.RGBA           // last completely drawn tile, which is never modified, so it can be used by any number of paints
+	tileGen int                   // gen that the tile was drawn for -- it is drawn again if not the current gen
+	gen     int                   // generation of the drawing or image, incremented by Update
+	drawing bool                  // true while a new tile is being drawn, during which the last tile is used, e.g., when used within its own tile
+	mu      sync.Mutex
+}
+
+// PatternDrawer draws the contents of a pattern tile
+type PatternDrawer interface {
+	// DrawPattern draws the contents of one tile of given size (in user
+	// units) into the render state, whose transform maps the tile
+	// coordinates, from 0,0 to size, to the pixels of the tile -- the
+	// render state is only used for the tile, but it may be that of a
+	// viewport, with its painting saved and restored around the call
+	DrawPattern(rs *RenderState, size Vec2D)
+}
+
+// PatternDrawFunc is a function that draws a pattern tile, as a PatternDrawer
+type PatternDrawFunc func(rs *RenderState, size Vec2D)
+
+// DrawPattern calls the function
+func (f PatternDrawFunc) DrawPattern(rs *RenderState, size Vec2D) {
+	f(rs, size)
+}
+
+// Patterner is implemented by elements that can be referenced as a
+// pattern, with url(#name) in a color property
+type Patterner interface {
+	// Pattern returns the pattern of the element
+	Pattern() *Pattern
+}
+
+// NewImagePattern returns a pattern tiling given image at its size in pixels
+// (as user units) -- set Size to scale it
+func NewImagePattern(img image.Image) *Pattern {
+	return &Pattern{Image: img, Units: rasterx.UserSpaceOnUse, XForm: Identity2D()}
+}
+
+// NewDrawPattern returns a pattern tiling tiles of given size, drawn by given
+// drawer
+func NewDrawPattern(size Vec2D, drawer PatternDrawer) *Pattern {
+	return &Pattern{Drawer: drawer, Size: size, Units: rasterx.UserSpaceOnUse, XForm: Identity2D()}
+}
+
+// NewHatchPattern returns a pattern of parallel lines of given color and
+// width, spaced by given spacing, at given angle in degrees (0 =
+// horizontal, positive = clockwise on the screen)
+func NewHatchPattern(clr color.Color, width, spacing, angle float32) *Pattern {
+	pt := NewDrawPattern(Vec2D{X: spacing, Y: spacing}, PatternDrawFunc(func(rs *RenderState, size Vec2D) {
+		pc := &rs.Paint
+		pc.StrokeStyle.SetColor(nil)
+		pc.FillStyle.SetColor(clr)
+		pc.DrawRectangle(rs, 0, 0.5*(size.Y-width), size.X, width)
+		pc.Fill(rs)
+	}))
+	pt.XForm = Rotate2D(Radians(angle))
+	return pt
+}
+
+// Update marks the rendered tile as out of date, so that it is drawn again
+// the next time the pattern is painted -- call when the drawing or image
+// changes
+func (pt *Pattern) Update() {
+	pt.mu.Lock()
+	pt.gen++
+	pt.mu.Unlock()
+}
+
+var (
+	patterns   = map[string]*Pattern{}
+	patternsMu sync.RWMutex
+)
+
+// AddPattern registers given pattern under given name, replacing any
+// existing one, so that it can be used as url(#name) in color properties,
+// e.g., "background-color": "url(#hatch)"
+func AddPattern(name string, pt *Pattern) {
+	patternsMu.Lock()
+	patterns[name] = pt
+	patternsMu.Unlock()
+}
+
+// PatternByName returns the pattern registered under given name, and false
+// if not found
+func PatternByName(name string) (*Pattern, bool) {
+	patternsMu.RLock()
+	defer patternsMu.RUnlock()
+	pt, ok := patterns[name]
+	return pt, ok
+}
+
+// SetPattern sets the color spec to paint with given pattern
+func (cs *ColorSpec) SetPattern(pt *Pattern) {
+	cs.Source = TiledPattern
+	cs.Pattern = pt
+	cs.Gradient = nil
+}
+
+// PreparePattern prepares a pattern for painting the current path of the
+// render state, drawing its tile as needed -- this is done by the fill and
+// stroke painting, before rasterizing, which then paints with the returned
+// tile.  Returns nil if not a pattern, or there is no tile to paint with.
+func (cs *ColorSpec) PreparePattern(rs *RenderState) *PaintPattern {
+	if cs.Source != TiledPattern || cs.Pattern == nil {
+		return nil
+	}
+	return cs.Pattern.prepare(rs, pathBounds(rs.Path))
+}
+
+// tileSize returns the size of a tile in user units, and the transform from
+// tile coordinates to device coordinates, for an element with given
+// bounding box in device coordinates, and given user to device transform
+func (pt *Pattern) tileSize(bounds image.Rectangle, xform Matrix2D) (Vec2D, Matrix2D) {
+	pos, sz := pt.Pos, pt.Size
+	if sz.IsZero() && pt.Image != nil {
+		sz = NewVec2DFmPoint(pt.Image.Bounds().Size())
+	}
+	if pt.Units == rasterx.ObjectBoundingBox {
+		// bounding box in user units -- exact for axis-aligned transforms
+		inv := xform.Inverse()
+		bmin := inv.TransformPointVec2D(NewVec2DFmPoint(bounds.Min))
+		bmax := inv.TransformPointVec2D(NewVec2DFmPoint(bounds.Max))
+		bsz := bmax.Sub(bmin)
+		bsz = Vec2D{X: math32.Abs(bsz.X), Y: math32.Abs(bsz.Y)}
+		bmin.SetMin(bmax)
+		pos = bmin.Add(pos.Mul(bsz))
+		sz = sz.Mul(bsz)
+	}
+	pxf := pt.XForm
+	if pxf == (Matrix2D{}) {
+		pxf = Identity2D()
+	}
+	return sz, Translate2D(pos.X, pos.Y).Multiply(pxf).Multiply(xform)
+}
+
+// prepare returns the tile for painting an element with given bounding box
+// in device coordinates, with the current transform of the render state,
+// drawing it first if it is not at the resolution of the device -- it must
+// be called before the painting, outside of the rasterizer lock, as the
+// drawing of the tile uses the render state.  The tile is drawn into a new
+// image, which replaces the last tile once it is complete -- while it is
+// being drawn, other paints (including those within the tile itself) use
+// the last tile, or nothing if there is none.  Returns nil if there is no
+// tile to paint with.
+func (pt *Pattern) prepare(rs *RenderState, bounds image.Rectangle) *PaintPattern {
+	sz, xf := pt.tileSize(bounds, rs.XForm)
+	// tile resolution is that of the device
+	w := int(math32.Ceil(sz.X * math32.Hypot(xf.XX, xf.YX)))
+	h := int(math32.Ceil(sz.Y * math32.Hypot(xf.XY, xf.YY)))
+	if sz.X <= 0 || sz.Y <= 0 || w < 1 || h < 1 || w*h > 1<<24 {
+		return nil
+	}
+	pt.mu.Lock()
+	tile := pt.tile
+	if pt.drawing || (tile != nil && pt.tileGen == pt.gen && tile.Bounds().Size() == (image.Point{w, h})) {
+		pt.mu.Unlock()
+		return pt.paintTile(tile, sz, xf)
+	}
+	pt.drawing = true
+	gen := pt.gen
+	pt.mu.Unlock() // the drawing can paint with patterns
+	tile = image.NewRGBA(image.Rect(0, 0, w, h))
+	switch {
+	case pt.Drawer != nil:
+		rs.drawTile(tile, Scale2D(float32(w)/sz.X, float32(h)/sz.Y), func() {
+			pt.Drawer.DrawPattern(rs, sz)
+		})
+	case pt.Image != nil:
+		draw.CatmullRom.Scale(tile, tile.Bounds(), pt.Image, pt.Image.Bounds(), draw.Src, nil)
+	}
+	pt.mu.Lock()
+	pt.tile = tile
+	pt.tileGen = gen
+	pt.drawing = false
+	pt.mu.Unlock()
+	return pt.paintTile(tile, sz, xf)
+}
+
+// paintTile returns given tile for painting tiles of given size in user
+// units, with given transform from tile to device coordinates -- the tile
+// can be at a different resolution than the device -- nil if no tile
+func (pt *Pattern) paintTile(tile *image.RGBA, sz Vec2D, xf Matrix2D) *PaintPattern {
+	if tile == nil {
+		return nil
+	}
+	tsz := tile.Bounds().Size()
+	return &PaintPattern{Image: tile, XForm: Scale2D(sz.X/float32(tsz.X), sz.Y/float32(tsz.Y)).Multiply(xf), Repeat: pt.Repeat}
+}
+
+// colorFunc returns the color function for painting the tile, with given
+// opacity
+func (pp *PaintPattern) colorFunc(opacity float32) interface{} {
+	tile := pp.Image
+	inv := pp.XForm.Inverse()
+	w, h := tile.Bounds().Dx(), tile.Bounds().Dy()
+	fw, fh := float32(w), float32(h)
+	rx := pp.Repeat == BgRepeat || pp.Repeat == BgRepeatX
+	ry := pp.Repeat == BgRepeat || pp.Repeat == BgRepeatY
+	op := InRange32(opacity, 0, 1)
+	return rasterx.ColorFunc(func(x, y int) color.Color {
+		p := inv.TransformPointVec2D(Vec2D{X: float32(x) + 0.5, Y: float32(y) + 0.5})
+		if rx {
+			p.X -= math32.Floor(p.X/fw) * fw
+		}
+		if ry {
+			p.Y -= math32.Floor(p.Y/fh) * fh
+		}
+		tx, ty := int(p.X), int(p.Y)
+		if p.X < 0 || p.Y < 0 || tx >= w || ty >= h {
+			return color.Transparent
+		}
+		c := tile.RGBAAt(tx, ty)
+		if op < 1 {
+			c = color.RGBA{uint8(float32(c.R) * op), uint8(float32(c.G) * op), uint8(float32(c.B) * op), uint8(float32(c.A) * op)}
+		}
+		return c
+	})
+}
+
+// withOpacity returns the tile for recording, with given opacity applied
+// to a copy of the tile image if < 1
+func (pp *PaintPattern) withOpacity(opacity float32) *PaintPattern {
+	op := InRange32(opacity, 0, 1)
+	if op >= 1 {
+		return pp
+	}
+	opp := *pp
+	img := image.NewRGBA(pp.Image.Bounds())
+	draw.DrawMask(img, img.Bounds(), pp.Image, image.ZP, image.NewUniform(color.Alpha{uint8(op * 255)}), image.ZP, draw.Src)
+	opp.Image = img
+	return &opp
+}
+
+// drawTile calls given function to draw into given tile image, with given
+// transform, using this render state, with its painting state saved and
+// restored around the call
+func (rs *RenderState) drawTile(tile *image.RGBA, xf Matrix2D, fun func()) {
+	paint := rs.Paint
+	xform, xstack := rs.XForm, rs.XFormStack
+	path, start, cur, hascur := rs.Path, rs.Start, rs.Current, rs.HasCurrent
+	img, mask, bounds, lastbb := rs.Image, rs.Mask, rs.Bounds, rs.LastRenderBBox
+	raster, scanner, traster, tscanner := rs.Raster, rs.Scanner, rs.TileRaster, rs.TileScanner
+	rec := rs.Recorder
+
+	ib := tile.Bounds()
+	rs.Paint.Defaults()
+	rs.XForm, rs.XFormStack = xf, nil
+	rs.Path, rs.HasCurrent = nil, false
+	rs.Image, rs.Mask, rs.Bounds = tile, nil, ib
+	rs.Scanner = scanFT.NewScannerFT(ib.Dx(), ib.Dy(), scanFT.NewRGBAPainter(tile))
+	rs.Raster = rasterx.NewDasher(ib.Dx(), ib.Dy(), rs.Scanner)
+	rs.TileRaster, rs.TileScanner = nil, nil
+	rs.Recorder = nil
+
+	fun()
+
+	rs.Paint = paint
+	rs.XForm, rs.XFormStack = xform, xstack
+	rs.Path, rs.Start, rs.Current, rs.HasCurrent = path, start, cur, hascur
+	rs.Image, rs.Mask, rs.Bounds, rs.LastRenderBBox = img, mask, bounds, lastbb
+	rs.Raster, rs.Scanner, rs.TileRaster, rs.TileScanner = raster, scanner, traster, tscanner
+	rs.Recorder = rec
+}
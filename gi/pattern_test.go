@@ -0,0 +1,134 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// renderPatternScene fills a 40x40 square with given pattern, over white
+func renderPatternScene(pt *Pattern) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	rs := &RenderState{}
+	rs.Init(40, 40, img)
+	rs.Bounds = img.Bounds()
+	pc := &rs.Paint
+	pc.StrokeStyle.SetColor(nil)
+	pc.FillStyle.Color.SetColor(color.White)
+	pc.DrawRectangle(rs, 0, 0, 40, 40)
+	pc.Fill(rs)
+	pc.FillStyle.Color.SetPattern(pt)
+	pc.DrawRectangle(rs, 0, 0, 40, 40)
+	pc.Fill(rs)
+	return img
+}
+
+func TestHatchPattern(t *testing.T) {
+	img := renderPatternScene(NewHatchPattern(color.Black, 4, 10, 0))
+	white := color.RGBA{255, 255, 255, 255}
+	black := color.RGBA{0, 0, 0, 255}
+	for _, y := range []int{4, 14, 25, 35} {
+		if c := img.RGBAAt(7, y); c != black {
+			t.Errorf("hatch line at y %v: got %v, expected black\n", y, c)
+		}
+	}
+	for _, y := range []int{0, 9, 11, 21} {
+		if c := img.RGBAAt(7, y); c != white {
+			t.Errorf("hatch gap at y %v: got %v, expected white\n", y, c)
+		}
+	}
+}
+
+func TestImagePattern(t *testing.T) {
+	tile := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+	tile.SetRGBA(0, 0, red)
+	tile.SetRGBA(1, 1, red)
+	tile.SetRGBA(1, 0, blue)
+	tile.SetRGBA(0, 1, blue)
+	pt := NewImagePattern(tile)
+	pt.Size.Set(10, 10)
+	AddPattern("test-checks", pt)
+	var cs ColorSpec
+	if ok := cs.SetString("url(#test-checks)", nil); !ok || cs.Source != TiledPattern || cs.Pattern != pt {
+		t.Fatalf("url(#test-checks) did not set the registered pattern: %v\n", cs.Source)
+	}
+	img := renderPatternScene(cs.Pattern)
+	for _, tst := range []struct {
+		x, y   int
+		expect color.RGBA
+	}{
+		{2, 2, red}, {7, 2, blue}, {22, 2, red}, {27, 17, red}, {12, 37, blue},
+	} {
+		if c := img.RGBAAt(tst.x, tst.y); c != tst.expect {
+			t.Errorf("image pattern at %v,%v: got %v, expected %v\n", tst.x, tst.y, c, tst.expect)
+		}
+	}
+}
+
+func TestSelfPattern(t *testing.T) {
+	// a tile that paints with its own pattern gets nothing for that part,
+	// as the tile is not used until it is completely drawn -- the scaling
+	// makes the tile size different within the tile
+	var pt *Pattern
+	pt = NewDrawPattern(Vec2D{10, 10}, PatternDrawFunc(func(rs *RenderState, size Vec2D) {
+		pc := &rs.Paint
+		pc.StrokeStyle.SetColor(nil)
+		pc.FillStyle.SetColor(color.Black)
+		pc.DrawRectangle(rs, 0, 0, 5, 5)
+		pc.Fill(rs)
+		pc.FillStyle.Color.SetPattern(pt)
+		pc.DrawRectangle(rs, 5, 5, 5, 5)
+		pc.Fill(rs)
+	}))
+	pt.XForm = Scale2D(2, 2)
+	img := renderPatternScene(pt)
+	white := color.RGBA{255, 255, 255, 255}
+	black := color.RGBA{0, 0, 0, 255}
+	for _, tst := range []struct {
+		x, y   int
+		expect color.RGBA
+	}{
+		{5, 5, black}, {25, 5, black}, {15, 15, white}, {35, 35, white},
+	} {
+		if c := img.RGBAAt(tst.x, tst.y); c != tst.expect {
+			t.Errorf("self pattern at %v,%v: got %v, expected %v\n", tst.x, tst.y, c, tst.expect)
+		}
+	}
+}
+
+func TestPatternRedraw(t *testing.T) {
+	// painting the pattern while its tile is drawn again, as when rendered
+	// in another window, uses the last complete tile
+	clr := color.RGBA{0, 0, 0, 255}
+	var during *image.RGBA
+	var pt *Pattern
+	pt = NewDrawPattern(Vec2D{10, 10}, PatternDrawFunc(func(rs *RenderState, size Vec2D) {
+		pc := &rs.Paint
+		pc.StrokeStyle.SetColor(nil)
+		pc.FillStyle.SetColor(clr)
+		pc.DrawRectangle(rs, 0, 0, 5, 5)
+		pc.Fill(rs)
+		if during == nil && clr.R != 0 {
+			during = renderPatternScene(pt)
+		}
+	}))
+	renderPatternScene(pt)
+	clr = color.RGBA{255, 0, 0, 255}
+	pt.Update()
+	after := renderPatternScene(pt)
+	if during == nil {
+		t.Fatalf("pattern tile was not drawn again after Update\n")
+	}
+	if c := during.RGBAAt(2, 2); c != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("pattern during redraw: got %v, expected black from the last tile\n", c)
+	}
+	if c := after.RGBAAt(2, 2); c != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("pattern after redraw: got %v, expected red from the new tile\n", c)
+	}
+}
@@ -0,0 +1,782 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/goki/freetype/truetype"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// pdfenc.go writes the paint ops recorded by a PaintRecorder as the pages of
+// a PDF document: paths are written as PDF paths, gradients as shading
+// patterns, patterns as tiling patterns of their tile image, images as
+// compressed image XObjects, and text as text in the embedded TrueType font
+// files of the faces, so that the output matches the screen rendering.  All
+// pages share one resource dictionary, written at the end along with the
+// fonts.  Gradient spread methods other than pad are
+// not supported, and gradient stop opacities are averaged into one overall
+// opacity.  Layers are written as transparency groups, with their opacity
+// and blend mode, but always with the CompSrcOver compositing operator.
+
+// PDFWriter writes pages of recorded paint ops as a PDF document -- create
+// with NewPDFWriter, add pages with AddPage, and call Close when done
+type PDFWriter struct {
+	Title string `desc:"title of the document, written in its info dictionary"`
+
+	w      *bufio.Writer
+	pos    int                   // current byte position
+	offs   []int                 // byte offsets of objects, by object number - 1
+	pages  []int                 // page object numbers
+	fonts  map[string]*pdfFont   // embedded fonts, by font name -- "" is the fallback
+	faces  map[font.Face]pdfFace // fonts and sizes of faces
+	gstate map[pdfGState]string  // graphics state names
+	res    [3]bytes.Buffer       // ExtGState, Pattern and XObject resources
+	cont   bytes.Buffer          // content of current page
+	ctm    Matrix2D              // current transform from device to page coordinates
+	err    error                 // first error
+}
+
+// pdfFace is the font and size of a face
+type pdfFace struct {
+	font *pdfFont
+	size float32
+}
+
+// pdfFont is a font used in the document
+type pdfFont struct {
+	name   string                  // resource name
+	obj    int                     // object number of the font dictionary
+	base   string                  // PostScript name of the font
+	ttf    *truetype.Font          // parsed font, nil for the Helvetica fallback
+	data   []byte                  // font file data
+	widths map[truetype.Index]int  // widths of used glyphs, in 1/1000 em
+	runes  map[truetype.Index]rune // runes of used glyphs
+}
+
+// pdfGState is the fill and stroke alpha and blend mode of a graphics state
+type pdfGState struct {
+	fill, stroke uint8
+	blend        BlendModes
+}
+
+// object numbers of the objects that are always present
+const (
+	pdfCatalogObj = iota + 1
+	pdfPagesObj
+	pdfResourcesObj
+)
+
+// resources in PDFWriter.res
+const (
+	pdfResExtGState = iota
+	pdfResPattern
+	pdfResXObject
+)
+
+// NewPDFWriter returns a new PDFWriter writing to given writer, with the
+// PDF header already written
+func NewPDFWriter(w io.Writer) *PDFWriter {
+	pw := &PDFWriter{w: bufio.NewWriter(w)}
+	pw.fonts = make(map[string]*pdfFont)
+	pw.faces = make(map[font.Face]pdfFace)
+	pw.gstate = make(map[pdfGState]string)
+	pw.offs = make([]int, pdfResourcesObj)
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+	return pw
+}
+
+// AddPage writes a page of given size, in points (1/72 inch), with given
+// paint ops, whose device coordinates are scaled by given scale to points
+// (e.g., 72 / DPI) -- the top-left of the device coordinates is the top-left
+// of the page
+func (pw *PDFWriter) AddPage(ops []PaintOp, size Vec2D, scale float32) error {
+	pw.cont.Reset()
+	pw.ctm = Matrix2D{XX: scale, YY: -scale, Y0: size.Y}
+	fmt.Fprintf(&pw.cont, "q %s cm\n", pdfMatrix(pw.ctm))
+	bounds := image.Rect(0, 0, int(math.Ceil(float64(size.X/scale))), int(math.Ceil(float64(size.Y/scale))))
+	pw.writeOps(ops, bounds)
+	pw.cont.WriteString("Q\n")
+	cobj := pw.newObj()
+	pw.writeStream(cobj, "", pw.cont.Bytes())
+	pobj := pw.newObj()
+	pw.startObj(pobj)
+	pw.printf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] /Resources %d 0 R /Contents %d 0 R >>\nendobj\n", pdfPagesObj, pdfNum(size.X), pdfNum(size.Y), pdfResourcesObj, cobj)
+	pw.pages = append(pw.pages, pobj)
+	return pw.err
+}
+
+// Close writes the fonts, shared resources, page tree, cross-reference
+// table and trailer, finishing the document -- it does not close the
+// underlying writer
+func (pw *PDFWriter) Close() error {
+	fnms := make([]string, 0, len(pw.fonts))
+	for nm := range pw.fonts {
+		fnms = append(fnms, nm)
+	}
+	sort.Strings(fnms)
+	var fres bytes.Buffer
+	for _, nm := range fnms {
+		pf := pw.fonts[nm]
+		pw.writeFont(pf)
+		fmt.Fprintf(&fres, "/%s %d 0 R ", pf.name, pf.obj)
+	}
+	pw.startObj(pdfResourcesObj)
+	pw.printf("<< /ProcSet [/PDF /Text /ImageB /ImageC]")
+	for i, nm := range [3]string{"ExtGState", "Pattern", "XObject"} {
+		if pw.res[i].Len() > 0 {
+			pw.printf(" /%s << %s>>", nm, pw.res[i].Bytes())
+		}
+	}
+	if fres.Len() > 0 {
+		pw.printf(" /Font << %s>>", fres.Bytes())
+	}
+	pw.printf(" >>\nendobj\n")
+	pw.startObj(pdfPagesObj)
+	pw.printf("<< /Type /Pages /Count %d /Kids [", len(pw.pages))
+	for _, p := range pw.pages {
+		pw.printf(" %d 0 R", p)
+	}
+	pw.printf(" ] >>\nendobj\n")
+	pw.startObj(pdfCatalogObj)
+	pw.printf("<< /Type /Catalog /Pages %d 0 R >>\nendobj\n", pdfPagesObj)
+	info := 0
+	if pw.Title != "" {
+		info = pw.newObj()
+		pw.startObj(info)
+		pw.printf("<< /Title %s /Producer (GoGi) >>\nendobj\n", pdfTextString(pw.Title))
+	}
+	xref := pw.pos
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", len(pw.offs)+1)
+	for _, off := range pw.offs {
+		pw.printf("%010d 00000 n \n", off)
+	}
+	pw.printf("trailer\n<< /Size %d /Root %d 0 R", len(pw.offs)+1, pdfCatalogObj)
+	if info > 0 {
+		pw.printf(" /Info %d 0 R", info)
+	}
+	pw.printf(" >>\nstartxref\n%d\n%%%%EOF\n", xref)
+	if err := pw.w.Flush(); err != nil && pw.err == nil {
+		pw.err = err
+	}
+	return pw.err
+}
+
+// printf writes formatted output to the document
+func (pw *PDFWriter) printf(format string, args ...interface{}) {
+	n, err := fmt.Fprintf(pw.w, format, args...)
+	pw.pos += n
+	if err != nil && pw.err == nil {
+		pw.err = err
+	}
+}
+
+// newObj allocates a new object number
+func (pw *PDFWriter) newObj() int {
+	pw.offs = append(pw.offs, 0)
+	return len(pw.offs)
+}
+
+// startObj starts writing given object
+func (pw *PDFWriter) startObj(obj int) {
+	pw.offs[obj-1] = pw.pos
+	pw.printf("%d 0 obj\n", obj)
+}
+
+// writeStream writes given object as a compressed stream of given data,
+// with given additional dictionary entries
+func (pw *PDFWriter) writeStream(obj int, dict string, data []byte) {
+	var zb bytes.Buffer
+	zw := zlib.NewWriter(&zb)
+	zw.Write(data)
+	zw.Close()
+	pw.startObj(obj)
+	pw.printf("<< %s/Filter /FlateDecode /Length %d >>\nstream\n", dict, zb.Len())
+	n, err := pw.w.Write(zb.Bytes())
+	pw.pos += n
+	if err != nil && pw.err == nil {
+		pw.err = err
+	}
+	pw.printf("\nendstream\nendobj\n")
+}
+
+// writeOps writes given ops, within given bounds, which need no clipping
+func (pw *PDFWriter) writeOps(ops []PaintOp, bounds image.Rectangle) {
+	b := &pw.cont
+	cur := bounds
+	for _, op := range ops {
+		clip := op.ClipBox().Intersect(bounds)
+		if clip.Empty() {
+			continue
+		}
+		if clip != cur {
+			if cur != bounds {
+				b.WriteString("Q\n")
+			}
+			if clip != bounds {
+				fmt.Fprintf(b, "q %d %d %d %d re W n\n", clip.Min.X, clip.Min.Y, clip.Dx(), clip.Dy())
+			}
+			cur = clip
+		}
+		switch op := op.(type) {
+		case *PaintPathOp:
+			pw.writePath(op)
+		case *PaintTextOp:
+			pw.writeText(op)
+		case *PaintImageOp:
+			pw.writeImage(op)
+		case *PaintGroupOp:
+			ctm := pw.ctm
+			pw.ctm = op.XForm.Multiply(ctm)
+			fmt.Fprintf(b, "q %s cm\n", pdfMatrix(op.XForm))
+			pw.writeOps(op.Ops, op.InnerBounds(clip))
+			b.WriteString("Q\n")
+			pw.ctm = ctm
+		case *PaintLayerOp:
+			pw.writeLayer(op, clip)
+		}
+	}
+	if cur != bounds {
+		b.WriteString("Q\n")
+	}
+}
+
+// alphaGS returns the name of the graphics state with given fill and stroke
+// alpha, defining it if new
+func (pw *PDFWriter) alphaGS(fill, stroke uint8) string {
+	return pw.blendGS(fill, stroke, BlendNormal)
+}
+
+// blendGS returns the name of the graphics state with given fill and stroke
+// alpha and blend mode, defining it if new
+func (pw *PDFWriter) blendGS(fill, stroke uint8, blend BlendModes) string {
+	key := pdfGState{fill, stroke, blend}
+	if nm, ok := pw.gstate[key]; ok {
+		return nm
+	}
+	nm := fmt.Sprintf("GS%d", len(pw.gstate)+1)
+	pw.gstate[key] = nm
+	obj := pw.newObj()
+	pw.startObj(obj)
+	pw.printf("<< /Type /ExtGState /ca %s /CA %s", pdfNum(float32(fill)/255), pdfNum(float32(stroke)/255))
+	if blend != BlendNormal {
+		pw.printf(" /BM /%s", strings.TrimPrefix(blend.String(), "Blend"))
+	}
+	pw.printf(" >>\nendobj\n")
+	fmt.Fprintf(&pw.res[pdfResExtGState], "/%s %d 0 R ", nm, obj)
+	return nm
+}
+
+// writeLayer writes a layer as a transparency group form XObject, drawn with
+// its opacity and blend mode -- its content is in the current coordinates
+func (pw *PDFWriter) writeLayer(op *PaintLayerOp, bounds image.Rectangle) {
+	b := &pw.cont
+	st := b.Len()
+	ctm := pw.ctm
+	pw.ctm = Identity2D() // patterns in forms are relative to the form coordinates
+	pw.writeOps(op.Ops, bounds)
+	pw.ctm = ctm
+	cont := append([]byte(nil), b.Bytes()[st:]...)
+	b.Truncate(st)
+	obj := pw.newObj()
+	pw.writeStream(obj, fmt.Sprintf("/Type /XObject /Subtype /Form /BBox [%d %d %d %d] /Group << /S /Transparency /I true >> /Resources %d 0 R ", bounds.Min.X, bounds.Min.Y, bounds.Max.X, bounds.Max.Y, pdfResourcesObj), cont)
+	nm := fmt.Sprintf("Fm%d", obj)
+	fmt.Fprintf(&pw.res[pdfResXObject], "/%s %d 0 R ", nm, obj)
+	alpha := uint8(InRange32(op.Opacity, 0, 1)*255 + 0.5)
+	if alpha < 255 || op.Blend != BlendNormal {
+		fmt.Fprintf(b, "q /%s gs /%s Do Q\n", pw.blendGS(alpha, alpha, op.Blend), nm)
+	} else {
+		fmt.Fprintf(b, "/%s Do\n", nm)
+	}
+}
+
+// writePath writes a filled or stroked path
+func (pw *PDFWriter) writePath(op *PaintPathOp) {
+	if len(op.Path) == 0 || (op.Gradient == nil && op.Pattern == nil && op.Color.A == 0) {
+		return
+	}
+	if op.Stroke && op.Width <= 0 {
+		return
+	}
+	b := &pw.cont
+	b.WriteString("q\n")
+	alpha := op.Color.A
+	if op.Pattern != nil {
+		alpha = 255 // in the tile
+		pat := pw.tilingPattern(op.Pattern)
+		if op.Stroke {
+			fmt.Fprintf(b, "/Pattern CS /%s SCN\n", pat)
+		} else {
+			fmt.Fprintf(b, "/Pattern cs /%s scn\n", pat)
+		}
+	} else if op.Gradient != nil {
+		alpha = pdfGradientAlpha(op.Gradient, op.Opacity)
+		pat := pw.gradientPattern(op.Gradient, op.Path)
+		if op.Stroke {
+			fmt.Fprintf(b, "/Pattern CS /%s SCN\n", pat)
+		} else {
+			fmt.Fprintf(b, "/Pattern cs /%s scn\n", pat)
+		}
+	} else if op.Stroke {
+		fmt.Fprintf(b, "%s RG\n", pdfColor(op.Color))
+	} else {
+		fmt.Fprintf(b, "%s rg\n", pdfColor(op.Color))
+	}
+	if alpha < 255 {
+		fmt.Fprintf(b, "/%s gs\n", pw.alphaGS(alpha, alpha))
+	}
+	if op.Stroke {
+		fmt.Fprintf(b, "%s w\n", pdfNum(op.Width))
+		switch op.Cap {
+		case LineCapRound, LineCapCubic, LineCapQuadratic:
+			b.WriteString("1 J\n")
+		case LineCapSquare:
+			b.WriteString("2 J\n")
+		}
+		switch op.Join {
+		case LineJoinRound:
+			b.WriteString("1 j\n")
+		case LineJoinBevel:
+			b.WriteString("2 j\n")
+		default:
+			if op.MiterLimit >= 1 {
+				fmt.Fprintf(b, "%s M\n", pdfNum(op.MiterLimit))
+			}
+		}
+		if len(op.Dashes) > 0 {
+			b.WriteString("[")
+			for i, d := range op.Dashes {
+				if i > 0 {
+					b.WriteByte(' ')
+				}
+				b.WriteString(pdfNum(float32(d)))
+			}
+			b.WriteString("] 0 d\n")
+		}
+	}
+	pdfPathData(b, op.Path)
+	switch {
+	case op.Stroke:
+		b.WriteString("S\n")
+	case op.Rule == FillRuleEvenOdd:
+		b.WriteString("f*\n")
+	default:
+		b.WriteString("f\n")
+	}
+	b.WriteString("Q\n")
+}
+
+// gradientPattern defines a shading pattern for given gradient, used to
+// paint given path, returning its name
+func (pw *PDFWriter) gradientPattern(g *rasterx.Gradient, path rasterx.Path) string {
+	gm := g.Matrix
+	m := Matrix2D{float32(gm.A), float32(gm.B), float32(gm.C), float32(gm.D), float32(gm.E), float32(gm.F)}
+	if g.Units == rasterx.ObjectBoundingBox {
+		bb := g.Bounds
+		if bb.W == 0 || bb.H == 0 {
+			pb := pathBounds(path)
+			bb.X, bb.Y, bb.W, bb.H = float64(pb.Min.X), float64(pb.Min.Y), float64(pb.Dx()), float64(pb.Dy())
+		}
+		m = m.Multiply(Scale2D(float32(bb.W), float32(bb.H))).Multiply(Translate2D(float32(bb.X), float32(bb.Y)))
+	}
+	m = m.Multiply(pw.ctm)
+	var sh bytes.Buffer
+	p := g.Points
+	if g.IsRadial {
+		fmt.Fprintf(&sh, "/ShadingType 3 /Coords [%s %s 0 %s %s %s]", pdfNum(float32(p[2])), pdfNum(float32(p[3])), pdfNum(float32(p[0])), pdfNum(float32(p[1])), pdfNum(float32(p[4])))
+	} else {
+		fmt.Fprintf(&sh, "/ShadingType 2 /Coords [%s %s %s %s]", pdfNum(float32(p[0])), pdfNum(float32(p[1])), pdfNum(float32(p[2])), pdfNum(float32(p[3])))
+	}
+	obj := pw.newObj()
+	pw.startObj(obj)
+	pw.printf("<< /Type /Pattern /PatternType 2 /Matrix [%s] /Shading << %s /ColorSpace /DeviceRGB /Function %s /Extend [true true] >> >>\nendobj\n", pdfMatrix(m), sh.Bytes(), pdfGradientFunc(g))
+	nm := fmt.Sprintf("P%d", obj)
+	fmt.Fprintf(&pw.res[pdfResPattern], "/%s %d 0 R ", nm, obj)
+	return nm
+}
+
+// tilingPattern defines a tiling pattern drawing the tile image of given
+// pattern, returning its name -- the axes that are not repeated get one
+// huge tile
+func (pw *PDFWriter) tilingPattern(pp *PaintPattern) string {
+	im := pw.imageXObject(pp.Image)
+	w, h := pp.Image.Bounds().Dx(), pp.Image.Bounds().Dy()
+	xs, ys := w, h
+	if pp.Repeat != BgRepeat && pp.Repeat != BgRepeatX {
+		xs = 1 << 24
+	}
+	if pp.Repeat != BgRepeat && pp.Repeat != BgRepeatY {
+		ys = 1 << 24
+	}
+	obj := pw.newObj()
+	cont := fmt.Sprintf("%d 0 0 %d 0 %d cm /%s Do", w, -h, h, im)
+	pw.writeStream(obj, fmt.Sprintf("/Type /Pattern /PatternType 1 /PaintType 1 /TilingType 1 /BBox [0 0 %d %d] /XStep %d /YStep %d /Matrix [%s] /Resources %d 0 R ", w, h, xs, ys, pdfMatrix(pp.XForm.Multiply(pw.ctm)), pdfResourcesObj), []byte(cont))
+	nm := fmt.Sprintf("P%d", obj)
+	fmt.Fprintf(&pw.res[pdfResPattern], "/%s %d 0 R ", nm, obj)
+	return nm
+}
+
+// pdfGradientFunc returns the function mapping the gradient position to the
+// colors of its stops
+func pdfGradientFunc(g *rasterx.Gradient) string {
+	stops := make([]rasterx.GradStop, len(g.Stops))
+	copy(stops, g.Stops)
+	sort.SliceStable(stops, func(i, j int) bool { return stops[i].Offset < stops[j].Offset })
+	if len(stops) == 0 {
+		return "<< /FunctionType 2 /Domain [0 1] /C0 [0 0 0] /C1 [0 0 0] /N 1 >>"
+	}
+	if stops[0].Offset > 0 {
+		stops = append([]rasterx.GradStop{stops[0]}, stops...)
+		stops[0].Offset = 0
+	}
+	if last := stops[len(stops)-1]; last.Offset < 1 {
+		last.Offset = 1
+		stops = append(stops, last)
+	}
+	seg := func(c0, c1 color.Color) string {
+		return fmt.Sprintf("<< /FunctionType 2 /Domain [0 1] /C0 [%s] /C1 [%s] /N 1 >>", pdfColor(rasterx.ApplyOpacity(c0, 1)), pdfColor(rasterx.ApplyOpacity(c1, 1)))
+	}
+	if len(stops) == 1 {
+		return seg(stops[0].StopColor, stops[0].StopColor)
+	}
+	if len(stops) == 2 {
+		return seg(stops[0].StopColor, stops[1].StopColor)
+	}
+	var fn, bnds, enc bytes.Buffer
+	for i := 0; i < len(stops)-1; i++ {
+		fn.WriteString(seg(stops[i].StopColor, stops[i+1].StopColor))
+		enc.WriteString("0 1 ")
+		if i > 0 {
+			fmt.Fprintf(&bnds, "%s ", pdfNum(float32(math.Min(math.Max(stops[i].Offset, 0), 1))))
+		}
+	}
+	return fmt.Sprintf("<< /FunctionType 3 /Domain [0 1] /Functions [%s] /Bounds [%s] /Encode [%s] >>", fn.Bytes(), bytes.TrimSpace(bnds.Bytes()), bytes.TrimSpace(enc.Bytes()))
+}
+
+// pdfGradientAlpha returns the overall alpha of a gradient with given
+// opacity, averaging the stop opacities
+func pdfGradientAlpha(g *rasterx.Gradient, opacity float32) uint8 {
+	op := 1.0
+	if len(g.Stops) > 0 {
+		op = 0
+		for _, st := range g.Stops {
+			op += st.Opacity
+		}
+		op /= float64(len(g.Stops))
+	}
+	return uint8(math.Round(math.Min(math.Max(op*float64(opacity), 0), 1) * 255))
+}
+
+// writeImage writes an image, as an image XObject
+func (pw *PDFWriter) writeImage(op *PaintImageOp) {
+	ib := op.Image.Bounds()
+	if ib.Empty() {
+		return
+	}
+	nm := pw.imageXObject(op.Image)
+	fmt.Fprintf(&pw.cont, "q %s cm %d 0 0 %d %d %d cm /%s Do Q\n", pdfMatrix(op.XForm), ib.Dx(), -ib.Dy(), ib.Min.X, ib.Max.Y, nm)
+}
+
+// imageXObject writes given image as an image XObject, with an alpha soft
+// mask if it has any transparency, returning its name
+func (pw *PDFWriter) imageXObject(img image.Image) string {
+	ib := img.Bounds()
+	w, h := ib.Dx(), ib.Dy()
+	rgb := make([]byte, 0, 3*w*h)
+	alpha := make([]byte, 0, w*h)
+	opaque := true
+	for y := ib.Min.Y; y < ib.Max.Y; y++ {
+		for x := ib.Min.X; x < ib.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			rgb = append(rgb, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			if c.A != 255 {
+				opaque = false
+			}
+		}
+	}
+	dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 ", w, h)
+	if !opaque {
+		mobj := pw.newObj()
+		pw.writeStream(mobj, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8 ", w, h), alpha)
+		dict += fmt.Sprintf("/SMask %d 0 R ", mobj)
+	}
+	obj := pw.newObj()
+	pw.writeStream(obj, dict, rgb)
+	nm := fmt.Sprintf("Im%d", obj)
+	fmt.Fprintf(&pw.res[pdfResXObject], "/%s %d 0 R ", nm, obj)
+	return nm
+}
+
+// writeText writes the runes of a text op, in runs on the same baseline in
+// the same face and color, positioned exactly as rendered
+func (pw *PDFWriter) writeText(op *PaintTextOp) {
+	b := &pw.cont
+	b.WriteString("q BT\n")
+	var cf pdfFace
+	var cc color.NRGBA
+	rns := op.Runes
+	for st := 0; st < len(rns); {
+		r0 := &rns[st]
+		pf := pw.faceFont(r0.Face)
+		if pf != cf {
+			fmt.Fprintf(b, "/%s %s Tf\n", pf.font.name, pdfNum(pf.size))
+			cf = pf
+		}
+		if st == 0 || r0.Color != cc {
+			fmt.Fprintf(b, "%s rg\n", pdfColor(r0.Color))
+			if r0.Color.A < 255 || (st > 0 && cc.A < 255) {
+				fmt.Fprintf(b, "/%s gs\n", pw.alphaGS(r0.Color.A, 255))
+			}
+			cc = r0.Color
+		}
+		scx := r0.ScaleX
+		if scx == 0 {
+			scx = 1
+		}
+		ed := st + 1
+		if pf.font.ttf != nil && r0.RotRad == 0 && scx == 1 {
+			for ed < len(rns) && rns[ed].Face == r0.Face && rns[ed].Color == r0.Color && rns[ed].Pos.Y == r0.Pos.Y &&
+				rns[ed].RotRad == 0 && (rns[ed].ScaleX == 0 || rns[ed].ScaleX == 1) {
+				ed++
+			}
+		}
+		m := Translate2D(r0.Pos.X, r0.Pos.Y).Scale(scx, 1).Rotate(r0.RotRad).Scale(1, -1)
+		fmt.Fprintf(b, "%s Tm ", pdfMatrix(m))
+		if pf.font.ttf == nil {
+			b.WriteString("(")
+			pdfEscape(b, pdfWinAnsi(r0.Rune))
+			b.WriteString(") Tj\n")
+		} else {
+			b.WriteString("[")
+			for i := st; i < ed; i++ {
+				gid := pf.font.glyph(rns[i].Rune)
+				fmt.Fprintf(b, "<%04x>", gid)
+				if i < ed-1 && pf.size > 0 {
+					adv := float32(pf.font.widths[gid]) * pf.size / 1000
+					adj := (rns[i].Pos.X + adv - rns[i+1].Pos.X) * 1000 / pf.size
+					if adj > 2 || adj < -2 { // ignore rounding of positions
+						fmt.Fprintf(b, " %s ", pdfNum(adj))
+					}
+				}
+			}
+			b.WriteString("] TJ\n")
+		}
+		st = ed
+	}
+	b.WriteString("ET Q\n")
+}
+
+// faceFont returns the font and size for given face, embedding the font
+// file of the face if it is a TrueType font in the FontLibrary, and
+// otherwise falling back on the standard Helvetica font
+func (pw *PDFWriter) faceFont(face font.Face) pdfFace {
+	if pf, ok := pw.faces[face]; ok {
+		return pf
+	}
+	fnm, sz, ok := FontLibrary.FaceInfo(face)
+	size := float32(sz)
+	if !ok {
+		fnm = ""
+		size = FixedToFloat32(face.Metrics().Height)
+	}
+	pf := pdfFace{font: pw.fonts[fnm], size: size}
+	if pf.font == nil && fnm != "" {
+		if data, err := FontLibrary.FontData(fnm); err == nil {
+			if ttf, err := truetype.Parse(data); err == nil {
+				pf.font = &pdfFont{base: pdfFontName(fnm), ttf: ttf, data: data}
+				pf.font.widths = make(map[truetype.Index]int)
+				pf.font.runes = make(map[truetype.Index]rune)
+			}
+		}
+		if pf.font == nil {
+			fnm = ""
+			pf.font = pw.fonts[fnm]
+		}
+	}
+	if pf.font == nil {
+		pf.font = &pdfFont{base: "Helvetica"}
+	}
+	if pf.font.obj == 0 {
+		pf.font.obj = pw.newObj()
+		pf.font.name = fmt.Sprintf("F%d", len(pw.fonts)+1)
+		pw.fonts[fnm] = pf.font
+	}
+	pw.faces[face] = pf
+	return pf
+}
+
+// glyph returns the glyph index of given rune, recording its use
+func (pf *pdfFont) glyph(r rune) truetype.Index {
+	gid := pf.ttf.Index(r)
+	if _, has := pf.widths[gid]; !has {
+		upem := pf.ttf.FUnitsPerEm()
+		hm := pf.ttf.HMetric(fixed.Int26_6(upem), gid)
+		pf.widths[gid] = int(hm.AdvanceWidth) * 1000 / int(upem)
+		pf.runes[gid] = r
+	}
+	return gid
+}
+
+// writeFont writes the objects of a font: the standard Helvetica font for
+// the fallback, and otherwise a composite font with the embedded TrueType
+// font file and the widths and unicode mapping of the used glyphs
+func (pw *PDFWriter) writeFont(pf *pdfFont) {
+	if pf.ttf == nil {
+		pw.startObj(pf.obj)
+		pw.printf("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>\nendobj\n")
+		return
+	}
+	gis := make([]int, 0, len(pf.widths))
+	for gid := range pf.widths {
+		gis = append(gis, int(gid))
+	}
+	sort.Ints(gis)
+
+	cid, desc, file, tou := pw.newObj(), pw.newObj(), pw.newObj(), pw.newObj()
+	pw.startObj(pf.obj)
+	pw.printf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>\nendobj\n", pf.base, cid, tou)
+
+	pw.startObj(cid)
+	pw.printf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /CIDToGIDMap /Identity /W [", pf.base, desc)
+	for _, gid := range gis {
+		pw.printf(" %d [%d]", gid, pf.widths[truetype.Index(gid)])
+	}
+	pw.printf(" ] >>\nendobj\n")
+
+	upem := pf.ttf.FUnitsPerEm()
+	bb := pf.ttf.Bounds(fixed.Int26_6(upem))
+	em := func(v fixed.Int26_6) int { return int(v) * 1000 / int(upem) }
+	pw.startObj(desc)
+	pw.printf("<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>\nendobj\n",
+		pf.base, em(bb.Min.X), em(bb.Min.Y), em(bb.Max.X), em(bb.Max.Y), em(bb.Max.Y), em(bb.Min.Y), em(bb.Max.Y), file)
+	pw.writeStream(file, fmt.Sprintf("/Length1 %d ", len(pf.data)), pf.data)
+
+	var cm bytes.Buffer
+	cm.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for st := 0; st < len(gis); st += 100 {
+		ed := st + 100
+		if ed > len(gis) {
+			ed = len(gis)
+		}
+		fmt.Fprintf(&cm, "%d beginbfchar\n", ed-st)
+		for _, gid := range gis[st:ed] {
+			fmt.Fprintf(&cm, "<%04x> <", gid)
+			for _, u := range utf16.Encode([]rune{pf.runes[truetype.Index(gid)]}) {
+				fmt.Fprintf(&cm, "%04x", u)
+			}
+			cm.WriteString(">\n")
+		}
+		cm.WriteString("endbfchar\n")
+	}
+	cm.WriteString("endcmap\nCMapName currentdict /CMapResource defineresource pop\nend\nend\n")
+	pw.writeStream(tou, "", cm.Bytes())
+}
+
+// pdfPathData writes the path commands of given path in PDF syntax --
+// quadratic curves are converted to cubic ones
+func pdfPathData(b *bytes.Buffer, p rasterx.Path) {
+	var cx, cy float32
+	pt := func(i int) (float32, float32) {
+		return float32(p[i]) / 64, float32(p[i+1]) / 64
+	}
+	for i := 0; i < len(p); {
+		switch rasterx.PathCommand(p[i]) {
+		case rasterx.PathMoveTo:
+			cx, cy = pt(i + 1)
+			fmt.Fprintf(b, "%s %s m\n", pdfNum(cx), pdfNum(cy))
+			i += 3
+		case rasterx.PathLineTo:
+			cx, cy = pt(i + 1)
+			fmt.Fprintf(b, "%s %s l\n", pdfNum(cx), pdfNum(cy))
+			i += 3
+		case rasterx.PathQuadTo:
+			qx, qy := pt(i + 1)
+			ex, ey := pt(i + 3)
+			fmt.Fprintf(b, "%s %s %s %s %s %s c\n", pdfNum(cx+2*(qx-cx)/3), pdfNum(cy+2*(qy-cy)/3), pdfNum(ex+2*(qx-ex)/3), pdfNum(ey+2*(qy-ey)/3), pdfNum(ex), pdfNum(ey))
+			cx, cy = ex, ey
+			i += 5
+		case rasterx.PathCubicTo:
+			x1, y1 := pt(i + 1)
+			x2, y2 := pt(i + 3)
+			cx, cy = pt(i + 5)
+			fmt.Fprintf(b, "%s %s %s %s %s %s c\n", pdfNum(x1), pdfNum(y1), pdfNum(x2), pdfNum(y2), pdfNum(cx), pdfNum(cy))
+			i += 7
+		case rasterx.PathClose:
+			b.WriteString("h\n")
+			i++
+		default:
+			return
+		}
+	}
+}
+
+// pdfNum formats a number for PDF, to 1/1000 of a unit
+func pdfNum(v float32) string {
+	return strconv.FormatFloat(math.Round(float64(v)*1000)/1000, 'f', -1, 64)
+}
+
+// pdfColor returns the r g b color components, without alpha
+func pdfColor(c color.NRGBA) string {
+	return fmt.Sprintf("%s %s %s", pdfNum(float32(c.R)/255), pdfNum(float32(c.G)/255), pdfNum(float32(c.B)/255))
+}
+
+// pdfMatrix returns the matrix operands for given matrix
+func pdfMatrix(m Matrix2D) string {
+	return fmt.Sprintf("%s %s %s %s %s %s", pdfNum(m.XX), pdfNum(m.YX), pdfNum(m.XY), pdfNum(m.YY), pdfNum(m.X0), pdfNum(m.Y0))
+}
+
+// pdfEscape writes given bytes as the contents of a PDF literal string
+func pdfEscape(b *bytes.Buffer, s []byte) {
+	for _, c := range s {
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+}
+
+// pdfWinAnsi returns the WinAnsi encoding of a rune, for the fallback font
+// -- runes outside of Latin-1 are written as ?
+func pdfWinAnsi(r rune) []byte {
+	if r < 32 || r > 255 || (r >= 127 && r < 160) {
+		return []byte{'?'}
+	}
+	return []byte{byte(r)}
+}
+
+// pdfTextString returns a PDF text string for given string, in UTF-16
+func pdfTextString(s string) string {
+	var b bytes.Buffer
+	b.WriteString("<feff")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04x", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+// pdfNameRE matches characters that are not valid in font names
+var pdfNameRE = regexp.MustCompile(`[^A-Za-z0-9\-]`)
+
+// pdfFontName returns the PostScript name for given font name
+func pdfFontName(fnm string) string {
+	return pdfNameRE.ReplaceAllString(fnm, "")
+}
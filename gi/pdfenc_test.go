@@ -0,0 +1,92 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/gitest"
+	"github.com/goki/gi/giv"
+	"github.com/goki/gi/svg"
+)
+
+// TestMain runs the tests of the gi package with the offscreen driver, as
+// printing needs the fonts and images of an oswin App
+func TestMain(m *testing.M) {
+	gitest.Main(m)
+}
+
+type printRec struct {
+	Name  string
+	Value float32
+	Note  string
+}
+
+func TestPrintPDF(t *testing.T) {
+	recs := make([]*printRec, 120)
+	for i := range recs {
+		recs[i] = &printRec{Name: fmt.Sprintf("rec%v", i), Value: float32(i) / 4, Note: "a somewhat longer note that may need to be wrapped"}
+	}
+	var sv *svg.SVG
+	var txv *giv.TextView
+	var tbv *giv.TableView
+	win := gitest.NewWindow("printpdf", 400, 300, func(mfr *gi.Frame) {
+		tb := giv.NewTextBuf()
+		tb.Opts.LineNos = true
+		tb.SetText([]byte(strings.Repeat("func main() {\n\tfmt.Println(\"printed\")\n}\n", 30)))
+		txv = mfr.AddNewChild(giv.KiT_TextView, "txv").(*giv.TextView)
+		txv.SetBuf(tb)
+		tbv = mfr.AddNewChild(giv.KiT_TableView, "tbv").(*giv.TableView)
+		tbv.SetSlice(&recs, nil)
+		sv = addCircleSVG(mfr)
+	})
+	defer win.OSWin.Close()
+
+	setup := &gi.PageSetup{}
+	setup.Defaults()
+	setup.Header = "{title}\t\tprinted"
+	pd := gi.NewPrintDoc(setup, "Print Test")
+	pd.AddHTML("<b>Printing</b> test")
+	txv.Print(pd)
+	pd.AddSpace(12)
+	tbv.Print(pd)
+	if err := pd.AddViewport(&sv.Viewport2D); err != nil {
+		t.Fatal(err)
+	}
+	if pd.NPages() < 3 {
+		t.Errorf("expected at least 3 pages, got: %v\n", pd.NPages())
+	}
+	var b bytes.Buffer
+	if err := pd.EncodePDF(&b); err != nil {
+		t.Fatal(err)
+	}
+	pdf := b.Bytes()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("not a PDF document\n")
+	}
+	if n := bytes.Count(pdf, []byte("/Type /Page /Parent")); n != pd.NPages() {
+		t.Errorf("PDF has %v pages, doc has %v\n", n, pd.NPages())
+	}
+	if !bytes.Contains(pdf, []byte(fmt.Sprintf("/Count %v ", pd.NPages()))) || !bytes.Contains(pdf, []byte("/FontFile2")) {
+		t.Errorf("PDF missing page count or embedded font\n")
+	}
+	// check that the cross-reference table points to the objects
+	sx := bytes.LastIndex(pdf, []byte("startxref\n"))
+	var xref, nobj int
+	fmt.Sscanf(string(pdf[sx+10:]), "%d", &xref)
+	fmt.Sscanf(string(pdf[xref:]), "xref\n0 %d\n", &nobj)
+	lines := strings.Split(string(pdf[xref:]), "\n")[3 : 3+nobj-1]
+	for i, ln := range lines {
+		var off int
+		fmt.Sscanf(ln, "%d", &off)
+		if !bytes.HasPrefix(pdf[off:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))) {
+			t.Errorf("xref entry for object %v does not point to it\n", i+1)
+		}
+	}
+}
@@ -77,7 +77,8 @@ type Preferences struct {
 	KeyMap               KeyMapName             `desc:"select the active keymap from list of available keymaps -- see Edit KeyMaps for editing / saving / loading that list"`
 	SaveKeyMaps          bool                   `desc:"if set, the current available set of key maps is saved to your preferences directory, and automatically loaded at startup -- this should be set if you are using custom key maps, but it may be safer to keep it <i>OFF</i> if you are <i>not</i> using custom key maps, so that you'll always have the latest compiled-in standard key maps with all the current key functions bound to standard key chords"`
 	SaveDetailed         bool                   `desc:"if set, the detailed preferences are saved and loaded at startup -- only "`
-	CustomStyles         ki.Props               `desc:"a custom style sheet -- add a separate Props entry for each type of object, e.g., button, or class using .classname, or specific named element using #name -- all are case insensitive"`
+	CustomStyles         ki.Props               `desc:"a custom style sheet -- add a separate Props entry for each CSS selector, e.g., button for a type of object, .classname for a class, #name for a specific named element, or combinations such as .toolbar button:hover -- type, class and name are case insensitive"`
+	ColorScheme          ColorSchemes           `desc:"whether you prefer a light or dark color scheme -- selects the styles for (prefers-color-scheme: light) or (prefers-color-scheme: dark) in @media rules of style sheets"`
 	CustomStylesOverride bool                   `desc:"if true my custom styles override other styling (i.e., they come <i>last</i> in styling process -- otherwise they provide defaults that can be overridden by app-specific styling (i.e, they come first)."`
 	FontFamily           FontName               `desc:"default font family when otherwise not specified"`
 	FontPaths            []string               `desc:"extra font paths, beyond system defaults -- searched first"`
@@ -246,21 +247,23 @@ func (pf *Preferences) Apply() {
 	pf.ApplyDPI()
 }
 
-// ApplyDPI updates the screen LogicalDPI values according to current
-// preferences and zoom factor, and then updates all open windows as well.
+// ApplyDPI updates the LogicalDPI of all open windows according to current
+// preferences and zoom factor, for the screen each window is on -- the
+// screens are shared with the driver and are not changed.
 func (pf *Preferences) ApplyDPI() {
-	n := oswin.TheApp.NScreens()
-	for i := 0; i < n; i++ {
-		sc := oswin.TheApp.Screen(i)
-		if scp, ok := pf.ScreenPrefs[sc.Name]; ok {
-			sc.LogicalDPI = oswin.LogicalFmPhysicalDPI(ZoomFactor*scp.LogicalDPIScale, sc.PhysicalDPI)
-		} else {
-			sc.LogicalDPI = oswin.LogicalFmPhysicalDPI(ZoomFactor*pf.LogicalDPIScale, sc.PhysicalDPI)
-		}
-	}
 	for _, w := range AllWindows {
-		w.OSWin.SetLogicalDPI(w.OSWin.Screen().LogicalDPI)
+		w.OSWin.SetLogicalDPI(pf.ScreenLogicalDPI(w.OSWin.Screen()))
+	}
+}
+
+// ScreenLogicalDPI returns the LogicalDPI for given screen according to the
+// ScreenPrefs for that screen (or overall LogicalDPIScale if none), and
+// current zoom factor -- the screen itself is not changed.
+func (pf *Preferences) ScreenLogicalDPI(sc *oswin.Screen) float32 {
+	if scp, ok := pf.ScreenPrefs[sc.Name]; ok {
+		return oswin.LogicalFmPhysicalDPI(ZoomFactor*scp.LogicalDPIScale, sc.PhysicalDPI)
 	}
+	return oswin.LogicalFmPhysicalDPI(ZoomFactor*pf.LogicalDPIScale, sc.PhysicalDPI)
 }
 
 // Update updates all open windows with current preferences -- triggers
@@ -286,10 +289,11 @@ func (pf *Preferences) ScreenInfo() string {
 	scinfo := ""
 	for i := 0; i < ns; i++ {
 		sc := oswin.TheApp.Screen(i)
+		ldpi := pf.ScreenLogicalDPI(sc)
 		if i > 0 {
 			scinfo += "<br><br>\n"
 		}
-		scinfo += fmt.Sprintf("Screen number: %v name: %v\n<br>    geom: %v, depth: %v, logical DPI: %v, physical DPI: %v, logical DPI scale: %v, physical size: %v\n<br>    device pixel ratio: %v, refresh rate: %v\n<br>    orientation: %v, native orientation: %v, primary orientation: %v\n", i, sc.Name, sc.Geometry, sc.Depth, sc.LogicalDPI, sc.PhysicalDPI, sc.LogicalDPI/sc.PhysicalDPI, sc.PhysicalSize, sc.DevicePixelRatio, sc.RefreshRate, sc.Orientation, sc.NativeOrientation, sc.PrimaryOrientation)
+		scinfo += fmt.Sprintf("Screen number: %v name: %v\n<br>    geom: %v, depth: %v, logical DPI: %v, physical DPI: %v, logical DPI scale: %v, physical size: %v\n<br>    device pixel ratio: %v, refresh rate: %v\n<br>    orientation: %v, native orientation: %v, primary orientation: %v\n", i, sc.Name, sc.Geometry, sc.Depth, ldpi, sc.PhysicalDPI, ldpi/sc.PhysicalDPI, sc.PhysicalSize, sc.DevicePixelRatio, sc.RefreshRate, sc.Orientation, sc.NativeOrientation, sc.PrimaryOrientation)
 	}
 	return scinfo
 }
@@ -309,13 +313,13 @@ func (pf *Preferences) SaveZoom(forCurrentScreen bool) {
 		if !ok {
 			sp = ScreenPrefs{}
 		}
-		sp.LogicalDPIScale = Truncate32(sc.LogicalDPI/sc.PhysicalDPI, 2)
+		sp.LogicalDPIScale = Truncate32(pf.ScreenLogicalDPI(sc)/sc.PhysicalDPI, 2)
 		if pf.ScreenPrefs == nil {
 			pf.ScreenPrefs = make(map[string]ScreenPrefs)
 		}
 		pf.ScreenPrefs[sc.Name] = sp
 	} else {
-		pf.LogicalDPIScale = Truncate32(sc.LogicalDPI/sc.PhysicalDPI, 2)
+		pf.LogicalDPIScale = Truncate32(pf.ScreenLogicalDPI(sc)/sc.PhysicalDPI, 2)
 	}
 	pf.Save()
 }
@@ -0,0 +1,415 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki/ints"
+)
+
+// print.go supports "printing" documents to multi-page PDF files: a PrintDoc
+// flows text, tables and drawings down the pages of a PageSetup, painting
+// them with the usual Paint and TextRender rendering, which is recorded as
+// the vector paint ops of each page (see paintrec.go) and then written out
+// as a PDF document (see pdfenc.go), with headers and footers added.  The
+// pages are rendered at 72 DPI, so all positions and sizes on the pages are
+// in points (1/72 inch).
+
+// Standard page sizes, in points
+var (
+	PageLetter = Vec2D{612, 792}
+	PageLegal  = Vec2D{612, 1008}
+	PageA4     = Vec2D{595.28, 841.89}
+)
+
+// PageMargins are the margins around the content of a page, in points
+type PageMargins struct {
+	Top    float32 `desc:"top margin, in points -- the header is centered within it"`
+	Right  float32 `desc:"right margin, in points"`
+	Bottom float32 `desc:"bottom margin, in points -- the footer is centered within it"`
+	Left   float32 `desc:"left margin, in points"`
+}
+
+// PageSetup specifies the pages of a PrintDoc
+type PageSetup struct {
+	Size       Vec2D       `desc:"size of the pages, in points (1/72 inch) -- see PageLetter, PageA4 etc"`
+	Margins    PageMargins `desc:"margins around the content of the pages"`
+	Header     string      `desc:"header at the top of each page -- tabs separate left, center and right aligned parts, and {title}, {page} and {pages} are replaced with the document title, page number and number of pages"`
+	Footer     string      `desc:"footer at the bottom of each page, in the same format as the Header"`
+	HeaderSize units.Value `desc:"font size of the header and footer"`
+}
+
+// Defaults sets the default page setup: Letter size with 1 inch margins,
+// and page numbers in the footer
+func (ps *PageSetup) Defaults() {
+	ps.Size = PageLetter
+	ps.Margins = PageMargins{72, 72, 72, 72}
+	ps.Header = ""
+	ps.Footer = "\t{page} / {pages}"
+	ps.HeaderSize = units.NewValue(9, units.Pt)
+}
+
+// PrintDoc is a multi-page document that content is added to from top to
+// bottom, with page breaks as needed, and then written as a PDF document --
+// see EncodePDF, SavePDF.  Create with NewPrintDoc.
+type PrintDoc struct {
+	Setup  PageSetup        `desc:"page setup"`
+	Title  string           `desc:"title of the document, for the {title} in headers and footers, and the PDF info"`
+	Style  Style            `desc:"default style of text and tables, at the 72 DPI of the pages -- see PageStyle"`
+	Ctxt   units.Context    `desc:"units context of the pages, at 72 DPI"`
+	Pages  []*PaintRecorder `desc:"the recorded painting of each page, without the headers and footers"`
+	Pos    float32          `desc:"current vertical position on the last page, where the next content is added"`
+	Render RenderState      `json:"-" xml:"-" view:"-" desc:"render state for painting onto the pages"`
+}
+
+// NewPrintDoc returns a new document with given page setup (nil for the
+// defaults) and title, starting on its first page
+func NewPrintDoc(setup *PageSetup, title string) *PrintDoc {
+	pd := &PrintDoc{Title: title}
+	if setup != nil {
+		pd.Setup = *setup
+	} else {
+		pd.Setup.Defaults()
+	}
+	sz := pd.Setup.Size
+	min, max := pd.ContentBox()
+	pd.Ctxt.Defaults()
+	pd.Ctxt.DPI = 72
+	pd.Ctxt.SetSizes(sz.X, sz.Y, max.X-min.X, max.Y-min.Y)
+	st := NewStyle()
+	pd.Style = *pd.PageStyle(&st)
+	w, h := int(math32.Ceil(sz.X)), int(math32.Ceil(sz.Y))
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	pd.Render.Init(w, h, img)
+	pd.Render.Bounds = img.Bounds()
+	pd.NewPage()
+	return pd
+}
+
+// PageStyle returns a copy of given style (e.g., of a widget) converted to
+// the 72 DPI of the pages, so that text is printed at its point size
+func (pd *PrintDoc) PageStyle(st *Style) *Style {
+	ps := &Style{}
+	ps.CopyFrom(st)
+	ps.UnContext = pd.Ctxt
+	ps.Font.Size.Dots = 0
+	ps.Font.OpenFont(&ps.UnContext)
+	ps.ToDots()
+	return ps
+}
+
+// ContentBox returns the top-left and bottom-right of the content area of
+// the pages, inside the margins, in points
+func (pd *PrintDoc) ContentBox() (min, max Vec2D) {
+	m := &pd.Setup.Margins
+	return Vec2D{m.Left, m.Top}, Vec2D{pd.Setup.Size.X - m.Right, pd.Setup.Size.Y - m.Bottom}
+}
+
+// NPages returns the number of pages
+func (pd *PrintDoc) NPages() int {
+	return len(pd.Pages)
+}
+
+// NewPage starts a new page, with content added at its top from now on
+func (pd *PrintDoc) NewPage() {
+	pr := &PaintRecorder{}
+	pd.Pages = append(pd.Pages, pr)
+	pd.Render.Recorder = pr
+	pd.Pos = pd.Setup.Margins.Top
+}
+
+// PageRender returns the render state for painting onto given page (index
+// from 0), e.g., to add to a previous page -- the following Add methods
+// return to painting the last page
+func (pd *PrintDoc) PageRender(page int) *RenderState {
+	pd.Render.Recorder = pd.Pages[page]
+	return &pd.Render
+}
+
+// render returns the render state for painting onto the last page
+func (pd *PrintDoc) render() *RenderState {
+	return pd.PageRender(len(pd.Pages) - 1)
+}
+
+// Reserve starts a new page if the given height does not fit below the
+// current position on the last page, unless nothing has yet been added to
+// that page, returning true if a new page was started
+func (pd *PrintDoc) Reserve(h float32) bool {
+	_, max := pd.ContentBox()
+	if pd.Pos+h <= max.Y || pd.Pos <= pd.Setup.Margins.Top {
+		return false
+	}
+	pd.NewPage()
+	return true
+}
+
+// AddSpace adds given vertical space, in points, starting a new page if it
+// goes past the end of the last page
+func (pd *PrintDoc) AddSpace(h float32) {
+	_, max := pd.ContentBox()
+	pd.Pos += h
+	if pd.Pos >= max.Y {
+		pd.NewPage()
+	}
+}
+
+// AddText adds text that has been laid out (e.g., with LayoutStdLR) at
+// given indent from the left margin, breaking pages between its lines
+// (spans) as needed -- returns the page and the position at which the first
+// line was rendered, e.g., for adding line numbers
+func (pd *PrintDoc) AddText(tr *TextRender, indent float32) (page int, pos Vec2D) {
+	min, _ := pd.ContentBox()
+	rs := pd.render()
+	page, pos = len(pd.Pages)-1, Vec2D{min.X + indent, pd.Pos}
+	nsp := len(tr.Spans)
+	if nsp == 0 {
+		return
+	}
+	base := tr.Spans[0].RelPos.Y
+	for si := range tr.Spans {
+		top := tr.Spans[si].RelPos.Y - base
+		bot := tr.Size.Y
+		if si < nsp-1 {
+			bot = tr.Spans[si+1].RelPos.Y - base
+		}
+		if pd.Reserve(bot - top) {
+			rs = pd.render()
+		}
+		tpos := Vec2D{min.X + indent, pd.Pos - top}
+		if si == 0 {
+			page, pos = len(pd.Pages)-1, tpos
+		}
+		ltr := TextRender{Spans: tr.Spans[si : si+1]}
+		ltr.Render(rs, tpos)
+		pd.Pos += bot - top
+	}
+	return
+}
+
+// AddString adds a paragraph of plain text in the document Style, wrapped
+// to the width of the page
+func (pd *PrintDoc) AddString(str string) {
+	st := &pd.Style
+	min, max := pd.ContentBox()
+	tr := &TextRender{}
+	tr.SetString(str, &st.Font, &st.UnContext, &st.Text, true, 0, 0)
+	tr.LayoutStdLR(&st.Text, &st.Font, &st.UnContext, Vec2D{max.X - min.X, 0})
+	pd.AddText(tr, 0)
+}
+
+// AddHTML adds a paragraph of text with HTML markup in the document Style,
+// wrapped to the width of the page
+func (pd *PrintDoc) AddHTML(html string) {
+	st := &pd.Style
+	min, max := pd.ContentBox()
+	tr := &TextRender{}
+	tr.SetHTML(html, &st.Font, &st.Text, &st.UnContext, nil)
+	tr.LayoutStdLR(&st.Text, &st.Font, &st.UnContext, Vec2D{max.X - min.X, 0})
+	pd.AddText(tr, 0)
+}
+
+// AddViewport adds the rendering of given viewport (e.g., of an svg.SVG
+// drawing), recorded as vector graphics, at its actual size given its DPI,
+// or scaled down to fit the width of the page -- it starts a new page if
+// the viewport does not fit on the last page, and is split across pages if
+// it is taller than one page
+func (pd *PrintDoc) AddViewport(vp *Viewport2D) error {
+	if vp.Pixels == nil {
+		return fmt.Errorf("gi.PrintDoc AddViewport: viewport %v has not been rendered", vp.Nm)
+	}
+	pr := vp.RecordRender()
+	sz := vp.Pixels.Bounds().Size()
+	if sz.X == 0 || sz.Y == 0 {
+		return nil
+	}
+	min, max := pd.ContentBox()
+	sc := 72 / vp.DPI()
+	if float32(sz.X)*sc > max.X-min.X {
+		sc = (max.X - min.X) / float32(sz.X)
+	}
+	if h := float32(sz.Y) * sc; h <= max.Y-min.Y {
+		pd.Reserve(h)
+	}
+	w := float32(sz.X) * sc
+	for y := 0; y < sz.Y; {
+		sh := ints.MinInt(int((max.Y-pd.Pos)/sc), sz.Y-y) // height of this slice, in viewport dots
+		if sh <= 0 {
+			pd.NewPage()
+			continue
+		}
+		xf := Translate2D(min.X, pd.Pos-float32(y)*sc).Scale(sc, sc)
+		clip := image.Rect(int(math32.Floor(min.X)), int(math32.Floor(pd.Pos)), int(math32.Ceil(min.X+w)), int(math32.Ceil(pd.Pos+float32(sh)*sc)))
+		pd.render().Recorder.Add(&PaintGroupOp{Ops: pr.Ops, XForm: xf, Clip: clip})
+		pd.Pos += float32(sh) * sc
+		y += sh
+	}
+	return nil
+}
+
+// AddTable adds a table with given header and rows of cells, in the
+// document Style, with the header in bold -- the columns are as wide as
+// their widest cells, or scaled down to fit the width of the page with the
+// text wrapped within the cells, and the header is repeated at the top of
+// each page that the table continues onto
+func (pd *PrintDoc) AddTable(header []string, rows [][]string) {
+	ncol := len(header)
+	for _, r := range rows {
+		if len(r) > ncol {
+			ncol = len(r)
+		}
+	}
+	if ncol == 0 {
+		return
+	}
+	st := &pd.Style
+	hst := &Style{}
+	hst.CopyFrom(st)
+	hst.Font.Weight = WeightBold
+	hst.Font.OpenFont(&hst.UnContext)
+	pad := 0.25 * st.Font.Em
+	min, max := pd.ContentBox()
+
+	cell := func(row []string, col int, sty *Style, w float32) *TextRender {
+		tr := &TextRender{}
+		if col < len(row) {
+			tr.SetString(row[col], &sty.Font, &sty.UnContext, &sty.Text, true, 0, 0)
+			tr.LayoutStdLR(&sty.Text, &sty.Font, &sty.UnContext, Vec2D{w, 0})
+		}
+		return tr
+	}
+	wds := make([]float32, ncol)
+	tot := float32(0)
+	for c := range wds {
+		wds[c] = cell(header, c, hst, 0).Size.X
+		for _, r := range rows {
+			wds[c] = Max32(wds[c], cell(r, c, st, 0).Size.X)
+		}
+		wds[c] += 2 * pad
+		tot += wds[c]
+	}
+	if cw := max.X - min.X; tot > cw {
+		for c := range wds {
+			wds[c] *= cw / tot
+		}
+	}
+	layRow := func(row []string, sty *Style) ([]*TextRender, float32) {
+		trs := make([]*TextRender, ncol)
+		h := sty.Font.Height * sty.Text.EffLineHeight()
+		for c := range trs {
+			trs[c] = cell(row, c, sty, wds[c]-2*pad)
+			h = Max32(h, trs[c].Size.Y)
+		}
+		return trs, h + 2*pad
+	}
+	drawRow := func(trs []*TextRender, h float32, bg bool) {
+		rs := pd.render()
+		pc := &rs.Paint
+		x := min.X
+		for c, tr := range trs {
+			if bg {
+				pc.FillBoxColor(rs, Vec2D{x, pd.Pos}, Vec2D{wds[c], h}, color.Gray{235})
+			}
+			tr.Render(rs, Vec2D{x + pad, pd.Pos + pad})
+			pc.StrokeStyle.Color.SetColor(color.Gray{128})
+			pc.StrokeStyle.Width.Dots = 0.5
+			pc.DrawRectangle(rs, x, pd.Pos, wds[c], h)
+			pc.Stroke(rs)
+			x += wds[c]
+		}
+		pd.Pos += h
+	}
+
+	htrs, hh := layRow(header, hst)
+	for ri, r := range rows {
+		trs, h := layRow(r, st)
+		if ri == 0 {
+			pd.Reserve(hh + h)
+			drawRow(htrs, hh, true)
+		} else if pd.Reserve(h) {
+			drawRow(htrs, hh, true)
+		}
+		drawRow(trs, h, false)
+	}
+	if len(rows) == 0 {
+		pd.Reserve(hh)
+		drawRow(htrs, hh, true)
+	}
+}
+
+// renderHeader renders a header (top = true) or footer for given page, into
+// the current Recorder
+func (pd *PrintDoc) renderHeader(hdr string, page int, top bool) {
+	if hdr == "" {
+		return
+	}
+	rep := strings.NewReplacer("{title}", pd.Title, "{page}", strconv.Itoa(page+1), "{pages}", strconv.Itoa(len(pd.Pages)))
+	parts := strings.SplitN(rep.Replace(hdr), "\t", 3)
+	ctxt := pd.Ctxt
+	fs := pd.Style.Font
+	fs.Size = pd.Setup.HeaderSize
+	fs.Size.Dots = 0
+	fs.OpenFont(&ctxt)
+	ts := pd.Style.Text
+	min, max := pd.ContentBox()
+	m := &pd.Setup.Margins
+	rs := &pd.Render
+	for i, pt := range parts {
+		if pt == "" {
+			continue
+		}
+		tr := &TextRender{}
+		tr.SetString(pt, &fs, &ctxt, &ts, true, 0, 0)
+		sz := tr.LayoutStdLR(&ts, &fs, &ctxt, Vec2D{})
+		pos := Vec2D{min.X, (m.Top - sz.Y) / 2}
+		if !top {
+			pos.Y = max.Y + (m.Bottom-sz.Y)/2
+		}
+		switch i {
+		case 1:
+			pos.X = 0.5 * (min.X + max.X - sz.X)
+		case 2:
+			pos.X = max.X - sz.X
+		}
+		tr.Render(rs, pos)
+	}
+}
+
+// EncodePDF writes the document as a PDF document to the provided
+// io.Writer, adding the headers and footers to the pages
+func (pd *PrintDoc) EncodePDF(w io.Writer) error {
+	pw := NewPDFWriter(w)
+	pw.Title = pd.Title
+	for p, pr := range pd.Pages {
+		hf := &PaintRecorder{}
+		pd.Render.Recorder = hf
+		pd.renderHeader(pd.Setup.Header, p, true)
+		pd.renderHeader(pd.Setup.Footer, p, false)
+		ops := make([]PaintOp, 0, len(pr.Ops)+len(hf.Ops))
+		ops = append(append(ops, pr.Ops...), hf.Ops...)
+		if err := pw.AddPage(ops, pd.Setup.Size, 1); err != nil {
+			return err
+		}
+	}
+	return pw.Close()
+}
+
+// SavePDF saves the document as a PDF document to given file path
+func (pd *PrintDoc) SavePDF(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return pd.EncodePDF(file)
+}
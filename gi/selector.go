@@ -0,0 +1,865 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/goki/ki"
+	"github.com/goki/ki/kit"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Selector
+
+// SelectorAttr is an [attribute] element of a selector -- attributes of a
+// node are its class, id (name) and type, and otherwise its properties
+type SelectorAttr struct {
+	Name string `desc:"attribute name"`
+	Op   string `desc:"comparison operator: empty to test for presence, or one of = ~= |= ^= $= *="`
+	Val  string `desc:"value to compare with"`
+}
+
+// SelectorPseudo is a :pseudo-class element of a selector, e.g., :hover or
+// :nth-child(2n+1)
+type SelectorPseudo struct {
+	Name string    `desc:"pseudo-class name, without the : and lower case"`
+	A, B int       `desc:"for nth-child and related: matches elements at positions A*n+B (1-based), for n >= 0"`
+	Not  *Selector `desc:"for :not(..) -- the selector that must not match"`
+}
+
+// SelectorPart is one compound element of a Selector, e.g., button.primary#ok
+// -- all of the specified elements must match
+type SelectorPart struct {
+	Type    string           `desc:"type name, matched case-insensitively against the node type name -- empty or * matches any type"`
+	Classes []string         `desc:"class names, all of which must be in the node Class"`
+	Name    string           `desc:"node name (as in #name), matched case-insensitively -- empty matches any name"`
+	Attrs   []SelectorAttr   `desc:"[attribute] elements, all of which must match"`
+	Pseudos []SelectorPseudo `desc:"pseudo-classes, all of which must match"`
+	Comb    rune             `desc:"combinator relating this part to the prior part: ' ' for any descendant of it, '>' for a direct child, '+' for the next sibling after it, '~' for any later sibling -- 0 for the first part"`
+}
+
+// StatePseudos are the pseudo-classes that select one of the state styles
+// of widgets that have them (e.g., the StateStyles of buttons), instead of
+// being matched against the current state of the node -- the names are
+// those used in the selectors for the states (e.g., ButtonSelectors), with
+// :disabled an alias for :inactive
+var StatePseudos = map[string]bool{
+	"active": true, "inactive": true, "disabled": true, "hover": true, "focus": true,
+	"down": true, "selected": true, "value": true, "box": true, "highlight": true,
+}
+
+// statePseudo returns the state selector for a pseudo-class name, mapping
+// aliases
+func statePseudo(name string) string {
+	if name == "disabled" {
+		return ":inactive"
+	}
+	return ":" + name
+}
+
+// Match returns true if given node matches this part (ignoring combinators)
+func (sp *SelectorPart) Match(k ki.Ki) bool {
+	return sp.matchState(k, "", false)
+}
+
+// matchState matches the node against this part, where state pseudo-classes
+// must select given state if last is true (i.e., for the part matching the
+// node being styled), and otherwise are matched against the node flags
+func (sp *SelectorPart) matchState(k ki.Ki, state string, last bool) bool {
+	if sp.Type != "" && sp.Type != "*" && !strings.EqualFold(sp.Type, k.Type().Name()) {
+		return false
+	}
+	if sp.Name != "" && !strings.EqualFold(sp.Name, k.Name()) {
+		return false
+	}
+	nb, _ := k.Embed(KiT_NodeBase).(*NodeBase)
+	if len(sp.Classes) > 0 {
+		if nb == nil {
+			return false
+		}
+		for _, cl := range sp.Classes {
+			if !nb.HasClass(cl) {
+				return false
+			}
+		}
+	}
+	for i := range sp.Attrs {
+		if !sp.Attrs[i].Match(k) {
+			return false
+		}
+	}
+	gotState := false
+	for i := range sp.Pseudos {
+		ps := &sp.Pseudos[i]
+		if last && StatePseudos[ps.Name] {
+			if statePseudo(ps.Name) != state {
+				return false
+			}
+			gotState = true
+			continue
+		}
+		if !ps.Match(k, nb) {
+			return false
+		}
+	}
+	return !last || state == "" || gotState
+}
+
+// attrValue returns the value of given attribute of the node
+func attrValue(k ki.Ki, name string) (string, bool) {
+	switch name {
+	case "class":
+		if nb, ok := k.Embed(KiT_NodeBase).(*NodeBase); ok {
+			return nb.Class, nb.Class != ""
+		}
+		return "", false
+	case "id", "name":
+		return k.Name(), true
+	case "type":
+		return k.Type().Name(), true
+	}
+	pv, ok := k.Prop(name)
+	if !ok {
+		return "", false
+	}
+	return kit.ToString(pv), true
+}
+
+// Match returns true if the attribute of the node matches
+func (sa *SelectorAttr) Match(k ki.Ki) bool {
+	av, ok := attrValue(k, sa.Name)
+	if !ok {
+		return false
+	}
+	switch sa.Op {
+	case "":
+		return true
+	case "=":
+		return av == sa.Val
+	case "~=":
+		for _, f := range strings.Fields(av) {
+			if f == sa.Val {
+				return true
+			}
+		}
+		return false
+	case "|=":
+		return av == sa.Val || strings.HasPrefix(av, sa.Val+"-")
+	case "^=":
+		return sa.Val != "" && strings.HasPrefix(av, sa.Val)
+	case "$=":
+		return sa.Val != "" && strings.HasSuffix(av, sa.Val)
+	case "*=":
+		return sa.Val != "" && strings.Contains(av, sa.Val)
+	}
+	return false
+}
+
+// siblingPos returns the 1-based position of the node among its siblings,
+// and the number of siblings (including itself), counting only siblings of
+// the same type if ofType -- returns 1, 1 for a node without a parent
+func siblingPos(k ki.Ki, ofType bool) (pos, n int) {
+	par := k.Parent()
+	if par == nil {
+		return 1, 1
+	}
+	for _, sib := range *par.Children() {
+		if ofType && sib.Type() != k.Type() {
+			continue
+		}
+		n++
+		if sib == k {
+			pos = n
+		}
+	}
+	return
+}
+
+// nthMatch returns true if pos = A*n+B for some n >= 0
+func (ps *SelectorPseudo) nthMatch(pos int) bool {
+	d := pos - ps.B
+	if ps.A == 0 {
+		return d == 0
+	}
+	return d%ps.A == 0 && d/ps.A >= 0
+}
+
+// Match returns true if the node matches the pseudo-class, for the current
+// state of the node for state pseudo-classes -- nb is the NodeBase of the
+// node if it has one
+func (ps *SelectorPseudo) Match(k ki.Ki, nb *NodeBase) bool {
+	switch ps.Name {
+	case "first-child", "last-child", "only-child", "nth-child", "nth-last-child",
+		"first-of-type", "last-of-type", "only-of-type", "nth-of-type", "nth-last-of-type":
+		pos, n := siblingPos(k, strings.HasSuffix(ps.Name, "of-type"))
+		switch {
+		case strings.HasPrefix(ps.Name, "first"):
+			return pos == 1
+		case strings.HasPrefix(ps.Name, "last"):
+			return pos == n
+		case strings.HasPrefix(ps.Name, "only"):
+			return n == 1
+		case strings.HasPrefix(ps.Name, "nth-last"):
+			return ps.nthMatch(n - pos + 1)
+		default:
+			return ps.nthMatch(pos)
+		}
+	case "root":
+		return k.Parent() == nil
+	case "empty":
+		return !k.HasChildren()
+	case "not":
+		return ps.Not != nil && !ps.Not.Match(k)
+	}
+	if nb == nil {
+		return false
+	}
+	switch ps.Name {
+	case "active", "enabled":
+		return !nb.IsInactive()
+	case "inactive", "disabled":
+		return nb.IsInactive()
+	case "hover":
+		return nb.HasFlag(int(MouseHasEntered))
+	case "focus":
+		return nb.HasFocus()
+	case "focus-within":
+		if n2, ok := k.(Node2D); ok {
+			return n2.AsNode2D().ContainsFocus()
+		}
+		return nb.HasFocus()
+	case "selected":
+		return nb.IsSelected()
+	case "checked":
+		if cb, ok := k.Embed(KiT_ButtonBase).(*ButtonBase); ok {
+			return cb.IsChecked()
+		}
+	}
+	return false
+}
+
+// Specificity returns the CSS specificity of the part: the number of names
+// (ids), classes, attributes and pseudo-classes, and types, weighted so
+// that higher categories always win
+func (sp *SelectorPart) Specificity() int {
+	spec := 0
+	if sp.Name != "" {
+		spec += 10000
+	}
+	spec += 100 * (len(sp.Classes) + len(sp.Attrs))
+	for i := range sp.Pseudos {
+		if ps := &sp.Pseudos[i]; ps.Not != nil {
+			spec += ps.Not.Specificity()
+		} else {
+			spec += 100
+		}
+	}
+	if sp.Type != "" && sp.Type != "*" {
+		spec++
+	}
+	return spec
+}
+
+// Selector is a CSS-style selector for finding and styling nodes in the
+// scene graph, e.g., "frame#main > button.primary:hover" -- supports type,
+// .class, #name, [attribute] and :pseudo-class elements, and the
+// descendant (space), child (>), next sibling (+) and later sibling (~)
+// combinators.  See MatchCSS for its use in styling.
+type Selector struct {
+	Parts []SelectorPart
+}
+
+// selParser is the state for parsing a selector
+type selParser struct {
+	rs  []rune
+	i   int
+	sel string
+}
+
+func (p *selParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("gi.ParseSelector: "+format+" in selector: %v", append(args, p.sel)...)
+}
+
+func (p *selParser) skipSpace() {
+	for p.i < len(p.rs) && unicode.IsSpace(p.rs[p.i]) {
+		p.i++
+	}
+}
+
+// ident parses an identifier at the current position
+func (p *selParser) ident() string {
+	st := p.i
+	for p.i < len(p.rs) && isSelectorIdentRune(p.rs[p.i]) {
+		p.i++
+	}
+	return string(p.rs[st:p.i])
+}
+
+// ParseSelector parses given selector string into a Selector
+func ParseSelector(sel string) (*Selector, error) {
+	p := &selParser{rs: []rune(strings.TrimSpace(sel)), sel: sel}
+	if len(p.rs) == 0 {
+		return nil, fmt.Errorf("gi.ParseSelector: empty selector")
+	}
+	sl := &Selector{}
+	comb := rune(0)
+	for p.i < len(p.rs) {
+		c := p.rs[p.i]
+		switch {
+		case unicode.IsSpace(c):
+			p.skipSpace()
+			if comb == 0 && len(sl.Parts) > 0 {
+				comb = ' '
+			}
+			continue
+		case c == '>' || c == '+' || c == '~':
+			if len(sl.Parts) == 0 || (comb != 0 && comb != ' ') {
+				return nil, p.errorf("misplaced %q", c)
+			}
+			comb = c
+			p.i++
+			continue
+		}
+		sp, err := p.part()
+		if err != nil {
+			return nil, err
+		}
+		if len(sl.Parts) > 0 {
+			sp.Comb = comb
+		}
+		comb = 0
+		sl.Parts = append(sl.Parts, sp)
+	}
+	if comb != 0 && comb != ' ' {
+		return nil, p.errorf("ends with %q", comb)
+	}
+	return sl, nil
+}
+
+// part parses one compound selector part, up to a space or combinator
+func (p *selParser) part() (SelectorPart, error) {
+	var sp SelectorPart
+	for p.i < len(p.rs) {
+		c := p.rs[p.i]
+		if unicode.IsSpace(c) || c == '>' || c == '+' || c == '~' || c == ')' {
+			break
+		}
+		switch c {
+		case '*':
+			if sp.Type != "" {
+				return sp, p.errorf("multiple types")
+			}
+			sp.Type = "*"
+			p.i++
+			continue
+		case '[':
+			p.i++
+			sa, err := p.attr()
+			if err != nil {
+				return sp, err
+			}
+			sp.Attrs = append(sp.Attrs, sa)
+			continue
+		case ':':
+			p.i++
+			if p.i < len(p.rs) && p.rs[p.i] == ':' {
+				return sp, p.errorf("pseudo-elements are not supported")
+			}
+			ps, err := p.pseudo()
+			if err != nil {
+				return sp, err
+			}
+			sp.Pseudos = append(sp.Pseudos, ps)
+			continue
+		case '.', '#':
+			p.i++
+		}
+		id := p.ident()
+		if id == "" {
+			if p.i < len(p.rs) {
+				return sp, p.errorf("invalid character: %q", p.rs[p.i])
+			}
+			return sp, p.errorf("missing name after %q", c)
+		}
+		switch c {
+		case '.':
+			sp.Classes = append(sp.Classes, id)
+		case '#':
+			sp.Name = id
+		default:
+			if sp.Type != "" {
+				return sp, p.errorf("multiple types")
+			}
+			sp.Type = id
+		}
+	}
+	return sp, nil
+}
+
+// attr parses an attribute element, after the [
+func (p *selParser) attr() (SelectorAttr, error) {
+	var sa SelectorAttr
+	p.skipSpace()
+	sa.Name = strings.ToLower(p.ident())
+	if sa.Name == "" {
+		return sa, p.errorf("missing attribute name")
+	}
+	p.skipSpace()
+	if p.i < len(p.rs) && p.rs[p.i] != ']' {
+		st := p.i
+		if strings.ContainsRune("~|^$*", p.rs[p.i]) {
+			p.i++
+		}
+		if p.i >= len(p.rs) || p.rs[p.i] != '=' {
+			return sa, p.errorf("invalid attribute operator")
+		}
+		p.i++
+		sa.Op = string(p.rs[st:p.i])
+		p.skipSpace()
+		if p.i < len(p.rs) && (p.rs[p.i] == '"' || p.rs[p.i] == '\'') {
+			q := p.rs[p.i]
+			p.i++
+			st := p.i
+			for p.i < len(p.rs) && p.rs[p.i] != q {
+				p.i++
+			}
+			if p.i >= len(p.rs) {
+				return sa, p.errorf("unterminated string")
+			}
+			sa.Val = string(p.rs[st:p.i])
+			p.i++
+		} else {
+			sa.Val = p.ident()
+		}
+		p.skipSpace()
+	}
+	if p.i >= len(p.rs) || p.rs[p.i] != ']' {
+		return sa, p.errorf("missing ]")
+	}
+	p.i++
+	return sa, nil
+}
+
+// pseudo parses a pseudo-class, after the :
+func (p *selParser) pseudo() (SelectorPseudo, error) {
+	var ps SelectorPseudo
+	ps.Name = strings.ToLower(p.ident())
+	if ps.Name == "" {
+		return ps, p.errorf("missing pseudo-class name")
+	}
+	if p.i >= len(p.rs) || p.rs[p.i] != '(' {
+		if strings.HasPrefix(ps.Name, "nth-") || ps.Name == "not" {
+			return ps, p.errorf("missing argument for :%v", ps.Name)
+		}
+		return ps, nil
+	}
+	p.i++
+	st := p.i
+	depth := 1
+	for p.i < len(p.rs) {
+		if p.rs[p.i] == '(' {
+			depth++
+		} else if p.rs[p.i] == ')' {
+			depth--
+			if depth == 0 {
+				break
+			}
+		}
+		p.i++
+	}
+	if p.i >= len(p.rs) {
+		return ps, p.errorf("missing )")
+	}
+	arg := strings.TrimSpace(string(p.rs[st:p.i]))
+	p.i++
+	switch {
+	case ps.Name == "not":
+		ns, err := ParseSelector(arg)
+		if err != nil {
+			return ps, err
+		}
+		ps.Not = ns
+	case strings.HasPrefix(ps.Name, "nth-"):
+		a, b, err := parseNth(arg)
+		if err != nil {
+			return ps, p.errorf("%v", err)
+		}
+		ps.A, ps.B = a, b
+	default:
+		return ps, p.errorf("unexpected argument for :%v", ps.Name)
+	}
+	return ps, nil
+}
+
+// parseNth parses the argument of :nth-child and related: odd, even, an+b,
+// or b
+func parseNth(arg string) (a, b int, err error) {
+	arg = strings.Replace(strings.ToLower(arg), " ", "", -1)
+	switch arg {
+	case "odd":
+		return 2, 1, nil
+	case "even":
+		return 2, 0, nil
+	}
+	ni := strings.IndexByte(arg, 'n')
+	if ni < 0 {
+		b, err = strconv.Atoi(arg)
+		return 0, b, err
+	}
+	switch as := arg[:ni]; as {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		if a, err = strconv.Atoi(as); err != nil {
+			return
+		}
+	}
+	if bs := arg[ni+1:]; bs != "" {
+		b, err = strconv.Atoi(bs)
+	}
+	return
+}
+
+// isSelectorIdentRune returns true if rune is valid in a type, class or name
+func isSelectorIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_'
+}
+
+// String returns the selector part in standard CSS form
+func (sp *SelectorPart) String() string {
+	var sb strings.Builder
+	sb.WriteString(sp.Type)
+	for _, cl := range sp.Classes {
+		sb.WriteString("." + cl)
+	}
+	if sp.Name != "" {
+		sb.WriteString("#" + sp.Name)
+	}
+	for _, sa := range sp.Attrs {
+		sb.WriteString("[" + sa.Name)
+		if sa.Op != "" {
+			sb.WriteString(sa.Op + strconv.Quote(sa.Val))
+		}
+		sb.WriteString("]")
+	}
+	for _, ps := range sp.Pseudos {
+		sb.WriteString(":" + ps.Name)
+		switch {
+		case ps.Not != nil:
+			sb.WriteString("(" + ps.Not.String() + ")")
+		case strings.HasPrefix(ps.Name, "nth-"):
+			sb.WriteString(fmt.Sprintf("(%dn%+d)", ps.A, ps.B))
+		}
+	}
+	if sb.Len() == 0 {
+		sb.WriteString("*")
+	}
+	return sb.String()
+}
+
+// String returns the selector in standard CSS form
+func (sl *Selector) String() string {
+	var sb strings.Builder
+	for i := range sl.Parts {
+		sp := &sl.Parts[i]
+		if i > 0 {
+			if sp.Comb == ' ' {
+				sb.WriteString(" ")
+			} else {
+				sb.WriteString(" " + string(sp.Comb) + " ")
+			}
+		}
+		sb.WriteString(sp.String())
+	}
+	return sb.String()
+}
+
+// Specificity returns the CSS specificity of the selector, which determines
+// the order in which matching styles are applied -- see
+// SelectorPart.Specificity
+func (sl *Selector) Specificity() int {
+	spec := 0
+	for i := range sl.Parts {
+		spec += sl.Parts[i].Specificity()
+	}
+	return spec
+}
+
+// Match returns true if the given node matches the selector, for the
+// current state of the node
+func (sl *Selector) Match(k ki.Ki) bool {
+	if len(sl.Parts) == 0 {
+		return false
+	}
+	return sl.matchPart(k, len(sl.Parts)-1)
+}
+
+// MatchState returns true if the given node matches the selector for
+// styling its given state (e.g., :hover, or "" for the base style) --
+// StatePseudos in the last part of the selector must all select that
+// state, while elsewhere they are matched against the current state of the
+// nodes.
+func (sl *Selector) MatchState(k ki.Ki, state string) bool {
+	if len(sl.Parts) == 0 {
+		return false
+	}
+	if !sl.Parts[len(sl.Parts)-1].matchState(k, state, true) {
+		return false
+	}
+	return sl.matchPrior(k, len(sl.Parts)-1)
+}
+
+// matchPart matches node against part i and all prior parts against its
+// parents and siblings, according to the combinators
+func (sl *Selector) matchPart(k ki.Ki, i int) bool {
+	if !sl.Parts[i].Match(k) {
+		return false
+	}
+	return sl.matchPrior(k, i)
+}
+
+// matchPrior matches the parts prior to part i, which node k matches
+func (sl *Selector) matchPrior(k ki.Ki, i int) bool {
+	if i == 0 {
+		return true
+	}
+	switch sl.Parts[i].Comb {
+	case '>':
+		par := k.Parent()
+		return par != nil && sl.matchPart(par, i-1)
+	case '+', '~':
+		par := k.Parent()
+		if par == nil {
+			return false
+		}
+		idx, ok := k.IndexInParent()
+		if !ok {
+			return false
+		}
+		kids := *par.Children()
+		for si := idx - 1; si >= 0; si-- {
+			if sl.matchPart(kids[si], i-1) {
+				return true
+			}
+			if sl.Parts[i].Comb == '+' {
+				break
+			}
+		}
+		return false
+	}
+	for par := k.Parent(); par != nil; par = par.Parent() {
+		if sl.matchPart(par, i-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectAll returns all the nodes under root (including root) that match
+// the given selector, in depth-first order
+func SelectAll(root ki.Ki, sel string) ([]ki.Ki, error) {
+	sl, err := ParseSelector(sel)
+	if err != nil {
+		return nil, err
+	}
+	var sels []ki.Ki
+	root.FuncDownMeFirst(0, nil, func(k ki.Ki, level int, d interface{}) bool {
+		if sl.Match(k) {
+			sels = append(sels, k)
+		}
+		return true
+	})
+	return sels, nil
+}
+
+// SelectFirst returns the first node under root (including root) that
+// matches the given selector, in depth-first order -- nil if none
+func SelectFirst(root ki.Ki, sel string) (ki.Ki, error) {
+	sl, err := ParseSelector(sel)
+	if err != nil {
+		return nil, err
+	}
+	var fk ki.Ki
+	root.FuncDownMeFirst(0, nil, func(k ki.Ki, level int, d interface{}) bool {
+		if fk != nil {
+			return false
+		}
+		if sl.Match(k) {
+			fk = k
+			return false
+		}
+		return true
+	})
+	return fk, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  CSS matching
+
+// cssSelectors caches the parsed selectors for CSS keys -- a key can be a
+// comma-separated list of selectors -- keys that are not valid selectors
+// have nil
+var cssSelectors sync.Map
+
+// CSSSelectors returns the parsed selectors for a CSS key, which can be a
+// comma-separated list of selectors -- nil if it is not a valid selector
+func CSSSelectors(key string) []*Selector {
+	if sls, ok := cssSelectors.Load(key); ok {
+		return sls.([]*Selector)
+	}
+	var sls []*Selector
+	for _, s := range splitCSSList(key, ',') {
+		sl, err := ParseSelector(s)
+		if err != nil {
+			sls = nil
+			break
+		}
+		sls = append(sls, sl)
+	}
+	cssSelectors.Store(key, sls)
+	return sls
+}
+
+// cssMatch is a CSS rule that matches a node
+type cssMatch struct {
+	props ki.Props
+	spec  int
+	order int
+	media bool
+	key   string
+}
+
+// MatchCSS returns the properties of all the rules in css (keyed by
+// selector, as in the CSS field of nodes) that match given node, for
+// styling its given state (e.g., :hover, or "" for the base style), in the
+// order they should be applied: increasing specificity, then the source
+// order of the declarations in their style sheet (see CSSOrderKey), with
+// the properties of a selector split by their order as needed.  Rules
+// without a source order (e.g., written directly as ki.Props) come first,
+// with those in @media blocks (see MediaQueries) after others, and then in
+// selector order, as a map has no order of its own.  The properties of
+// rules for the base style can also contain properties for states, keyed
+// by the state selector, e.g., "button": ki.Props{":hover": ki.Props{..}},
+// which apply to that state.
+func MatchCSS(k ki.Ki, css ki.Props, state string) []ki.Props {
+	if len(css) == 0 {
+		return nil
+	}
+	var ms []cssMatch
+	matchCSSRules(k, css, state, false, &ms)
+	if len(ms) == 0 {
+		return nil
+	}
+	sort.SliceStable(ms, func(i, j int) bool {
+		if ms[i].spec != ms[j].spec {
+			return ms[i].spec < ms[j].spec
+		}
+		if ms[i].order != ms[j].order {
+			return ms[i].order < ms[j].order
+		}
+		if ms[i].media != ms[j].media {
+			return !ms[i].media
+		}
+		return ms[i].key < ms[j].key
+	})
+	pms := make([]ki.Props, len(ms))
+	for i := range ms {
+		pms[i] = ms[i].props
+	}
+	return pms
+}
+
+// matchCSSRules adds the rules in css that match the node to ms, including
+// those in any @media blocks whose queries match the window of the node
+func matchCSSRules(k ki.Ki, css ki.Props, state string, media bool, ms *[]cssMatch) {
+	for key, pv := range css {
+		pmap, ok := pv.(ki.Props)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(key, "@media") {
+			mqs, err := ParseMediaQueries(strings.TrimPrefix(key, "@media"))
+			if err != nil {
+				continue
+			}
+			var vp *Viewport2D
+			if n2, ok := k.(Node2D); ok {
+				vp = n2.AsNode2D().Viewport
+			}
+			if mqs.Match(MediaViewport(vp)) {
+				matchCSSRules(k, pmap, state, true, ms)
+			}
+			continue
+		}
+		best := cssMatch{spec: -1, order: -1, media: media, key: key}
+		for _, sl := range CSSSelectors(key) { // the most specific matching selector in a list applies
+			spec := sl.Specificity()
+			switch {
+			case sl.MatchState(k, state):
+				if spec > best.spec {
+					best.props, best.spec = pmap, spec
+				}
+			case state != "" && sl.MatchState(k, ""):
+				if sp, ok := ki.SubProps(pmap, state); ok && spec+100 > best.spec {
+					best.props, best.spec = sp, spec+100
+				}
+			}
+		}
+		if best.spec < 0 {
+			continue
+		}
+		ords, ok := best.props[CSSOrderKey].(map[string]int)
+		if !ok {
+			*ms = append(*ms, best)
+			continue
+		}
+		for ord, pm := range splitCSSOrders(best.props, ords) {
+			m := best
+			m.props, m.order = pm, ord
+			*ms = append(*ms, m)
+		}
+	}
+}
+
+// splitCSSOrders returns the properties of a selector split by the source
+// order of their declarations -- the props as-is if they all have the same
+// order
+func splitCSSOrders(props ki.Props, ords map[string]int) map[int]ki.Props {
+	first := -1
+	same := true
+	for _, ord := range ords {
+		if first < 0 {
+			first = ord
+		} else if ord != first {
+			same = false
+			break
+		}
+	}
+	if same {
+		return map[int]ki.Props{first: props}
+	}
+	sp := make(map[int]ki.Props)
+	for key, val := range props {
+		ord, ok := ords[key]
+		if !ok {
+			continue
+		}
+		pm, ok := sp[ord]
+		if !ok {
+			pm = ki.Props{}
+			sp[ord] = pm
+		}
+		pm[key] = val
+	}
+	return sp
+}
@@ -0,0 +1,336 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"log"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/chewxy/math32"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+)
+
+// shadow.go implements CSS box-shadow rendering:
+// https://www.w3.org/TR/css-backgrounds-3/#box-shadow -- each shadow is
+// rendered from an alpha mask of the blurred shadow shape, which is cached
+// by the geometry of the shadow, so re-rendering a shadow costs only the
+// final compositing of the mask.
+
+// ShadowStyle is one box shadow -- Style.BoxShadow is the first (top)
+// shadow, with any additional ones from a box-shadow list in Style.Shadows
+type ShadowStyle struct {
+	HOffset units.Value `xml:".h-offset" desc:"prop: .h-offset = horizontal offset of shadow -- positive = right side, negative = left side"`
+	VOffset units.Value `xml:".v-offset" desc:"prop: .v-offset = vertical offset of shadow -- positive = below, negative = above"`
+	Blur    units.Value `xml:".blur" desc:"prop: .blur = blur radius -- higher numbers = more blurry"`
+	Spread  units.Value `xml:".spread" desc:"prop: .spread = spread radius -- positive number increases size of shadow, negative decreases size"`
+	Color   Color       `xml:".color" desc:"prop: .color = color of the shadow"`
+	Inset   bool        `xml:".inset" desc:"prop: .inset = shadow is inset within box instead of outset outside of box"`
+}
+
+// HasShadow returns true if the shadow is visible
+func (s *ShadowStyle) HasShadow() bool {
+	if s.Color.A == 0 {
+		return false
+	}
+	return s.HOffset.Dots != 0 || s.VOffset.Dots != 0 || s.Blur.Dots > 0 || s.Spread.Dots != 0
+}
+
+// ToDots runs ToDots on the unit values
+func (s *ShadowStyle) ToDots(uc *units.Context) {
+	s.HOffset.ToDots(uc)
+	s.VOffset.ToDots(uc)
+	s.Blur.ToDots(uc)
+	s.Spread.ToDots(uc)
+}
+
+// ParseBoxShadows parses a CSS box-shadow property value: a comma-separated
+// list of: [inset] h-offset v-offset [blur [spread]] [color] -- the color
+// defaults to currentcolor.  Returns nil for none.
+func ParseBoxShadows(str string, vp *Viewport2D) ([]ShadowStyle, error) {
+	str = strings.TrimSpace(str)
+	if str == "" || str == "none" {
+		return nil, nil
+	}
+	var shs []ShadowStyle
+	for _, it := range splitCSSList(str, ',') {
+		var sh ShadowStyle
+		var lens []units.Value
+		clr := ""
+		for _, tok := range splitCSSList(it, ' ') {
+			switch {
+			case tok == "inset":
+				sh.Inset = true
+			case isCSSLength(tok) || tok[0] == '-' || tok[0] == '+':
+				lens = append(lens, units.StringToValue(tok))
+			default:
+				clr = tok
+			}
+		}
+		if len(lens) < 2 || len(lens) > 4 {
+			return nil, fmt.Errorf("gi.ParseBoxShadows: shadow must have 2 to 4 lengths: %v", it)
+		}
+		sh.HOffset = lens[0]
+		sh.VOffset = lens[1]
+		if len(lens) > 2 {
+			sh.Blur = lens[2]
+		}
+		if len(lens) > 3 {
+			sh.Spread = lens[3]
+		}
+		if clr == "" {
+			clr = "currentcolor"
+		}
+		if err := sh.Color.SetStringStyle(clr, nil, vp); err != nil {
+			sh.Color.SetUInt8(0, 0, 0, 255)
+		}
+		shs = append(shs, sh)
+	}
+	return shs, nil
+}
+
+// SetBoxShadowProp sets BoxShadow and Shadows from a box-shadow property
+// value string in props, if present
+func (s *Style) SetBoxShadowProp(props ki.Props, vp *Viewport2D) {
+	pv, ok := props["box-shadow"]
+	if !ok {
+		return
+	}
+	str, ok := pv.(string)
+	if !ok {
+		return
+	}
+	shs, err := ParseBoxShadows(str, vp)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	s.Shadows = nil
+	if len(shs) == 0 {
+		s.BoxShadow = ShadowStyle{}
+		return
+	}
+	s.BoxShadow = shs[0]
+	if len(shs) > 1 {
+		s.Shadows = shs[1:]
+	}
+}
+
+// HasBoxShadow returns true if the style has any visible box shadows
+func (s *Style) HasBoxShadow() bool {
+	if s.BoxShadow.HasShadow() {
+		return true
+	}
+	for i := range s.Shadows {
+		if s.Shadows[i].HasShadow() {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderBoxShadows renders the box shadows in given style for a box at
+// given position and size, with given corner radius -- outset shadows
+// (inset = false) are rendered outside the box, before its background, and
+// inset shadows inside the box (which should exclude the border), after its
+// background.  The first shadow is on top, as in CSS.
+func (wb *WidgetBase) RenderBoxShadows(st *Style, pos, sz Vec2D, rad float32, inset bool) {
+	rs := &wb.Viewport.Render
+	for i := len(st.Shadows) - 1; i >= -1; i-- {
+		sh := &st.BoxShadow
+		if i >= 0 {
+			sh = &st.Shadows[i]
+		}
+		if sh.Inset != inset || !sh.HasShadow() {
+			continue
+		}
+		RenderBoxShadow(rs, sh, pos, sz, rad)
+	}
+}
+
+// RenderBoxShadow renders one box shadow for a box at given position and
+// size, with given corner radius, using a cached blurred mask
+func RenderBoxShadow(rs *RenderState, sh *ShadowStyle, pos, sz Vec2D, rad float32) {
+	key := shadowKey{
+		w: int(sz.X + 0.5), h: int(sz.Y + 0.5), rad: rad,
+		hoff: sh.HOffset.Dots, voff: sh.VOffset.Dots,
+		blur: sh.Blur.Dots, spread: sh.Spread.Dots, inset: sh.Inset,
+	}
+	if key.w <= 0 || key.h <= 0 {
+		return
+	}
+	mask := shadowMask(key)
+	org := pos.ToPointFloor()
+	r := mask.Rect.Add(org).Intersect(rs.Bounds)
+	if r.Empty() {
+		return
+	}
+	draw.DrawMask(rs.Image, r, &image.Uniform{sh.Color}, image.ZP, mask, r.Min.Sub(org), draw.Over)
+	if rs.Recorder != nil {
+		img := image.NewRGBA(r)
+		draw.DrawMask(img, r, &image.Uniform{sh.Color}, image.ZP, mask, r.Min.Sub(org), draw.Src)
+		rs.RecordImage(img, Identity2D())
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//   Shadow masks
+
+// shadowKey is the geometry of a shadow mask, relative to the box origin
+type shadowKey struct {
+	w, h                          int
+	rad, hoff, voff, blur, spread float32
+	inset                         bool
+}
+
+// ShadowCacheMax is the maximum number of shadow masks to keep in the cache
+var ShadowCacheMax = 256
+
+var (
+	shadowCache   = map[shadowKey]*image.Alpha{}
+	shadowCacheMu sync.Mutex
+)
+
+// shadowMask returns the cached mask for the given shadow, creating it if
+// needed -- the mask bounds are relative to the box origin
+func shadowMask(key shadowKey) *image.Alpha {
+	shadowCacheMu.Lock()
+	mask, ok := shadowCache[key]
+	shadowCacheMu.Unlock()
+	if ok {
+		return mask
+	}
+	mask = newShadowMask(key)
+	shadowCacheMu.Lock()
+	if len(shadowCache) >= ShadowCacheMax {
+		shadowCache = map[shadowKey]*image.Alpha{}
+	}
+	shadowCache[key] = mask
+	shadowCacheMu.Unlock()
+	return mask
+}
+
+// newShadowMask renders the mask for a shadow: the shadow shape (the box
+// offset, grown by the spread for outset or shrunk for inset) is blurred with
+// a gaussian of standard deviation half the blur radius, and then clipped to
+// the outside (outset) or inside (inset) of the box
+func newShadowMask(key shadowKey) *image.Alpha {
+	w, h := float32(key.w), float32(key.h)
+	sigma := key.blur / 2
+	ext := int(math.Ceil(float64(3*sigma))) + 1
+	sp := key.spread
+	if key.inset {
+		sp = -sp
+	}
+	x0, y0 := key.hoff-sp, key.voff-sp
+	sw, sh := w+2*sp, h+2*sp
+	srad := key.rad
+	if srad > 0 {
+		srad = Max32(srad+sp, 0)
+	}
+	// the shape is rendered with the margin needed for the blur, which is
+	// also the extent of the mask for outset shadows
+	r := image.Rect(0, 0, key.w, key.h)
+	var br image.Rectangle
+	if key.inset {
+		br = r.Inset(-ext)
+	} else {
+		sw, sh = Max32(sw, 0), Max32(sh, 0)
+		br = image.Rect(int(math.Floor(float64(x0))), int(math.Floor(float64(y0))), int(math.Ceil(float64(x0+sw))), int(math.Ceil(float64(y0+sh)))).Inset(-ext)
+		r = br
+	}
+	bw, bh := br.Dx(), br.Dy()
+	buf := make([]float32, bw*bh)
+	for y := 0; y < bh; y++ {
+		py := float32(br.Min.Y+y) + 0.5
+		for x := 0; x < bw; x++ {
+			px := float32(br.Min.X+x) + 0.5
+			buf[y*bw+x] = coverage(roundRectDist(px, py, x0, y0, sw, sh, srad))
+		}
+	}
+	if sigma > 0 {
+		gaussBlur(buf, bw, bh, sigma)
+	}
+	mask := image.NewAlpha(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		py := float32(y) + 0.5
+		for x := r.Min.X; x < r.Max.X; x++ {
+			px := float32(x) + 0.5
+			a := buf[(y-br.Min.Y)*bw+(x-br.Min.X)]
+			box := coverage(roundRectDist(px, py, 0, 0, w, h, key.rad))
+			if key.inset {
+				a = box * (1 - a)
+			} else {
+				a = a * (1 - box)
+			}
+			mask.Pix[mask.PixOffset(x, y)] = uint8(InRange32(a*255+0.5, 0, 255))
+		}
+	}
+	return mask
+}
+
+// coverage returns the approximate pixel coverage for a signed distance
+// from the edge of a shape (negative inside)
+func coverage(d float32) float32 {
+	return InRange32(0.5-d, 0, 1)
+}
+
+// roundRectDist returns the signed distance from point px, py to the edge
+// of the rounded rectangle at x, y of size w, h, with corner radius rad --
+// negative inside
+func roundRectDist(px, py, x, y, w, h, rad float32) float32 {
+	hx, hy := 0.5*w, 0.5*h
+	rad = Min32(rad, Min32(hx, hy))
+	qx := math32.Abs(px-(x+hx)) - (hx - rad)
+	qy := math32.Abs(py-(y+hy)) - (hy - rad)
+	ox, oy := Max32(qx, 0), Max32(qy, 0)
+	out := float32(math.Sqrt(float64(ox*ox + oy*oy)))
+	return out + Min32(Max32(qx, qy), 0) - rad
+}
+
+// gaussBlur applies a separable gaussian blur with given standard deviation
+// to the w x h buffer, treating values outside as 0
+func gaussBlur(buf []float32, w, h int, sigma float32) {
+	kr := int(math.Ceil(float64(3 * sigma)))
+	kern := make([]float32, 2*kr+1)
+	var sum float32
+	for i := range kern {
+		d := float64(i - kr)
+		kern[i] = float32(math.Exp(-d * d / float64(2*sigma*sigma)))
+		sum += kern[i]
+	}
+	for i := range kern {
+		kern[i] /= sum
+	}
+	tmp := make([]float32, len(buf))
+	for y := 0; y < h; y++ {
+		row := buf[y*w : (y+1)*w]
+		for x := 0; x < w; x++ {
+			var v float32
+			for k, kv := range kern {
+				if sx := x + k - kr; sx >= 0 && sx < w {
+					v += kv * row[sx]
+				}
+			}
+			tmp[y*w+x] = v
+		}
+	}
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			var v float32
+			for k, kv := range kern {
+				if sy := y + k - kr; sy >= 0 && sy < h {
+					v += kv * tmp[sy*w+x]
+				}
+			}
+			buf[y*w+x] = v
+		}
+	}
+}
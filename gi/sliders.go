@@ -565,6 +565,7 @@ func (sr *Slider) StyleSlider() {
 	for i := 0; i < int(SliderStatesN); i++ {
 		sr.StateStyles[i].CopyFrom(&sr.Sty)
 		sr.StateStyles[i].SetStyleProps(pst, sr.StyleProps(SliderSelectors[i]), sr.Viewport)
+		sr.StyleCSS(&sr.StateStyles[i], SliderSelectors[i])
 		sr.StateStyles[i].CopyUnitContext(&sr.Sty.UnContext)
 	}
 	SliderFields.Style(sr, nil, sr.Props, sr.Viewport)
@@ -763,6 +764,7 @@ func (sb *ScrollBar) StyleScrollBar() {
 	for i := 0; i < int(SliderStatesN); i++ {
 		sb.StateStyles[i].CopyFrom(&sb.Sty)
 		sb.StateStyles[i].SetStyleProps(pst, sb.StyleProps(SliderSelectors[i]), sb.Viewport)
+		sb.StyleCSS(&sb.StateStyles[i], SliderSelectors[i])
 		sb.StateStyles[i].CopyUnitContext(&sb.Sty.UnContext)
 	}
 	SliderFields.Style(sb, nil, sb.Props, sb.Viewport)
@@ -494,6 +494,7 @@ func (sr *Splitter) Style2D() {
 	for i := 0; i < int(SliderStatesN); i++ {
 		sr.StateStyles[i].CopyFrom(&sr.Sty)
 		sr.StateStyles[i].SetStyleProps(pst, sr.StyleProps(SliderSelectors[i]), sr.Viewport)
+		sr.StyleCSS(&sr.StateStyles[i], SliderSelectors[i])
 		sr.StateStyles[i].CopyUnitContext(&sr.Sty.UnContext)
 	}
 	SliderFields.Style(sr, nil, sr.Props, sr.Viewport)
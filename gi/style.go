@@ -30,25 +30,29 @@ import (
 
 // Style has all the CSS-based style elements -- used for widget-type objects
 type Style struct {
-	Display       bool          `xml:"display" desc:"todo big enum of how to display item -- controls layout etc"`
-	Visible       bool          `xml:"visible" desc:"todo big enum of how to display item -- controls layout etc"`
-	Inactive      bool          `xml:"inactive" desc:"make a control inactive so it does not respond to input"`
-	Layout        LayoutStyle   `desc:"layout styles -- do not prefix with any xml"`
-	Border        BorderStyle   `xml:"border" desc:"border around the box element -- todo: can have separate ones for different sides"`
-	BoxShadow     ShadowStyle   `xml:"box-shadow" desc:"prop: box-shadow = type of shadow to render around box"`
-	Font          FontStyle     `desc:"font parameters -- no xml prefix -- also has color, background-color"`
-	Text          TextStyle     `desc:"text parameters -- no xml prefix"`
-	Outline       BorderStyle   `xml:"outline" desc:"prop: outline = draw an outline around an element -- mostly same styles as border -- default to none"`
-	PointerEvents bool          `xml:"pointer-events" desc:"prop: pointer-events = does this element respond to pointer events -- default is true"`
-	UnContext     units.Context `xml:"-" desc:"units context -- parameters necessary for anchoring relative units"`
-	IsSet         bool          `desc:"has this style been set from object values yet?"`
-	PropsNil      bool          `desc:"set to true if parent node has no props -- allows optimization of styling"`
+	Display       bool             `xml:"display" desc:"todo big enum of how to display item -- controls layout etc"`
+	Visible       bool             `xml:"visible" desc:"todo big enum of how to display item -- controls layout etc"`
+	Inactive      bool             `xml:"inactive" desc:"make a control inactive so it does not respond to input"`
+	Layout        LayoutStyle      `desc:"layout styles -- do not prefix with any xml"`
+	Border        BorderStyle      `xml:"border" desc:"border around the box element -- the values for all sides, from the shorthand properties -- see BorderSides for the values used for each side"`
+	BorderSides   BorderSidesStyle `desc:"no xml prefix -- border values for each side and corner of the box, set from the per-side properties (e.g., border-top-width, border-top-left-radius) and the shorthand ones (e.g., border, border-width, which can have 1-4 values)"`
+	Background    BackgroundStyle  `xml:"background" desc:"background image, rendered over the background-color"`
+	BoxShadow     ShadowStyle      `xml:"box-shadow" desc:"prop: box-shadow = type of shadow to render around box -- the first shadow if a list is given in the box-shadow property"`
+	Shadows       []ShadowStyle    `xml:"-" desc:"any additional shadows after BoxShadow, from a list of shadows in the box-shadow property -- rendered in reverse order, below BoxShadow"`
+	Font          FontStyle        `desc:"font parameters -- no xml prefix -- also has color, background-color"`
+	Text          TextStyle        `desc:"text parameters -- no xml prefix"`
+	Outline       BorderStyle      `xml:"outline" desc:"prop: outline = draw an outline around an element -- mostly same styles as border -- default to none"`
+	PointerEvents bool             `xml:"pointer-events" desc:"prop: pointer-events = does this element respond to pointer events -- default is true"`
+	Transition    string           `xml:"transition" desc:"prop: transition = how changes to this style from another state (e.g., hover, focus) are animated: comma-separated list of: property duration [timing-function] [delay] -- see StyleAnimator"`
+	Animation     string           `xml:"animation" desc:"prop: animation = keyframe animation to run while in this style: name duration [timing-function] [delay] [iteration-count|infinite] [direction] -- keyframes are registered with AddKeyFrames or @keyframes in a StyleSheet"`
+	Vars          CSSVars          `xml:"-" desc:"CSS custom properties (--name: value) set on this style or inherited from the parent style, for var() references in property values -- see CSSVars"`
+	UnContext     units.Context    `xml:"-" desc:"units context -- parameters necessary for anchoring relative units"`
+	IsSet         bool             `desc:"has this style been set from object values yet?"`
+	PropsNil      bool             `desc:"set to true if parent node has no props -- allows optimization of styling"`
 	dotsSet       bool
 	lastUnCtxt    units.Context
 }
 
-// todo: Animation
-
 // Clear -- no floating elements
 
 // Clip -- clip images
@@ -58,6 +62,7 @@ type Style struct {
 // LayoutStyle is in layout.go
 // FontStyle is in font.go
 // TextStyle is in text.go
+// Transition and Animation are implemented in animation.go
 
 // List-style for lists
 
@@ -65,8 +70,6 @@ type Style struct {
 
 // visibility -- support more than just hidden  inherit:"true"
 
-// transition -- animation of hover, etc
-
 // RebuildDefaultStyles is a global state var used by Prefs to trigger rebuild
 // of all the default styles, which are otherwise compiled and not updated
 var RebuildDefaultStyles bool
@@ -106,21 +109,7 @@ func SetStylePropsXML(style string, props *ki.Props) {
 // note: background-color is in FontStyle as it is needed to make that the
 // only style needed for text render styling
 
-// // BackgroundStyle has style parameters for backgrounds
-// type BackgroundStyle struct {
-// 	// todo: all the properties not yet implemented -- mostly about images
-// 	// Image is like a PaintServer -- includes gradients etc
-// 	// Attachment -- how the image moves
-// 	// Clip -- how to clip the image
-// 	// Origin
-// 	// Position
-// 	// Repeat
-// 	// Size
-// }
-
-// func (b *BackgroundStyle) Defaults() {
-// 	b.Color.SetColor(color.White)
-// }
+// BackgroundStyle is in background.go
 
 // BoxSides specifies sides of a box -- some properties can be specified per
 // each side (e.g., border) or not
@@ -173,19 +162,8 @@ type BorderStyle struct {
 	Color  Color           `xml:"color" desc:"prop: border-color = color of the border"`
 }
 
-// style parameters for shadows
-type ShadowStyle struct {
-	HOffset units.Value `xml:".h-offset" desc:"prop: .h-offset = horizontal offset of shadow -- positive = right side, negative = left side"`
-	VOffset units.Value `xml:".v-offset" desc:"prop: .v-offset = vertical offset of shadow -- positive = below, negative = above"`
-	Blur    units.Value `xml:".blur" desc:"prop: .blur = blur radius -- higher numbers = more blurry"`
-	Spread  units.Value `xml:".spread" desc:"prop: .spread = spread radius -- positive number increases size of shadow, negative decreases size"`
-	Color   Color       `xml:".color" desc:"prop: .color = color of the shadow"`
-	Inset   bool        `xml:".inset" desc:"prop: .inset = shadow is inset within box instead of outset outside of box"`
-}
-
-func (s *ShadowStyle) HasShadow() bool {
-	return (s.HOffset.Dots > 0 || s.VOffset.Dots > 0)
-}
+// ShadowStyle is in shadow.go
+// BorderSidesStyle is in border.go
 
 func (s *Style) Defaults() {
 	// mostly all the defaults are 0 initial values, except these..
@@ -212,6 +190,9 @@ func (s *Style) CopyFrom(cp *Style) {
 	lu := s.lastUnCtxt
 	*s = *cp
 	s.Font.BgColor = cp.Font.BgColor
+	if cp.Shadows != nil {
+		s.Shadows = append([]ShadowStyle(nil), cp.Shadows...)
+	}
 	s.IsSet = is
 	s.PropsNil = pn
 	s.dotsSet = ds
@@ -223,6 +204,7 @@ func (s *Style) CopyFrom(cp *Style) {
 func (s *Style) InheritFields(par *Style) {
 	s.Font.InheritFields(&par.Font)
 	s.Text.InheritFields(&par.Text)
+	s.Vars = par.Vars.Inherit(s.Vars)
 }
 
 // SetStyleProps sets style values based on given property map (name: value pairs),
@@ -232,6 +214,8 @@ func (s *Style) SetStyleProps(par *Style, props ki.Props, vp *Viewport2D) {
 		// StyleFields.Inherit(s, par) // very slow for some mysterious reason
 		s.InheritFields(par)
 	}
+	s.Vars = s.Vars.SetFmProps(props)
+	props = s.Vars.ResolveProps(props)
 	StyleFields.Style(s, par, props, vp)
 	s.Text.AlignV = s.Layout.AlignV
 	if s.Layout.Margin.Val > 0 && s.Text.ParaSpacing.Val == 0 {
@@ -240,6 +224,9 @@ func (s *Style) SetStyleProps(par *Style, props ki.Props, vp *Viewport2D) {
 	s.Layout.SetStylePost(props)
 	s.Font.SetStylePost(props)
 	s.Text.SetStylePost(props)
+	s.SetBorderPost(props, vp)
+	s.SetBackgroundPost(props)
+	s.SetBoxShadowProp(props, vp)
 	s.PropsNil = (len(props) == 0)
 	s.IsSet = true
 }
@@ -303,13 +290,17 @@ func (s *Style) CopyUnitContext(ctxt *units.Context) {
 // need to have set the UnContext first
 func (s *Style) ToDots() {
 	StyleFields.ToDots(s, &s.UnContext)
+	for i := range s.Shadows {
+		s.Shadows[i].ToDots(&s.UnContext)
+	}
 }
 
 // BoxSpace returns extra space around the central content in the box model,
-// in dots -- todo: must complicate this if we want different spacing on
-// different sides box outside-in: margin | border | padding | content
+// in dots, using the widest border side -- todo: must complicate this if we
+// want different spacing on different sides box outside-in: margin | border |
+// padding | content
 func (s *Style) BoxSpace() float32 {
-	return s.Layout.Margin.Dots + s.Border.Width.Dots + s.Layout.Padding.Dots
+	return s.Layout.Margin.Dots + Max32(s.Border.Width.Dots, s.BorderSides.MaxWidth()) + s.Layout.Padding.Dots
 }
 
 // ApplyCSS applies css styles for given node, using key to select sub-props
@@ -335,19 +326,19 @@ func (s *Style) ApplyCSS(node Node2D, css ki.Props, key, selector string, vp *Vi
 	return true
 }
 
-// StyleCSS applies css style properties to given Widget node, parsing out
-// type, .class, and #name selectors, along with optional sub-selector
-// (:hover, :active etc)
+// StyleCSS applies css style properties to given Widget node, for all the
+// rules whose selectors match the node (see MatchCSS), in order of
+// specificity, for the base style if selector is empty, or otherwise the
+// state style selected by the selector (:hover, :active etc)
 func (s *Style) StyleCSS(node Node2D, css ki.Props, selector string, vp *Viewport2D) {
-	tyn := strings.ToLower(node.Type().Name()) // type is most general, first
-	s.ApplyCSS(node, css, tyn, selector, vp)
-	classes := strings.Split(strings.ToLower(node.AsNode2D().Class), " ")
-	for _, cl := range classes {
-		cln := "." + strings.TrimSpace(cl)
-		s.ApplyCSS(node, css, cln, selector, vp)
-	}
-	idnm := "#" + strings.ToLower(node.Name()) // then name
-	s.ApplyCSS(node, css, idnm, selector, vp)
+	pms := MatchCSS(node, css, selector)
+	if len(pms) == 0 {
+		return
+	}
+	parSty := node.AsNode2D().ParentStyle()
+	for _, pmap := range pms {
+		s.SetStyleProps(parSty, pmap, vp)
+	}
 }
 
 // SubProps returns a sub-property map from given prop map for a given styling
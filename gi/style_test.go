@@ -6,9 +6,12 @@ package gi
 
 import (
 	"fmt"
+	"image"
 	// "reflect"
 	"testing"
+	"time"
 
+	"github.com/chewxy/math32"
 	"github.com/goki/gi/units"
 	"github.com/goki/ki"
 )
@@ -38,3 +41,361 @@ func TestStyle(t *testing.T) {
 	fmt.Printf("style box-shadow.v-offset: %v\n", s.BoxShadow.VOffset)
 	fmt.Printf("style border-style: %v\n", s.Border.Style)
 }
+
+func TestTransition(t *testing.T) {
+	trs, err := ParseTransitions("background-color .5s linear, border 200ms cubic-bezier(0, 0, 1, 1) 100ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trs) != 2 || trs[0].Prop != "background-color" || trs[0].Duration != 500*time.Millisecond || trs[1].Delay != 100*time.Millisecond {
+		t.Fatalf("transitions not parsed correctly: %+v\n", trs)
+	}
+	if pos, done := trs[1].Pos(200 * time.Millisecond); done || math32.Abs(pos-0.5) > 0.01 {
+		t.Errorf("border transition pos: %v done: %v, want 0.5 false\n", pos, done)
+	}
+
+	var from, to, s Style
+	from.Defaults()
+	to.Defaults()
+	from.Font.BgColor.SetColor(Color{0, 0, 200, 255})
+	to.Font.BgColor.SetColor(Color{200, 0, 0, 255})
+	from.Border.Width = units.NewValue(0, units.Px)
+	to.Border.Width = units.NewValue(4, units.Px)
+	from.Font.Opacity = 0
+	s = to
+	s.Interpolate(&from, &to, 0.5, "background-color")
+	if c := s.Font.BgColor.Color; c != (Color{100, 0, 100, 255}) {
+		t.Errorf("background-color at 0.5: %v\n", c)
+	}
+	if s.Border.Width.Val != 4 || s.Font.Opacity != 1 {
+		t.Errorf("other properties changed: border-width %v opacity %v\n", s.Border.Width, s.Font.Opacity)
+	}
+	s.Interpolate(&from, &to, 0.25, "all")
+	if s.Border.Width.Val != 1 || s.Font.Opacity != 0.25 {
+		t.Errorf("all at 0.25: border-width %v opacity %v\n", s.Border.Width, s.Font.Opacity)
+	}
+}
+
+func TestAnimation(t *testing.T) {
+	an, err := ParseAnimation("pulse 1s linear 2 alternate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if an.Name != "pulse" || an.Duration != time.Second || an.Iterations != 2 || an.Direction != AnimAlternate {
+		t.Fatalf("animation not parsed correctly: %+v\n", an)
+	}
+	if pos, started, done := an.Progress(1250 * time.Millisecond); !started || done || math32.Abs(pos-0.75) > 0.001 {
+		t.Errorf("2nd alternate cycle pos: %v started: %v done: %v\n", pos, started, done)
+	}
+	if _, _, done := an.Progress(2 * time.Second); !done {
+		t.Errorf("animation not done after 2 iterations\n")
+	}
+	AddKeyFrames("test-fade", ki.Props{"50%": ki.Props{"opacity": 0.5}})
+	kfs, ok := KeyFramesByName("test-fade")
+	if !ok || len(kfs) != 3 || kfs[0].Offset != 0 || kfs[1].Offset != 0.5 || kfs[2].Offset != 1 {
+		t.Errorf("keyframes with implicit from and to: %+v\n", kfs)
+	}
+}
+
+func TestBoxShadow(t *testing.T) {
+	props := ki.Props{"box-shadow": "2px 3px 4px 1px red, inset 0 0 5px rgba(0,0,255,128)"}
+	var s Style
+	s.Defaults()
+	s.SetStyleProps(nil, props, nil)
+	if len(s.Shadows) != 1 || s.BoxShadow.HOffset.Val != 2 || s.BoxShadow.Spread.Val != 1 || s.BoxShadow.Color != (Color{R: 255, A: 255}) {
+		t.Fatalf("box-shadow not parsed correctly: %+v %+v\n", s.BoxShadow, s.Shadows)
+	}
+	if ins := s.Shadows[0]; !ins.Inset || ins.Blur.Val != 5 || ins.Color.A != 128 {
+		t.Errorf("inset shadow not parsed correctly: %+v\n", ins)
+	}
+
+	key := shadowKey{w: 20, h: 10, blur: 4, voff: 2}
+	mask := shadowMask(key)
+	if mask2 := shadowMask(key); mask2 != mask {
+		t.Errorf("shadow mask not cached\n")
+	}
+	if a := mask.AlphaAt(10, 5).A; a != 0 {
+		t.Errorf("outset shadow not clipped to outside of box: %v\n", a)
+	}
+	if a0, a1 := mask.AlphaAt(10, 11).A, mask.AlphaAt(10, 15).A; a0 == 0 || a1 >= a0 {
+		t.Errorf("outset shadow not blurred below box: %v then %v\n", a0, a1)
+	}
+	inm := shadowMask(shadowKey{w: 20, h: 10, blur: 4, inset: true})
+	if inm.Bounds() != image.Rect(0, 0, 20, 10) || inm.AlphaAt(0, 5).A <= inm.AlphaAt(10, 5).A {
+		t.Errorf("inset shadow not darker at edge: %v %v\n", inm.AlphaAt(0, 5), inm.AlphaAt(10, 5))
+	}
+}
+
+func TestBorderSides(t *testing.T) {
+	props := ki.Props{
+		"border":                    "2px solid red",
+		"border-left":               "4px dashed",
+		"border-color":              "red blue",
+		"border-top-color":          "green",
+		"border-radius":             "1px 2px 3px",
+		"border-bottom-left-radius": "8px",
+	}
+	var s Style
+	s.Defaults()
+	s.SetStyleProps(nil, props, nil)
+	bs := &s.BorderSides
+	if bs.TopWidth.Val != 2 || bs.RightWidth.Val != 2 || bs.LeftWidth.Val != 4 || bs.LeftStyle != BorderDashed || bs.BottomStyle != BorderSolid {
+		t.Errorf("border sides not set from shorthands: %+v\n", bs)
+	}
+	if bs.TopColor != (Color{G: 128, A: 255}) || bs.RightColor != (Color{B: 255, A: 255}) || bs.BottomColor != (Color{R: 255, A: 255}) {
+		t.Errorf("border colors: %v %v %v\n", bs.TopColor, bs.RightColor, bs.BottomColor)
+	}
+	if bs.TopLeftRadius.Val != 1 || bs.TopRightRadius.Val != 2 || bs.BottomRightRadius.Val != 3 || bs.BottomLeftRadius.Val != 8 {
+		t.Errorf("border radii: %v %v %v %v\n", bs.TopLeftRadius, bs.TopRightRadius, bs.BottomRightRadius, bs.BottomLeftRadius)
+	}
+	if bs.IsUniform() {
+		t.Errorf("non-uniform border reported as uniform\n")
+	}
+
+	var u Style
+	u.Defaults()
+	u.SetStyleProps(nil, ki.Props{"border-width": units.NewValue(3, units.Px), "border-style": BorderGroove}, nil)
+	if !u.BorderSides.IsUniform() || u.BorderSides.BottomWidth.Val != 3 || u.BorderSides.LeftStyle != BorderGroove {
+		t.Errorf("uniform border not set from values: %+v\n", u.BorderSides)
+	}
+	if c := borderSideColors(BoxTop, BorderGroove, Color{R: 128, A: 255}); c[0] == c[1] {
+		t.Errorf("groove border halves not shaded differently: %v\n", c)
+	}
+}
+
+func TestBackground(t *testing.T) {
+	props := ki.Props{
+		"background-image":  "linear-gradient(to right, red, blue)",
+		"background-size":   "50% 100%",
+		"background-repeat": "repeat-x",
+	}
+	var s Style
+	s.Defaults()
+	s.SetStyleProps(nil, props, nil)
+	if !s.Background.HasImage() || s.Background.Repeat != BgRepeatX {
+		t.Fatalf("background not set from props: %+v\n", s.Background)
+	}
+	rs := &RenderState{}
+	rs.Image = image.NewRGBA(image.Rect(0, 0, 20, 10))
+	rs.Bounds = rs.Image.Bounds()
+	RenderBackground(rs, &s, NewVec2D(0, 0), NewVec2D(20, 10), nil)
+	if c0, c1 := rs.Image.RGBAAt(1, 5), rs.Image.RGBAAt(11, 5); c0 != c1 || c0.R < 200 {
+		t.Errorf("gradient not repeated in 50%% tiles: %v %v\n", c0, c1)
+	}
+	if c := rs.Image.RGBAAt(9, 5); c.B < 200 {
+		t.Errorf("gradient tile not ending in blue: %v\n", c)
+	}
+	if xs, ys := (&BackgroundStyle{Position: "bottom"}).positions(); xs != "center" || ys != "bottom" {
+		t.Errorf("background position: %v %v\n", xs, ys)
+	}
+}
+
+func TestSelectorCSS(t *testing.T) {
+	fr := &Frame{}
+	fr.InitName(fr, "main")
+	fr.AddClass("toolbar")
+	var buts []*Button
+	for i := 0; i < 4; i++ {
+		but := fr.AddNewChild(KiT_Button, fmt.Sprintf("b%d", i)).(*Button)
+		buts = append(buts, but)
+	}
+	buts[1].SetProp("kind", "primary ok")
+	buts[2].SetInactive()
+
+	for _, tst := range []struct {
+		sel   string
+		match []bool
+	}{
+		{".toolbar button", []bool{true, true, true, true}},
+		{"frame#main > button:nth-child(odd)", []bool{true, false, true, false}},
+		{"button:nth-last-child(-n+2)", []bool{false, false, true, true}},
+		{"button[kind~=ok]", []bool{false, true, false, false}},
+		{"button[kind^=prim]:first-child", []bool{false, false, false, false}},
+		{"#b1 + button", []bool{false, false, true, false}},
+		{"#b1 ~ button:not(#b3)", []bool{false, false, true, false}},
+		{"button:disabled", []bool{false, false, true, false}},
+		{"layout button", []bool{false, false, false, false}},
+	} {
+		sl, err := ParseSelector(tst.sel)
+		if err != nil {
+			t.Errorf("ParseSelector(%q): %v\n", tst.sel, err)
+			continue
+		}
+		for i, but := range buts {
+			if got := sl.Match(but); got != tst.match[i] {
+				t.Errorf("%q (parsed: %q) match %v: %v != %v\n", tst.sel, sl.String(), but.Name(), got, tst.match[i])
+			}
+		}
+	}
+	for _, bad := range []string{"button >", "a::before", "[kind", ":nth-child(x)"} {
+		if _, err := ParseSelector(bad); err == nil {
+			t.Errorf("ParseSelector(%q) did not fail\n", bad)
+		}
+	}
+
+	css := ki.Props{
+		"#b1":                              ki.Props{"color": "#00f"},
+		".toolbar button":                  ki.Props{"color": "#f00", ":focus": ki.Props{"color": "#0f0"}},
+		"button":                           ki.Props{"color": "#000"},
+		".toolbar #b1:hover, button:hover": ki.Props{"color": "#ff0"},
+	}
+	pms := MatchCSS(buts[1], css, "")
+	if len(pms) != 3 || pms[0]["color"] != "#000" || pms[2]["color"] != "#00f" {
+		t.Errorf("base css rules not in specificity order: %v\n", pms)
+	}
+	if pms := MatchCSS(buts[1], css, ":hover"); len(pms) != 1 || pms[0]["color"] != "#ff0" {
+		t.Errorf("hover css rules: %v\n", pms)
+	}
+	if pms := MatchCSS(buts[0], css, ":focus"); len(pms) != 1 || pms[0]["color"] != "#0f0" {
+		t.Errorf("focus css sub-props: %v\n", pms)
+	}
+
+	// equal specificity: the later rule in the style sheet wins
+	ss := &StyleSheet{}
+	if err := ss.ParseString(`.b { color: red; } .a { color: blue; }`); err != nil {
+		t.Fatal(err)
+	}
+	buts[3].AddClass("b")
+	buts[3].AddClass("a")
+	s := NewStyle()
+	s.StyleCSS(buts[3], ss.CSSProps(), "", nil)
+	if s.Font.Color != (Color{0, 0, 0xff, 0xff}) {
+		t.Errorf("css rules of equal specificity not in source order: color %v\n", s.Font.Color)
+	}
+	// a selector repeated later only applies its later declarations later
+	if err := ss.ParseString(`.a { color: red; } .b { color: blue; } .a { background-color: green; }`); err != nil {
+		t.Fatal(err)
+	}
+	s = NewStyle()
+	s.StyleCSS(buts[3], ss.CSSProps(), "", nil)
+	if s.Font.Color != (Color{0, 0, 0xff, 0xff}) || s.Font.BgColor.Color != (Color{0, 0x80, 0, 0xff}) {
+		t.Errorf("css declarations of repeated selector not in source order: color %v background %v\n", s.Font.Color, s.Font.BgColor.Color)
+	}
+}
+
+func TestMediaQueries(t *testing.T) {
+	vp := &Viewport2D{}
+	vp.Geom.Size = image.Point{800, 600}
+	for _, tst := range []struct {
+		query string
+		match bool
+	}{
+		{"screen", true},
+		{"print", false},
+		{"not print", true},
+		{"(min-width: 600px)", true},
+		{"screen and (max-width: 600px)", false},
+		{"(width > 799px) and (height <= 600px)", true},
+		{"(orientation: portrait), (aspect-ratio: 4/3)", true},
+		{"(min-resolution: 2dppx)", false},
+	} {
+		mqs, err := ParseMediaQueries(tst.query)
+		if err != nil {
+			t.Errorf("ParseMediaQueries(%q): %v\n", tst.query, err)
+			continue
+		}
+		if got := mqs.Match(vp); got != tst.match {
+			t.Errorf("media query %q match: %v != %v\n", tst.query, got, tst.match)
+		}
+	}
+	for _, bad := range []string{"tv", "screen (min-width: 600px)", "(width: 10px"} {
+		if _, err := ParseMediaQueries(bad); err == nil {
+			t.Errorf("ParseMediaQueries(%q) did not fail\n", bad)
+		}
+	}
+
+	ss := &StyleSheet{}
+	err := ss.ParseString(`button { color: #000; }
+@media (prefers-color-scheme: dark) { button { color: #fff; } }
+@media (max-width: 400px) { button { color: #f00; } }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	css := ss.CSSProps()
+	but := &Button{}
+	but.InitName(but, "but")
+	but.Viewport = vp
+	defer func() { Prefs.ColorScheme = ColorSchemeLight }()
+	for _, cs := range []ColorSchemes{ColorSchemeLight, ColorSchemeDark} {
+		Prefs.ColorScheme = cs
+		pms := MatchCSS(but, css, "")
+		clr := "#000"
+		if cs == ColorSchemeDark {
+			clr = "#fff"
+		}
+		if len(pms) == 0 || pms[len(pms)-1]["color"] != clr {
+			t.Errorf("color scheme %v css rules: %v\n", cs, pms)
+		}
+	}
+}
+
+func TestCSSVars(t *testing.T) {
+	par := NewStyle()
+	par.SetStyleProps(nil, ki.Props{"--accent": "#39f", "--gap": "4px", "--pad": "calc(var(--gap) * 2)"}, nil)
+
+	s := NewStyle()
+	s.SetStyleProps(&par, ki.Props{
+		"--gap":            "1em",
+		"color":            "var(--accent)",
+		"background-color": "var(--missing, var(--accent))",
+		"border-width":     "var(--missing)",
+		"margin":           "calc(100% - var(--gap))",
+		"padding":          "var(--pad)",
+	}, nil)
+	if s.Font.Color != (Color{0x33, 0x99, 0xff, 0xff}) || s.Font.BgColor.Color != s.Font.Color {
+		t.Errorf("var() colors not resolved: %v %v\n", s.Font.Color, s.Font.BgColor.Color)
+	}
+	if par.Vars["--gap"] != "4px" || s.Vars["--gap"] != "1em" || s.Vars["--accent"] != "#39f" {
+		t.Errorf("vars not inherited without modifying parent: %v %v\n", par.Vars, s.Vars)
+	}
+	if s.Border.Width.Val != 0 {
+		t.Errorf("invalid var() property was set: %v\n", s.Border.Width)
+	}
+	var uc units.Context
+	uc.Defaults()
+	uc.SetSizes(800, 600, 200, 100)
+	s.Layout.Margin.ToDots(&uc)
+	s.Layout.Padding.ToDots(&uc)
+	if s.Layout.Margin.Dots != 200-12 || s.Layout.Padding.Dots != 8 {
+		t.Errorf("calc() with var() not computed: margin %v padding %v\n", s.Layout.Margin, s.Layout.Padding)
+	}
+
+	ss := &StyleSheet{}
+	if err := ss.ParseString(`frame { --accent: #f00; } button { color: var(--accent, #000); }`); err != nil {
+		t.Fatal(err)
+	}
+	css := ss.CSSProps()
+	fp, _ := css["frame"].(ki.Props)
+	bp, _ := css["button"].(ki.Props)
+	if fp["--accent"] != "#f00" || bp["color"] != "var(--accent, #000)" {
+		t.Errorf("style sheet custom properties: %v\n", css)
+	}
+}
+
+func TestDamageRegion(t *testing.T) {
+	var dr DamageRegion
+	dr.Add(image.Rect(0, 0, 10, 10))
+	dr.Add(image.Rect(10, 0, 20, 10)) // adjacent: merged
+	dr.Add(image.Rect(2, 2, 8, 8))    // contained
+	dr.Add(image.Rect(50, 50, 60, 60))
+	if len(dr.Rects) != 2 || dr.Rects[0] != image.Rect(0, 0, 20, 10) || dr.Area() != 300 {
+		t.Errorf("damage rects not merged: %v\n", dr.Rects)
+	}
+	dr.Add(image.Rect(15, 5, 55, 55)) // overlaps both, but merging would add too much
+	if len(dr.Rects) != 3 {
+		t.Errorf("damage rects merged too much: %v\n", dr.Rects)
+	}
+	if bb := dr.Bounds(); bb != image.Rect(0, 0, 60, 60) {
+		t.Errorf("damage bounds: %v\n", bb)
+	}
+	for i := 0; i < MaxDamageRects; i++ {
+		dr.Add(image.Rect(100+20*i, 0, 110+20*i, 10))
+	}
+	if len(dr.Rects) > MaxDamageRects || dr.Bounds() != image.Rect(0, 0, 410, 60) {
+		t.Errorf("too many damage rects not merged into bounds: %v\n", dr.Rects)
+	}
+	dr.Reset()
+	if !dr.IsEmpty() {
+		t.Errorf("damage not reset: %v\n", dr.Rects)
+	}
+}
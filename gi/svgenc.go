@@ -0,0 +1,452 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/font"
+)
+
+// svgenc.go writes the paint ops recorded by a PaintRecorder as an SVG
+// vector graphics document: paths are written as path elements, text as
+// text elements in the font family, size, weight and style of the font
+// faces, and images as embedded PNG images, as are the tiles of patterns.
+// Clipping to the render bounds is done with shared clipPath rectangles, and
+// layers are isolated groups with their opacity and mix-blend-mode.
+
+// EncodeSVG writes the recorded ops as an SVG vector graphics document of
+// given size, in pixels (which are the user units of the document)
+func (pr *PaintRecorder) EncodeSVG(w io.Writer, size image.Point) error {
+	pr.mu.Lock()
+	se := &svgEncoder{clips: make(map[image.Rectangle]string), fonts: make(map[font.Face]string)}
+	se.writeOps(pr.Ops, image.Rectangle{Max: size})
+	pr.mu.Unlock()
+	if se.err != nil {
+		return se.err
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" xmlns:xlink=\"http://www.w3.org/1999/xlink\" version=\"1.1\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", size.X, size.Y, size.X, size.Y)
+	if se.defs.Len() > 0 {
+		bw.WriteString("<defs>\n")
+		bw.Write(se.defs.Bytes())
+		bw.WriteString("</defs>\n")
+	}
+	bw.Write(se.body.Bytes())
+	bw.WriteString("</svg>\n")
+	return bw.Flush()
+}
+
+// svgEncoder holds the state for writing recorded paint ops as SVG
+type svgEncoder struct {
+	defs  bytes.Buffer               // clip paths, gradients and patterns
+	body  bytes.Buffer               // the painting
+	clips map[image.Rectangle]string // ids of clip paths
+	fonts map[font.Face]string       // font attributes of faces
+	ngrad int                        // number of gradients
+	npat  int                        // number of patterns
+	err   error                      // first error
+}
+
+// writeOps writes given ops, within given bounds, which need no clipping
+func (se *svgEncoder) writeOps(ops []PaintOp, bounds image.Rectangle) {
+	b := &se.body
+	cur := bounds
+	for _, op := range ops {
+		clip := op.ClipBox().Intersect(bounds)
+		if clip.Empty() {
+			continue
+		}
+		if clip != cur {
+			if cur != bounds {
+				b.WriteString("</g>\n")
+			}
+			if clip != bounds {
+				fmt.Fprintf(b, "<g clip-path=\"url(#%s)\">\n", se.clipID(clip))
+			}
+			cur = clip
+		}
+		switch op := op.(type) {
+		case *PaintPathOp:
+			se.writePath(op)
+		case *PaintTextOp:
+			se.writeText(op)
+		case *PaintImageOp:
+			se.writeImage(op)
+		case *PaintGroupOp:
+			if xf := op.XForm; xf.XX == 1 && xf.YX == 0 && xf.XY == 0 && xf.YY == 1 {
+				fmt.Fprintf(b, "<g transform=\"translate(%s %s)\">\n", svgNum(float64(xf.X0)), svgNum(float64(xf.Y0)))
+			} else {
+				fmt.Fprintf(b, "<g transform=\"%s\">\n", svgMatrix(xf))
+			}
+			se.writeOps(op.Ops, op.InnerBounds(clip))
+			b.WriteString("</g>\n")
+		case *PaintLayerOp:
+			b.WriteString("<g")
+			if op.Opacity < 1 {
+				fmt.Fprintf(b, " opacity=\"%s\"", svgNum(float64(op.Opacity)))
+			}
+			if op.Blend != BlendNormal {
+				fmt.Fprintf(b, " style=\"mix-blend-mode:%s;isolation:isolate\"", op.Blend.CSSName())
+			} else {
+				b.WriteString(" style=\"isolation:isolate\"")
+			}
+			b.WriteString(">\n")
+			se.writeOps(op.Ops, clip)
+			b.WriteString("</g>\n")
+		}
+	}
+	if cur != bounds {
+		b.WriteString("</g>\n")
+	}
+}
+
+// clipID returns the id of the clip path for given rect, defining it if new
+func (se *svgEncoder) clipID(r image.Rectangle) string {
+	if id, ok := se.clips[r]; ok {
+		return id
+	}
+	id := fmt.Sprintf("clip%d", len(se.clips))
+	se.clips[r] = id
+	fmt.Fprintf(&se.defs, "<clipPath id=\"%s\"><rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\"/></clipPath>\n", id, r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+	return id
+}
+
+// writePath writes a filled or stroked path
+func (se *svgEncoder) writePath(op *PaintPathOp) {
+	if len(op.Path) == 0 || (op.Gradient == nil && op.Pattern == nil && op.Color.A == 0) {
+		return
+	}
+	if op.Stroke && op.Width <= 0 {
+		return
+	}
+	b := &se.body
+	b.WriteString("<path d=\"")
+	svgPathData(b, op.Path)
+	b.WriteString("\"")
+	paint := ""
+	solid := false
+	switch {
+	case op.Pattern != nil:
+		paint = "url(#" + se.patternID(op.Pattern) + ")"
+	case op.Gradient != nil:
+		paint = "url(#" + se.gradientID(op.Gradient, op.Opacity) + ")"
+	default:
+		paint = svgHex(op.Color)
+		solid = true
+	}
+	if op.Stroke {
+		fmt.Fprintf(b, " fill=\"none\" stroke=\"%s\"", paint)
+		if solid && op.Color.A < 255 {
+			fmt.Fprintf(b, " stroke-opacity=\"%s\"", svgNum(float64(op.Color.A)/255))
+		}
+		fmt.Fprintf(b, " stroke-width=\"%s\"", svgNum(float64(op.Width)))
+		switch op.Cap {
+		case LineCapRound, LineCapCubic, LineCapQuadratic:
+			b.WriteString(" stroke-linecap=\"round\"")
+		case LineCapSquare:
+			b.WriteString(" stroke-linecap=\"square\"")
+		}
+		switch op.Join {
+		case LineJoinRound:
+			b.WriteString(" stroke-linejoin=\"round\"")
+		case LineJoinBevel:
+			b.WriteString(" stroke-linejoin=\"bevel\"")
+		default:
+			if op.MiterLimit > 0 && op.MiterLimit != 4 {
+				fmt.Fprintf(b, " stroke-miterlimit=\"%s\"", svgNum(float64(op.MiterLimit)))
+			}
+		}
+		if len(op.Dashes) > 0 {
+			b.WriteString(" stroke-dasharray=\"")
+			for i, d := range op.Dashes {
+				if i > 0 {
+					b.WriteByte(' ')
+				}
+				b.WriteString(svgNum(d))
+			}
+			b.WriteString("\"")
+		}
+	} else {
+		fmt.Fprintf(b, " fill=\"%s\"", paint)
+		if solid && op.Color.A < 255 {
+			fmt.Fprintf(b, " fill-opacity=\"%s\"", svgNum(float64(op.Color.A)/255))
+		}
+		if op.Rule == FillRuleEvenOdd {
+			b.WriteString(" fill-rule=\"evenodd\"")
+		}
+	}
+	b.WriteString("/>\n")
+}
+
+// gradientID defines given gradient, with given overall opacity, returning
+// its id
+func (se *svgEncoder) gradientID(g *rasterx.Gradient, opacity float32) string {
+	id := fmt.Sprintf("grad%d", se.ngrad)
+	se.ngrad++
+	d := &se.defs
+	units := "objectBoundingBox"
+	if g.Units == rasterx.UserSpaceOnUse {
+		units = "userSpaceOnUse"
+	}
+	p := g.Points
+	if g.IsRadial {
+		fmt.Fprintf(d, "<radialGradient id=\"%s\" gradientUnits=\"%s\" cx=\"%s\" cy=\"%s\" fx=\"%s\" fy=\"%s\" r=\"%s\"", id, units, svgNum(p[0]), svgNum(p[1]), svgNum(p[2]), svgNum(p[3]), svgNum(p[4]))
+	} else {
+		fmt.Fprintf(d, "<linearGradient id=\"%s\" gradientUnits=\"%s\" x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\"", id, units, svgNum(p[0]), svgNum(p[1]), svgNum(p[2]), svgNum(p[3]))
+	}
+	if g.Matrix != rasterx.Identity {
+		m := g.Matrix
+		fmt.Fprintf(d, " gradientTransform=\"matrix(%s %s %s %s %s %s)\"", svgNum(m.A), svgNum(m.B), svgNum(m.C), svgNum(m.D), svgNum(m.E), svgNum(m.F))
+	}
+	switch g.Spread {
+	case rasterx.ReflectSpread:
+		d.WriteString(" spreadMethod=\"reflect\"")
+	case rasterx.RepeatSpread:
+		d.WriteString(" spreadMethod=\"repeat\"")
+	}
+	d.WriteString(">\n")
+	for _, st := range g.Stops {
+		c := rasterx.ApplyOpacity(st.StopColor, st.Opacity*float64(opacity))
+		fmt.Fprintf(d, "<stop offset=\"%s\" stop-color=\"%s\" stop-opacity=\"%s\"/>\n", svgNum(st.Offset), svgHex(c), svgNum(float64(c.A)/255))
+	}
+	if g.IsRadial {
+		d.WriteString("</radialGradient>\n")
+	} else {
+		d.WriteString("</linearGradient>\n")
+	}
+	return id
+}
+
+// patternID defines given pattern, returning its id -- the tile is embedded
+// as a PNG image, and the axes that are not repeated get one huge tile
+func (se *svgEncoder) patternID(pp *PaintPattern) string {
+	id := fmt.Sprintf("pat%d", se.npat)
+	se.npat++
+	var pb bytes.Buffer
+	if err := png.Encode(&pb, pp.Image); err != nil {
+		if se.err == nil {
+			se.err = err
+		}
+		return id
+	}
+	w, h := pp.Image.Bounds().Dx(), pp.Image.Bounds().Dy()
+	pw, ph := w, h
+	if pp.Repeat != BgRepeat && pp.Repeat != BgRepeatX {
+		pw = 1 << 24
+	}
+	if pp.Repeat != BgRepeat && pp.Repeat != BgRepeatY {
+		ph = 1 << 24
+	}
+	d := &se.defs
+	fmt.Fprintf(d, "<pattern id=\"%s\" patternUnits=\"userSpaceOnUse\" width=\"%d\" height=\"%d\"", id, pw, ph)
+	if pp.XForm != Identity2D() {
+		fmt.Fprintf(d, " patternTransform=\"%s\"", svgMatrix(pp.XForm))
+	}
+	fmt.Fprintf(d, ">\n<image width=\"%d\" height=\"%d\" preserveAspectRatio=\"none\" xlink:href=\"data:image/png;base64,", w, h)
+	d.WriteString(base64.StdEncoding.EncodeToString(pb.Bytes()))
+	d.WriteString("\"/>\n</pattern>\n")
+	return id
+}
+
+// writeText writes the runes of a text op as text elements, one for each
+// run of runes with the same face and color
+func (se *svgEncoder) writeText(op *PaintTextOp) {
+	rns := op.Runes
+	for st := 0; st < len(rns); {
+		r0 := &rns[st]
+		if r0.ScaleX != 0 && r0.ScaleX != 1 { // needs its own transform
+			se.writeRunes(rns[st : st+1])
+			st++
+			continue
+		}
+		ed := st + 1
+		for ed < len(rns) && rns[ed].Face == r0.Face && rns[ed].Color == r0.Color && (rns[ed].ScaleX == 0 || rns[ed].ScaleX == 1) {
+			ed++
+		}
+		se.writeRunes(rns[st:ed])
+		st = ed
+	}
+}
+
+// writeRunes writes a text element for runes with the same face and color
+func (se *svgEncoder) writeRunes(rns []PaintRune) {
+	b := &se.body
+	r0 := &rns[0]
+	fmt.Fprintf(b, "<text %s fill=\"%s\"", se.fontAttrs(r0.Face), svgHex(r0.Color))
+	if r0.Color.A < 255 {
+		fmt.Fprintf(b, " fill-opacity=\"%s\"", svgNum(float64(r0.Color.A)/255))
+	}
+	if r0.ScaleX != 0 && r0.ScaleX != 1 {
+		m := Translate2D(r0.Pos.X, r0.Pos.Y).Scale(r0.ScaleX, 1).Rotate(r0.RotRad)
+		fmt.Fprintf(b, " transform=\"%s\" x=\"0\" y=\"0\"", svgMatrix(m))
+	} else {
+		hasRot := false
+		b.WriteString(" x=\"")
+		for i := range rns {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(svgNum(float64(rns[i].Pos.X)))
+			if rns[i].RotRad != 0 {
+				hasRot = true
+			}
+		}
+		b.WriteString("\" y=\"")
+		for i := range rns {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(svgNum(float64(rns[i].Pos.Y)))
+		}
+		b.WriteString("\"")
+		if hasRot {
+			b.WriteString(" rotate=\"")
+			for i := range rns {
+				if i > 0 {
+					b.WriteByte(' ')
+				}
+				b.WriteString(svgNum(float64(rns[i].RotRad) * 180 / math.Pi))
+			}
+			b.WriteString("\"")
+		}
+	}
+	b.WriteString(" xml:space=\"preserve\">")
+	str := make([]rune, len(rns))
+	for i := range rns {
+		str[i] = rns[i].Rune
+	}
+	xml.EscapeText(b, []byte(string(str)))
+	b.WriteString("</text>\n")
+}
+
+// fontAttrs returns the font attributes for given face
+func (se *svgEncoder) fontAttrs(face font.Face) string {
+	if fa, ok := se.fonts[face]; ok {
+		return fa
+	}
+	fam, wt, sty := "sans-serif", 400, ""
+	var size float32
+	if fnm, sz, ok := FontLibrary.FaceInfo(face); ok {
+		base, _, fwt, fsty := FontNameToMods(fnm)
+		generic := "sans-serif"
+		if strings.Contains(strings.ToLower(base), "mono") {
+			generic = "monospace"
+		}
+		fam = "'" + base + "', " + generic
+		if cwt, has := FontWeightCSSVals[fwt]; has {
+			wt = cwt
+		}
+		switch fsty {
+		case FontItalic:
+			sty = "italic"
+		case FontOblique:
+			sty = "oblique"
+		}
+		size = float32(sz)
+	} else {
+		size = FixedToFloat32(face.Metrics().Height)
+	}
+	fa := fmt.Sprintf("font-family=\"%s\" font-size=\"%s\"", fam, svgNum(float64(size)))
+	if wt != 400 {
+		fa += fmt.Sprintf(" font-weight=\"%d\"", wt)
+	}
+	if sty != "" {
+		fa += fmt.Sprintf(" font-style=\"%s\"", sty)
+	}
+	se.fonts[face] = fa
+	return fa
+}
+
+// writeImage writes an image as an embedded PNG
+func (se *svgEncoder) writeImage(op *PaintImageOp) {
+	ib := op.Image.Bounds()
+	if ib.Empty() {
+		return
+	}
+	var pb bytes.Buffer
+	if err := png.Encode(&pb, op.Image); err != nil {
+		if se.err == nil {
+			se.err = err
+		}
+		return
+	}
+	b := &se.body
+	fmt.Fprintf(b, "<image x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" preserveAspectRatio=\"none\"", ib.Min.X, ib.Min.Y, ib.Dx(), ib.Dy())
+	if op.XForm != Identity2D() {
+		fmt.Fprintf(b, " transform=\"%s\"", svgMatrix(op.XForm))
+	}
+	b.WriteString(" xlink:href=\"data:image/png;base64,")
+	b.WriteString(base64.StdEncoding.EncodeToString(pb.Bytes()))
+	b.WriteString("\"/>\n")
+}
+
+// svgPathData writes the path commands of given path in SVG path syntax
+func svgPathData(b *bytes.Buffer, p rasterx.Path) {
+	pt := func(i int) {
+		b.WriteString(svgNum(float64(p[i]) / 64))
+		b.WriteByte(' ')
+		b.WriteString(svgNum(float64(p[i+1]) / 64))
+	}
+	for i := 0; i < len(p); {
+		switch rasterx.PathCommand(p[i]) {
+		case rasterx.PathMoveTo:
+			b.WriteByte('M')
+			pt(i + 1)
+			i += 3
+		case rasterx.PathLineTo:
+			b.WriteByte('L')
+			pt(i + 1)
+			i += 3
+		case rasterx.PathQuadTo:
+			b.WriteByte('Q')
+			pt(i + 1)
+			b.WriteByte(' ')
+			pt(i + 3)
+			i += 5
+		case rasterx.PathCubicTo:
+			b.WriteByte('C')
+			pt(i + 1)
+			b.WriteByte(' ')
+			pt(i + 3)
+			b.WriteByte(' ')
+			pt(i + 5)
+			i += 7
+		case rasterx.PathClose:
+			b.WriteByte('Z')
+			i++
+		default:
+			return
+		}
+	}
+}
+
+// svgNum formats a number for SVG, to 1/100 of a unit
+func svgNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// svgHex returns the #rrggbb hex string for the color, without alpha
+func svgHex(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// svgMatrix returns the SVG transform for given matrix
+func svgMatrix(m Matrix2D) string {
+	return fmt.Sprintf("matrix(%s %s %s %s %s %s)", svgNum(float64(m.XX)), svgNum(float64(m.YX)), svgNum(float64(m.XY)), svgNum(float64(m.YY)), svgNum(float64(m.X0)), svgNum(float64(m.Y0)))
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/draw"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/gitest"
+	"github.com/goki/gi/svg"
+	"github.com/goki/gi/units"
+)
+
+// addCircleSVG adds a 50x50 pixel SVG with a red circle on white
+func addCircleSVG(mfr *gi.Frame) *svg.SVG {
+	sv := mfr.AddNewChild(svg.KiT_SVG, "sv").(*svg.SVG)
+	sv.Fill = true
+	sv.SetProp("background-color", "white")
+	sv.SetProp("width", units.NewValue(50, units.Px))
+	sv.SetProp("height", units.NewValue(50, units.Px))
+	c := sv.AddNewChild(svg.KiT_Circle, "c").(*svg.Circle)
+	c.Pos.Set(25, 25)
+	c.Radius = 20
+	c.SetProp("fill", "red")
+	return sv
+}
+
+func TestEncodeSVG(t *testing.T) {
+	win := gitest.NewWindow("encsvg", 300, 200, func(mfr *gi.Frame) {
+		addLabel(mfr, "lbl", "Vector & label")
+		but := mfr.AddNewChild(gi.KiT_Button, "but").(*gi.Button)
+		but.SetText("Click")
+		addCircleSVG(mfr)
+	})
+	defer win.OSWin.Close()
+	pix := image.NewRGBA(win.Viewport.Pixels.Bounds())
+	draw.Draw(pix, pix.Bounds(), win.Viewport.Pixels, image.ZP, draw.Src)
+	var b bytes.Buffer
+	if err := win.Viewport.EncodeSVG(&b); err != nil {
+		t.Fatal(err)
+	}
+	if diff := gitest.Compare(pix, win.Viewport.Pixels, 0); diff.NPixels != 0 {
+		t.Errorf("recorded render differs from plain render in %v pixels\n", diff.NPixels)
+	}
+	elems := map[string]int{}
+	text := ""
+	dec := xml.NewDecoder(&b)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("SVG output does not parse: %v\n", err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			elems[tok.Name.Local]++
+		case xml.CharData:
+			text += string(tok)
+		}
+	}
+	if elems["svg"] != 1 || elems["path"] == 0 || elems["text"] == 0 || elems["g"] == 0 {
+		t.Errorf("missing SVG elements: %v\n", elems)
+	}
+	for _, s := range []string{"Vector & label", "Click"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("SVG text does not contain: %q\n", s)
+		}
+	}
+	if win.Viewport.Render.Recorder != nil {
+		t.Errorf("recorder not cleared after EncodeSVG\n")
+	}
+}
@@ -722,6 +722,11 @@ func (tr *TextRender) Render(rs *RenderState, pos Vec2D) {
 			sr.RenderLine(rs, tpos, DecoOverline, 1.1)
 		}
 
+		var rec *PaintTextOp
+		if rs.Recorder != nil {
+			rec = &PaintTextOp{Runes: make([]PaintRune, 0, len(sr.Text)), Clip: rs.Bounds}
+		}
+
 		for i, r := range sr.Text {
 			rr := &(sr.Render[i])
 			if rr.Color != nil {
@@ -752,6 +757,9 @@ func (tr *TextRender) Render(rs *RenderState, pos Vec2D) {
 				// fmt.Printf("not ok rendering rune: %v\n", string(r))
 				continue
 			}
+			if rec != nil {
+				rec.Runes = append(rec.Runes, PaintRune{Rune: r, Pos: rp, Face: curFace, Color: color.NRGBAModel.Convert(curColor).(color.NRGBA), RotRad: rr.RotRad, ScaleX: rr.ScaleX})
+			}
 			if rr.RotRad == 0 && (rr.ScaleX == 0 || rr.ScaleX == 1) {
 				idr := dr.Intersect(rs.Bounds)
 				soff := image.ZP
@@ -778,6 +786,9 @@ func (tr *TextRender) Render(rs *RenderState, pos Vec2D) {
 				})
 			}
 		}
+		if rec != nil && len(rec.Runes) > 0 {
+			rs.Recorder.Add(rec)
+		}
 		if bitflag.Has32(int32(sr.HasDeco), int(DecoLineThrough)) {
 			sr.RenderLine(rs, tpos, DecoLineThrough, 0.25)
 		}
@@ -58,10 +58,13 @@ type TextField struct {
 	SelectEnd    int                     `xml:"-" desc:"ending position of selection in the string"`
 	SelectInit   int                     `xml:"-" desc:"initial selection position -- where it started"`
 	SelectMode   bool                    `xml:"-" desc:"if true, select text as cursor moves"`
+	PreeditStart int                     `xml:"-" desc:"starting position of the input method (IME) preedit text being composed, which is shown underlined in EditTxt until it is committed"`
+	PreeditLen   int                     `xml:"-" desc:"length of the IME preedit text -- 0 if not composing"`
 	TextFieldSig ki.Signal               `json:"-" xml:"-" view:"-" desc:"signal for line edit -- see TextFieldSignals for the types"`
 	RenderAll    TextRender              `json:"-" xml:"-" desc:"render version of entire text, for sizing"`
 	RenderVis    TextRender              `json:"-" xml:"-" desc:"render version of just visible text"`
 	StateStyles  [TextFieldStatesN]Style `json:"-" xml:"-" desc:"normal style and focus style"`
+	StyleAnim    StyleAnimator           `json:"-" xml:"-" view:"-" desc:"animates the transitions between state styles, and any keyframe animations"`
 	FontHeight   float32                 `json:"-" xml:"-" desc:"font height, cached during styling"`
 	BlinkOn      bool                    `json:"-" xml:"-" desc:"oscillates between on and off for blinking"`
 	CursorMu     sync.Mutex              `json:"-" xml:"-" view:"-" desc:"mutex for updating cursor between blinker and field"`
@@ -198,6 +201,7 @@ func (tf *TextField) Label() string {
 // EditDone completes editing and copies the active edited text to the text --
 // called when the return key is pressed or goes out of focus
 func (tf *TextField) EditDone() {
+	tf.ClearPreedit()
 	if tf.Edited {
 		tf.Edited = false
 		tf.Txt = string(tf.EditTxt)
@@ -210,6 +214,7 @@ func (tf *TextField) EditDone() {
 // EditDeFocused completes editing and copies the active edited text to the text --
 // called when field is made inactive due to interactions elsewhere.
 func (tf *TextField) EditDeFocused() {
+	tf.ClearPreedit()
 	if tf.Edited {
 		tf.Edited = false
 		tf.Txt = string(tf.EditTxt)
@@ -416,6 +421,7 @@ func (tf *TextField) SelectRegUpdate(pos int) {
 		tf.SelectEnd = pos
 	}
 	tf.SelectUpdate()
+	tf.SelectionToPrimary()
 }
 
 // SelectAll selects all the text
@@ -425,6 +431,7 @@ func (tf *TextField) SelectAll() {
 	tf.SelectInit = 0
 	tf.SelectEnd = len(tf.EditTxt)
 	tf.UpdateEnd(updt)
+	tf.SelectionToPrimary()
 }
 
 // IsWordBreak defines what counts as a word break for the purposes of selecting words
@@ -478,6 +485,7 @@ func (tf *TextField) SelectWord() {
 		}
 	}
 	tf.SelectInit = tf.SelectStart
+	tf.SelectionToPrimary()
 }
 
 // SelectReset resets the selection
@@ -572,6 +580,33 @@ func (tf *TextField) Paste() {
 	}
 }
 
+// SelectionToPrimary sets the PRIMARY selection (on platforms that have one)
+// to the currently selected text
+func (tf *TextField) SelectionToPrimary() {
+	if tf.Viewport == nil || !tf.HasSelection() {
+		return
+	}
+	if pc := PrimaryClip(tf.Viewport.Win); pc != nil {
+		pc.Write(mimedata.NewText(tf.Selection()))
+	}
+}
+
+// PastePrimary inserts text from the PRIMARY selection at current cursor
+// position -- does a regular Paste on platforms without a primary selection
+func (tf *TextField) PastePrimary() {
+	pc := PrimaryClip(tf.Viewport.Win)
+	if pc == nil {
+		tf.Paste()
+		return
+	}
+	wupdt := tf.Viewport.Win.UpdateStart()
+	defer tf.Viewport.Win.UpdateEnd(wupdt)
+	data := pc.Read([]string{filecat.TextPlain})
+	if data != nil {
+		tf.InsertAtCursor(data.Text(filecat.TextPlain))
+	}
+}
+
 // InsertAtCursor inserts given text at current cursor position
 func (tf *TextField) InsertAtCursor(str string) {
 	wupdt := tf.Viewport.Win.UpdateStart()
@@ -592,6 +627,46 @@ func (tf *TextField) InsertAtCursor(str string) {
 	tf.CursorForward(rsl)
 }
 
+// IMEInput handles composed text input from the input method (IME) --
+// preedit text is shown at the cursor, underlined, replacing any prior
+// preedit text, until the final text is committed
+func (tf *TextField) IMEInput(ie *key.IMEEvent) {
+	if tf.IsInactive() {
+		return
+	}
+	ie.SetProcessed()
+	wupdt := tf.Viewport.Win.UpdateStart()
+	defer tf.Viewport.Win.UpdateEnd(wupdt)
+	updt := tf.UpdateStart()
+	defer tf.UpdateEnd(updt)
+	tf.ClearPreedit()
+	if ie.Text == "" {
+		return
+	}
+	tf.InsertAtCursor(ie.Text)
+	if ie.Action == key.IMEPreedit {
+		n := len([]rune(ie.Text))
+		tf.PreeditStart = tf.CursorPos - n
+		tf.PreeditLen = n
+		tf.CursorPos = tf.PreeditStart + ints.MinInt(ie.Cursor, n)
+	}
+}
+
+// ClearPreedit removes any IME preedit text, putting the cursor back where
+// the preedit started
+func (tf *TextField) ClearPreedit() {
+	if tf.PreeditLen == 0 {
+		return
+	}
+	sz := len(tf.EditTxt)
+	st := ints.MinInt(tf.PreeditStart, sz)
+	ed := ints.MinInt(st+tf.PreeditLen, sz)
+	tf.EditTxt = append(tf.EditTxt[:st], tf.EditTxt[ed:]...)
+	tf.EndPos = ints.MinInt(tf.EndPos, len(tf.EditTxt))
+	tf.CursorPos = st
+	tf.PreeditLen = 0
+}
+
 // cpos := tf.CharStartPos(tf.CursorPos).ToPoint()
 
 func (tf *TextField) MakeContextMenu(m *Menu) {
@@ -792,6 +867,7 @@ func (tf *TextField) StartCursor() {
 		return
 	}
 	tf.BlinkOn = true
+	tf.SetIMECursor(true)
 	if CursorBlinkMSec == 0 {
 		tf.RenderCursor(true)
 		return
@@ -858,6 +934,22 @@ func (tf *TextField) RenderCursor(on bool) {
 	win.UpdateSig()      // publish
 }
 
+// SetIMECursor tells the input method (IME) where our cursor is, for
+// positioning its candidate window, or that text input has ended if !on
+func (tf *TextField) SetIMECursor(on bool) {
+	win := tf.ParentWindow()
+	if win == nil {
+		return
+	}
+	if !on {
+		win.SetIMECursor(image.ZR)
+		return
+	}
+	cpos := tf.CharStartPos(tf.CursorPos).ToPointFloor()
+	bbsz := image.Point{int(math32.Ceil(tf.CursorWidth.Dots)), int(math32.Ceil(tf.FontHeight))}
+	win.SetIMECursor(image.Rectangle{Min: cpos, Max: cpos.Add(bbsz)})
+}
+
 // ScrollLayoutToCursor scrolls any scrolling layout above us so that the cursor is in view
 func (tf *TextField) ScrollLayoutToCursor() bool {
 	ly := tf.ParentScrollLayout()
@@ -918,6 +1010,25 @@ func (tf *TextField) RenderSelect() {
 	pc.FillBox(rs, spos, Vec2D{tsz, tf.FontHeight}, &st.Font.BgColor)
 }
 
+// RenderPreedit underlines the IME preedit text, if any
+func (tf *TextField) RenderPreedit() {
+	if tf.PreeditLen == 0 {
+		return
+	}
+	effst := ints.MaxInt(tf.StartPos, tf.PreeditStart)
+	effed := ints.MinInt(tf.EndPos, tf.PreeditStart+tf.PreeditLen)
+	if effed <= effst {
+		return
+	}
+	spos := tf.CharStartPos(effst)
+	rs := &tf.Viewport.Render
+	pc := &rs.Paint
+	st := &tf.Sty
+	tsz := tf.TextWidth(effst, effed)
+	lw := math32.Max(1, math32.Floor(tf.FontHeight/16))
+	pc.FillBoxColor(rs, Vec2D{spos.X, spos.Y + tf.FontHeight - lw}, Vec2D{tsz, lw}, st.Font.Color)
+}
+
 // AutoScroll scrolls the starting position to keep the cursor visible
 func (tf *TextField) AutoScroll() {
 	st := &tf.Sty
@@ -934,6 +1045,12 @@ func (tf *TextField) AutoScroll() {
 	}
 	spc := st.BoxSpace()
 	maxw := tf.EffSize.X - 2.0*spc
+	if maxw <= 0 { // no room to show anything
+		tf.CursorPos = ints.MinInt(tf.CursorPos, sz)
+		tf.EndPos = tf.CursorPos
+		tf.StartPos = tf.CursorPos
+		return
+	}
 	tf.CharWidth = int(maxw / st.UnContext.ToDotsFactor(units.Ch)) // rough guess in chars
 
 	// first rationalize all the values
@@ -1232,7 +1349,7 @@ func (tf *TextField) HandleMouseEvent(me *mouse.Event) {
 			me.SetProcessed()
 			pt := tf.PointToRelPos(me.Pos())
 			tf.SetCursorFromPixel(float32(pt.X), me.SelectMode())
-			tf.Paste()
+			tf.PastePrimary()
 		}
 	case mouse.Right:
 		if me.Action == mouse.Press {
@@ -1296,12 +1413,21 @@ func (tf *TextField) KeyChordEvent() {
 	}
 }
 
+func (tf *TextField) IMEEvent() {
+	tf.ConnectEvent(oswin.IMEEvent, RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+		tff := recv.Embed(KiT_TextField).(*TextField)
+		ie := d.(*key.IMEEvent)
+		tff.IMEInput(ie)
+	})
+}
+
 func (tf *TextField) TextFieldEvents() {
 	tf.HoverTooltipEvent()
 	tf.MouseDragEvent()
 	tf.MouseEvent()
 	tf.MouseFocusEvent()
 	tf.KeyChordEvent()
+	tf.IMEEvent()
 }
 
 func (tf *TextField) ConfigParts() {
@@ -1347,7 +1473,7 @@ func (tf *TextField) StyleTextField() {
 	for i := 0; i < int(TextFieldStatesN); i++ {
 		tf.StateStyles[i].CopyFrom(&tf.Sty)
 		tf.StateStyles[i].SetStyleProps(pst, tf.StyleProps(TextFieldSelectors[i]), tf.Viewport)
-		tf.StateStyles[i].StyleCSS(tf.This().(Node2D), tf.CSSAgg, TextFieldSelectors[i], tf.Viewport)
+		tf.StyleCSS(&tf.StateStyles[i], TextFieldSelectors[i])
 		tf.StateStyles[i].CopyUnitContext(&tf.Sty.UnContext)
 	}
 	tf.CursorWidth.SetFmInheritProp("cursor-width", tf.This(), true, true) // get type defaults
@@ -1361,6 +1487,7 @@ func (tf *TextField) StyleTextField() {
 
 func (tf *TextField) Style2D() {
 	tf.StyleTextField()
+	tf.StyleAnim.Reset()
 	tf.LayData.SetFromStyle(&tf.Sty.Layout) // also does reset
 }
 
@@ -1422,23 +1549,21 @@ func (tf *TextField) Render2D() {
 		rs := &tf.Viewport.Render
 		rs.Lock()
 		tf.AutoScroll() // inits paint with our style
+		state := TextFieldActive
 		if tf.IsInactive() {
 			if tf.IsSelected() {
-				tf.Sty = tf.StateStyles[TextFieldSel]
+				state = TextFieldSel
 			} else {
-				tf.Sty = tf.StateStyles[TextFieldInactive]
+				state = TextFieldInactive
 			}
 		} else if tf.HasFocus() {
 			if tf.IsFocusActive() {
-				tf.Sty = tf.StateStyles[TextFieldFocus]
-			} else {
-				tf.Sty = tf.StateStyles[TextFieldActive]
+				state = TextFieldFocus
 			}
 		} else if tf.IsSelected() {
-			tf.Sty = tf.StateStyles[TextFieldSel]
-		} else {
-			tf.Sty = tf.StateStyles[TextFieldActive]
+			state = TextFieldSel
 		}
+		tf.StyleAnim.Update(tf.AsWidget(), int(state), &tf.StateStyles[state], &tf.Sty)
 		st := &tf.Sty
 		st.Font.OpenFont(&st.UnContext)
 		tf.RenderStdBox(st)
@@ -1453,6 +1578,7 @@ func (tf *TextField) Render2D() {
 		} else {
 			tf.RenderVis.SetRunes(cur, &st.Font, &st.UnContext, &st.Text, true, 0, 0)
 			tf.RenderVis.RenderTopPos(rs, pos)
+			tf.RenderPreedit()
 		}
 		rs.Unlock()
 		if tf.IsActive() {
@@ -1478,6 +1604,7 @@ func (tf *TextField) FocusChanged2D(change FocusChanges) {
 	switch change {
 	case FocusLost:
 		tf.ClearFlag(int(TextFieldFocusActive))
+		tf.SetIMECursor(false)
 		tf.EditDone()
 		tf.UpdateSig()
 	case FocusGot:
@@ -1488,6 +1615,7 @@ func (tf *TextField) FocusChanged2D(change FocusChanges) {
 		tf.UpdateSig()
 	case FocusInactive:
 		tf.ClearFlag(int(TextFieldFocusActive))
+		tf.SetIMECursor(false)
 		tf.EditDeFocused()
 		tf.UpdateSig()
 	case FocusActive:
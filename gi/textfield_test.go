@@ -0,0 +1,65 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi_test
+
+import (
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/gitest"
+	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/mouse"
+	"github.com/goki/gi/units"
+	"github.com/goki/pi/filecat"
+)
+
+// addTextField adds a text field with given name and text, wide enough for
+// the text typed in the tests
+func addTextField(mfr *gi.Frame, name, text string) *gi.TextField {
+	tf := mfr.AddNewChild(gi.KiT_TextField, name).(*gi.TextField)
+	tf.SetProp("min-width", units.NewValue(20, units.Ch))
+	tf.SetText(text)
+	return tf
+}
+
+func TestIME(t *testing.T) {
+	au := gitest.NewWindowAuto(t, "ime", 300, 100, func(mfr *gi.Frame) {
+		addTextField(mfr, "name", "")
+	})
+	defer au.Close()
+
+	tf := au.Focus("textfield#name").(*gi.TextField)
+	au.Type("a")
+	au.Send(&key.IMEEvent{Action: key.IMEPreedit, Text: "ni", Cursor: 1})
+	var got string
+	var plen, cpos int
+	au.Do(func() {
+		got, plen, cpos = string(tf.EditTxt), tf.PreeditLen, tf.CursorPos
+	})
+	if got != "ani" || plen != 2 || cpos != 2 {
+		t.Errorf("preedit: text %q len %v cursor %v, want \"ani\" 2 2\n", got, plen, cpos)
+	}
+	au.Send(&key.IMEEvent{Action: key.IMECommit, Text: "你"})
+	au.AssertText("#name", "a你")
+}
+
+func TestPrimarySelection(t *testing.T) {
+	au := gitest.NewWindowAuto(t, "primary", 300, 100, func(mfr *gi.Frame) {
+		addTextField(mfr, "src", "hello")
+		addTextField(mfr, "dst", "")
+	})
+	defer au.Close()
+
+	au.DoubleClick("#src")
+	pc := gi.PrimaryClip(au.Win)
+	if pc == nil {
+		t.Fatalf("no primary selection\n")
+	}
+	if got := pc.Read([]string{filecat.TextPlain}).Text(filecat.TextPlain); got != "hello" {
+		t.Errorf("primary selection: %q != \"hello\"\n", got)
+	}
+	au.ClickAt(au.Center(au.Find("#dst")), mouse.Middle)
+	au.AssertText("#dst", "hello")
+}
@@ -0,0 +1,351 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"image"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/srwiley/rasterx"
+	"github.com/srwiley/scanFT"
+	"golang.org/x/image/math/fixed"
+)
+
+// tiles.go implements tiled parallel rasterization: when the TileSize of a
+// RenderState is set, fills and strokes record the line segments of the
+// path, and then rasterize each square tile of the image that the path
+// covers in its own scanner, with the tiles rasterized concurrently.  Each
+// tile only paints its own pixels, so the result does not depend on the
+// order in which the tiles are done, and it is the same as rasterizing in
+// one scanner.
+
+// DefaultTileSize is the tile size used by a TileScanner without one
+var DefaultTileSize = 128
+
+// TileScanner is a rasterx.Scanner that rasterizes in tiles concurrently
+// (see TileSize in RenderState) -- it records the path, and rasterizes it
+// at Draw, using one scanFT.ScannerFT per worker, sized to one tile plus
+// a guard pixel (see tileOrigin)
+type TileScanner struct {
+	TileSize int         `desc:"size of the square tiles in pixels -- DefaultTileSize if 0"`
+	Workers  int         `desc:"maximum number of tiles rasterized concurrently -- 0 = runtime.GOMAXPROCS"`
+	Image    *image.RGBA `desc:"image to render into"`
+
+	width, height          int
+	clip                   image.Rectangle
+	winding                bool
+	color                  interface{}
+	pen                    fixed.Point26_6
+	segs                   []tileSeg
+	minX, minY, maxX, maxY fixed.Int26_6
+	workers                []*tileWorker
+	scanSize               int
+	bands                  [][]int32
+}
+
+// tileSeg is one line segment of the recorded path
+type tileSeg struct {
+	a, b fixed.Point26_6
+}
+
+// NewTileScanner returns a new TileScanner for rasterizing into given image
+// of given size, in tiles of given size
+func NewTileScanner(width, height, tileSize int, img *image.RGBA) *TileScanner {
+	ts := &TileScanner{TileSize: tileSize, Image: img, winding: true}
+	ts.SetBounds(width, height)
+	return ts
+}
+
+// SetBounds sets the size of the image and calls Clear
+func (ts *TileScanner) SetBounds(width, height int) {
+	ts.width, ts.height = width, height
+	ts.Clear()
+}
+
+// SetClip sets an optional clipping rectangle to restrict rendering only to
+// that region -- if size is 0 then ignored (set to image.ZR to clear)
+func (ts *TileScanner) SetClip(rect image.Rectangle) {
+	ts.clip = rect
+}
+
+// SetWinding sets the use of the non-zero winding rule, vs. even-odd
+func (ts *TileScanner) SetWinding(useNonZeroWinding bool) {
+	ts.winding = useNonZeroWinding
+}
+
+// SetColor sets the color or rasterx.ColorFunc to paint with
+func (ts *TileScanner) SetColor(clr interface{}) {
+	ts.color = clr
+}
+
+// set updates the path extent for point a
+func (ts *TileScanner) set(a fixed.Point26_6) {
+	if ts.maxX < a.X {
+		ts.maxX = a.X
+	}
+	if ts.maxY < a.Y {
+		ts.maxY = a.Y
+	}
+	if ts.minX > a.X {
+		ts.minX = a.X
+	}
+	if ts.minY > a.Y {
+		ts.minY = a.Y
+	}
+}
+
+// Start starts a new curve at the given point
+func (ts *TileScanner) Start(a fixed.Point26_6) {
+	ts.set(a)
+	ts.pen = a
+}
+
+// Line adds a line segment to the current curve
+func (ts *TileScanner) Line(b fixed.Point26_6) {
+	ts.set(b)
+	ts.segs = append(ts.segs, tileSeg{ts.pen, b})
+	ts.pen = b
+}
+
+// GetPathExtent returns the extent of the path
+func (ts *TileScanner) GetPathExtent() fixed.Rectangle26_6 {
+	return fixed.Rectangle26_6{Min: fixed.Point26_6{X: ts.minX, Y: ts.minY}, Max: fixed.Point26_6{X: ts.maxX, Y: ts.maxY}}
+}
+
+// Clear clears the recorded path
+func (ts *TileScanner) Clear() {
+	ts.pen = fixed.Point26_6{}
+	ts.segs = ts.segs[:0]
+	const mxfi = fixed.Int26_6(math.MaxInt32)
+	ts.minX, ts.minY, ts.maxX, ts.maxY = mxfi, mxfi, -mxfi, -mxfi
+}
+
+// tileOrigin returns the origin of the scanner for the tile starting at
+// given pixel: one pixel before the tile, except at 0, which is the origin
+// of the whole image -- the extra guard pixel receives any coverage of the
+// pixels just before the origin, which scanFT.ScannerFT puts in the first
+// pixel, as it does not handle negative coordinates
+func tileOrigin(st int) int {
+	if st == 0 {
+		return 0
+	}
+	return st - 1
+}
+
+// tileRows returns the range of y coordinates of the rows of the scanner
+// for the tile starting at given pixel row, with tiles of size sz: segments
+// entirely outside of the range do not cover any rows
+func tileRows(st, sz int) (top, bot fixed.Int26_6) {
+	oy := tileOrigin(st)
+	return fixed.Int26_6((oy - 1) * 64), fixed.Int26_6((oy + sz + 1) * 64)
+}
+
+// Draw rasterizes the recorded path in all the tiles that it covers,
+// concurrently
+func (ts *TileScanner) Draw() {
+	if len(ts.segs) == 0 || ts.Image == nil {
+		return
+	}
+	sz := ts.TileSize
+	if sz <= 0 {
+		sz = DefaultTileSize
+	}
+	ext := image.Rect(ts.minX.Floor(), ts.minY.Floor(), ts.maxX.Ceil(), ts.maxY.Ceil())
+	clip := image.Rect(0, 0, ts.width, ts.height).Intersect(ts.Image.Bounds())
+	if ts.clip.Size() != image.ZP {
+		clip = clip.Intersect(ts.clip)
+	}
+	ext = ext.Intersect(clip)
+	if ext.Empty() {
+		return
+	}
+	tx0, ty0 := ext.Min.X/sz, ext.Min.Y/sz
+	tx1, ty1 := (ext.Max.X-1)/sz, (ext.Max.Y-1)/sz
+	nbands := ty1 - ty0 + 1
+	ntiles := (tx1 - tx0 + 1) * nbands
+
+	// segments are binned into the bands of tiles whose rows they cover
+	if cap(ts.bands) < nbands {
+		ts.bands = make([][]int32, nbands)
+	}
+	ts.bands = ts.bands[:nbands]
+	for bi := range ts.bands {
+		top, bot := tileRows((ty0+bi)*sz, sz)
+		band := ts.bands[bi][:0]
+		for si, sg := range ts.segs {
+			if (sg.a.Y <= top && sg.b.Y <= top) || (sg.a.Y >= bot && sg.b.Y >= bot) {
+				continue
+			}
+			band = append(band, int32(si))
+		}
+		ts.bands[bi] = band
+	}
+
+	nw := ts.Workers
+	if nw <= 0 {
+		nw = runtime.GOMAXPROCS(0)
+	}
+	if nw > ntiles {
+		nw = ntiles
+	}
+	if ts.scanSize != sz {
+		ts.workers = nil
+		ts.scanSize = sz
+	}
+	for len(ts.workers) < nw {
+		ts.workers = append(ts.workers, &tileWorker{s: scanFT.NewScannerFT(sz+1, sz+1, scanFT.NewRGBAPainter(ts.Image))})
+	}
+	tile := func(tw *tileWorker, ti int) {
+		tx, bi := tx0+ti%(tx1-tx0+1), ti/(tx1-tx0+1)
+		tr := image.Rect(tx*sz, (ty0+bi)*sz, (tx+1)*sz, (ty0+bi+1)*sz).Intersect(clip)
+		if tr.Empty() {
+			return
+		}
+		ts.drawTile(tw, tr, sz, ts.bands[bi])
+	}
+	if nw == 1 {
+		for ti := 0; ti < ntiles; ti++ {
+			tile(ts.workers[0], ti)
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	next := int32(-1)
+	for w := 0; w < nw; w++ {
+		wg.Add(1)
+		go func(tw *tileWorker) {
+			defer wg.Done()
+			for {
+				ti := int(atomic.AddInt32(&next, 1))
+				if ti >= ntiles {
+					return
+				}
+				tile(tw, ti)
+			}
+		}(ts.workers[w])
+	}
+	wg.Wait()
+}
+
+// tileWorker has the scanner and buffers for rasterizing one tile at a time
+type tileWorker struct {
+	s     *scanFT.ScannerFT
+	edges [2][]int32 `desc:"changes in the number of times the left and right tile edges are covered, in the direction of increasing y, at each y from the top of the scanner (see drawTile)"`
+}
+
+// drawTile rasterizes the given segments of the path into tile rectangle
+// tr of the image, with tiles of size sz
+func (ts *TileScanner) drawTile(tw *tileWorker, tr image.Rectangle, sz int, band []int32) {
+	s := tw.s
+	if s.Pntr.Image != ts.Image {
+		s.Pntr.Image = ts.Image
+	}
+	ox, oy := tileOrigin(tr.Min.X), tileOrigin(tr.Min.Y)
+	s.Clear()
+	s.Dx, s.Dy = ox, oy
+	s.SetClip(tr)
+	s.SetWinding(ts.winding)
+	s.SetColor(ts.color)
+	off := fixed.Point26_6{X: fixed.Int26_6(ox * 64), Y: fixed.Int26_6(oy * 64)}
+	started := false
+	var pen fixed.Point26_6
+	line := func(a, b fixed.Point26_6) {
+		a, b = a.Sub(off), b.Sub(off)
+		if !started || a != pen {
+			s.Start(a)
+			started = true
+		}
+		s.Line(b)
+		pen = b
+	}
+
+	// segments entirely left or right of the tile give the same coverage of
+	// its pixels as vertical lines on its left or right edge (outside of
+	// it), at the same y, and segments outside of its rows give none -- so
+	// the vertical lines on each edge are added up, and replaced with as few
+	// lines as give the same total coverage
+	top, bot := tileRows(tr.Min.Y, sz)
+	ex := [2]fixed.Int26_6{off.X - 64, off.X + fixed.Int26_6((sz+1)*64)}
+	ne := int(bot-top) + 1
+	for ei := range tw.edges {
+		if len(tw.edges[ei]) != ne {
+			tw.edges[ei] = make([]int32, ne)
+		}
+	}
+	for _, si := range band {
+		sg := ts.segs[si]
+		if (sg.a.Y <= top && sg.b.Y <= top) || (sg.a.Y >= bot && sg.b.Y >= bot) {
+			continue
+		}
+		ei := 0
+		switch {
+		case sg.a.X <= ex[0] && sg.b.X <= ex[0]:
+		case sg.a.X >= ex[1] && sg.b.X >= ex[1]:
+			ei = 1
+		default:
+			line(sg.a, sg.b)
+			continue
+		}
+		y0, y1 := clampFixed(sg.a.Y, top, bot)-top, clampFixed(sg.b.Y, top, bot)-top
+		if y0 < y1 {
+			tw.edges[ei][y0]++
+			tw.edges[ei][y1]--
+		} else if y0 > y1 {
+			tw.edges[ei][y1]--
+			tw.edges[ei][y0]++
+		}
+	}
+	for ei, edges := range tw.edges {
+		n, st := int32(0), 0 // number of times covered, with sign for direction, since st
+		for y, d := range edges {
+			if d == 0 {
+				continue
+			}
+			edges[y] = 0
+			if n != 0 {
+				a := fixed.Point26_6{X: ex[ei], Y: top + fixed.Int26_6(st)}
+				b := fixed.Point26_6{X: ex[ei], Y: top + fixed.Int26_6(y)}
+				if n < 0 {
+					a, b = b, a
+				}
+				for k := int32(0); k < n || k < -n; k++ {
+					line(a, b)
+				}
+			}
+			n += d
+			st = y
+		}
+	}
+	s.Draw()
+}
+
+// clampFixed returns v clamped to the range [min, max]
+func clampFixed(v, min, max fixed.Int26_6) fixed.Int26_6 {
+	switch {
+	case v < min:
+		return min
+	case v > max:
+		return max
+	}
+	return v
+}
+
+// rasterizer returns the rasterizer to use for rendering: Raster, or
+// TileRaster if TileSize is set, which is made as needed
+func (rs *RenderState) rasterizer() *rasterx.Dasher {
+	if rs.TileSize <= 0 || rs.Image == nil {
+		return rs.Raster
+	}
+	sz := rs.Image.Bounds().Size()
+	if rs.TileScanner == nil || rs.TileScanner.Image != rs.Image {
+		rs.TileScanner = NewTileScanner(sz.X, sz.Y, rs.TileSize, rs.Image)
+		rs.TileRaster = rasterx.NewDasher(sz.X, sz.Y, rs.TileScanner)
+	}
+	rs.TileScanner.TileSize = rs.TileSize
+	return rs.TileRaster
+}
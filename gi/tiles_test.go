@@ -0,0 +1,76 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+// renderTileScene renders random filled and stroked shapes, some extending
+// outside of the image, with tiles of given size (0 = serial), rasterized by
+// given number of workers
+func renderTileScene(tileSize, workers int, bounds image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	rs := &RenderState{}
+	rs.Init(300, 200, img)
+	rs.TileSize = tileSize
+	if tileSize > 0 {
+		rs.rasterizer()
+		rs.TileScanner.Workers = workers
+	}
+	rs.Bounds = bounds
+	pc := &rs.Paint
+	rnd := rand.New(rand.NewSource(42))
+	pt := func() (float32, float32) {
+		return rnd.Float32()*400 - 50, rnd.Float32()*300 - 50
+	}
+	for i := 0; i < 40; i++ {
+		pc.FillStyle.Color.SetColor(color.RGBA{uint8(rnd.Intn(256)), uint8(rnd.Intn(256)), 128, 200})
+		pc.StrokeStyle.Color.SetColor(color.RGBA{0, 0, uint8(rnd.Intn(256)), 255})
+		pc.StrokeStyle.Width.Dots = 1 + rnd.Float32()*6
+		pc.FillStyle.Rule = FillRule(i % 2)
+		switch i % 4 {
+		case 0:
+			x, y := pt()
+			pc.DrawCircle(rs, x, y, rnd.Float32()*120)
+		case 1:
+			x, y := pt()
+			pc.DrawRoundedRectangle(rs, x, y, rnd.Float32()*200, rnd.Float32()*150, 10)
+		default:
+			x, y := pt()
+			pc.MoveTo(rs, x, y)
+			for j := 0; j < 6; j++ {
+				x, y = pt()
+				pc.LineTo(rs, x, y)
+			}
+			pc.ClosePath(rs)
+		}
+		pc.FillPreserve(rs)
+		pc.Stroke(rs)
+	}
+	return img
+}
+
+func TestTiledRaster(t *testing.T) {
+	for _, bounds := range []image.Rectangle{image.Rect(0, 0, 300, 200), image.Rect(17, 23, 251, 180)} {
+		serial := renderTileScene(0, 0, bounds)
+		for i, sz := range []int{16, 37, 64, 128} {
+			tiled := renderTileScene(sz, 1+i, bounds)
+			if !bytes.Equal(serial.Pix, tiled.Pix) {
+				n := 0
+				for i := range serial.Pix {
+					if serial.Pix[i] != tiled.Pix[i] {
+						n++
+					}
+				}
+				t.Errorf("tile size %v, bounds %v: tiled rendering differs from serial in %v bytes\n", sz, bounds, n)
+			}
+		}
+	}
+}
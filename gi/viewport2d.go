@@ -12,9 +12,12 @@ import (
 	"image/png"
 	"io"
 	"log"
+	"os"
 	"sync"
 
 	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/mimedata"
+	"github.com/goki/gi/units"
 	"github.com/goki/ki"
 	"github.com/goki/ki/bitflag"
 	"github.com/goki/ki/kit"
@@ -211,6 +214,9 @@ func (vp *Viewport2D) DrawIntoParent(parVp *Viewport2D) {
 		pos := vp.LayData.AllocPos.ToPoint() // get updated pos
 		r = r.Add(pos)
 		draw.Draw(parVp.Pixels, r, vp.Pixels, image.ZP, draw.Over)
+		if pr := parVp.Render.Recorder; pr != nil {
+			vp.recordIntoParent(pr, r, image.ZP)
+		}
 		return
 	}
 	r := vp.Geom.Bounds()
@@ -224,7 +230,14 @@ func (vp *Viewport2D) DrawIntoParent(parVp *Viewport2D) {
 	if Render2DTrace {
 		fmt.Printf("Render: vp DrawIntoParent: %v parVp: %v rect: %v sp: %v\n", vp.PathUnique(), parVp.PathUnique(), r, sp)
 	}
-	draw.Draw(parVp.Pixels, r, vp.Pixels, sp, draw.Over)
+	dst := parVp.Pixels
+	if parVp.Render.Image != nil {
+		dst = parVp.Render.Image // a compositing layer, if one is pushed
+	}
+	draw.Draw(dst, r, vp.Pixels, sp, draw.Over)
+	if pr := parVp.Render.Recorder; pr != nil {
+		vp.recordIntoParent(pr, r, sp)
+	}
 }
 
 // ReRender2DNode re-renders a specific node
@@ -449,6 +462,9 @@ func (vp *Viewport2D) PushBounds() bool {
 		}
 	}
 	rs := &vp.Render
+	if rs.Recorder == nil && vp.IsRecording() { // record as a group in parent recording
+		rs.Recorder = &PaintRecorder{}
+	}
 	bb := vp.Pixels.Bounds() // our bounds.. not vp.VpBBox)
 	rs.PushBounds(bb)
 	if Render2DTrace {
@@ -679,3 +695,93 @@ func (vp *Viewport2D) SavePNG(path string) error {
 func (vp *Viewport2D) EncodePNG(w io.Writer) error {
 	return png.Encode(w, vp.Pixels)
 }
+
+// RecordRender fully re-renders the viewport while recording everything
+// that is painted, returning the recording of it as vector paint ops --
+// this is used for vector graphics output (e.g., EncodeSVG)
+func (vp *Viewport2D) RecordRender() *PaintRecorder {
+	pr := &PaintRecorder{}
+	vp.Render.Recorder = pr
+	vp.FullRender2DTree()
+	vp.Render.Recorder = nil
+	return pr
+}
+
+// EncodeSVG re-renders the viewport, recording it, and writes it as an SVG
+// vector graphics document to the provided io.Writer -- see RecordRender.
+func (vp *Viewport2D) EncodeSVG(w io.Writer) error {
+	if vp.Pixels == nil {
+		return fmt.Errorf("gi.Viewport2D EncodeSVG: viewport %v has not been rendered", vp.Nm)
+	}
+	pr := vp.RecordRender()
+	return pr.EncodeSVG(w, vp.Pixels.Bounds().Size())
+}
+
+// SaveSVG re-renders the viewport, recording it, and saves it as an SVG
+// vector graphics document to given file path.
+func (vp *Viewport2D) SaveSVG(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return vp.EncodeSVG(file)
+}
+
+// EncodePDF re-renders the viewport, recording it, and writes it as a
+// one-page PDF document to the provided io.Writer, with the page the size of
+// the viewport at its DPI -- see RecordRender.
+func (vp *Viewport2D) EncodePDF(w io.Writer) error {
+	if vp.Pixels == nil {
+		return fmt.Errorf("gi.Viewport2D EncodePDF: viewport %v has not been rendered", vp.Nm)
+	}
+	pr := vp.RecordRender()
+	sc := 72 / vp.DPI()
+	sz := vp.Pixels.Bounds().Size()
+	pw := NewPDFWriter(w)
+	pw.AddPage(pr.Ops, Vec2D{float32(sz.X) * sc, float32(sz.Y) * sc}, sc)
+	return pw.Close()
+}
+
+// SavePDF re-renders the viewport, recording it, and saves it as a one-page
+// PDF document to given file path.
+func (vp *Viewport2D) SavePDF(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return vp.EncodePDF(file)
+}
+
+// DPI returns the dots per inch of the viewport rendering, from its window
+// if it has one, and otherwise its style
+func (vp *Viewport2D) DPI() float32 {
+	if vp.Win != nil {
+		return vp.Win.LogicalDPI()
+	}
+	if vp.Sty.UnContext.DPI > 0 {
+		return vp.Sty.UnContext.DPI
+	}
+	return units.PxPerInch
+}
+
+// CopyImage copies the rendered image of the viewport to the clipboard, as
+// image/png data.
+func (vp *Viewport2D) CopyImage() error {
+	if vp.Pixels == nil {
+		return fmt.Errorf("gi.Viewport2D CopyImage: viewport %v has not been rendered", vp.Nm)
+	}
+	md, err := mimedata.NewImage(vp.Pixels)
+	if err != nil {
+		return err
+	}
+	win := oswin.TheApp.ContextWindow()
+	if vp.Win != nil && vp.Win.OSWin != nil {
+		win = vp.Win.OSWin
+	}
+	if win == nil {
+		return fmt.Errorf("gi.Viewport2D CopyImage: no window for clipboard")
+	}
+	return oswin.TheApp.ClipBoard(win).Write(md)
+}
@@ -0,0 +1,26 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi_test
+
+import (
+	"testing"
+
+	"github.com/goki/gi/gitest"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/mimedata"
+)
+
+func TestCopyImage(t *testing.T) {
+	win := gitest.NewWindow("copyimg", 100, 50, nil)
+	defer win.OSWin.Close()
+	if err := win.Viewport.CopyImage(); err != nil {
+		t.Fatal(err)
+	}
+	md := oswin.TheApp.ClipBoard(win.OSWin).Read([]string{mimedata.ImagePNG})
+	img := md.Image()
+	if img == nil || img.Bounds().Size() != win.Viewport.Pixels.Bounds().Size() {
+		t.Errorf("clipboard image: %v, want size: %v\n", img, win.Viewport.Pixels.Bounds().Size())
+	}
+}
@@ -32,6 +32,7 @@ type WidgetBase struct {
 	LayData      LayoutData   `json:"-" xml:"-" desc:"all the layout information for this item"`
 	WidgetSig    ki.Signal    `json:"-" xml:"-" view:"-" desc:"general widget signals supported by all widgets, including select, focus, and context menu (right mouse button) events, which can be used by views and other compound widgets"`
 	CtxtMenuFunc CtxtMenuFunc `view:"-" json:"-" xml:"-" desc:"optional context menu function called by MakeContextMenu AFTER any native items are added -- this function can decide where to insert new elements -- typically add a separator to disambiguate"`
+	LayerPushed  bool         `view:"-" json:"-" xml:"-" desc:"true if a compositing layer was pushed in PushBounds, for an opacity or mix-blend-mode, which PopBounds composites into the viewport -- see HasLayer"`
 }
 
 var KiT_WidgetBase = kit.Types.AddType(&WidgetBase{}, WidgetBaseProps)
@@ -164,7 +165,7 @@ func (wb *WidgetBase) Style2DWidget() {
 		wb.CSSAgg = nil // restart
 	}
 	AggCSS(&wb.CSSAgg, wb.CSS)
-	wb.Sty.StyleCSS(gii, wb.CSSAgg, "", wb.Viewport)
+	wb.StyleCSS(&wb.Sty, "")
 
 	wb.Sty.SetUnitContext(wb.Viewport, Vec2DZero) // todo: test for use of el-relative
 	if wb.Sty.Inactive {                          // inactive can only set, not clear
@@ -173,6 +174,15 @@ func (wb *WidgetBase) Style2DWidget() {
 	wb.Sty.Use(wb.Viewport) // activates currentColor etc
 }
 
+// StyleCSS applies the CSS styles that match this widget to given style,
+// for given state selector (e.g., :hover, or "" for the base style), from
+// the CSS aggregated from this widget and its parents (CSSAgg, set in
+// Style2DWidget)
+func (wb *WidgetBase) StyleCSS(st *Style, selector string) {
+	gii, _ := wb.This().(Node2D)
+	st.StyleCSS(gii, wb.CSSAgg, selector, wb.Viewport)
+}
+
 // StylePart sets the style properties for a child in parts (or any other
 // child) based on its name -- only call this when new parts were created --
 // name of properties is #partname (lower cased) and it should contain a
@@ -349,6 +359,10 @@ func (wb *WidgetBase) PushBounds() bool {
 	}
 	rs := &wb.Viewport.Render
 	rs.PushBounds(wb.VpBBox)
+	wb.LayerPushed = wb.HasLayer()
+	if wb.LayerPushed {
+		rs.PushLayer(wb.VpBBox, wb.Sty.Font.Opacity, wb.Sty.Font.Blend, CompSrcOver)
+	}
 	wb.ConnectToViewport()
 	if Render2DTrace {
 		fmt.Printf("Render: %v at %v\n", wb.PathUnique(), wb.VpBBox)
@@ -364,9 +378,21 @@ func (wb *WidgetBase) PopBounds() {
 		return
 	}
 	rs := &wb.Viewport.Render
+	if wb.LayerPushed {
+		rs.PopLayer()
+		wb.LayerPushed = false
+	}
 	rs.PopBounds()
 }
 
+// HasLayer returns true if the widget is rendered as a whole into a
+// compositing layer, which is then composited into the viewport with its
+// opacity and mix-blend-mode, so that overlapping parts, and children, are
+// not seen through each other
+func (wb *WidgetBase) HasLayer() bool {
+	return wb.Sty.Font.Opacity < 1 || wb.Sty.Font.Blend != BlendNormal
+}
+
 func (wb *WidgetBase) Render2D() {
 	if wb.FullReRenderIfNeeded() {
 		return
@@ -628,37 +654,45 @@ func (wb *WidgetBase) RenderStdBox(st *Style) {
 
 	pos := wb.LayData.AllocPos.AddVal(st.Layout.Margin.Dots)
 	sz := wb.LayData.AllocSize.AddVal(-2.0 * st.Layout.Margin.Dots)
-	rad := st.Border.Radius.Dots
+	rad, urad := st.BorderSides.UniformRadius()
+	op := st.Font.Opacity
+	if wb.LayerPushed && st == &wb.Sty {
+		op = 1 // the layer applies our opacity
+	}
+	pop := pc.FontStyle.Opacity
+	pc.FontStyle.Opacity = op
+	defer func() { pc.FontStyle.Opacity = pop }()
 
 	// first do any shadow
-	if st.BoxShadow.HasShadow() {
-		spos := pos.Add(Vec2D{st.BoxShadow.HOffset.Dots, st.BoxShadow.VOffset.Dots})
-		pc.StrokeStyle.SetColor(nil)
-		pc.FillStyle.Color.SetShadowGradient(st.BoxShadow.Color, "")
-		// todo: this is not rendering a transparent gradient
-		// pc.FillStyle.Opacity = .5
-		wb.RenderBoxImpl(spos, sz, rad)
-		// pc.FillStyle.Opacity = 1.0
+	if st.HasBoxShadow() {
+		wb.RenderBoxShadows(st, pos, sz, rad, false)
 	}
 	// then draw the box over top of that -- note: won't work well for
 	// transparent! need to set clipping to box first..
 	if !st.Font.BgColor.IsNil() {
-		if rad == 0 {
+		if rad == 0 && urad && op >= 1 {
 			pc.FillBox(rs, pos, sz, &st.Font.BgColor)
 		} else {
 			pc.FillStyle.SetColorSpec(&st.Font.BgColor)
-			pc.DrawRoundedRectangle(rs, pos.X, pos.Y, sz.X, sz.Y, rad)
+			switch {
+			case !urad:
+				pc.DrawRoundedRectangleCorners(rs, pos.X, pos.Y, sz.X, sz.Y, st.BorderSides.Radii())
+			case rad == 0:
+				pc.DrawRectangle(rs, pos.X, pos.Y, sz.X, sz.Y)
+			default:
+				pc.DrawRoundedRectangle(rs, pos.X, pos.Y, sz.X, sz.Y, rad)
+			}
 			pc.Fill(rs)
 		}
 	}
+	wb.RenderBackgroundImage(st, pos, sz)
+
+	if st.HasBoxShadow() {
+		bw := st.Border.Width.Dots
+		wb.RenderBoxShadows(st, pos.AddVal(bw), sz.SubVal(2*bw), Max32(rad-bw, 0), true)
+	}
 
-	pc.StrokeStyle.SetColor(&st.Border.Color)
-	pc.StrokeStyle.Width = st.Border.Width
-	// pc.FillStyle.SetColor(&st.Font.BgColor)
-	pos = pos.AddVal(0.5 * st.Border.Width.Dots)
-	sz = sz.SubVal(st.Border.Width.Dots)
-	pc.FillStyle.SetColor(nil)
-	wb.RenderBoxImpl(pos, sz, st.Border.Radius.Dots)
+	wb.RenderBorder(st, pos, sz)
 }
 
 // set our LayData.AllocSize from constraints
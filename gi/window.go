@@ -126,6 +126,8 @@ type Window struct {
 	WinTex            oswin.Texture                           `json:"-" xml:"-" view:"-" desc:"texture for the entire window -- all rendering is done onto this texture, which is then published into the window"`
 	EventSigs         [oswin.EventTypeN][EventPrisN]ki.Signal `json:"-" xml:"-" view:"-" desc:"signals for communicating each type of event, organized by priority"`
 	EventMu           sync.Mutex                              `json:"-" xml:"-" view:"-" desc:"mutex that protects event sending"`
+	EventRec          *EventRecording                         `json:"-" xml:"-" view:"-" desc:"if non-nil, raw input events are being recorded into this recording -- use StartEventRecording, StopEventRecording"`
+	EventRecMu        sync.Mutex                              `json:"-" xml:"-" view:"-" desc:"mutex that protects EventRec"`
 	OverTex           oswin.Texture                           `json:"-" xml:"-" view:"-" desc:"overlay texture that is updated by OverlayVp viewport"`
 	OverlayVp         *Viewport2D                             `json:"-" xml:"-" desc:"a separate collection of items to be rendered as overlays -- this viewport is cleared to transparent and all the elements in it are re-rendered if any of them needs to be updated -- generally each item should be manually positioned"`
 	Sprites           map[string]*Viewport2D                  `json:"-" xml:"-" desc:"sprites are named viewports that are rendered into the overlay.  If they are marked inactive then they are not rendered, otherwise automatically rendered."`
@@ -153,7 +155,13 @@ type Window struct {
 	DelPopup          ki.Ki                                   `json:"-" xml:"-" desc:"this popup will be popped at the end of the current event cycle -- use SetDelPopup"`
 	PopMu             sync.RWMutex                            `json:"-" xml:"-" view:"-" desc:"read-write mutex that protects popup updating and access"`
 	TimerMu           sync.Mutex                              `json:"-" xml:"-" view:"-" desc:"mutex that protects timer variable updates (e.g., hover AfterFunc's)"`
+	FrameClock        FrameClock                              `json:"-" xml:"-" desc:"frame clock that runs animation frame callbacks and coalesces publishing -- use RequestAnimationFrame"`
+	Damage            DamageRegion                            `json:"-" xml:"-" desc:"regions of the window texture that have been updated since the last publish, in window coordinates -- Publish only copies these to the window, when the window preserves its contents between publishes"`
+	DamageStats       DamageStats                             `json:"-" xml:"-" desc:"statistics on the uploading and publishing of damaged regions -- updated under UpMu: use ResetDamageStats and DamageStatsSnapshot while the window is running"`
 	lastWinMenuUpdate time.Time
+	pendUploads       map[*Viewport2D]*vpDamage
+	publishFull       bool
+	overPublished     bool
 }
 
 var KiT_Window = kit.Types.AddType(&Window{}, nil)
@@ -265,6 +273,9 @@ func NewWindow(name, title string, opts *oswin.NewWindowOptions) *Window {
 		fmt.Printf("GoGi NewTexture error: %v \n", err)
 		return nil
 	}
+	if sc := win.OSWin.Screen(); sc != nil {
+		win.OSWin.SetLogicalDPI(Prefs.ScreenLogicalDPI(sc))
+	}
 	win.OSWin.SetName(title)
 	win.OSWin.SetParent(win.This())
 	win.NodeSig.Connect(win.This(), SignalWindowPublish)
@@ -492,10 +503,9 @@ func (w *Window) LogicalDPI() float32 {
 // in increments of 6 dots to keep fonts rendering clearly.
 func (w *Window) ZoomDPI(steps int) {
 	w.InactivateAllSprites()
-	sc := oswin.TheApp.Screen(0)
-	pdpi := sc.PhysicalDPI
+	pdpi := w.OSWin.Screen().PhysicalDPI
 	// ldpi = pdpi * zoom * ldpi
-	cldpinet := sc.LogicalDPI
+	cldpinet := w.LogicalDPI()
 	cldpi := cldpinet / ZoomFactor
 	nldpinet := cldpinet + float32(6*steps)
 	if nldpinet < 6 {
@@ -507,6 +517,28 @@ func (w *Window) ZoomDPI(steps int) {
 	w.FullReRender()
 }
 
+// ScreenChanged is called when the window has moved to a different screen,
+// or its screen has changed (e.g., resolution or DPI) -- applies the
+// ScreenPrefs for the screen to the window (the screen is shared with the
+// driver and is not changed), and re-renders with the new LogicalDPI if it
+// has changed, which re-styles everything.
+func (w *Window) ScreenChanged() {
+	if w.OSWin == nil {
+		return
+	}
+	sc := w.OSWin.Screen()
+	if sc == nil {
+		return
+	}
+	ldpi := Prefs.ScreenLogicalDPI(sc)
+	if w.OSWin.LogicalDPI() == ldpi {
+		return
+	}
+	w.InactivateAllSprites()
+	w.OSWin.SetLogicalDPI(ldpi)
+	w.FullReRender()
+}
+
 // WinViewport2D returns the viewport directly under this window that serves
 // as the master viewport for the entire window.
 func (w *Window) WinViewport2D() *Viewport2D {
@@ -778,6 +810,14 @@ func (w *Window) SendWinFocusEvent(act window.Actions) {
 	w.SendEventSignal(&se, true) // popup = true by default
 }
 
+// SendWinStateEvent sends the WindowStateEvent to widgets
+func (w *Window) SendWinStateEvent() {
+	se := window.StateEvent{}
+	se.Action = window.StateChange
+	se.Init()
+	w.SendEventSignal(&se, true) // popup = true by default
+}
+
 // FullReRender performs a full re-render of the window -- each node renders
 // into its viewport, aggregating into the main window viewport, which will
 // drive an UploadAllViewports call after all the rendering is done, and
@@ -797,7 +837,9 @@ func (w *Window) FullReRender() {
 // UploadVpRegion uploads image for one viewport region on the screen, using
 // vpBBox bounding box for the viewport, and winBBox bounding box for the
 // window -- called after re-rendering specific nodes to update only the
-// relevant part of the overall viewport image
+// relevant part of the overall viewport image.  During a frame (see
+// FrameClock), the upload is deferred to the Publish at the end of the
+// frame, merged with the other regions updated in the frame.
 func (w *Window) UploadVpRegion(vp *Viewport2D, vpBBox, winBBox image.Rectangle) {
 	if !w.IsVisible() {
 		return
@@ -812,7 +854,9 @@ func (w *Window) UploadVpRegion(vp *Viewport2D, vpBBox, winBBox image.Rectangle)
 	if Render2DTrace {
 		fmt.Printf("Window: %v uploading region Vp %v, vpbbox: %v, wintex bounds: %v\n", w.PathUnique(), vp.PathUnique(), vpBBox, w.WinTex.Bounds())
 	}
-	w.WinTex.Upload(winBBox.Min, vp.OSImage, vpBBox)
+	if !w.deferUpload(vp, vpBBox, winBBox) {
+		w.uploadRegion(vp, vpBBox, winBBox)
+	}
 	pr.End()
 	w.ClearWinUpdating()
 	w.UpMu.Unlock()
@@ -835,7 +879,11 @@ func (w *Window) UploadVp(vp *Viewport2D, offset image.Point) {
 	if Render2DTrace {
 		fmt.Printf("Window: %v uploading Vp %v, image bound: %v, wintex bounds: %v\n", w.PathUnique(), vp.PathUnique(), vp.OSImage.Bounds(), w.WinTex.Bounds())
 	}
-	w.WinTex.Upload(offset, vp.OSImage, vp.OSImage.Bounds())
+	vpBBox := vp.OSImage.Bounds()
+	winBBox := vpBBox.Add(offset)
+	if !w.deferUpload(vp, vpBBox, winBBox) {
+		w.uploadRegion(vp, vpBBox, winBBox)
+	}
 	pr.End()
 	w.ClearWinUpdating()
 	w.UpMu.Unlock()
@@ -846,6 +894,18 @@ func (w *Window) UploadVp(vp *Viewport2D, offset image.Point) {
 // proper order, so as to completely refresh the window texture based on
 // everything rendered
 func (w *Window) UploadAllViewports() {
+	if !w.IsVisible() {
+		return
+	}
+	w.UploadViewportsRegion(w.Viewport.OSImage.Bounds())
+}
+
+// UploadViewportsRegion uploads given region of the window (in window
+// coordinates) from all active viewports, in the proper order: the main
+// viewport and then the popups over it -- e.g., to restore the region
+// under a popup that has been closed.  During a frame (see FrameClock), the
+// upload is deferred to the Publish at the end of the frame.
+func (w *Window) UploadViewportsRegion(r image.Rectangle) {
 	if !w.IsVisible() {
 		return
 	}
@@ -858,9 +918,19 @@ func (w *Window) UploadAllViewports() {
 	pr := prof.Start("win.UploadAllViewports")
 	updt := w.UpdateStart()
 	if Render2DTrace {
-		fmt.Printf("Window: %v uploading full Vp, image bound: %v, wintex bounds: %v\n", w.PathUnique(), w.Viewport.OSImage.Bounds(), w.WinTex.Bounds())
+		fmt.Printf("Window: %v uploading Vps region: %v, image bound: %v, wintex bounds: %v\n", w.PathUnique(), r, w.Viewport.OSImage.Bounds(), w.WinTex.Bounds())
+	}
+	upVp := func(vp *Viewport2D, pos image.Point) {
+		wr := r.Intersect(vp.OSImage.Bounds().Add(pos))
+		if wr.Empty() {
+			return
+		}
+		vr := wr.Sub(pos)
+		if !w.deferUpload(vp, vr, wr) {
+			w.uploadRegion(vp, vr, wr)
+		}
 	}
-	w.WinTex.Upload(image.ZP, w.Viewport.OSImage, w.Viewport.OSImage.Bounds())
+	upVp(w.Viewport, image.ZP)
 	// then all the current popups
 	w.PopMu.RLock()
 	// fmt.Printf("upload all views pop locked: %v\n", w.Nm)
@@ -869,11 +939,10 @@ func (w *Window) UploadAllViewports() {
 			gii, _ := KiToNode2D(pop)
 			if gii != nil {
 				vp := gii.AsViewport2D()
-				r := vp.Geom.Bounds()
 				if Render2DTrace {
-					fmt.Printf("Window: %v uploading popup stack Vp %v, image bound: %v, wintex bounds: %v\n", w.PathUnique(), vp.PathUnique(), r.Min, vp.OSImage.Bounds())
+					fmt.Printf("Window: %v uploading popup stack Vp %v, image bound: %v, wintex bounds: %v\n", w.PathUnique(), vp.PathUnique(), vp.Geom.Pos, vp.OSImage.Bounds())
 				}
-				w.WinTex.Upload(r.Min, vp.OSImage, vp.OSImage.Bounds())
+				upVp(vp, vp.Geom.Pos)
 			}
 		}
 	}
@@ -881,11 +950,10 @@ func (w *Window) UploadAllViewports() {
 		gii, _ := KiToNode2D(w.Popup)
 		if gii != nil {
 			vp := gii.AsViewport2D()
-			r := vp.Geom.Bounds()
 			if Render2DTrace {
-				fmt.Printf("Window: %v uploading top popup Vp %v, image bound: %v, wintex bounds: %v\n", w.PathUnique(), vp.PathUnique(), r.Min, vp.OSImage.Bounds())
+				fmt.Printf("Window: %v uploading top popup Vp %v, image bound: %v, wintex bounds: %v\n", w.PathUnique(), vp.PathUnique(), vp.Geom.Pos, vp.OSImage.Bounds())
 			}
-			w.WinTex.Upload(r.Min, vp.OSImage, vp.OSImage.Bounds())
+			upVp(vp, vp.Geom.Pos)
 		}
 	}
 	w.PopMu.RUnlock()
@@ -912,7 +980,10 @@ func (w *Window) ClearWinUpdating() {
 }
 
 // Publish does the final step of updating of the window based on the current
-// texture (and overlay texture if active)
+// texture (and overlay texture if active) -- any deferred uploads are done
+// first, and then only the damaged regions of the texture are copied to the
+// window, if its contents were preserved after the last publish (see
+// DamageAll)
 func (w *Window) Publish() {
 	if !w.IsVisible() || w.OSWin.IsMinimized() {
 		// fmt.Printf("skipping update on inactive / minimized window: %v\n", w.Nm)
@@ -931,14 +1002,33 @@ func (w *Window) Publish() {
 
 	w.SetWinUpdating()
 	// fmt.Printf("Win %v doing publish\n", w.Nm)
+	w.uploadPending()
 	pr := prof.Start("win.Publish.Copy")
-	w.OSWin.Copy(image.ZP, w.WinTex, w.WinTex.Bounds(), oswin.Src, nil)
-	if w.OverTex != nil && w.HasFlag(int(WinFlagOverTexActive)) {
-		w.OSWin.Copy(image.ZP, w.OverTex, w.OverTex.Bounds(), oswin.Over, nil)
+	over := w.OverTex != nil && w.HasFlag(int(WinFlagOverTexActive))
+	// the overlay is not tracked, so it is always published in full, and
+	// once more after it is no longer active, to clear it
+	full := w.publishFull || over || w.overPublished
+	w.DamageStats.Publishes++
+	if full {
+		w.OSWin.Copy(image.ZP, w.WinTex, w.WinTex.Bounds(), oswin.Src, nil)
+		if over {
+			w.OSWin.Copy(image.ZP, w.OverTex, w.OverTex.Bounds(), oswin.Over, nil)
+		}
+		w.DamageStats.FullPublishes++
+		w.DamageStats.PublishPixels += rectArea(w.WinTex.Bounds())
+	} else {
+		for _, r := range w.Damage.Rects {
+			r = r.Intersect(w.WinTex.Bounds())
+			w.OSWin.Copy(r.Min, w.WinTex, r, oswin.Src, nil)
+			w.DamageStats.PublishPixels += rectArea(r)
+		}
 	}
+	w.Damage.Reset()
+	w.overPublished = over
 	pr.End()
 	pr2 := prof.Start("win.Publish.Publish")
-	w.OSWin.Publish()
+	res := w.OSWin.Publish()
+	w.publishFull = !res.BackImagePreserved
 	pr2.End()
 	w.ClearWinUpdating()
 	w.UpMu.Unlock()
@@ -954,6 +1044,9 @@ func SignalWindowPublish(winki, node ki.Ki, sig int64, data interface{}) {
 	if !win.IsVisible() || win.IsResizing() || win.IsWinUpdating() {
 		return
 	}
+	if win.deferPublish() {
+		return
+	}
 	win.Publish()
 }
 
@@ -1251,6 +1344,18 @@ mainloop:
 			fmt.Println("stop event loop")
 			break
 		}
+		if se, ok := evi.(*eventSync); ok {
+			if se.fun != nil {
+				se.fun()
+			}
+			close(se.done)
+			continue
+		}
+		if _, ok := evi.(*frameTick); ok {
+			w.runFrame()
+			continue
+		}
+		w.recordEvent(evi)
 		et := evi.Type()
 		delPop := false                      // if true, delete this popup after event loop
 		if et > oswin.EventTypeN || et < 0 { // we don't handle other types of events here
@@ -1289,6 +1394,7 @@ mainloop:
 				if WinEventTrace {
 					fmt.Printf("Win: %v skipping paint after resize\n", w.Nm)
 				}
+				w.DamageAll()
 				w.Publish() // this is essential on mac for any paint event
 				w.SetFlag(int(WinFlagGotPaint))
 				continue // X11 always sends a paint after a resize -- we just use resize
@@ -1520,6 +1626,7 @@ mainloop:
 					}
 					w.SendShowEvent() // happens AFTER full render
 				}
+				w.DamageAll() // window contents may have been lost
 				w.Publish()
 			case window.Move:
 				e.SetProcessed()
@@ -1543,6 +1650,10 @@ mainloop:
 				// fmt.Printf("win de-foc: %v\n", w.Nm)
 				w.ClearFlag(int(WinFlagGotFocus))
 				w.SendWinFocusEvent(window.DeFocus)
+			case window.ScreenUpdate:
+				w.ScreenChanged()
+			case window.StateChange:
+				w.SendWinStateEvent()
 			}
 			continue // don't do anything else!
 		case *mouse.DragEvent:
@@ -1595,6 +1706,10 @@ mainloop:
 			if keyDelPop {
 				delPop = true
 			}
+		case *dnd.MoveEvent: // internal dnd events are sent directly, so these are from other apps
+			w.DNDExtMoveEvent(e)
+		case *dnd.Event:
+			w.DNDExtEvent(e)
 		}
 
 		////////////////////////////////////////////////////////////////////////////
@@ -2026,16 +2141,12 @@ func (w *Window) SendKeyFunEvent(kf KeyFuns, popup bool) {
 	if chord == "" {
 		return
 	}
-	r, mods, err := chord.Decode()
+	ke, err := chord.ChordEvent()
 	if err != nil {
 		return
 	}
-	ke := key.ChordEvent{}
 	ke.SetTime()
-	ke.Modifiers = mods
-	ke.Rune = r
-	ke.Action = key.Press
-	w.SendEventSignal(&ke, popup)
+	w.SendEventSignal(ke, popup)
 }
 
 // AddShortcut adds given shortcut -- will issue warning about conflicting
@@ -2252,7 +2363,11 @@ func (w *Window) ClosePopup(pop ki.Ki) bool {
 	if popped {
 		w.PopFocus()
 	}
-	w.UploadAllViewports()
+	if gii, _ := KiToNode2D(pop); gii != nil {
+		w.UploadViewportsRegion(gii.AsViewport2D().Geom.Bounds()) // restore what was under it
+	} else {
+		w.UploadAllViewports()
+	}
 	return true
 }
 
@@ -2755,6 +2870,15 @@ func (w *Window) DNDStartEvent(e *mouse.DragEvent) {
 	// now up to receiver to call StartDragNDrop if they want to..
 }
 
+// SetIMECursor tells the input method (IME) where the text cursor of the
+// focused widget is, in window coordinates, for positioning its candidate
+// window -- an empty rectangle means that text input has ended
+func (w *Window) SetIMECursor(r image.Rectangle) {
+	if w.OSWin != nil {
+		w.OSWin.SetIMECursor(r)
+	}
+}
+
 // StartDragNDrop is called by a node to start a drag-n-drop operation on
 // given source node, which is responsible for providing the data and image
 // representation of the node.
@@ -2772,6 +2896,7 @@ func (w *Window) StartDragNDrop(src ki.Ki, data mimedata.Mimes, img Node2D) {
 	w.AddOverlay(img)
 	w.DNDImage = wimg.This()
 	w.DNDSetCursor(dnd.DefaultModBits(w.LastModBits))
+	w.OSWin.StartDragNDrop(data) // so it can be dragged out to other apps
 	// fmt.Printf("starting dnd: %v\n", src.Name())
 }
 
@@ -2782,8 +2907,7 @@ func (w *Window) DNDMoveEvent(e *mouse.DragEvent) {
 			wg.LayData.AllocPos.SetPoint(e.Where)
 		}
 	} // else 3d..
-	// todo: when e.Where goes negative, transition to OS DND
-	// todo: send move / enter / exit events to anyone listening
+	// note: the OS driver takes over when the mouse is over another app
 	de := dnd.MoveEvent{Event: dnd.Event{EventBase: e.Event.EventBase, Where: e.Event.Where, Modifiers: e.Event.Modifiers}, From: e.From, LastTime: e.LastTime}
 	de.Processed = false
 	de.DefaultMod() // based on current key modifiers
@@ -2897,6 +3021,36 @@ func (w *Window) FinalizeDragNDrop(action dnd.DropMods) {
 	w.DNDFinalEvent = nil
 }
 
+// DNDExtMoveEvent handles drag-n-drop move events from other applications,
+// which are delivered by the OS driver -- an Action of dnd.Exit means that
+// the drag has left the window.
+func (w *Window) DNDExtMoveEvent(e *dnd.MoveEvent) {
+	if e.Action != dnd.Exit {
+		w.SendEventSignal(e, false) // popup = false: ignore any popups
+	}
+	w.GenDNDFocusEvents(e, false)
+	if e.Action == dnd.Exit {
+		w.DNDClearCursor()
+	}
+	e.SetProcessed()
+}
+
+// DNDExtEvent handles drag-n-drop events from other applications, delivered
+// by the OS driver: DropOnTarget for data dropped onto this window (with a
+// nil Source), and DropFmSource when our data was dropped onto another app,
+// which finalizes our drag-n-drop with the action taken by that app.
+func (w *Window) DNDExtEvent(e *dnd.Event) {
+	switch e.Action {
+	case dnd.DropOnTarget:
+		w.SendEventSignal(e, false) // popup = false: ignore any popups
+		w.GenDNDFocusEvents(&dnd.MoveEvent{Event: dnd.Event{Where: image.Point{-1, -1}}}, false)
+		w.DNDClearCursor()
+	case dnd.DropFmSource:
+		w.FinalizeDragNDrop(e.Mod)
+	}
+	e.SetProcessed()
+}
+
 // ClearDragNDrop clears any existing DND values.
 func (w *Window) ClearDragNDrop() {
 	w.DNDSource = nil
@@ -3042,6 +3196,7 @@ func (w *Window) BenchmarkReRender() {
 	fmt.Println("Starting BenchmarkReRender")
 	w.ReportWinNodes()
 	StartTargProfile()
+	w.ResetDamageStats()
 	ts := time.Now()
 	n := 50
 	for i := 0; i < n; i++ {
@@ -3049,6 +3204,8 @@ func (w *Window) BenchmarkReRender() {
 	}
 	td := time.Now().Sub(ts)
 	fmt.Printf("Time for %v Re-Renders: %12.2f s\n", n, float64(td)/float64(time.Second))
+	ds := w.DamageStatsSnapshot()
+	fmt.Printf("Damage: %v\n", ds.String())
 	EndTargProfile()
 }
 
@@ -3335,9 +3492,9 @@ func (wg *WindowGeomPrefs) RecordPref(win *Window) {
 	wgr := WindowGeom{WinName: winName, Screen: sc.Name, LogicalDPI: win.LogicalDPI()}
 	wgr.Pos = win.OSWin.Position()
 	wgr.Size = win.OSWin.Size()
-	if wgr.Size == image.ZP {
+	if wgr.Size == image.ZP || win.OSWin.IsMaximized() || win.OSWin.IsFullscreen() {
 		WinGeomPrefsMu.Unlock()
-		// fmt.Printf("Pref: NOT storing null size for win: %v scrn: %v\n", winName, sc.Name)
+		// fmt.Printf("Pref: NOT storing null size or filled screen for win: %v scrn: %v\n", winName, sc.Name)
 		return
 	}
 
@@ -3380,17 +3537,18 @@ func (wg *WindowGeomPrefs) Pref(winName string, scrn *oswin.Screen) *WindowGeom
 		// fmt.Printf("Pref: using scrn 0: %v\n", scrn.Name)
 	}
 	scsz := scrn.Geometry.Size()
+	trgdpi := Prefs.ScreenLogicalDPI(scrn)
 
 	wp, ok := wps[scrn.Name]
 	if ok {
-		if scrn.LogicalDPI == wp.LogicalDPI {
+		if trgdpi == wp.LogicalDPI {
 			wp.Size.X = ints.MinInt(wp.Size.X, scsz.X)
 			wp.Size.Y = ints.MinInt(wp.Size.Y, scsz.Y)
 			return &wp
 		} else {
-			// fmt.Printf("rescaling scrn dpi: %v saved dpi: %v\n", scrn.LogicalDPI, wp.LogicalDPI)
-			wp.Size.X = int(float32(wp.Size.X) * (scrn.LogicalDPI / wp.LogicalDPI))
-			wp.Size.Y = int(float32(wp.Size.Y) * (scrn.LogicalDPI / wp.LogicalDPI))
+			// fmt.Printf("rescaling scrn dpi: %v saved dpi: %v\n", trgdpi, wp.LogicalDPI)
+			wp.Size.X = int(float32(wp.Size.X) * (trgdpi / wp.LogicalDPI))
+			wp.Size.Y = int(float32(wp.Size.Y) * (trgdpi / wp.LogicalDPI))
 			wp.Size.X = ints.MinInt(wp.Size.X, scsz.X)
 			wp.Size.Y = ints.MinInt(wp.Size.Y, scsz.Y)
 			return &wp
@@ -3401,7 +3559,6 @@ func (wg *WindowGeomPrefs) Pref(winName string, scrn *oswin.Screen) *WindowGeom
 		return nil
 	}
 
-	trgdpi := scrn.LogicalDPI
 	// fmt.Printf("Pref: falling back on dpi conversion: %v\n", trgdpi)
 
 	// try to find one with same logical dpi, else closest
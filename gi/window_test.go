@@ -0,0 +1,86 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gi_test
+
+import (
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/gitest"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/offscreen"
+	"github.com/goki/gi/oswin/window"
+	"github.com/goki/ki"
+)
+
+// addLabel adds a label with given name and text
+func addLabel(mfr *gi.Frame, name, text string) *gi.Label {
+	lbl := mfr.AddNewChild(gi.KiT_Label, name).(*gi.Label)
+	lbl.SetText(text)
+	return lbl
+}
+
+func TestScreenDPI(t *testing.T) {
+	au := gitest.NewWindowAuto(t, "dpi", 200, 100, func(mfr *gi.Frame) {
+		addLabel(mfr, "lbl", "DPI")
+	})
+	defer au.Close()
+	defer offscreen.SetScreens(offscreen.DefaultScreen)
+	win := au.Win
+
+	odpi := win.LogicalDPI()
+	hidpi := offscreen.DefaultScreen
+	hidpi.PhysicalDPI = 2 * odpi
+	hidpi.LogicalDPI = 0
+	offscreen.SetScreens(hidpi)
+	au.Wait()
+	sdpi := gi.Prefs.ScreenLogicalDPI(win.OSWin.Screen())
+	if ldpi := win.LogicalDPI(); ldpi <= odpi || ldpi != sdpi {
+		t.Errorf("LogicalDPI after screen change: %v, was: %v, screen prefs: %v\n", ldpi, odpi, sdpi)
+	}
+
+	// zooming sets the window DPI, without changing the shared screen
+	scdpi := win.OSWin.Screen().LogicalDPI
+	defer func() { gi.ZoomFactor = 1 }()
+	au.Do(func() {
+		gi.ZoomFactor = 2
+		gi.Prefs.ApplyDPI()
+	})
+	if ldpi := win.LogicalDPI(); ldpi != gi.Prefs.ScreenLogicalDPI(win.OSWin.Screen()) || ldpi <= sdpi {
+		t.Errorf("LogicalDPI after zoom: %v, was: %v\n", ldpi, sdpi)
+	}
+	if sc := win.OSWin.Screen(); sc.LogicalDPI != scdpi {
+		t.Errorf("screen LogicalDPI changed by ApplyDPI: %v, was: %v\n", sc.LogicalDPI, scdpi)
+	}
+}
+
+func TestWindowState(t *testing.T) {
+	var lbl *gi.Label
+	au := gitest.NewWindowAuto(t, "state", 200, 100, func(mfr *gi.Frame) {
+		lbl = addLabel(mfr, "lbl", "State")
+	})
+	defer au.Close()
+	win := au.Win
+
+	got := make(chan bool, 4)
+	au.Do(func() {
+		lbl.ConnectEvent(oswin.WindowStateEvent, gi.RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+			we := d.(*window.StateEvent)
+			if we.Action == window.StateChange {
+				got <- win.OSWin.IsMaximized()
+			}
+		})
+	})
+	win.OSWin.SetMaximized(true)
+	au.Wait()
+	select {
+	case max := <-got:
+		if !max {
+			t.Errorf("window not maximized when state event received\n")
+		}
+	default:
+		t.Errorf("widget did not receive the window state event\n")
+	}
+}
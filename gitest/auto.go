@@ -0,0 +1,491 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitest
+
+import (
+	"image"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/mouse"
+	"github.com/goki/ki"
+)
+
+// DragSteps is the number of intermediate drag events generated by Drag
+var DragSteps = 10
+
+// Auto provides Selenium-style automation of a running gi.Window for
+// end-to-end tests: widgets are located by selector, path, name or type,
+// and input events are synthesized and sent through the window event loop
+// exactly as if they came from the OS.  Each input method waits until the
+// resulting events have been fully processed, so the state of the widgets
+// can be checked immediately afterward -- the finding and checking is done
+// on the event loop (see Do), which is running the whole time.  All methods
+// must be called from the test goroutine, and errors are reported via T.
+type Auto struct {
+	T   testing.TB  `desc:"the test that errors are reported to"`
+	Win *gi.Window  `desc:"the window being automated"`
+	Pos image.Point `desc:"current mouse position"`
+}
+
+// NewAuto starts the event loop of the given window (e.g., from NewWindow)
+// and returns an Auto for it, once the window is fully rendered and ready
+// to receive events.
+func NewAuto(t testing.TB, win *gi.Window) *Auto {
+	t.Helper()
+	au := &Auto{T: t, Win: win}
+	win.GoStartEventLoop()
+	au.Wait()
+	return au
+}
+
+// NewWindowAuto creates a new window with NewWindow, and returns an Auto
+// for it from NewAuto -- call Close when done.
+func NewWindowAuto(t testing.TB, name string, width, height int, config func(mfr *gi.Frame)) *Auto {
+	t.Helper()
+	return NewAuto(t, NewWindow(name, width, height, config))
+}
+
+// Close closes the window
+func (au *Auto) Close() {
+	au.Win.OSWin.Close()
+}
+
+// Wait waits until all events sent to the window have been processed
+func (au *Auto) Wait() {
+	au.T.Helper()
+	if err := au.Win.WaitEvents(); err != nil {
+		au.T.Fatal(err)
+	}
+}
+
+// Send sends given event to the window and waits for it to be processed
+func (au *Auto) Send(ev oswin.Event) {
+	au.T.Helper()
+	ev.Init()
+	au.Win.OSWin.Send(ev)
+	au.Wait()
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Finding
+
+// Do calls given function on the event loop of the window, once all of the
+// events sent to it so far have been processed, and waits for it to return
+// -- the event loop updates the widget tree, so this is how the widgets can
+// be safely inspected or changed while it is running.  The function must
+// not call the Fatal methods of T, which only work on the test goroutine.
+func (au *Auto) Do(fun func()) {
+	au.T.Helper()
+	if err := au.Win.RunOnEventLoop(fun); err != nil {
+		au.T.Fatal(err)
+	}
+}
+
+// FindAll returns all nodes in the window matching given CSS-style
+// selector (see gi.Selector), including any popups -- fails the test if
+// the selector is invalid.
+func (au *Auto) FindAll(sel string) []ki.Ki {
+	au.T.Helper()
+	var ks []ki.Ki
+	var err error
+	au.Do(func() {
+		ks, err = gi.SelectAll(au.Win.This(), sel)
+	})
+	if err != nil {
+		au.T.Fatal(err)
+	}
+	return ks
+}
+
+// Find returns the first node in the window matching given CSS-style
+// selector (see gi.Selector) -- fails the test if not found.
+func (au *Auto) Find(sel string) gi.Node2D {
+	au.T.Helper()
+	var k ki.Ki
+	var err error
+	au.Do(func() {
+		k, err = gi.SelectFirst(au.Win.This(), sel)
+	})
+	if err != nil {
+		au.T.Fatal(err)
+	}
+	if k == nil {
+		au.T.Fatalf("gitest: no node found matching selector: %v", sel)
+	}
+	return au.node2D(k, sel)
+}
+
+// FindPath returns the node at given unique path relative to the window
+// (see ki.FindPathUnique) -- fails the test if not found.
+func (au *Auto) FindPath(path string) gi.Node2D {
+	au.T.Helper()
+	var k ki.Ki
+	var ok bool
+	au.Do(func() {
+		k, ok = au.Win.FindPathUnique(path)
+	})
+	if !ok {
+		au.T.Fatalf("gitest: no node found at path: %v", path)
+	}
+	return au.node2D(k, path)
+}
+
+// FindName returns the first node with given name in the window -- fails
+// the test if not found.
+func (au *Auto) FindName(name string) gi.Node2D {
+	au.T.Helper()
+	var fk ki.Ki
+	au.Do(func() {
+		au.Win.FuncDownMeFirst(0, nil, func(k ki.Ki, level int, d interface{}) bool {
+			if fk != nil {
+				return false
+			}
+			if k.Name() == name {
+				fk = k
+				return false
+			}
+			return true
+		})
+	})
+	if fk == nil {
+		au.T.Fatalf("gitest: no node found with name: %v", name)
+	}
+	return au.node2D(fk, name)
+}
+
+// FindType returns the first node of given type (or embedding it) in the
+// window -- fails the test if not found.
+func (au *Auto) FindType(typ reflect.Type) gi.Node2D {
+	au.T.Helper()
+	var fk ki.Ki
+	au.Do(func() {
+		au.Win.FuncDownMeFirst(0, nil, func(k ki.Ki, level int, d interface{}) bool {
+			if fk != nil {
+				return false
+			}
+			if k.TypeEmbeds(typ) {
+				fk = k
+				return false
+			}
+			return true
+		})
+	})
+	if fk == nil {
+		au.T.Fatalf("gitest: no node found of type: %v", typ.Name())
+	}
+	return au.node2D(fk, typ.Name())
+}
+
+// node2D returns node as a Node2D, failing if it is not one
+func (au *Auto) node2D(k ki.Ki, desc string) gi.Node2D {
+	au.T.Helper()
+	nii, ok := k.(gi.Node2D)
+	if !ok {
+		au.T.Fatalf("gitest: node of type: %T found for: %v is not a Node2D", k, desc)
+	}
+	return nii
+}
+
+// Center returns the center of the node in window coordinates
+func (au *Auto) Center(nii gi.Node2D) image.Point {
+	au.T.Helper()
+	var bb image.Rectangle
+	au.Do(func() {
+		bb = nii.AsNode2D().WinBBox
+	})
+	return image.Point{(bb.Min.X + bb.Max.X) / 2, (bb.Min.Y + bb.Max.Y) / 2}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Mouse
+
+// MoveTo moves the mouse to given position, generating a mouse move event
+func (au *Auto) MoveTo(pos image.Point) {
+	au.T.Helper()
+	me := &mouse.MoveEvent{}
+	me.Where = pos
+	me.From = au.Pos
+	me.Action = mouse.Move
+	me.LastTime = time.Now()
+	au.Pos = pos
+	au.Send(me)
+}
+
+// Press presses the given mouse button at the current position
+func (au *Auto) Press(but mouse.Buttons, mods ...key.Modifiers) {
+	au.T.Helper()
+	me := &mouse.Event{Where: au.Pos, Button: but, Action: mouse.Press}
+	me.SetModifiers(mods...)
+	au.Send(me)
+}
+
+// Release releases the given mouse button at the current position
+func (au *Auto) Release(but mouse.Buttons, mods ...key.Modifiers) {
+	au.T.Helper()
+	me := &mouse.Event{Where: au.Pos, Button: but, Action: mouse.Release}
+	me.SetModifiers(mods...)
+	au.Send(me)
+}
+
+// ClickAt moves the mouse to given position and clicks the given button
+func (au *Auto) ClickAt(pos image.Point, but mouse.Buttons, mods ...key.Modifiers) {
+	au.T.Helper()
+	au.MoveTo(pos)
+	au.Press(but, mods...)
+	au.Release(but, mods...)
+}
+
+// ClickNode clicks the left mouse button in the center of given node
+func (au *Auto) ClickNode(nii gi.Node2D, mods ...key.Modifiers) {
+	au.T.Helper()
+	au.ClickAt(au.Center(nii), mouse.Left, mods...)
+}
+
+// Click clicks the left mouse button in the center of the first node
+// matching given selector, returning the node
+func (au *Auto) Click(sel string, mods ...key.Modifiers) gi.Node2D {
+	au.T.Helper()
+	nii := au.Find(sel)
+	au.ClickNode(nii, mods...)
+	return nii
+}
+
+// DoubleClick double-clicks the left mouse button in the center of the
+// first node matching given selector, returning the node
+func (au *Auto) DoubleClick(sel string, mods ...key.Modifiers) gi.Node2D {
+	au.T.Helper()
+	nii := au.Find(sel)
+	au.MoveTo(au.Center(nii))
+	au.Press(mouse.Left, mods...)
+	au.Release(mouse.Left, mods...)
+	me := &mouse.Event{Where: au.Pos, Button: mouse.Left, Action: mouse.DoubleClick}
+	me.SetModifiers(mods...)
+	au.Send(me)
+	au.Release(mouse.Left, mods...)
+	return nii
+}
+
+// Drag drags the mouse with the left button down from one position to
+// another, pausing long enough at the start for both dragging and
+// drag-n-drop to be initiated by the window.
+func (au *Auto) Drag(from, to image.Point, mods ...key.Modifiers) {
+	au.T.Helper()
+	au.MoveTo(from)
+	au.Press(mouse.Left, mods...)
+	au.dragTo(from.Add(image.Point{1, 1}), mods...)
+	wait := gi.DragStartMSec
+	if gi.DNDStartMSec > wait {
+		wait = gi.DNDStartMSec
+	}
+	time.Sleep(time.Duration(wait+10) * time.Millisecond)
+	for i := 1; i <= DragSteps; i++ {
+		pos := image.Point{from.X + (to.X-from.X)*i/DragSteps, from.Y + (to.Y-from.Y)*i/DragSteps}
+		au.dragTo(pos, mods...)
+	}
+	au.Release(mouse.Left, mods...)
+}
+
+// DragNode drags from the center of one node to the center of another --
+// e.g., for drag-n-drop
+func (au *Auto) DragNode(from, to gi.Node2D, mods ...key.Modifiers) {
+	au.T.Helper()
+	au.Drag(au.Center(from), au.Center(to), mods...)
+}
+
+// dragTo generates a drag event to given position
+func (au *Auto) dragTo(pos image.Point, mods ...key.Modifiers) {
+	au.T.Helper()
+	me := &mouse.DragEvent{}
+	me.Where = pos
+	me.From = au.Pos
+	me.Button = mouse.Left
+	me.Action = mouse.Drag
+	me.SetModifiers(mods...)
+	me.LastTime = time.Now()
+	au.Pos = pos
+	au.Send(me)
+}
+
+// Scroll generates a scroll wheel event at the current position
+func (au *Auto) Scroll(delta image.Point) {
+	au.T.Helper()
+	me := &mouse.ScrollEvent{Delta: delta}
+	me.Where = au.Pos
+	me.Action = mouse.Scroll
+	au.Send(me)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Keyboard
+
+// Type types the given text into the node that has the keyboard focus
+func (au *Auto) Type(text string) {
+	au.T.Helper()
+	for _, r := range text {
+		ke := &key.ChordEvent{}
+		ke.Rune = r
+		ke.Action = key.Press
+		au.Send(ke)
+	}
+}
+
+// KeyChord sends the given key chord (e.g., "Control+A", "UpArrow") to the
+// node that has the keyboard focus
+func (au *Auto) KeyChord(chord key.Chord) {
+	au.T.Helper()
+	ke, err := chord.ChordEvent()
+	if err != nil {
+		au.T.Fatal(err)
+	}
+	au.Send(ke)
+}
+
+// KeyFun sends the first key chord for given key function in the active
+// keymap to the node that has the keyboard focus
+func (au *Auto) KeyFun(kf gi.KeyFuns) {
+	au.T.Helper()
+	chord := gi.ActiveKeyMap.ChordForFun(kf)
+	if chord == "" {
+		au.T.Fatalf("gitest: no key chord for key function: %v in active keymap", kf)
+	}
+	au.KeyChord(chord)
+}
+
+// Focus clicks on the first node matching given selector to give it the
+// keyboard focus, failing if it does not get it
+func (au *Auto) Focus(sel string) gi.Node2D {
+	au.T.Helper()
+	nii := au.Click(sel)
+	au.AssertFocus(sel)
+	return nii
+}
+
+////////////////////////////////////////////////////////////////////////////////////////
+//  Assertions
+
+// Text returns the text of given node, for the standard widgets with text
+// (Label, TextField, and all Buttons) -- false if not a text widget.  If
+// the event loop is running, call it within Auto.Do.
+func Text(k ki.Ki) (string, bool) {
+	switch {
+	case k.TypeEmbeds(gi.KiT_TextField):
+		return k.Embed(gi.KiT_TextField).(*gi.TextField).Text(), true
+	case k.TypeEmbeds(gi.KiT_Label):
+		return k.Embed(gi.KiT_Label).(*gi.Label).Text, true
+	case k.TypeEmbeds(gi.KiT_ButtonBase):
+		return k.Embed(gi.KiT_ButtonBase).(*gi.ButtonBase).Text, true
+	}
+	if tx, ok := k.(interface{ Text() string }); ok {
+		return tx.Text(), true
+	}
+	return "", false
+}
+
+// AssertExists checks that there is a node matching given selector
+func (au *Auto) AssertExists(sel string) bool {
+	au.T.Helper()
+	if len(au.FindAll(sel)) == 0 {
+		au.T.Errorf("gitest: expected a node matching selector: %v", sel)
+		return false
+	}
+	return true
+}
+
+// AssertNotExists checks that there is no node matching given selector
+func (au *Auto) AssertNotExists(sel string) bool {
+	au.T.Helper()
+	if ks := au.FindAll(sel); len(ks) > 0 {
+		var path string
+		au.Do(func() {
+			path = ks[0].PathUnique()
+		})
+		au.T.Errorf("gitest: expected no node matching selector: %v, found: %v", sel, path)
+		return false
+	}
+	return true
+}
+
+// AssertText checks the text of the first node matching given selector
+func (au *Auto) AssertText(sel, want string) bool {
+	au.T.Helper()
+	nii := au.Find(sel)
+	var txt string
+	var ok bool
+	au.Do(func() {
+		txt, ok = Text(nii)
+	})
+	if !ok {
+		au.T.Errorf("gitest: node matching: %v does not have text", sel)
+		return false
+	}
+	if txt != want {
+		au.T.Errorf("gitest: text of: %v is: %q, expected: %q", sel, txt, want)
+		return false
+	}
+	return true
+}
+
+// AssertFocus checks that the keyboard focus is on (or within) the first
+// node matching given selector
+func (au *Auto) AssertFocus(sel string) bool {
+	au.T.Helper()
+	nii := au.Find(sel)
+	focus := false
+	fnm := "nil"
+	au.Do(func() {
+		foc := au.Win.CurFocus()
+		if foc != nil {
+			focus = foc == nii.This() || foc.ParentLevel(nii.This()) >= 0
+			fnm = foc.PathUnique()
+		}
+	})
+	if !focus {
+		au.T.Errorf("gitest: expected focus on: %v, but it is on: %v", sel, fnm)
+		return false
+	}
+	return true
+}
+
+// AssertChecked checks the checked state of the first button matching
+// given selector
+func (au *Auto) AssertChecked(sel string, checked bool) bool {
+	au.T.Helper()
+	nii := au.Find(sel)
+	bb, ok := nii.Embed(gi.KiT_ButtonBase).(*gi.ButtonBase)
+	if !ok {
+		au.T.Errorf("gitest: node matching: %v is not a button", sel)
+		return false
+	}
+	var chk bool
+	au.Do(func() {
+		chk = bb.IsChecked()
+	})
+	if chk != checked {
+		au.T.Errorf("gitest: checked state of: %v is: %v, expected: %v", sel, chk, checked)
+		return false
+	}
+	return true
+}
+
+// AssertActive checks the active (vs. inactive) state of the first node
+// matching given selector
+func (au *Auto) AssertActive(sel string, active bool) bool {
+	au.T.Helper()
+	nii := au.Find(sel)
+	var act bool
+	au.Do(func() {
+		act = nii.AsNode2D().IsActive()
+	})
+	if act != active {
+		au.T.Errorf("gitest: active state of: %v is: %v, expected: %v", sel, act, active)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitest
+
+import (
+	"image"
+	"image/color"
+)
+
+// Diff is the result of comparing an image with a golden image
+type Diff struct {
+
+	// NPixels is the number of pixels that differ by more than the tolerance
+	NPixels int
+
+	// MaxDelta is the maximum difference in any color channel, over all pixels
+	MaxDelta int
+
+	// Size is the size of the golden image if it differs in size from the
+	// image being compared (in which case nothing else is computed) -- zero
+	// otherwise
+	Size image.Point
+
+	// Image shows the differences: pixels that differ beyond tolerance are
+	// red (with intensity proportional to the difference), and the rest are
+	// a faded grayscale version of the golden image
+	Image *image.RGBA
+}
+
+// Compare compares img with golden image, with pixels considered the same
+// if all channels (including alpha) differ by no more than tol (0-255).
+func Compare(img, golden image.Image, tol int) *Diff {
+	diff := &Diff{}
+	ib := img.Bounds()
+	gb := golden.Bounds()
+	if ib.Size() != gb.Size() {
+		diff.Size = gb.Size()
+		return diff
+	}
+	sz := ib.Size()
+	diff.Image = image.NewRGBA(image.Rectangle{Max: sz})
+	for y := 0; y < sz.Y; y++ {
+		for x := 0; x < sz.X; x++ {
+			ic := color.RGBAModel.Convert(img.At(ib.Min.X+x, ib.Min.Y+y)).(color.RGBA)
+			gc := color.RGBAModel.Convert(golden.At(gb.Min.X+x, gb.Min.Y+y)).(color.RGBA)
+			d := maxDelta(ic, gc)
+			if d > diff.MaxDelta {
+				diff.MaxDelta = d
+			}
+			if d > tol {
+				diff.NPixels++
+				diff.Image.SetRGBA(x, y, color.RGBA{uint8(128 + d/2), 0, 0, 255})
+			} else {
+				gy := color.GrayModel.Convert(gc).(color.Gray).Y
+				fy := 192 + gy/4
+				diff.Image.SetRGBA(x, y, color.RGBA{fy, fy, fy, 255})
+			}
+		}
+	}
+	return diff
+}
+
+// maxDelta returns the maximum absolute difference over the channels
+func maxDelta(a, b color.RGBA) int {
+	md := 0
+	for _, d := range [4]int{int(a.R) - int(b.R), int(a.G) - int(b.G), int(a.B) - int(b.B), int(a.A) - int(b.A)} {
+		if d < 0 {
+			d = -d
+		}
+		if d > md {
+			md = d
+		}
+	}
+	return md
+}
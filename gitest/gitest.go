@@ -0,0 +1,202 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gitest provides golden-image visual regression testing for GoGi:
+// a widget tree or svg.SVG is rendered into a Viewport2D using the offscreen
+// driver, and compared against a stored PNG file (a "golden" image), with
+// a per-pixel tolerance.  If the images differ, the actual and diff images
+// are saved into FailDir for inspection.
+//
+// Typical usage, in a _test.go file:
+//
+//   func TestMain(m *testing.M) {
+//   	gitest.Main(m)
+//   }
+//
+//   func TestButton(t *testing.T) {
+//   	win := gitest.NewWindow("button", 200, 100, func(mfr *gi.Frame) {
+//   		but := mfr.AddNewChild(gi.KiT_Button, "but").(*gi.Button)
+//   		but.SetText("Click")
+//   	})
+//   	defer win.OSWin.Close()
+//   	gitest.AssertWindow(t, "button", win)
+//   }
+//
+// Run the tests with -gitest.update (or the GOGI_UPDATE_GOLDENS environment
+// variable set to 1) to write the current renders as the new goldens.
+package gitest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/offscreen"
+	"github.com/goki/gi/svg"
+)
+
+// Update, if true, causes the Assert functions to write the current
+// images as the new golden images, instead of comparing -- set by the
+// -gitest.update flag or the GOGI_UPDATE_GOLDENS=1 environment variable.
+var Update = flag.Bool("gitest.update", os.Getenv("GOGI_UPDATE_GOLDENS") == "1", "update golden images instead of comparing against them")
+
+// GoldenDir is the directory where golden images are stored, relative to
+// the package directory of the test.
+var GoldenDir = "testdata"
+
+// FailDir is the directory where the actual and diff images are saved
+// when a comparison fails.
+var FailDir = filepath.Join("testdata", "failed")
+
+// Tolerance is the maximum difference in any one color channel (0-255) for
+// pixels to be considered the same -- a small tolerance absorbs minor
+// anti-aliasing differences.
+var Tolerance = 2
+
+// MaxDiffPixels is the number of pixels that can differ (beyond Tolerance)
+// with the comparison still passing.
+var MaxDiffPixels = 0
+
+// Main runs the tests in m using the offscreen driver, with a temporary
+// prefs directory so that user preferences do not affect the results, and
+// without cursor blinking, which is done outside of the window event loop
+// -- call it from the TestMain function of the package.
+func Main(m *testing.M) {
+	flag.Parse()
+	pdir, err := ioutil.TempDir("", "gitest")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	offscreen.PrefsDir = pdir
+	code := 0
+	offscreen.Main(func(app oswin.App) {
+		gi.Init()
+		gi.CursorBlinkMSec = 0
+		code = m.Run()
+		app.Quit()
+	})
+	os.RemoveAll(pdir)
+	os.Exit(code)
+}
+
+// NewWindow creates a new window of given size (in raw pixels) with a main
+// frame, calls config to add the widgets to be tested to the frame, and does
+// a full render of the window viewport.  The event loop is not started, so
+// the window is fully synchronous -- call GoStartEventLoop for interactive
+// testing.
+func NewWindow(name string, width, height int, config func(mfr *gi.Frame)) *gi.Window {
+	win := gi.NewWindow2D(name, name, width, height, false)
+	vp := win.WinViewport2D()
+	updt := vp.UpdateStart()
+	mfr := win.SetMainFrame()
+	if config != nil {
+		config(mfr)
+	}
+	vp.UpdateEndNoSig(updt)
+	vp.FullRender2DTree()
+	return win
+}
+
+// NewSVG returns a new standalone SVG of given size, filled with a white
+// background -- add elements and then call RenderSVG.
+func NewSVG(name string, width, height int) *svg.SVG {
+	sv := &svg.SVG{}
+	sv.InitName(sv, name)
+	sv.Resize(image.Point{width, height})
+	sv.Fill = true
+	sv.SetProp("background-color", "white")
+	return sv
+}
+
+// RenderSVG does a full render of the given SVG, returning its viewport
+func RenderSVG(sv *svg.SVG) *gi.Viewport2D {
+	sv.FullRender2DTree()
+	return &sv.Viewport2D
+}
+
+// GoldenPath returns the path to the golden image file with given name
+func GoldenPath(name string) string {
+	return filepath.Join(GoldenDir, name+".png")
+}
+
+// AssertWindow compares the window's main viewport with the named golden
+// image -- see AssertViewport.
+func AssertWindow(t testing.TB, name string, win *gi.Window) bool {
+	t.Helper()
+	return AssertViewport(t, name, win.Viewport)
+}
+
+// AssertViewport compares the viewport image with the named golden image,
+// reporting an error via t if they differ -- or updates the golden image
+// if Update is set.  Returns true if the images match.
+func AssertViewport(t testing.TB, name string, vp *gi.Viewport2D) bool {
+	t.Helper()
+	var b bytes.Buffer
+	if err := vp.EncodePNG(&b); err != nil {
+		t.Errorf("gitest: %v: could not encode viewport image: %v", name, err)
+		return false
+	}
+	return assertPNG(t, name, b.Bytes())
+}
+
+// AssertImage compares the image with the named golden image, reporting an
+// error via t if they differ -- or updates the golden image if Update is
+// set.  Returns true if the images match.
+func AssertImage(t testing.TB, name string, img image.Image) bool {
+	t.Helper()
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		t.Errorf("gitest: %v: could not encode image: %v", name, err)
+		return false
+	}
+	return assertPNG(t, name, b.Bytes())
+}
+
+// assertPNG does the work for the Assert functions, on PNG-encoded image data
+func assertPNG(t testing.TB, name string, pngData []byte) bool {
+	t.Helper()
+	gpath := GoldenPath(name)
+	if *Update {
+		os.MkdirAll(filepath.Dir(gpath), 0755)
+		if err := ioutil.WriteFile(gpath, pngData, 0644); err != nil {
+			t.Errorf("gitest: %v: could not write golden image: %v", name, err)
+			return false
+		}
+		return true
+	}
+	img, err := png.Decode(bytes.NewReader(pngData))
+	if err != nil {
+		t.Errorf("gitest: %v: could not decode image: %v", name, err)
+		return false
+	}
+	golden, err := gi.OpenPNG(gpath)
+	if err != nil {
+		t.Errorf("gitest: %v: could not open golden image (run with -gitest.update to create it): %v", name, err)
+		return false
+	}
+	diff := Compare(img, golden, Tolerance)
+	if diff.Size == (image.Point{}) && diff.NPixels <= MaxDiffPixels {
+		return true
+	}
+	os.MkdirAll(FailDir, 0755)
+	apath := filepath.Join(FailDir, name+".png")
+	ioutil.WriteFile(apath, pngData, 0644)
+	if diff.Size != (image.Point{}) {
+		t.Errorf("gitest: %v: image size: %v != golden size: %v -- actual image saved to: %v", name, img.Bounds().Size(), diff.Size, apath)
+		return false
+	}
+	dpath := filepath.Join(FailDir, name+".diff.png")
+	gi.SavePNG(dpath, diff.Image)
+	t.Errorf("gitest: %v: %v pixels differ from golden (max channel delta: %v, tolerance: %v) -- actual image saved to: %v, diff to: %v", name, diff.NPixels, diff.MaxDelta, Tolerance, apath, dpath)
+	return false
+}
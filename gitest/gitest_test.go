@@ -0,0 +1,141 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gitest
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"path/filepath"
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/svg"
+	"github.com/goki/gi/units"
+	"github.com/goki/ki"
+)
+
+func TestMain(m *testing.M) {
+	Main(m)
+}
+
+func TestCompare(t *testing.T) {
+	a := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	b := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(a, a.Bounds(), image.White, image.ZP, draw.Src)
+	draw.Draw(b, b.Bounds(), image.White, image.ZP, draw.Src)
+	b.SetRGBA(1, 1, color.RGBA{254, 255, 255, 255})
+	b.SetRGBA(2, 2, color.RGBA{0, 0, 0, 255})
+	diff := Compare(a, b, 2)
+	if diff.NPixels != 1 || diff.MaxDelta != 255 {
+		t.Errorf("NPixels: %v != 1 or MaxDelta: %v != 255\n", diff.NPixels, diff.MaxDelta)
+	}
+	if c := diff.Image.RGBAAt(2, 2); c.R < 128 || c.G != 0 {
+		t.Errorf("diff image pixel not marked: %v\n", c)
+	}
+	diff = Compare(a, image.NewRGBA(image.Rect(0, 0, 5, 5)), 2)
+	if diff.Size != (image.Point{5, 5}) {
+		t.Errorf("size mismatch not detected: %v\n", diff.Size)
+	}
+}
+
+func TestSVGCircle(t *testing.T) {
+	sv := NewSVG("circle", 100, 100)
+	c := sv.AddNewChild(svg.KiT_Circle, "c").(*svg.Circle)
+	c.Pos.Set(50, 50)
+	c.Radius = 30
+	c.SetProp("fill", "red")
+	AssertViewport(t, "svg-circle", RenderSVG(sv))
+}
+
+func TestButton(t *testing.T) {
+	win := NewWindow("button", 200, 100, func(mfr *gi.Frame) {
+		but := mfr.AddNewChild(gi.KiT_Button, "but").(*gi.Button)
+		but.SetText("Click")
+	})
+	defer win.OSWin.Close()
+	AssertWindow(t, "button", win)
+}
+
+func TestAuto(t *testing.T) {
+	clicks := 0
+	win := NewWindow("auto", 300, 200, func(mfr *gi.Frame) {
+		tf := mfr.AddNewChild(gi.KiT_TextField, "name").(*gi.TextField)
+		tf.SetProp("min-width", units.NewValue(20, units.Ch))
+		tf.SetText("")
+		but := mfr.AddNewChild(gi.KiT_Button, "ok").(*gi.Button)
+		but.SetText("OK")
+		but.AddClass("primary")
+		lbl := mfr.AddNewChild(gi.KiT_Label, "result").(*gi.Label)
+		lbl.SetText("none")
+		but.ButtonSig.Connect(mfr.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			if sig == int64(gi.ButtonClicked) {
+				clicks++
+				lbl.SetText(tf.Text())
+			}
+		})
+	})
+	au := NewAuto(t, win)
+	defer au.Close()
+
+	au.AssertExists("frame > button.primary#ok")
+	au.AssertNotExists("button.secondary")
+	au.Focus("textfield#name")
+	au.Type("gogi")
+	au.AssertText("#name", "gogi")
+	au.KeyFun(gi.KeyFunBackspace)
+	au.AssertText("#name", "gog")
+	au.Click("button.primary")
+	if clicks != 1 {
+		t.Errorf("button clicks: %v != 1\n", clicks)
+	}
+	au.AssertText("label#result", "gog")
+}
+
+func TestEventReplay(t *testing.T) {
+	config := func(mfr *gi.Frame) {
+		tf := mfr.AddNewChild(gi.KiT_TextField, "name").(*gi.TextField)
+		tf.SetProp("min-width", units.NewValue(20, units.Ch))
+		tf.SetText("")
+		but := mfr.AddNewChild(gi.KiT_Button, "ok").(*gi.Button)
+		but.SetText("OK")
+		lbl := mfr.AddNewChild(gi.KiT_Label, "result").(*gi.Label)
+		lbl.SetText("none")
+		but.ButtonSig.Connect(mfr.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+			if sig == int64(gi.ButtonClicked) {
+				lbl.SetText(tf.Text())
+			}
+		})
+	}
+	win := NewWindow("record", 300, 200, config)
+	au := NewAuto(t, win)
+	win.StartEventRecording()
+	au.Focus("#name")
+	au.Type("gogi")
+	au.KeyFun(gi.KeyFunBackspace)
+	au.Click("#ok")
+	au.AssertText("#result", "gog")
+	rec := win.StopEventRecording()
+	au.Close()
+
+	fn := gi.FileName(filepath.Join(t.TempDir(), "events.json"))
+	if err := rec.SaveJSON(fn); err != nil {
+		t.Fatal(err)
+	}
+	rrec := &gi.EventRecording{}
+	if err := rrec.OpenJSON(fn); err != nil {
+		t.Fatal(err)
+	}
+
+	rwin := NewWindow("replay", 300, 200, config)
+	rau := NewAuto(t, rwin)
+	defer rau.Close()
+	if err := rwin.ReplayEvents(rrec, 0); err != nil {
+		t.Fatal(err)
+	}
+	rau.AssertFocus("#name")
+	rau.AssertText("#name", "gog")
+	rau.AssertText("#result", "gog")
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/goki/gi/units"
 	"github.com/goki/gi/vci"
 	"github.com/goki/ki"
+	"github.com/goki/ki/ints"
 	"github.com/goki/ki/kit"
 	"github.com/goki/ki/runes"
 	"github.com/goki/pi/filecat"
@@ -1280,9 +1281,19 @@ func (ftv *FileTreeView) PasteMime(md mimedata.Mimes) {
 	if tfn == nil {
 		return
 	}
+	if !md.HasType(filecat.TextPlain) { // file:/// paths from another app
+		ftv.PasteFiles(md.FilePaths())
+		return
+	}
 	if !tfn.IsDir() {
-		if len(md) != 2 {
-			gi.PromptDialog(ftv.Viewport, gi.DlgOpts{Title: "Can Only Copy 1 File", Prompt: fmt.Sprintf("Only one file can be copied target file: %v -- currently have: %v", tfn.Name(), len(md)/2)}, true, false, nil, nil)
+		nf := 0
+		for _, d := range md {
+			if d.Type == filecat.TextPlain {
+				nf++
+			}
+		}
+		if nf != 1 {
+			gi.PromptDialog(ftv.Viewport, gi.DlgOpts{Title: "Can Only Copy 1 File", Prompt: fmt.Sprintf("Only one file can be copied target file: %v -- currently have: %v", tfn.Name(), nf)}, true, false, nil, nil)
 			return
 		}
 	}
@@ -1290,7 +1301,6 @@ func (ftv *FileTreeView) PasteMime(md mimedata.Mimes) {
 		if d.Type != filecat.TextPlain {
 			continue
 		}
-		// todo: process file:/// kinds of paths..
 		path := string(d.Data)
 		sfni, ok := sroot.FindPathUnique(path)
 		if !ok {
@@ -1310,6 +1320,63 @@ func (ftv *FileTreeView) PasteMime(md mimedata.Mimes) {
 	tfn.UpdateNode()
 }
 
+// PasteFiles copies the given files (full paths, e.g., dropped from another
+// application) into / onto this node
+func (ftv *FileTreeView) PasteFiles(paths []string) {
+	tfn := ftv.FileNode()
+	if tfn == nil || len(paths) == 0 {
+		return
+	}
+	if !tfn.IsDir() && len(paths) != 1 {
+		gi.PromptDialog(ftv.Viewport, gi.DlgOpts{Title: "Can Only Copy 1 File", Prompt: fmt.Sprintf("Only one file can be copied target file: %v -- currently have: %v", tfn.Name(), len(paths))}, true, false, nil, nil)
+		return
+	}
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil || fi.IsDir() {
+			fmt.Printf("giv.FileTreeView: can only copy files, not: %v\n", path)
+			continue
+		}
+		if tfn.IsDir() {
+			tfn.CopyFileToDir(path, fi.Mode())
+		} else {
+			tfn.CopyFileToFile(path, fi.Mode())
+		}
+	}
+	tfn.UpdateNode()
+}
+
+// DragNDropStart starts a drag-n-drop on this node -- it includes any other
+// selected nodes as well, each as additional records in mimedata, plus a
+// text/uri-list of all the file paths, so the files can also be dropped
+// into other applications
+func (ftv *FileTreeView) DragNDropStart() {
+	sels := ftv.SelectedViews()
+	nitms := ints.MaxInt(1, len(sels))
+	md := make(mimedata.Mimes, 0, 2*nitms+1)
+	ftv.MimeData(&md) // source is always first..
+	var paths []string
+	if fn := ftv.FileNode(); fn != nil {
+		paths = append(paths, string(fn.FPath))
+	}
+	if nitms > 1 {
+		for _, sn := range sels {
+			if sn.This() != ftv.This() {
+				sn.MimeData(&md)
+				if fn, ok := sn.SrcNode.Ptr.Embed(KiT_FileNode).(*FileNode); ok {
+					paths = append(paths, string(fn.FPath))
+				}
+			}
+		}
+	}
+	md = append(md, mimedata.NewFileURIs(paths))
+	bi := &gi.Bitmap{}
+	bi.InitName(bi, ftv.UniqueName())
+	bi.GrabRenderFrom(ftv) // todo: show number of items?
+	gi.ImageClearer(bi.Pixels, 50.0)
+	ftv.Viewport.Win.StartDragNDrop(ftv.This(), md, bi)
+}
+
 // Dragged is called after target accepts the drop -- we just remove
 // elements that were moved
 // satisfies gi.DragNDropper interface and can be overridden by subtypes
@@ -1324,6 +1391,14 @@ func (ftv *FileTreeView) Dragged(de *dnd.Event) {
 		return
 	}
 	md := de.Data
+	if de.Target == nil { // moved by another application -- just update dirs
+		for _, path := range md.FilePaths() {
+			if dfn, ok := tfn.FRoot.FindFile(filepath.Dir(path)); ok {
+				dfn.UpdateNode()
+			}
+		}
+		return
+	}
 	for _, d := range md {
 		if d.Type != filecat.TextPlain {
 			continue
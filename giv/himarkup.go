@@ -278,3 +278,47 @@ func (hm *HiMarkup) MarkupLine(txt []byte, hitags, tags lex.Line) []byte {
 	}
 	return mu
 }
+
+// HTMLLine returns a standalone HTML version of the given range of runes in
+// the line, with the highlighting styles for the tags inlined as CSS style
+// attributes, so it can be used outside of GoGi (e.g., in the clipboard).
+func (hm *HiMarkup) HTMLLine(txt []rune, hitags lex.Line, st, ed int) []byte {
+	ed = ints.MinInt(ed, len(txt))
+	var b strings.Builder
+	spst := -1
+	curTok := token.None
+	endSpan := func(cp int) {
+		if spst < 0 {
+			return
+		}
+		if curTok != token.None {
+			if css := hm.HiStyle.Tag(curTok).ToCSS(); css != "" {
+				b.WriteString(`<span style="` + css + `">`)
+				b.WriteString(htmlstd.EscapeString(string(txt[spst:cp])))
+				b.WriteString(`</span>`)
+				spst = -1
+				return
+			}
+		}
+		b.WriteString(htmlstd.EscapeString(string(txt[spst:cp])))
+		spst = -1
+	}
+	for cp := st; cp < ed; cp++ {
+		tok := token.None
+		for _, tr := range hitags { // innermost tag containing cp is the last one
+			if tr.St > cp {
+				break
+			}
+			if cp < tr.Ed {
+				tok = tr.Tok.Tok
+			}
+		}
+		if spst < 0 || tok != curTok {
+			endSpan(cp)
+			spst = cp
+			curTok = tok
+		}
+	}
+	endSpan(ed)
+	return []byte(b.String())
+}
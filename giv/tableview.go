@@ -815,6 +815,39 @@ func (tv *TableView) RowStruct(row int) interface{} {
 	return stru
 }
 
+// Print adds the slice of structs to given print document as a table, with
+// the visible fields as columns, headed by the field names, and the row
+// index if shown -- the values are printed as strings, in the default style
+// of the document
+func (tv *TableView) Print(pd *gi.PrintDoc) {
+	if kit.IfaceIsNil(tv.Slice) {
+		return
+	}
+	tv.CacheVisFields()
+	mvnp := kit.NonPtrValue(reflect.ValueOf(tv.Slice))
+	sz := mvnp.Len()
+	hdr := make([]string, 0, tv.NVisFields+1)
+	if tv.ShowIndex {
+		hdr = append(hdr, "Index")
+	}
+	for _, fld := range tv.VisFields {
+		hdr = append(hdr, fld.Name)
+	}
+	rows := make([][]string, sz)
+	for i := 0; i < sz; i++ {
+		val := kit.OnePtrValue(mvnp.Index(i)) // deal with pointer lists
+		row := make([]string, 0, len(hdr))
+		if tv.ShowIndex {
+			row = append(row, fmt.Sprintf("%v", i))
+		}
+		for _, fld := range tv.VisFields {
+			row = append(row, kit.ToString(val.Elem().Field(fld.Index[0]).Interface()))
+		}
+		rows[i] = row
+	}
+	pd.AddTable(hdr, rows)
+}
+
 // RowFirstWidget returns the first widget for given row (could be index or
 // not) -- false if out of range
 func (tv *TableView) RowFirstWidget(row int) (*gi.WidgetBase, bool) {
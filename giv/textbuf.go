@@ -1526,6 +1526,47 @@ func (tb *TextBuf) InsertText(st TextPos, text []byte, saveUndo, signal bool) *T
 	return tbe
 }
 
+// RegionHTML returns an HTML representation of the text between start and
+// end positions, with syntax highlighting styles inlined so it can be pasted
+// into other apps -- returns nil if not a valid region or there is no
+// highlighting.
+func (tb *TextBuf) RegionHTML(st, ed TextPos) []byte {
+	if !tb.Hi.HasHi() {
+		return nil
+	}
+	st = tb.ValidPos(st)
+	ed = tb.ValidPos(ed)
+	if !st.IsLess(ed) {
+		return nil
+	}
+	tb.LinesMu.RLock()
+	defer tb.LinesMu.RUnlock()
+	tb.MarkupMu.RLock()
+	defer tb.MarkupMu.RUnlock()
+	var b bytes.Buffer
+	b.WriteString(`<pre style="` + tb.Hi.HiStyle.Tag(token.Text).ToCSS() + `">`)
+	for ln := st.Ln; ln <= ed.Ln; ln++ {
+		if ln > st.Ln {
+			b.WriteByte('\n')
+		}
+		lst := 0
+		if ln == st.Ln {
+			lst = st.Ch
+		}
+		led := len(tb.Lines[ln])
+		if ln == ed.Ln {
+			led = ed.Ch
+		}
+		var tags lex.Line
+		if ln < len(tb.HiTags) {
+			tags = tb.HiTags[ln]
+		}
+		b.Write(tb.Hi.HTMLLine(tb.Lines[ln], tags, lst, led))
+	}
+	b.WriteString("</pre>")
+	return b.Bytes()
+}
+
 // Region returns a TextBufEdit representation of text between start and end positions
 // returns nil if not a valid region.  sets the timestamp on the TextBufEdit to now
 func (tb *TextBuf) Region(st, ed TextPos) *TextBufEdit {
@@ -20,6 +20,7 @@ import (
 	"github.com/chewxy/math32"
 	"github.com/goki/gi/gi"
 	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/dnd"
 	"github.com/goki/gi/oswin/key"
 	"github.com/goki/gi/oswin/mimedata"
 	"github.com/goki/gi/oswin/mouse"
@@ -62,6 +63,7 @@ type TextView struct {
 	Highlights     []TextRegion              `json:"-" xml:"-" desc:"highlighted regions, e.g., for search results"`
 	Scopelights    []TextRegion              `json:"-" xml:"-" desc:"highlighted regions, specific to scope markers"`
 	SelectMode     bool                      `json:"-" xml:"-" desc:"if true, select text as cursor moves"`
+	PreeditReg     TextRegion                `json:"-" xml:"-" desc:"region of the input method (IME) preedit text being composed, which is temporarily inserted into the buffer (without undo), and shown underlined until it is committed -- nil if not composing"`
 	ForceComplete  bool                      `json:"-" xml:"-" desc:"if true, complete regardless of any disqualifying reasons"`
 	ISearch        ISearch                   `json:"-" xml:"-" desc:"interactive search data"`
 	QReplace       QReplace                  `json:"-" xml:"-" desc:"query replace data"`
@@ -800,6 +802,7 @@ func (tv *TextView) SelectRegUpdate(pos TextPos) {
 		tv.SelectReg.Start = tv.SelectStart
 		tv.SelectReg.End = pos
 	}
+	tv.SelectionToPrimary()
 }
 
 // CursorSelect updates selection based on cursor movements, given starting
@@ -1989,6 +1992,7 @@ func (tv *TextView) SelectAll() {
 	tv.SelectReg.Start = TextPosZero
 	tv.SelectReg.End = tv.Buf.EndPos()
 	tv.RenderAllLines()
+	tv.SelectionToPrimary()
 }
 
 // IsWordBreak defines what counts as a word break for the purposes of selecting words
@@ -2131,6 +2135,7 @@ func (tv *TextView) SelectWord() bool {
 	region := tv.WordAt()
 	tv.SelectReg = region
 	tv.SelectStart = tv.SelectReg.Start
+	tv.SelectionToPrimary()
 	return true
 }
 
@@ -2325,7 +2330,11 @@ func (tv *TextView) Copy(reset bool) *TextBufEdit {
 	defer tv.Viewport.Win.UpdateEnd(updt)
 	cb := tbe.ToBytes()
 	TextViewClipHistAdd(cb)
-	oswin.TheApp.ClipBoard(tv.Viewport.Win.OSWin).Write(mimedata.NewTextBytes(cb))
+	md := mimedata.NewTextBytes(cb)
+	if hb := tv.Buf.RegionHTML(tbe.Reg.Start, tbe.Reg.End); hb != nil {
+		md = append(md, &mimedata.Data{Type: mimedata.TextHTML, Data: hb})
+	}
+	oswin.TheApp.ClipBoard(tv.Viewport.Win.OSWin).Write(md)
 	if reset {
 		tv.SelectReset()
 	}
@@ -2344,6 +2353,66 @@ func (tv *TextView) Paste() {
 	}
 }
 
+// SelectionToPrimary sets the PRIMARY selection (on platforms that have one)
+// to the currently selected text
+func (tv *TextView) SelectionToPrimary() {
+	if tv.Viewport == nil || tv.Buf == nil {
+		return
+	}
+	pc := gi.PrimaryClip(tv.Viewport.Win)
+	if pc == nil {
+		return
+	}
+	if tbe := tv.Selection(); tbe != nil {
+		pc.Write(mimedata.NewTextBytes(tbe.ToBytes()))
+	}
+}
+
+// PastePrimary inserts text from the PRIMARY selection at current cursor
+// position -- does a regular Paste on platforms without a primary selection
+func (tv *TextView) PastePrimary() {
+	pc := gi.PrimaryClip(tv.Viewport.Win)
+	if pc == nil {
+		tv.Paste()
+		return
+	}
+	updt := tv.Viewport.Win.UpdateStart()
+	defer tv.Viewport.Win.UpdateEnd(updt)
+	data := pc.Read([]string{filecat.TextPlain})
+	if data != nil {
+		tv.InsertAtCursor(data.TypeData(filecat.TextPlain))
+		tv.SavePosHistory(tv.CursorPos)
+	}
+}
+
+// DragNDropTarget handles a drag-n-drop onto this view, inserting the
+// dropped data at the drop position -- we only ever copy
+func (tv *TextView) DragNDropTarget(de *dnd.Event) {
+	if tv.IsInactive() || tv.Buf == nil {
+		return
+	}
+	de.Target = tv.This()
+	de.Mod = dnd.DropCopy
+	de.SetProcessed()
+	pt := tv.PointToRelPos(de.Pos())
+	tv.SetCursorFromMouse(pt, tv.PixelToCursor(pt), mouse.SelectOne)
+	tv.Drop(de.Data, de.Mod)
+	tv.Viewport.Win.FinalizeDragNDrop(de.Mod)
+}
+
+// Drop inserts the dropped text at current cursor position -- if there is no
+// text/plain data, the paths of any dropped files are inserted, one per line
+func (tv *TextView) Drop(md mimedata.Mimes, mod dnd.DropMods) {
+	if md.HasType(filecat.TextPlain) {
+		tv.InsertAtCursor(md.TypeData(filecat.TextPlain))
+	} else if fps := md.FilePaths(); len(fps) > 0 {
+		tv.InsertAtCursor([]byte(strings.Join(fps, "\n")))
+	} else {
+		return
+	}
+	tv.SavePosHistory(tv.CursorPos)
+}
+
 // InsertAtCursor inserts given text at current cursor position
 func (tv *TextView) InsertAtCursor(txt []byte) {
 	updt := tv.Viewport.Win.UpdateStart()
@@ -2364,6 +2433,69 @@ func (tv *TextView) InsertAtCursor(txt []byte) {
 	tv.SetCursorCol(tv.CursorPos)
 }
 
+// IMEInput handles composed text input from the input method (IME) --
+// preedit text is shown at the cursor, underlined, replacing any prior
+// preedit text, until the final text is committed
+func (tv *TextView) IMEInput(ie *key.IMEEvent) {
+	if tv.Buf == nil || tv.IsInactive() {
+		return
+	}
+	ie.SetProcessed()
+	updt := tv.Viewport.Win.UpdateStart()
+	defer tv.Viewport.Win.UpdateEnd(updt)
+	tv.ClearPreedit()
+	if ie.Text == "" {
+		return
+	}
+	if ie.Action == key.IMECommit {
+		tv.InsertAtCursor([]byte(ie.Text))
+		return
+	}
+	if tv.HasSelection() {
+		tbe := tv.DeleteSelection()
+		tv.CursorPos = tbe.AdjustPos(tv.CursorPos, AdjustPosDelStart)
+	}
+	tbe := tv.Buf.InsertText(tv.CursorPos, []byte(ie.Text), false, true)
+	if tbe == nil {
+		return
+	}
+	tv.PreeditReg = tbe.Reg
+	pos := tbe.Reg.Start
+	pos.Ch += ie.Cursor
+	if tbe.Reg.End.Ln != pos.Ln || pos.Ch > tbe.Reg.End.Ch {
+		pos = tbe.Reg.End
+	}
+	tv.SetCursorShow(pos)
+}
+
+// ClearPreedit removes any IME preedit text from the buffer, putting the
+// cursor back where the preedit started
+func (tv *TextView) ClearPreedit() {
+	if tv.PreeditReg.IsNil() {
+		return
+	}
+	reg := tv.PreeditReg
+	tv.PreeditReg = TextRegionNil
+	if tv.Buf != nil {
+		tv.Buf.DeleteText(reg.Start, reg.End, false, true)
+	}
+	tv.CursorPos = reg.Start
+}
+
+// SetIMECursor tells the input method (IME) where our cursor is, for
+// positioning its candidate window, or that text input has ended if !on
+func (tv *TextView) SetIMECursor(on bool) {
+	win := tv.ParentWindow()
+	if win == nil {
+		return
+	}
+	if !on {
+		win.SetIMECursor(image.ZR)
+		return
+	}
+	win.SetIMECursor(tv.CursorBBox(tv.CursorPos))
+}
+
 // ContextMenu displays the context menu with options dependent on situation
 func (tv *TextView) ContextMenu() {
 	if !tv.HasSelection() && tv.Buf.IsSpellCorrectEnabled(tv.CursorPos) {
@@ -2888,6 +3020,7 @@ func (tv *TextView) StartCursor() {
 		return
 	}
 	tv.BlinkOn = true
+	tv.SetIMECursor(true)
 	if gi.CursorBlinkMSec == 0 {
 		tv.RenderCursor(true)
 		return
@@ -3120,6 +3253,22 @@ func (tv *TextView) ClearScopelights() {
 	}
 }
 
+// RenderPreedit underlines the IME preedit text, if any
+func (tv *TextView) RenderPreedit() {
+	if tv.PreeditReg.IsNil() {
+		return
+	}
+	spos := tv.CharStartPos(tv.PreeditReg.Start)
+	epos := tv.CharStartPos(tv.PreeditReg.End)
+	if epos.X <= spos.X {
+		return
+	}
+	rs := &tv.Viewport.Render
+	pc := &rs.Paint
+	lw := math32.Max(1, math32.Floor(tv.FontHeight/16))
+	pc.FillBoxColor(rs, gi.NewVec2D(spos.X, spos.Y+tv.FontHeight-lw), gi.NewVec2D(epos.X-spos.X, lw), tv.Sty.Font.Color)
+}
+
 // RenderRegionBox renders a region in background color according to given state style
 func (tv *TextView) RenderRegionBox(reg TextRegion, state TextViewStates) {
 	sty := &tv.StateStyles[state]
@@ -3306,6 +3455,7 @@ func (tv *TextView) RenderAllLinesInBounds() {
 		lp.X += tv.LineNoOff
 		tv.Renders[ln].Render(rs, lp) // not top pos -- already has baseline offset
 	}
+	tv.RenderPreedit()
 	rs.Unlock()
 	if tv.HasLineNos() {
 		rs.PopBounds()
@@ -3372,6 +3522,52 @@ func (tv *TextView) RenderLineNo(ln int) {
 	// }
 }
 
+// Print adds the text of the buffer to given print document, rendered as in
+// the view, with syntax highlighting and line numbers if the view shows
+// them, in the font of the view at its point size, with long lines wrapped
+// to the width of the page
+func (tv *TextView) Print(pd *gi.PrintDoc) {
+	if tv.Buf == nil {
+		return
+	}
+	if tv.Sty.Font.Size.Val == 0 { // not yet styled
+		tv.StyleTextView()
+	}
+	sty := pd.PageStyle(&tv.Sty)
+	fst := sty.Font
+	fst.BgColor.SetColor(nil)
+	nln := tv.Buf.NumLines()
+	lnoff := float32(0)
+	lfmt := ""
+	if tv.Buf.Opts.LineNos {
+		digs := ints.MaxInt(1+int(math32.Log10(float32(nln))), 3)
+		lfmt = "%0" + fmt.Sprintf("%v", digs) + "d"
+		lnoff = float32(digs+2) * sty.Font.Ch
+	}
+	lht := sty.Font.Height * sty.Text.EffLineHeight()
+	min, max := pd.ContentBox()
+	sz := gi.Vec2D{X: max.X - min.X - lnoff}
+
+	tv.Buf.MarkupMu.RLock()
+	defer tv.Buf.MarkupMu.RUnlock()
+	for ln := 0; ln < nln && ln < len(tv.Buf.Markup); ln++ {
+		tr := &gi.TextRender{}
+		tr.SetHTMLPre(tv.Buf.Markup[ln], &fst, &sty.Text, &sty.UnContext, tv.CSS)
+		tr.LayoutStdLR(&sty.Text, &sty.Font, &sty.UnContext, sz)
+		pd.Reserve(lht)
+		page, pos := pd.AddText(tr, lnoff)
+		if lfmt != "" {
+			lntr := &gi.TextRender{}
+			lntr.SetString(fmt.Sprintf(lfmt, ln+1), &fst, &sty.UnContext, &sty.Text, true, 0, 0)
+			lntr.LayoutStdLR(&sty.Text, &sty.Font, &sty.UnContext, gi.Vec2D{})
+			lntr.Render(pd.PageRender(page), gi.Vec2D{X: min.X, Y: pos.Y})
+		}
+		if tr.Size.Y < lht {
+			pd.AddSpace(lht - tr.Size.Y)
+		}
+	}
+}
+
 // RenderScrolls renders scrollbars if needed
 func (tv *TextView) RenderScrolls() {
 	if tv.HasFlag(int(TextViewRenderScrolls)) {
@@ -3461,6 +3657,7 @@ func (tv *TextView) RenderLines(st, ed int) bool {
 			lp.X += tv.LineNoOff
 			tv.Renders[ln].Render(rs, lp) // not top pos -- already has baseline offset
 		}
+		tv.RenderPreedit()
 		rs.Unlock()
 		if tv.HasLineNos() {
 			rs.PopBounds()
@@ -4208,7 +4405,7 @@ func (tv *TextView) MouseEvent(me *mouse.Event) {
 			me.SetProcessed()
 			tv.SetCursorFromMouse(pt, newPos, me.SelectMode())
 			tv.SavePosHistory(tv.CursorPos)
-			tv.Paste()
+			tv.PastePrimary()
 		}
 	case mouse.Right:
 		if me.Action == mouse.Press {
@@ -4294,6 +4491,18 @@ func (tv *TextView) TextViewEvents() {
 		kt := d.(*key.ChordEvent)
 		txf.KeyInput(kt)
 	})
+	tv.ConnectEvent(oswin.IMEEvent, gi.RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+		txf := recv.Embed(KiT_TextView).(*TextView)
+		ie := d.(*key.IMEEvent)
+		txf.IMEInput(ie)
+	})
+	tv.ConnectEvent(oswin.DNDEvent, gi.RegPri, func(recv, send ki.Ki, sig int64, d interface{}) {
+		de := d.(*dnd.Event)
+		if de.Action == dnd.DropOnTarget {
+			txf := recv.Embed(KiT_TextView).(*TextView)
+			txf.DragNDropTarget(de)
+		}
+	})
 	if dlg, ok := tv.Viewport.This().(*gi.Dialog); ok {
 		dlg.DialogSig.Connect(tv.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
 			txf, _ := recv.Embed(KiT_TextView).(*TextView)
@@ -4319,7 +4528,7 @@ func (tv *TextView) StyleTextView() {
 	for i := 0; i < int(TextViewStatesN); i++ {
 		tv.StateStyles[i].CopyFrom(&tv.Sty)
 		tv.StateStyles[i].SetStyleProps(pst, tv.StyleProps(TextViewSelectors[i]), tv.Viewport)
-		tv.StateStyles[i].StyleCSS(tv.This().(gi.Node2D), tv.CSSAgg, TextViewSelectors[i], tv.Viewport)
+		tv.StyleCSS(&tv.StateStyles[i], TextViewSelectors[i])
 		tv.StateStyles[i].CopyUnitContext(&tv.Sty.UnContext)
 	}
 	tv.CursorWidth.SetFmInheritProp("cursor-width", tv.This(), true, true) // inherit and get type defaults
@@ -4422,6 +4631,8 @@ func (tv *TextView) FocusChanged2D(change gi.FocusChanges) {
 	switch change {
 	case gi.FocusLost:
 		tv.ClearFlag(int(TextViewFocusActive))
+		tv.SetIMECursor(false)
+		tv.ClearPreedit()
 		// tv.EditDone()
 		tv.UpdateSig()
 		// fmt.Printf("lost focus: %v\n", tv.Nm)
@@ -4432,6 +4643,8 @@ func (tv *TextView) FocusChanged2D(change gi.FocusChanges) {
 		// fmt.Printf("got focus: %v\n", tv.Nm)
 	case gi.FocusInactive:
 		tv.ClearFlag(int(TextViewFocusActive))
+		tv.SetIMECursor(false)
+		tv.ClearPreedit()
 		// tv.EditDone()
 		// tv.UpdateSig()
 		// fmt.Printf("focus inactive: %v\n", tv.Nm)
@@ -1990,6 +1990,7 @@ func (tv *TreeView) StyleTreeView() {
 	for i := 0; i < int(TreeViewStatesN); i++ {
 		tv.StateStyles[i].CopyFrom(&tv.Sty)
 		tv.StateStyles[i].SetStyleProps(pst, tv.StyleProps(TreeViewSelectors[i]), tv.Viewport)
+		tv.StyleCSS(&tv.StateStyles[i], TreeViewSelectors[i])
 		tv.StateStyles[i].CopyUnitContext(&tv.Sty.UnContext)
 	}
 	tv.Indent.SetFmInheritProp("indent", tv.This(), false, true) // no inherit, yes type defaults
@@ -23,5 +23,5 @@ require (
 	golang.org/x/image v0.0.0-20181116024801-cd38e8056d9b
 	golang.org/x/mobile v0.0.0-20190103144551-9a2b4796a4b7
 	golang.org/x/net v0.0.0-20181220203305-927f97764cc3
-	golang.org/x/text v0.3.0 // indirect
+	golang.org/x/text v0.3.0
 )
@@ -153,6 +153,10 @@ const (
 	// Windows is a Microsoft Windows machine
 	Windows
 
+	// Offscreen is the in-memory offscreen driver, with no display -- used for
+	// testing and for running on servers
+	Offscreen
+
 	PlatformsN
 )
 
@@ -41,3 +41,24 @@ type Board interface {
 	// Clear clears the clipboard
 	Clear()
 }
+
+// PrimaryBoard is implemented by clipboards on platforms that also have a
+// separate PRIMARY selection (X11) -- this holds the most recently selected
+// text, which is pasted with the middle mouse button, independent of the
+// explicit cut / copy / paste clipboard.
+type PrimaryBoard interface {
+	Board
+
+	// Primary returns the Board for the PRIMARY selection -- writing to it
+	// makes us the owner of the selection.
+	Primary() Board
+}
+
+// Primary returns the PRIMARY selection Board for given clipboard, or nil if
+// the platform does not have a primary selection.
+func Primary(b Board) Board {
+	if pb, ok := b.(PrimaryBoard); ok {
+		return pb.Primary()
+	}
+	return nil
+}
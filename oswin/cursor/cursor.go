@@ -3,19 +3,22 @@
 // license that can be found in the LICENSE file.
 
 // Package cursor defines the oswin cursor interface and standard system
-// cursors that are supported across platforms
+// cursors that are supported across platforms, plus custom cursors defined
+// by images, which are registered as additional Shapes (see AddCustom)
 package cursor
 
 import (
 	"fmt"
+	"image"
 	"log"
+	"sync"
 
 	"github.com/goki/ki/kit"
+	"golang.org/x/image/draw"
 )
 
-// todo: apps can add new named shapes starting at ShapesN
-
-// Shapes are the standard cursor shapes available on all platforms
+// Shapes are the cursor shapes: the standard ones below are available on all
+// platforms, and custom shapes registered with AddCustom start at ShapesN
 type Shapes int32
 
 const (
@@ -88,9 +91,8 @@ var Drags = map[Shapes]struct{}{
 	DragLink: struct{}{},
 }
 
-// Cursor manages the mouse cursor / pointer appearance.  Currently only a
-// fixed set of standard cursors are supported, but in the future it will be
-// possible to set the cursor from an image / svg.
+// Cursor manages the mouse cursor / pointer appearance, using the standard
+// Shapes, or custom shapes registered with AddCustom / AddCustomRender.
 type Cursor interface {
 
 	// Current returns the current shape of the cursor.
@@ -181,3 +183,130 @@ func (c *CursorBase) PeekStack() Shapes {
 	}
 	return c.Stack[sz-1]
 }
+
+////////////////////////////////////////////////////////////////////////////////
+//  Custom cursors
+
+// BaseDPI is the DPI at which custom cursors have their nominal size -- they
+// are scaled by the logical DPI of the window relative to this.
+const BaseDPI = 96
+
+// Custom is a custom cursor shape defined by an image, or a function that
+// renders the image at a given size (e.g., from an SVG icon) -- register
+// using AddCustom or AddCustomRender, which return the Shapes value to use
+// for Push etc, just like the standard cursors.
+type Custom struct {
+
+	// Name is the unique name of the cursor.
+	Name string
+
+	// Size is the nominal size in pixels, at BaseDPI.
+	Size image.Point
+
+	// HotSpot is the point within the cursor that is the pointer position,
+	// in pixels at the nominal Size.
+	HotSpot image.Point
+
+	// Image is the source image for bitmap cursors, which is scaled as
+	// needed for the DPI.
+	Image image.Image
+
+	// Render, if set, renders the cursor at given size in pixels -- used
+	// instead of Image, to render at full resolution for each DPI.
+	Render func(size image.Point) image.Image
+}
+
+var (
+	customs   []*Custom
+	customsMu sync.RWMutex
+)
+
+// AddCustom registers a custom cursor with given name, from given image,
+// with the hot spot in image pixel coordinates -- the image is taken as the
+// nominal size at BaseDPI and scaled for higher DPI.  Returns the shape for
+// the cursor -- if the name is already registered, the existing shape is
+// returned unchanged.
+func AddCustom(name string, img image.Image, hotSpot image.Point) Shapes {
+	return addCustom(&Custom{Name: name, Size: img.Bounds().Size(), HotSpot: hotSpot, Image: img})
+}
+
+// AddCustomRender registers a custom cursor with given name, rendered by
+// given function at the pixel size needed for the DPI, with given nominal
+// size and hot spot at BaseDPI.  Returns the shape for the cursor -- if the
+// name is already registered, the existing shape is returned unchanged.
+func AddCustomRender(name string, size, hotSpot image.Point, render func(size image.Point) image.Image) Shapes {
+	return addCustom(&Custom{Name: name, Size: size, HotSpot: hotSpot, Render: render})
+}
+
+func addCustom(cu *Custom) Shapes {
+	customsMu.Lock()
+	defer customsMu.Unlock()
+	for i, ec := range customs {
+		if ec.Name == cu.Name {
+			return ShapesN + Shapes(i)
+		}
+	}
+	customs = append(customs, cu)
+	return ShapesN + Shapes(len(customs)-1)
+}
+
+// CustomShape returns the custom cursor for given shape -- nil if it is
+// one of the standard shapes, or not registered.
+func CustomShape(sh Shapes) *Custom {
+	if sh < ShapesN {
+		return nil
+	}
+	customsMu.RLock()
+	defer customsMu.RUnlock()
+	idx := int(sh - ShapesN)
+	if idx >= len(customs) {
+		return nil
+	}
+	return customs[idx]
+}
+
+// CustomByName returns the shape of the custom cursor with given name, and
+// false if not found.
+func CustomByName(name string) (Shapes, bool) {
+	customsMu.RLock()
+	defer customsMu.RUnlock()
+	for i, cu := range customs {
+		if cu.Name == name {
+			return ShapesN + Shapes(i), true
+		}
+	}
+	return Arrow, false
+}
+
+// IsCustom returns true if given shape is a registered custom cursor.
+func IsCustom(sh Shapes) bool {
+	return CustomShape(sh) != nil
+}
+
+// ImageForDPI returns the cursor image and hot spot at the size for given
+// logical DPI -- the image is always a new RGBA image starting at 0,0.
+func (cu *Custom) ImageForDPI(dpi float32) (*image.RGBA, image.Point) {
+	scale := dpi / BaseDPI
+	if scale <= 0 {
+		scale = 1
+	}
+	sz := image.Point{int(float32(cu.Size.X)*scale + 0.5), int(float32(cu.Size.Y)*scale + 0.5)}
+	hot := image.Point{int(float32(cu.HotSpot.X) * scale), int(float32(cu.HotSpot.Y) * scale)}
+	rgba := image.NewRGBA(image.Rectangle{Max: sz})
+	var src image.Image
+	if cu.Render != nil {
+		src = cu.Render(sz)
+	} else {
+		src = cu.Image
+	}
+	if src == nil {
+		return rgba, hot
+	}
+	sb := src.Bounds()
+	if sb.Size() == sz {
+		draw.Draw(rgba, rgba.Bounds(), src, sb.Min, draw.Src)
+	} else {
+		draw.CatmullRom.Scale(rgba, rgba.Bounds(), src, sb, draw.Src, nil)
+	}
+	return rgba, hot
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cursor
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// renderSize is the last size rendered by the test-render cursor -- custom
+// cursors stay registered, so this is set by the first registered function
+// when the test is run more than once
+var renderSize image.Point
+
+func TestCustom(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.ZP, draw.Src)
+	sh := AddCustom("test-square", img, image.Point{8, 8})
+	if sh < ShapesN || !IsCustom(sh) {
+		t.Fatalf("custom shape: %v not custom\n", sh)
+	}
+	if sh2 := AddCustom("test-square", img, image.ZP); sh2 != sh {
+		t.Errorf("re-registering gave new shape: %v != %v\n", sh2, sh)
+	}
+	if fs, ok := CustomByName("test-square"); !ok || fs != sh {
+		t.Errorf("CustomByName: %v %v\n", fs, ok)
+	}
+	cu := CustomShape(sh)
+	ci, hot := cu.ImageForDPI(2 * BaseDPI)
+	if ci.Bounds().Size() != (image.Point{32, 32}) || hot != (image.Point{16, 16}) {
+		t.Errorf("2x: size %v hot %v\n", ci.Bounds().Size(), hot)
+	}
+	if ci.RGBAAt(16, 16).A != 255 {
+		t.Errorf("2x image not drawn: %v\n", ci.RGBAAt(16, 16))
+	}
+
+	renderSize = image.ZP
+	rsh := AddCustomRender("test-render", image.Point{20, 20}, image.ZP, func(sz image.Point) image.Image {
+		renderSize = sz
+		return image.NewRGBA(image.Rectangle{Max: sz})
+	})
+	CustomShape(rsh).ImageForDPI(144)
+	if renderSize != (image.Point{30, 30}) {
+		t.Errorf("render size at 1.5x: %v\n", renderSize)
+	}
+	if IsCustom(Arrow) || CustomShape(rsh+1) != nil {
+		t.Errorf("standard / unregistered shapes reported as custom\n")
+	}
+}
@@ -7,6 +7,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
+// +build !offscreen
+
 package driver
 
 import (
@@ -55,6 +55,9 @@ void clipWrite();
 void pushCursor(int);
 void popCursor();
 void setCursor(int);
+uintptr_t newImageCursor(void* pix, int w, int h, int ptw, int pth, int hotx, int hoty);
+void pushImageCursor(uintptr_t crID);
+void setImageCursor(uintptr_t crID);
 void hideCursor();
 void showCursor();
 void clipClear();
@@ -771,23 +774,58 @@ func addMimeData(ctyp *C.char, typlen C.int, cdata *C.char, datalen C.int) {
 
 type cursorImpl struct {
 	cursor.CursorBase
-	mu sync.Mutex
+	customs map[cursor.Shapes]C.uintptr_t
+	mu      sync.Mutex
 }
 
 var theCursor = cursorImpl{CursorBase: cursor.CursorBase{Vis: true}}
 
+// customCursor returns the NSCursor for given shape if it is a custom cursor,
+// creating it as needed -- 0 if not custom, or it could not be created, in
+// which case the standard cursor for the shape is used (Arrow if custom)
+func (c *cursorImpl) customCursor(sh cursor.Shapes) C.uintptr_t {
+	cu := cursor.CustomShape(sh)
+	if cu == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.customs == nil {
+		c.customs = make(map[cursor.Shapes]C.uintptr_t)
+	}
+	cr, ok := c.customs[sh]
+	if !ok {
+		// cursors are sized in points, so render at twice the size for Retina
+		img, _ := cu.ImageForDPI(2 * cursor.BaseDPI)
+		sz := img.Bounds().Size()
+		if sz.X > 0 && sz.Y > 0 {
+			cr = C.newImageCursor(unsafe.Pointer(&img.Pix[0]), C.int(sz.X), C.int(sz.Y), C.int(cu.Size.X), C.int(cu.Size.Y), C.int(cu.HotSpot.X), C.int(cu.HotSpot.Y))
+		}
+		c.customs[sh] = cr
+	}
+	return cr
+}
+
 func (c *cursorImpl) Push(sh cursor.Shapes) {
 	c.mu.Lock()
 	c.PushStack(sh)
 	c.mu.Unlock()
-	C.pushCursor(C.int(sh))
+	if cr := c.customCursor(sh); cr != 0 {
+		C.pushImageCursor(cr)
+	} else {
+		C.pushCursor(C.int(sh))
+	}
 }
 
 func (c *cursorImpl) Set(sh cursor.Shapes) {
 	c.mu.Lock()
 	c.Cur = sh
 	c.mu.Unlock()
-	C.setCursor(C.int(sh))
+	if cr := c.customCursor(sh); cr != 0 {
+		C.setImageCursor(cr)
+	} else {
+		C.setCursor(C.int(sh))
+	}
 }
 
 func (c *cursorImpl) Pop() {
@@ -979,10 +979,46 @@ NSCursor* getCursor(int curs) {
     case 16: // Wait
         cr = [NSCursor disappearingItemCursor]; // todo: needs custom
         break;
+    default: // custom cursors that could not be created
+        cr = [NSCursor arrowCursor];
+        break;
     }
     return cr;
 }
 
+// newImageCursor returns a new cursor from premultiplied RGBA pixels of given
+// size, shown at given size in points, with hot spot in points
+uintptr_t newImageCursor(void* pix, int w, int h, int ptw, int pth, int hotx, int hoty) {
+    NSBitmapImageRep* rep = [[NSBitmapImageRep alloc]
+        initWithBitmapDataPlanes:NULL
+        pixelsWide:w
+        pixelsHigh:h
+        bitsPerSample:8
+        samplesPerPixel:4
+        hasAlpha:YES
+        isPlanar:NO
+        colorSpaceName:NSDeviceRGBColorSpace
+        bytesPerRow:w*4
+        bitsPerPixel:32];
+    memcpy([rep bitmapData], pix, w*h*4);
+    NSImage* img = [[NSImage alloc] initWithSize:NSMakeSize(ptw, pth)];
+    [img addRepresentation:rep];
+    NSCursor* cr = [[NSCursor alloc] initWithImage:img hotSpot:NSMakePoint(hotx, hoty)];
+    [rep release];
+    [img release];
+    return (uintptr_t)cr;
+}
+
+void pushImageCursor(uintptr_t crID) {
+    NSCursor* cr = (NSCursor*)crID;
+    [cr push];
+}
+
+void setImageCursor(uintptr_t crID) {
+    NSCursor* cr = (NSCursor*)crID;
+    [cr set];
+}
+
 void pushCursor(int curs) {
     NSCursor* cr = getCursor(curs);
     if (cr != NULL) {
@@ -0,0 +1,373 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/clip"
+	"github.com/goki/gi/oswin/cursor"
+	"github.com/goki/gi/oswin/window"
+	"github.com/goki/ki/bitflag"
+)
+
+type appImpl struct {
+	mu            sync.Mutex
+	windows       []*windowImpl
+	screens       []*oswin.Screen
+	ctxtwin       *windowImpl
+	name          string
+	about         string
+	quitting      bool // set to true when quitting and closing windows
+	quitReqFunc   func()
+	quitCleanFunc func()
+}
+
+var theApp *appImpl
+
+func newAppImpl() *appImpl {
+	app := &appImpl{
+		windows: make([]*windowImpl, 0),
+		name:    "GoGi",
+	}
+	app.initScreens()
+	oswin.TheApp = app
+	theApp = app
+	return app
+}
+
+// initScreens initializes the screens from the Screens list, sending a
+// window.ScreenUpdate event to any window whose screen has changed
+func (app *appImpl) initScreens() {
+	app.mu.Lock()
+	if len(Screens) == 0 {
+		Screens = []oswin.Screen{DefaultScreen}
+	}
+	app.screens = make([]*oswin.Screen, len(Screens))
+	for i := range Screens {
+		sc := Screens[i] // copy
+		sc.ScreenNumber = i
+		if sc.PhysicalDPI == 0 {
+			sc.PhysicalDPI = 96
+		}
+		if sc.LogicalDPI == 0 {
+			sc.LogicalDPI = sc.PhysicalDPI
+		}
+		if sc.DevicePixelRatio == 0 {
+			sc.DevicePixelRatio = 1
+		}
+		if sc.Name == "" {
+			sc.Name = fmt.Sprintf("offscreen:%v", i)
+		}
+		app.screens[i] = &sc
+	}
+	var upd []*windowImpl
+	for _, w := range app.windows {
+		w.mu.Lock()
+		osc := w.Scrn
+		if w.Scrn == nil || w.Scrn.ScreenNumber >= len(app.screens) {
+			w.Scrn = app.screens[0]
+		} else {
+			w.Scrn = app.screens[w.Scrn.ScreenNumber]
+		}
+		w.PhysDPI = w.Scrn.PhysicalDPI
+		if osc != nil && *osc != *w.Scrn {
+			upd = append(upd, w)
+		}
+		w.mu.Unlock()
+	}
+	app.mu.Unlock()
+	for _, w := range upd {
+		sendWindowEvent(w, window.ScreenUpdate)
+	}
+}
+
+func (app *appImpl) NewImage(size image.Point) (oswin.Image, error) {
+	if size.X < 0 || size.Y < 0 {
+		return nil, fmt.Errorf("offscreen: invalid image size %v", size)
+	}
+	return &imageImpl{
+		size: size,
+		rgba: image.NewRGBA(image.Rectangle{Max: size}),
+	}, nil
+}
+
+func (app *appImpl) NewTexture(win oswin.Window, size image.Point) (oswin.Texture, error) {
+	if size.X < 0 || size.Y < 0 {
+		return nil, fmt.Errorf("offscreen: invalid texture size %v", size)
+	}
+	ww := win.(*windowImpl)
+	nt := &textureImpl{
+		w:    ww,
+		size: size,
+		rgba: image.NewRGBA(image.Rectangle{Max: size}),
+	}
+	ww.AddTexture(nt)
+	return nt, nil
+}
+
+func (app *appImpl) NewWindow(opts *oswin.NewWindowOptions) (oswin.Window, error) {
+	if opts == nil {
+		opts = &oswin.NewWindowOptions{}
+	}
+	opts.Fixup()
+
+	sc := app.Screen(0)
+
+	w := &windowImpl{
+		app: app,
+		WindowBase: oswin.WindowBase{
+			Titl:    opts.GetTitle(),
+			Sz:      opts.Size,
+			Pos:     opts.Pos,
+			PhysDPI: sc.PhysicalDPI,
+			LogDPI:  sc.LogicalDPI,
+			Scrn:    sc,
+			Flag:    opts.Flags,
+			Opac:    opts.Opacity,
+		},
+	}
+	if w.IsMaximized() || w.IsFullscreen() {
+		w.restore = image.Rectangle{Min: opts.Pos, Max: opts.Pos.Add(opts.Size)}
+		w.Pos = sc.Geometry.Min
+		w.Sz = sc.Geometry.Size()
+	}
+	w.back = image.NewRGBA(image.Rectangle{Max: w.Sz})
+	w.front = image.NewRGBA(image.Rectangle{Max: w.Sz})
+
+	app.mu.Lock()
+	app.windows = append(app.windows, w)
+	app.mu.Unlock()
+
+	// sequence of events that a window manager would generate for a newly
+	// mapped window
+	sendWindowEvent(w, window.Resize)
+	sendWindowEvent(w, window.Paint)
+	app.setFocus(w)
+	return w, nil
+}
+
+// setFocus gives the keyboard focus to given window, taking it away from
+// any other window that had it
+func (app *appImpl) setFocus(w *windowImpl) {
+	app.mu.Lock()
+	wins := make([]*windowImpl, len(app.windows))
+	copy(wins, app.windows)
+	app.mu.Unlock()
+	for _, ow := range wins {
+		if ow == w || !ow.IsFocus() {
+			continue
+		}
+		ow.mu.Lock()
+		bitflag.ClearAtomic(&ow.Flag, int(oswin.Focus))
+		ow.mu.Unlock()
+		sendWindowEvent(ow, window.DeFocus)
+	}
+	if w.IsFocus() {
+		return
+	}
+	w.mu.Lock()
+	bitflag.ClearAtomic(&w.Flag, int(oswin.Minimized))
+	bitflag.SetAtomic(&w.Flag, int(oswin.Focus))
+	w.mu.Unlock()
+	sendWindowEvent(w, window.Focus)
+}
+
+func (app *appImpl) DeleteWin(w *windowImpl) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	for i, wl := range app.windows {
+		if wl == w {
+			app.windows = append(app.windows[:i], app.windows[i+1:]...)
+			break
+		}
+	}
+	if app.ctxtwin == w {
+		app.ctxtwin = nil
+	}
+}
+
+func (app *appImpl) NScreens() int {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	return len(app.screens)
+}
+
+func (app *appImpl) Screen(scrN int) *oswin.Screen {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if scrN >= 0 && scrN < len(app.screens) {
+		return app.screens[scrN]
+	}
+	return nil
+}
+
+func (app *appImpl) NWindows() int {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	return len(app.windows)
+}
+
+func (app *appImpl) Window(win int) oswin.Window {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if win >= 0 && win < len(app.windows) {
+		return app.windows[win]
+	}
+	return nil
+}
+
+func (app *appImpl) WindowByName(name string) oswin.Window {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	for _, win := range app.windows {
+		if win.Name() == name {
+			return win
+		}
+	}
+	return nil
+}
+
+func (app *appImpl) WindowInFocus() oswin.Window {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	for _, win := range app.windows {
+		if win.IsFocus() {
+			return win
+		}
+	}
+	return nil
+}
+
+func (app *appImpl) ContextWindow() oswin.Window {
+	app.mu.Lock()
+	cw := app.ctxtwin
+	app.mu.Unlock()
+	if cw == nil {
+		return nil
+	}
+	return cw
+}
+
+func (app *appImpl) Platform() oswin.Platforms {
+	return oswin.Offscreen
+}
+
+func (app *appImpl) Name() string {
+	return app.name
+}
+
+func (app *appImpl) SetName(name string) {
+	app.name = name
+}
+
+func (app *appImpl) PrefsDir() string {
+	if PrefsDir != "" {
+		return PrefsDir
+	}
+	usr, err := user.Current()
+	if err != nil {
+		log.Print(err)
+		return os.TempDir()
+	}
+	return filepath.Join(usr.HomeDir, ".config")
+}
+
+func (app *appImpl) GoGiPrefsDir() string {
+	pdir := filepath.Join(app.PrefsDir(), "GoGi")
+	os.MkdirAll(pdir, 0755)
+	return pdir
+}
+
+func (app *appImpl) AppPrefsDir() string {
+	pdir := filepath.Join(app.PrefsDir(), app.Name())
+	os.MkdirAll(pdir, 0755)
+	return pdir
+}
+
+func (app *appImpl) FontPaths() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"/Library/Fonts"}
+	case "windows":
+		return []string{"C:\\Windows\\Fonts"}
+	}
+	return []string{"/usr/share/fonts/truetype"}
+}
+
+func (app *appImpl) ClipBoard(win oswin.Window) clip.Board {
+	app.mu.Lock()
+	app.ctxtwin, _ = win.(*windowImpl)
+	app.mu.Unlock()
+	return &theClip
+}
+
+func (app *appImpl) Cursor(win oswin.Window) cursor.Cursor {
+	app.mu.Lock()
+	app.ctxtwin, _ = win.(*windowImpl)
+	app.mu.Unlock()
+	return &theCursor
+}
+
+func (app *appImpl) About() string {
+	return app.about
+}
+
+func (app *appImpl) SetAbout(about string) {
+	app.about = about
+}
+
+func (app *appImpl) OpenURL(url string) {
+	log.Printf("offscreen: OpenURL not supported: %v\n", url)
+}
+
+func (app *appImpl) SetQuitReqFunc(fun func()) {
+	app.quitReqFunc = fun
+}
+
+func (app *appImpl) SetQuitCleanFunc(fun func()) {
+	app.quitCleanFunc = fun
+}
+
+func (app *appImpl) QuitReq() {
+	if app.quitting {
+		return
+	}
+	if app.quitReqFunc != nil {
+		app.quitReqFunc()
+	} else {
+		app.Quit()
+	}
+}
+
+func (app *appImpl) IsQuitting() bool {
+	return app.quitting
+}
+
+func (app *appImpl) QuitClean() {
+	app.quitting = true
+	if app.quitCleanFunc != nil {
+		app.quitCleanFunc()
+	}
+	app.mu.Lock()
+	wins := make([]*windowImpl, len(app.windows))
+	copy(wins, app.windows)
+	app.mu.Unlock()
+	for i := len(wins) - 1; i >= 0; i-- {
+		wins[i].Close()
+	}
+}
+
+func (app *appImpl) Quit() {
+	app.QuitClean()
+}
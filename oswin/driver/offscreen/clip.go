@@ -0,0 +1,58 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"sync"
+
+	"github.com/goki/gi/oswin/clip"
+	"github.com/goki/gi/oswin/mimedata"
+)
+
+// clipImpl is a process-local clipboard -- whatever was last written is
+// returned by Read, regardless of the types requested (as with the other
+// drivers, it is up to the caller to select among what is returned)
+type clipImpl struct {
+	lastWrite mimedata.Mimes
+	mu        sync.Mutex
+}
+
+var theClip = clipImpl{}
+
+// thePrimary is the PRIMARY selection, which we support as on X11 so that
+// it can be tested
+var thePrimary = clipImpl{}
+
+func (ci *clipImpl) IsEmpty() bool {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	return len(ci.lastWrite) == 0
+}
+
+func (ci *clipImpl) Read(types []string) mimedata.Mimes {
+	if types == nil {
+		return nil
+	}
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	return ci.lastWrite
+}
+
+func (ci *clipImpl) Write(data mimedata.Mimes) error {
+	ci.mu.Lock()
+	ci.lastWrite = data
+	ci.mu.Unlock()
+	return nil
+}
+
+func (ci *clipImpl) Clear() {
+	ci.mu.Lock()
+	ci.lastWrite = nil
+	ci.mu.Unlock()
+}
+
+func (ci *clipImpl) Primary() clip.Board {
+	return &thePrimary
+}
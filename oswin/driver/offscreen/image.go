@@ -0,0 +1,21 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"image"
+)
+
+// imageImpl is a plain in-memory image -- no shared memory is needed as
+// there is no display server to share it with
+type imageImpl struct {
+	size image.Point
+	rgba *image.RGBA
+}
+
+func (b *imageImpl) Size() image.Point       { return b.size }
+func (b *imageImpl) Bounds() image.Rectangle { return image.Rectangle{Max: b.size} }
+func (b *imageImpl) RGBA() *image.RGBA       { return b.rgba }
+func (b *imageImpl) Release()                {}
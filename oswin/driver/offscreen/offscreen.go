@@ -0,0 +1,91 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package offscreen provides an in-memory oswin driver that renders all
+// windows into image.RGBA buffers, without any connection to a display
+// server.  It is selected by building with the offscreen build tag, or by
+// setting the GOGI_DRIVER environment variable to "offscreen", and is
+// intended for running full gi.Window event loops in tests, CI containers
+// and servers.
+//
+// Events can be injected into windows using SendEvent (or directly via the
+// oswin.EventDeque Send method), and the most recently published contents of
+// a window can be obtained using WindowImage.  The virtual screens reported
+// by the App are configured via Screens, prior to calling Main, or SetScreens
+// at any point thereafter.
+package offscreen
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/goki/gi/oswin"
+)
+
+// DefaultScreen is the virtual screen used if Screens is empty: a standard
+// 1920x1080 display at 96 DPI.
+var DefaultScreen = oswin.Screen{
+	Geometry:         image.Rectangle{Max: image.Point{1920, 1080}},
+	Depth:            32,
+	LogicalDPI:       96,
+	PhysicalDPI:      96,
+	PhysicalSize:     image.Point{508, 286},
+	DevicePixelRatio: 1,
+	RefreshRate:      60,
+	Orientation:      oswin.Landscape,
+	Name:             "offscreen:0",
+}
+
+// Screens are the virtual screens reported by the offscreen App -- set prior
+// to calling Main to configure the geometry and DPI of the screens, or use
+// SetScreens once the App is running.  The first screen is the default one
+// on which all windows are opened.
+var Screens = []oswin.Screen{DefaultScreen}
+
+// PrefsDir, if non-empty, is used by the App as the prefs directory instead
+// of the standard user config directory -- e.g., set it to a temporary
+// directory so that tests are not affected by any user-specific preferences.
+var PrefsDir = ""
+
+// Main is called by the program's main function to run the graphical
+// application.
+//
+// It calls f on the App, in the same goroutine, and returns when f returns.
+func Main(f func(oswin.App)) {
+	app := newAppImpl()
+	f(app)
+}
+
+// SendEvent initializes the given event (setting its time to now) and sends
+// it to the given window, exactly as if it had been generated by the OS --
+// this is the primary means of simulating user input.
+func SendEvent(win oswin.Window, ev oswin.Event) {
+	ev.Init()
+	win.Send(ev)
+}
+
+// WindowImage returns a copy of the image most recently published by given
+// window, which must have been created by the offscreen App -- returns nil
+// otherwise.
+func WindowImage(win oswin.Window) *image.RGBA {
+	w, ok := win.(*windowImpl)
+	if !ok {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	img := image.NewRGBA(w.front.Bounds())
+	draw.Draw(img, img.Bounds(), w.front, image.ZP, draw.Src)
+	return img
+}
+
+// SetScreens sets the virtual screens reported by the running offscreen App
+// (and updates Screens accordingly) -- windows whose screen has changed are
+// sent a window.ScreenUpdate event.
+func SetScreens(scs ...oswin.Screen) {
+	Screens = scs
+	if theApp != nil {
+		theApp.initScreens()
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/window"
+)
+
+func TestOffscreenWindow(t *testing.T) {
+	Main(func(app oswin.App) {
+		if app.Platform() != oswin.Offscreen {
+			t.Errorf("Platform: %v != Offscreen\n", app.Platform())
+		}
+		win, err := app.NewWindow(&oswin.NewWindowOptions{Size: image.Point{64, 48}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		acts := []window.Actions{window.Resize, window.Paint, window.Focus}
+		for _, act := range acts {
+			ev, ok := win.NextEvent().(*window.Event)
+			if !ok || ev.Action != act {
+				t.Errorf("initial window event: %v != %v\n", ev, act)
+			}
+		}
+
+		red := color.RGBA{255, 0, 0, 255}
+		img, _ := app.NewImage(image.Point{10, 10})
+		draw.Draw(img.RGBA(), img.Bounds(), &image.Uniform{red}, image.ZP, draw.Src)
+		win.Fill(image.Rectangle{Max: win.Size()}, color.White, draw.Src)
+		win.Upload(image.Point{5, 5}, img, img.Bounds())
+		if wi := WindowImage(win); wi.RGBAAt(6, 6) == red {
+			t.Errorf("window image updated prior to Publish\n")
+		}
+		win.Publish()
+		wi := WindowImage(win)
+		if wi.Bounds().Size() != win.Size() {
+			t.Errorf("window image size: %v != %v\n", wi.Bounds().Size(), win.Size())
+		}
+		if c := wi.RGBAAt(6, 6); c != red {
+			t.Errorf("uploaded pixel: %v != %v\n", c, red)
+		}
+		if c := wi.RGBAAt(20, 20); c != (color.RGBA{255, 255, 255, 255}) {
+			t.Errorf("filled pixel: %v != white\n", c)
+		}
+
+		kev := &key.ChordEvent{}
+		kev.Rune = 'a'
+		SendEvent(win, kev)
+		if ev, ok := win.NextEvent().(*key.ChordEvent); !ok || ev.Rune != 'a' {
+			t.Errorf("injected event not received: %v\n", ev)
+		}
+
+		win.Close()
+		if app.NWindows() != 0 {
+			t.Errorf("NWindows after Close: %v != 0\n", app.NWindows())
+		}
+	})
+}
+
+func TestWindowState(t *testing.T) {
+	Main(func(app oswin.App) {
+		opts := &oswin.NewWindowOptions{Size: image.Point{64, 48}, Pos: image.Point{10, 10}}
+		opts.SetFrameless()
+		win, err := app.NewWindow(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer win.Close()
+		for i := 0; i < 3; i++ { // initial events
+			win.NextEvent()
+		}
+		if !win.IsFrameless() || win.Opacity() != 1 {
+			t.Errorf("frameless: %v, opacity: %v\n", win.IsFrameless(), win.Opacity())
+		}
+		nextAct := func() window.Actions {
+			ev, ok := win.NextEvent().(*window.Event)
+			if !ok {
+				return window.ActionsN
+			}
+			return ev.Action
+		}
+
+		win.SetMaximized(true)
+		if act := nextAct(); act != window.Resize || win.Size() != app.Screen(0).Geometry.Size() {
+			t.Errorf("maximize: %v, size: %v\n", act, win.Size())
+		}
+		if act := nextAct(); act != window.StateChange || !win.IsMaximized() {
+			t.Errorf("maximize: %v, maximized: %v\n", act, win.IsMaximized())
+		}
+		win.SetMaximized(false)
+		nextAct()
+		if act := nextAct(); act != window.StateChange || win.IsMaximized() || win.Size() != (image.Point{64, 48}) {
+			t.Errorf("restore: %v, maximized: %v, size: %v\n", act, win.IsMaximized(), win.Size())
+		}
+		win.SetAlwaysOnTop(true)
+		if act := nextAct(); act != window.StateChange || !win.IsAlwaysOnTop() {
+			t.Errorf("always on top: %v, %v\n", act, win.IsAlwaysOnTop())
+		}
+
+		win.SetDragRegions([]image.Rectangle{image.Rect(0, 0, 64, 10)})
+		wb := win.(*windowImpl)
+		if !wb.InDragRegion(image.Point{5, 5}) || wb.InDragRegion(image.Point{5, 20}) {
+			t.Errorf("InDragRegion wrong for regions: %v\n", win.DragRegions())
+		}
+	})
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/goki/gi/oswin"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// textureImpl is an in-memory texture -- there is no GPU so it is just an
+// image.RGBA, like an Image
+type textureImpl struct {
+	w    *windowImpl
+	size image.Point
+	rgba *image.RGBA
+
+	mu       sync.Mutex
+	released bool
+}
+
+func (t *textureImpl) Size() image.Point       { return t.size }
+func (t *textureImpl) Bounds() image.Rectangle { return image.Rectangle{Max: t.size} }
+
+func (t *textureImpl) Release() {
+	t.mu.Lock()
+	released := t.released
+	t.released = true
+	t.mu.Unlock()
+	if released {
+		return
+	}
+	t.w.DeleteTexture(t)
+}
+
+func (t *textureImpl) Upload(dp image.Point, src oswin.Image, sr image.Rectangle) {
+	t.mu.Lock()
+	upload(t.rgba, dp, src, sr)
+	t.mu.Unlock()
+}
+
+func (t *textureImpl) Fill(dr image.Rectangle, src color.Color, op draw.Op) {
+	t.mu.Lock()
+	draw.Draw(t.rgba, dr, &image.Uniform{src}, image.ZP, op)
+	t.mu.Unlock()
+}
+
+// upload copies the sr region of src into dst at dp, using draw.Src
+func upload(dst *image.RGBA, dp image.Point, src oswin.Image, sr image.Rectangle) {
+	originalSRMin := sr.Min
+	sr = sr.Intersect(src.Bounds())
+	if sr.Empty() {
+		return
+	}
+	dp = dp.Add(sr.Min.Sub(originalSRMin))
+	dr := image.Rectangle{Min: dp, Max: dp.Add(sr.Size())}
+	draw.Draw(dst, dr, src.RGBA(), sr.Min, draw.Src)
+}
+
+// drawImage draws the sr region of src onto dst, transformed by src2dst --
+// pure integer translations are done directly, and everything else uses
+// bilinear interpolation.
+func drawImage(dst *image.RGBA, src2dst *f64.Aff3, src image.Image, sr image.Rectangle, op draw.Op) {
+	if _, uni := src.(*image.Uniform); !uni {
+		sr = sr.Intersect(src.Bounds())
+	}
+	if sr.Empty() {
+		return
+	}
+	if src2dst[0] == 1 && src2dst[1] == 0 && src2dst[3] == 0 && src2dst[4] == 1 {
+		dx, dy := int(src2dst[2]), int(src2dst[5])
+		if float64(dx) == src2dst[2] && float64(dy) == src2dst[5] {
+			dr := sr.Add(image.Point{dx, dy})
+			draw.Draw(dst, dr, src, sr.Min, op)
+			return
+		}
+	}
+	xdraw.ApproxBiLinear.Transform(dst, *src2dst, src, sr, op, nil)
+}
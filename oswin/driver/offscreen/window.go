@@ -0,0 +1,357 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package offscreen
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/driver/internal/drawer"
+	"github.com/goki/gi/oswin/driver/internal/event"
+	"github.com/goki/gi/oswin/window"
+	"github.com/goki/ki/bitflag"
+	"golang.org/x/image/math/f64"
+)
+
+type windowImpl struct {
+	oswin.WindowBase
+
+	app *appImpl
+
+	event.Deque
+
+	// back is the back buffer that all Upload / Draw calls render into
+	back *image.RGBA
+
+	// front is the image as of the last Publish call
+	front *image.RGBA
+
+	// textures are the textures created for this window -- they are released
+	// when the window is closed
+	textures map[*textureImpl]struct{}
+
+	// restore is the geometry to restore when leaving the maximized or
+	// fullscreen state
+	restore image.Rectangle
+
+	mu             sync.Mutex
+	released       bool
+	closeReqFunc   func(win oswin.Window)
+	closeCleanFunc func(win oswin.Window)
+}
+
+// for sending window.Event's
+func sendWindowEvent(w *windowImpl, act window.Actions) {
+	winEv := window.Event{
+		Action: act,
+	}
+	winEv.Init()
+	w.Send(&winEv)
+}
+
+func (w *windowImpl) Upload(dp image.Point, src oswin.Image, sr image.Rectangle) {
+	w.mu.Lock()
+	upload(w.back, dp, src, sr)
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) Fill(dr image.Rectangle, src color.Color, op draw.Op) {
+	w.mu.Lock()
+	draw.Draw(w.back, dr, &image.Uniform{src}, image.ZP, op)
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) DrawUniform(src2dst f64.Aff3, src color.Color, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	w.mu.Lock()
+	drawImage(w.back, &src2dst, &image.Uniform{src}, sr, op)
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) Draw(src2dst f64.Aff3, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	t := src.(*textureImpl)
+	w.mu.Lock()
+	t.mu.Lock()
+	drawImage(w.back, &src2dst, t.rgba, sr, op)
+	t.mu.Unlock()
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) Copy(dp image.Point, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	drawer.Copy(w, dp, src, sr, op, opts)
+}
+
+func (w *windowImpl) Scale(dr image.Rectangle, src oswin.Texture, sr image.Rectangle, op draw.Op, opts *oswin.DrawOptions) {
+	drawer.Scale(w, dr, src, sr, op, opts)
+}
+
+// Publish copies the back buffer to the front image, which is what
+// WindowImage returns.
+func (w *windowImpl) Publish() oswin.PublishResult {
+	w.mu.Lock()
+	if w.front.Bounds() != w.back.Bounds() {
+		w.front = image.NewRGBA(w.back.Bounds())
+	}
+	copy(w.front.Pix, w.back.Pix)
+	w.mu.Unlock()
+	return oswin.PublishResult{BackImagePreserved: true}
+}
+
+func (w *windowImpl) Screen() *oswin.Screen {
+	w.mu.Lock()
+	if w.Scrn == nil {
+		w.Scrn = theApp.Screen(0)
+	}
+	sc := w.Scrn
+	w.mu.Unlock()
+	return sc
+}
+
+func (w *windowImpl) Size() image.Point {
+	w.mu.Lock()
+	sz := w.Sz
+	w.mu.Unlock()
+	return sz
+}
+
+func (w *windowImpl) Position() image.Point {
+	w.mu.Lock()
+	ps := w.Pos
+	w.mu.Unlock()
+	return ps
+}
+
+func (w *windowImpl) PhysicalDPI() float32 {
+	w.mu.Lock()
+	dpi := w.PhysDPI
+	w.mu.Unlock()
+	return dpi
+}
+
+func (w *windowImpl) LogicalDPI() float32 {
+	w.mu.Lock()
+	dpi := w.LogDPI
+	w.mu.Unlock()
+	return dpi
+}
+
+func (w *windowImpl) SetLogicalDPI(dpi float32) {
+	w.mu.Lock()
+	w.LogDPI = dpi
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) SetTitle(title string) {
+	w.mu.Lock()
+	w.Titl = title
+	w.mu.Unlock()
+}
+
+// setGeom updates the geometry and sends the appropriate window event
+func (w *windowImpl) setGeom(pos image.Point, sz image.Point) {
+	w.mu.Lock()
+	act := window.ActionsN
+	if sz != w.Sz {
+		act = window.Resize
+		w.Sz = sz
+		w.back = resizeRGBA(w.back, sz)
+	} else if pos != w.Pos {
+		act = window.Move
+	}
+	w.Pos = pos
+	w.mu.Unlock()
+	if act != window.ActionsN {
+		sendWindowEvent(w, act)
+	}
+}
+
+func (w *windowImpl) SetSize(sz image.Point) {
+	w.setGeom(w.Position(), sz)
+}
+
+func (w *windowImpl) SetPos(pos image.Point) {
+	w.setGeom(pos, w.Size())
+}
+
+func (w *windowImpl) SetGeom(pos image.Point, sz image.Point) {
+	w.setGeom(pos, sz)
+}
+
+func (w *windowImpl) MainMenu() oswin.MainMenu {
+	return nil
+}
+
+func (w *windowImpl) Raise() {
+	if w.IsMinimized() {
+		sendWindowEvent(w, window.Paint)
+	}
+	w.app.setFocus(w)
+}
+
+func (w *windowImpl) Minimize() {
+	w.mu.Lock()
+	bitflag.SetAtomic(&w.Flag, int(oswin.Minimized))
+	w.mu.Unlock()
+	sendWindowEvent(w, window.Minimize)
+}
+
+// setState sets given state flag, returning true if it changed
+func (w *windowImpl) setState(flag oswin.WindowFlags, on bool) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if bitflag.HasAtomic(&w.Flag, int(flag)) == on {
+		return false
+	}
+	bitflag.SetStateAtomic(&w.Flag, on, int(flag))
+	return true
+}
+
+// setFilled sets given state flag for a state that fills the screen
+// (Maximized or Fullscreen), updating the geometry accordingly
+func (w *windowImpl) setFilled(flag oswin.WindowFlags, on bool) {
+	wasFilled := w.IsMaximized() || w.IsFullscreen()
+	if !w.setState(flag, on) {
+		return
+	}
+	isFilled := w.IsMaximized() || w.IsFullscreen()
+	switch {
+	case isFilled && !wasFilled:
+		w.mu.Lock()
+		w.restore = image.Rectangle{Min: w.Pos, Max: w.Pos.Add(w.Sz)}
+		w.mu.Unlock()
+		sg := w.Screen().Geometry
+		w.setGeom(sg.Min, sg.Size())
+	case !isFilled && wasFilled:
+		w.mu.Lock()
+		rg := w.restore
+		w.mu.Unlock()
+		if rg.Dx() > 0 && rg.Dy() > 0 {
+			w.setGeom(rg.Min, rg.Size())
+		}
+	}
+	sendWindowEvent(w, window.StateChange)
+}
+
+func (w *windowImpl) SetMaximized(max bool) {
+	w.setFilled(oswin.Maximized, max)
+}
+
+func (w *windowImpl) SetFullscreen(fs bool) {
+	w.setFilled(oswin.Fullscreen, fs)
+}
+
+func (w *windowImpl) SetAlwaysOnTop(top bool) {
+	if w.setState(oswin.AlwaysOnTop, top) {
+		sendWindowEvent(w, window.StateChange)
+	}
+}
+
+func (w *windowImpl) SetOpacity(op float32) {
+	w.mu.Lock()
+	w.Opac = op
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) Opacity() float32 {
+	w.mu.Lock()
+	op := w.WindowBase.Opacity()
+	w.mu.Unlock()
+	return op
+}
+
+func (w *windowImpl) DragRegions() []image.Rectangle {
+	w.mu.Lock()
+	regs := w.DragRgs
+	w.mu.Unlock()
+	return regs
+}
+
+func (w *windowImpl) SetDragRegions(regs []image.Rectangle) {
+	w.mu.Lock()
+	w.DragRgs = regs
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) InDragRegion(pos image.Point) bool {
+	w.mu.Lock()
+	in := w.WindowBase.InDragRegion(pos)
+	w.mu.Unlock()
+	return in
+}
+
+func (w *windowImpl) AddTexture(t *textureImpl) {
+	w.mu.Lock()
+	if w.textures == nil {
+		w.textures = make(map[*textureImpl]struct{})
+	}
+	w.textures[t] = struct{}{}
+	w.mu.Unlock()
+}
+
+// DeleteTexture just deletes it from our list -- does not Release -- is called during t.Release
+func (w *windowImpl) DeleteTexture(t *textureImpl) {
+	w.mu.Lock()
+	if w.textures != nil {
+		delete(w.textures, t)
+	}
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) SetCloseReqFunc(fun func(win oswin.Window)) {
+	w.closeReqFunc = fun
+}
+
+func (w *windowImpl) SetCloseCleanFunc(fun func(win oswin.Window)) {
+	w.closeCleanFunc = fun
+}
+
+func (w *windowImpl) CloseReq() {
+	if theApp.quitting {
+		w.Close()
+		return
+	}
+	if w.closeReqFunc != nil {
+		w.closeReqFunc(w)
+	} else {
+		w.Close()
+	}
+}
+
+func (w *windowImpl) CloseClean() {
+	if w.closeCleanFunc != nil {
+		w.closeCleanFunc(w)
+	}
+}
+
+func (w *windowImpl) Close() {
+	w.mu.Lock()
+	released := w.released
+	w.released = true
+	texs := make([]*textureImpl, 0, len(w.textures))
+	for t := range w.textures {
+		texs = append(texs, t)
+	}
+	w.mu.Unlock()
+
+	if released {
+		return
+	}
+	w.CloseClean()
+	sendWindowEvent(w, window.Close)
+	for _, t := range texs {
+		t.Release() // deletes from map
+	}
+	w.app.DeleteWin(w)
+}
+
+// resizeRGBA returns an image of the given size, preserving the existing
+// contents of img where they overlap
+func resizeRGBA(img *image.RGBA, sz image.Point) *image.RGBA {
+	nimg := image.NewRGBA(image.Rectangle{Max: sz})
+	draw.Draw(nimg, nimg.Bounds(), img, image.ZP, draw.Src)
+	return nimg
+}
@@ -7,10 +7,15 @@
 package windriver
 
 import (
+	"image"
+	"image/draw"
+	"log"
 	"sync"
 	"syscall"
+	"unsafe"
 
 	"github.com/goki/gi/oswin/cursor"
+	"github.com/goki/gi/oswin/driver/internal/swizzle"
 )
 
 var cursorMap = map[cursor.Shapes]int{
@@ -36,19 +41,35 @@ var cursorMap = map[cursor.Shapes]int{
 type cursorImpl struct {
 	cursor.CursorBase
 	cursors map[cursor.Shapes]syscall.Handle
+	customs map[customCursorKey]syscall.Handle
 	mu      sync.Mutex
 }
 
+// customCursorKey identifies a custom cursor rendered for a given DPI
+type customCursorKey struct {
+	sh  cursor.Shapes
+	dpi float32
+}
+
 var theCursor = cursorImpl{CursorBase: cursor.CursorBase{Vis: true}}
 
 func (c *cursorImpl) cursorHandle(sh cursor.Shapes) syscall.Handle {
+	if cu := cursor.CustomShape(sh); cu != nil {
+		if ch := c.customHandle(sh, cu); ch != 0 {
+			return ch
+		}
+		sh = cursor.Arrow
+	}
 	c.mu.Lock()
 	if c.cursors == nil {
 		c.cursors = make(map[cursor.Shapes]syscall.Handle, cursor.ShapesN)
 	}
 	ch, ok := c.cursors[sh]
 	if !ok {
-		idc := cursorMap[sh]
+		idc, has := cursorMap[sh]
+		if !has {
+			idc = _IDC_ARROW
+		}
 		ch = _LoadCursor(0, uintptr(idc))
 		c.cursors[sh] = ch
 	}
@@ -56,6 +77,68 @@ func (c *cursorImpl) cursorHandle(sh cursor.Shapes) syscall.Handle {
 	return ch
 }
 
+// customHandle returns the cursor for given custom shape, at the DPI of the
+// current window -- 0 if it could not be created
+func (c *cursorImpl) customHandle(sh cursor.Shapes, cu *cursor.Custom) syscall.Handle {
+	dpi := float32(cursor.BaseDPI)
+	theApp.mu.Lock()
+	cw := theApp.ctxtwin
+	theApp.mu.Unlock()
+	if cw != nil {
+		dpi = cw.LogicalDPI()
+	}
+	key := customCursorKey{sh, dpi}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.customs == nil {
+		c.customs = make(map[customCursorKey]syscall.Handle)
+	}
+	ch, ok := c.customs[key]
+	if !ok {
+		ch = createImageCursor(cu, dpi)
+		c.customs[key] = ch
+	}
+	return ch
+}
+
+// createImageCursor creates a cursor from custom cursor image at given DPI,
+// with a 32 bit color bitmap whose alpha is used instead of the mask
+func createImageCursor(cu *cursor.Custom, dpi float32) syscall.Handle {
+	img, hot := cu.ImageForDPI(dpi)
+	sz := img.Bounds().Size()
+	if sz.X == 0 || sz.Y == 0 {
+		return 0
+	}
+	color, bits, err := mkbitmap(sz)
+	if err != nil {
+		log.Printf("windriver: mkbitmap for custom cursor %v failed: %v", cu.Name, err)
+		return 0
+	}
+	defer _DeleteObject(color)
+	// cursor alpha is not premultiplied, and the bitmap is BGRA
+	nimg := &image.NRGBA{
+		Pix:    (*[1 << 30]byte)(unsafe.Pointer(bits))[: 4*sz.X*sz.Y : 4*sz.X*sz.Y],
+		Stride: 4 * sz.X,
+		Rect:   image.Rectangle{Max: sz},
+	}
+	draw.Draw(nimg, nimg.Rect, img, image.ZP, draw.Src)
+	swizzle.BGRA(nimg.Pix)
+	mbits := make([]byte, (sz.X+15)/16*2*sz.Y) // rows are word aligned
+	mask, err := _CreateBitmap(int32(sz.X), int32(sz.Y), 1, 1, &mbits[0])
+	if err != nil {
+		log.Printf("windriver: CreateBitmap for custom cursor %v failed: %v", cu.Name, err)
+		return 0
+	}
+	defer _DeleteObject(mask)
+	ii := _ICONINFO{XHotspot: uint32(hot.X), YHotspot: uint32(hot.Y), Mask: mask, Color: color}
+	ch, err := _CreateIconIndirect(&ii)
+	if err != nil {
+		log.Printf("windriver: CreateIconIndirect for custom cursor %v failed: %v", cu.Name, err)
+		return 0
+	}
+	return ch
+}
+
 func (c *cursorImpl) setImpl(sh cursor.Shapes) {
 	_SetCursor(c.cursorHandle(sh))
 }
@@ -108,6 +108,14 @@ type _XFORM struct {
 	eDy  float32
 }
 
+type _ICONINFO struct {
+	Icon     int32
+	XHotspot uint32
+	YHotspot uint32
+	Mask     syscall.Handle
+	Color    syscall.Handle
+}
+
 type _DISPLAY_DEVICE struct {
 	CB           uint32
 	DeviceName   [32]byte
@@ -292,6 +300,7 @@ func _HIWORD(l uintptr) uint16 {
 //sys	_GetMessage(msg *_MSG, hwnd syscall.Handle, msgfiltermin uint32, msgfiltermax uint32) (ret int32, err error) [failretval==-1] = user32.GetMessageW
 //sys	_LoadCursor(hInstance syscall.Handle, cursorName uintptr) (cursor syscall.Handle) = user32.LoadCursorA
 //sys	_LoadIcon(hInstance syscall.Handle, iconName uintptr) (icon syscall.Handle, err error) = user32.LoadIconW
+//sys	_CreateIconIndirect(ii *_ICONINFO) (icon syscall.Handle, err error) = user32.CreateIconIndirect
 //sys	_SetCursor(hinst syscall.Handle) (prevcurs syscall.Handle) = user32.SetCursor
 //sys	_ShowCursor(show bool) (dispcnt int) = user32.ShowCursor
 //sys	_MoveWindow(hwnd syscall.Handle, x int32, y int32, w int32, h int32, repaint bool) (err error) = user32.MoveWindow
@@ -322,6 +331,7 @@ func _HIWORD(l uintptr) uint16 {
 
 //sys	_AlphaBlend(dcdest syscall.Handle, xoriginDest int32, yoriginDest int32, wDest int32, hDest int32, dcsrc syscall.Handle, xoriginSrc int32, yoriginSrc int32, wsrc int32, hsrc int32, ftn uintptr) (err error) = msimg32.AlphaBlend
 //sys	_BitBlt(dcdest syscall.Handle, xdest int32, ydest int32, width int32, height int32, dcsrc syscall.Handle, xsrc int32, ysrc int32, rop uint32) (err error) = gdi32.BitBlt
+//sys	_CreateBitmap(width int32, height int32, planes uint32, bitCount uint32, bits *byte) (bitmap syscall.Handle, err error) = gdi32.CreateBitmap
 //sys	_CreateCompatibleBitmap(dc syscall.Handle, width int32, height int32) (bitmap syscall.Handle, err error) = gdi32.CreateCompatibleBitmap
 //sys	_CreateCompatibleDC(dc syscall.Handle) (newdc syscall.Handle, err error) = gdi32.CreateCompatibleDC
 //sys	_CreateDIBSection(dc syscall.Handle, bmi *_BITMAPINFO, usage uint32, bits **byte, section syscall.Handle, offset uint32) (bitmap syscall.Handle, err error) = gdi32.CreateDIBSection
@@ -59,6 +59,7 @@ var (
 	procGetMessageW                = moduser32.NewProc("GetMessageW")
 	procLoadCursorA                = moduser32.NewProc("LoadCursorA")
 	procLoadIconW                  = moduser32.NewProc("LoadIconW")
+	procCreateIconIndirect         = moduser32.NewProc("CreateIconIndirect")
 	procSetCursor                  = moduser32.NewProc("SetCursor")
 	procShowCursor                 = moduser32.NewProc("ShowCursor")
 	procMoveWindow                 = moduser32.NewProc("MoveWindow")
@@ -88,6 +89,7 @@ var (
 	procRtlCopyMemory              = modkernel32.NewProc("RtlCopyMemory")
 	procAlphaBlend                 = modmsimg32.NewProc("AlphaBlend")
 	procBitBlt                     = modgdi32.NewProc("BitBlt")
+	procCreateBitmap               = modgdi32.NewProc("CreateBitmap")
 	procCreateCompatibleBitmap     = modgdi32.NewProc("CreateCompatibleBitmap")
 	procCreateCompatibleDC         = modgdi32.NewProc("CreateCompatibleDC")
 	procCreateDIBSection           = modgdi32.NewProc("CreateDIBSection")
@@ -260,6 +262,19 @@ func _LoadIcon(hInstance syscall.Handle, iconName uintptr) (icon syscall.Handle,
 	return
 }
 
+func _CreateIconIndirect(ii *_ICONINFO) (icon syscall.Handle, err error) {
+	r0, _, e1 := syscall.Syscall(procCreateIconIndirect.Addr(), 1, uintptr(unsafe.Pointer(ii)), 0, 0)
+	icon = syscall.Handle(r0)
+	if icon == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
 func _SetCursor(hinst syscall.Handle) (prevcurs syscall.Handle) {
 	r0, _, _ := syscall.Syscall(procSetCursor.Addr(), 1, uintptr(hinst), 0, 0)
 	prevcurs = syscall.Handle(r0)
@@ -474,6 +489,19 @@ func _BitBlt(dcdest syscall.Handle, xdest int32, ydest int32, width int32, heigh
 	return
 }
 
+func _CreateBitmap(width int32, height int32, planes uint32, bitCount uint32, bits *byte) (bitmap syscall.Handle, err error) {
+	r0, _, e1 := syscall.Syscall6(procCreateBitmap.Addr(), 5, uintptr(width), uintptr(height), uintptr(planes), uintptr(bitCount), uintptr(unsafe.Pointer(bits)), 0)
+	bitmap = syscall.Handle(r0)
+	if bitmap == 0 {
+		if e1 != 0 {
+			err = errnoErr(e1)
+		} else {
+			err = syscall.EINVAL
+		}
+	}
+	return
+}
+
 func _CreateCompatibleBitmap(dc syscall.Handle, width int32, height int32) (bitmap syscall.Handle, err error) {
 	r0, _, e1 := syscall.Syscall(procCreateCompatibleBitmap.Addr(), 3, uintptr(dc), uintptr(width), uintptr(height))
 	bitmap = syscall.Handle(r0)
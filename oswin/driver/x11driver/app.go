@@ -24,6 +24,7 @@ import (
 	"sync"
 
 	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/randr"
 	"github.com/BurntSushi/xgb/render"
 	"github.com/BurntSushi/xgb/shm"
 	"github.com/BurntSushi/xgb/xproto"
@@ -63,6 +64,32 @@ type appImpl struct {
 	atomTimestamp       xproto.Atom
 	atomIncr            xproto.Atom
 	atomText            xproto.Atom
+	atomMultipart       xproto.Atom
+	atomXdndAware       xproto.Atom
+	atomXdndEnter       xproto.Atom
+	atomXdndPosition    xproto.Atom
+	atomXdndStatus      xproto.Atom
+	atomXdndLeave       xproto.Atom
+	atomXdndDrop        xproto.Atom
+	atomXdndFinished    xproto.Atom
+	atomXdndSelection   xproto.Atom
+	atomXdndTypeList    xproto.Atom
+	atomXdndActionCopy  xproto.Atom
+	atomXdndActionMove  xproto.Atom
+	atomXdndActionLink  xproto.Atom
+	atomTextURIList     xproto.Atom
+	atomTextPlain       xproto.Atom
+	atomTextPlainUTF8   xproto.Atom
+
+	atomNetWMState           xproto.Atom
+	atomNetWMStateMaxVert    xproto.Atom
+	atomNetWMStateMaxHorz    xproto.Atom
+	atomNetWMStateFullscreen xproto.Atom
+	atomNetWMStateAbove      xproto.Atom
+	atomNetWMStateHidden     xproto.Atom
+	atomNetWMMoveResize      xproto.Atom
+	atomNetWMOpacity         xproto.Atom
+	atomMotifWMHints         xproto.Atom
 
 	pixelsPerPt  float32
 	pictformat24 render.Pictformat
@@ -91,6 +118,7 @@ type appImpl struct {
 	nPendingUploads int
 	completionKeys  []uint16
 	selNotifyChan   chan xproto.SelectionNotifyEvent
+	propNotifyChan  chan xproto.PropertyNotifyEvent
 	name            string
 	about           string
 	quitting        bool // set to true when quitting and closing windows
@@ -104,15 +132,16 @@ var theApp *appImpl
 
 func newAppImpl(xc *xgb.Conn) (*appImpl, error) {
 	app := &appImpl{
-		xc:            xc,
-		xsi:           xproto.Setup(xc),
-		images:        map[shm.Seg]*imageImpl{},
-		uploads:       map[uint16]chan struct{}{},
-		windows:       map[xproto.Window]*windowImpl{},
-		winlist:       make([]*windowImpl, 0),
-		selNotifyChan: make(chan xproto.SelectionNotifyEvent, 100),
-		quitCloseCnt:  make(chan struct{}),
-		name:          "GoGi",
+		xc:             xc,
+		xsi:            xproto.Setup(xc),
+		images:         map[shm.Seg]*imageImpl{},
+		uploads:        map[uint16]chan struct{}{},
+		windows:        map[xproto.Window]*windowImpl{},
+		winlist:        make([]*windowImpl, 0),
+		selNotifyChan:  make(chan xproto.SelectionNotifyEvent, 100),
+		propNotifyChan: make(chan xproto.PropertyNotifyEvent, 100),
+		quitCloseCnt:   make(chan struct{}),
+		name:           "GoGi",
 	}
 	app.xsci = app.xsi.DefaultScreen(xc)
 	if err := app.initAtoms(); err != nil {
@@ -210,6 +239,12 @@ func newAppImpl(xc *xgb.Conn) (*appImpl, error) {
 		sidx++
 	}
 
+	if app.initRandR() {
+		if scs := app.randrScreens(); len(scs) > 0 {
+			app.screens = scs
+		}
+	}
+
 	oswin.TheApp = app
 	theApp = app
 
@@ -243,6 +278,9 @@ mainloop:
 			app.mu.Unlock()
 
 		case xproto.ClientMessageEvent:
+			if theXim.handleClientMessage(ev) || theXdnd.handleClientMessage(ev) {
+				break
+			}
 			if ev.Type != app.atomWMProtocols || ev.Format != 32 {
 				break
 			}
@@ -257,6 +295,9 @@ mainloop:
 				xproto.SetInputFocus(app.xc, xproto.InputFocusParent, ev.Window, xproto.Timestamp(ev.Data.Data32[1]))
 			}
 
+		case randr.ScreenChangeNotifyEvent, randr.NotifyEvent:
+			app.refreshScreens()
+
 		case xproto.ConfigureNotifyEvent:
 			if w := app.findWindow(ev.Window); w != nil {
 				w.handleConfigureNotify(ev)
@@ -285,6 +326,7 @@ mainloop:
 				bitflag.Clear(&w.Flag, int(oswin.Minimized))
 				bitflag.Set(&w.Flag, int(oswin.Focus))
 				w.mu.Unlock()
+				theXim.windowFocus(w)
 				// fmt.Printf("focused %v\n", w.Name())
 				sendWindowEvent(w, window.Focus)
 			} else {
@@ -296,6 +338,7 @@ mainloop:
 				w.mu.Lock()
 				bitflag.Clear(&w.Flag, int(oswin.Focus))
 				w.mu.Unlock()
+				theXim.windowFocus(w)
 				// fmt.Printf("defocused %v\n", w.Name())
 				sendWindowEvent(w, window.DeFocus)
 			} else {
@@ -304,20 +347,28 @@ mainloop:
 
 		case xproto.KeyPressEvent:
 			if w := app.findWindow(ev.Event); w != nil {
-				w.handleKey(ev.Detail, ev.State, key.Press)
+				if !theXim.forwardKey(w, ev.Bytes(), ev.Sequence, xproto.EventMaskKeyPress) {
+					w.handleKey(ev.Detail, ev.State, key.Press)
+				}
 			} else {
 				noWindowFound = true
 			}
 
 		case xproto.KeyReleaseEvent:
 			if w := app.findWindow(ev.Event); w != nil {
-				w.handleKey(ev.Detail, ev.State, key.Release)
+				if !theXim.forwardKey(w, ev.Bytes(), ev.Sequence, xproto.EventMaskKeyRelease) {
+					w.handleKey(ev.Detail, ev.State, key.Release)
+				}
 			} else {
 				noWindowFound = true
 			}
 
 		case xproto.ButtonPressEvent:
 			if w := app.findWindow(ev.Event); w != nil {
+				if ev.Detail == 1 && w.InDragRegion(image.Point{int(ev.EventX), int(ev.EventY)}) {
+					w.startMoveDrag(ev.RootX, ev.RootY, ev.Time)
+					break
+				}
 				w.handleMouse(ev.EventX, ev.EventY, ev.Detail, ev.State, mouse.Press)
 			} else {
 				noWindowFound = true
@@ -326,6 +377,7 @@ mainloop:
 		case xproto.ButtonReleaseEvent:
 			if w := app.findWindow(ev.Event); w != nil {
 				w.handleMouse(ev.EventX, ev.EventY, ev.Detail, ev.State, mouse.Release)
+				theXdnd.sourceRelease(w, ev.Time)
 			} else {
 				noWindowFound = true
 			}
@@ -333,15 +385,35 @@ mainloop:
 		case xproto.MotionNotifyEvent:
 			if w := app.findWindow(ev.Event); w != nil {
 				w.handleMouse(ev.EventX, ev.EventY, 0, ev.State, mouse.NoAction)
+				theXdnd.sourceMotion(w, ev.RootX, ev.RootY, ev.State, ev.Time)
 			} else {
 				noWindowFound = true
 			}
 
 		case xproto.SelectionNotifyEvent:
-			app.selNotifyChan <- ev
+			if ev.Selection == app.atomXdndSelection {
+				theXdnd.selectionNotify(ev)
+			} else {
+				app.selNotifyChan <- ev
+			}
+
+		case xproto.PropertyNotifyEvent:
+			if ev.Atom == app.atomNetWMState {
+				if w := app.findWindow(ev.Window); w != nil {
+					w.handleWMStateNotify()
+				}
+				break
+			}
+			clipPropertyNotify(ev)
 
 		case xproto.SelectionRequestEvent:
-			theClip.SendLastWrite(ev)
+			if ev.Selection == app.atomXdndSelection {
+				theXdnd.sendData(ev)
+			} else if ev.Selection == app.atomPrimarySel {
+				thePrimary.SendLastWrite(ev)
+			} else {
+				theClip.SendLastWrite(ev)
+			}
 		}
 
 		if noWindowFound { // we expect this actually
@@ -519,7 +591,7 @@ func (app *appImpl) NewWindow(opts *oswin.NewWindowOptions) (oswin.Window, error
 		pictformat = app.pictformat32
 	}
 
-	// todo: multiple screens..
+	// actual screen is determined when the window is mapped
 	sc := app.Screen(0)
 	dpi := sc.PhysicalDPI
 	ldpi := sc.LogicalDPI
@@ -534,8 +606,13 @@ func (app *appImpl) NewWindow(opts *oswin.NewWindowOptions) (oswin.Window, error
 			Pos:     opts.Pos,
 			PhysDPI: dpi,
 			LogDPI:  ldpi,
+			Scrn:    sc,
+			Flag:    opts.Flags,
+			Opac:    opts.Opacity,
 		},
 	}
+	// state flags are only set when the window manager reports them
+	bitflag.Clear(&w.Flag, int(oswin.Maximized), int(oswin.Fullscreen), int(oswin.AlwaysOnTop))
 
 	app.mu.Lock()
 	app.windows[xw] = w
@@ -561,14 +638,17 @@ func (app *appImpl) NewWindow(opts *oswin.NewWindowOptions) (oswin.Window, error
 			xproto.EventMaskPointerMotion |
 			xproto.EventMaskExposure |
 			xproto.EventMaskStructureNotify |
+			xproto.EventMaskPropertyChange |
 			xproto.EventMaskFocusChange,
 		},
 	)
 	app.setProperty(xw, app.atomWMProtocols, app.atomWMDeleteWindow, app.atomWMTakeFocus)
+	w.initWMState(opts)
+	app.setXdndAware(xw)
+	theXim.addWindow(w)
 
 	// fmt.Printf("create pos: %v\n", opts.Pos)
-	// todo: opts
-	// dialog, modal, tool, fullscreen := oswin.WindowFlagsToBool(opts.Flags)
+	// todo: dialog, modal, tool opts
 
 	title := []byte(opts.GetTitle())
 	xproto.ChangeProperty(app.xc, xproto.PropModeReplace, xw, app.atomNETWMName, app.atomUTF8String, 8, uint32(len(title)), title)
@@ -646,7 +726,14 @@ func (app *appImpl) initAtoms() (err error) {
 	if err != nil {
 		return err
 	}
-	return nil
+	app.atomMultipart, err = app.internAtom("multipart/mixed")
+	if err != nil {
+		return err
+	}
+	if err = app.initWMStateAtoms(); err != nil {
+		return err
+	}
+	return app.initXdndAtoms()
 }
 
 func (app *appImpl) internAtom(name string) (xproto.Atom, error) {
@@ -744,8 +831,9 @@ func (app *appImpl) initWindow32() error {
 		xproto.WindowClassInputOutput, visualid,
 		// The CwBorderPixel attribute seems necessary for depth == 32. See
 		// http://stackoverflow.com/questions/3645632/how-to-create-a-window-with-a-bit-depth-of-32
-		xproto.CwBorderPixel|xproto.CwColormap,
-		[]uint32{0, uint32(colormap)},
+		// PropertyChange events are needed for incremental clipboard transfers
+		xproto.CwBorderPixel|xproto.CwEventMask|xproto.CwColormap,
+		[]uint32{0, xproto.EventMaskPropertyChange, uint32(colormap)},
 	)
 	xproto.CreateGC(app.xc, app.gcontext32, xproto.Drawable(app.window32), 0, nil)
 	return nil
@@ -826,13 +914,18 @@ func (app *appImpl) DeleteWin(id xproto.Window) {
 		}
 	}
 	delete(app.windows, id)
+	theXim.removeWindow(win)
 }
 
 func (app *appImpl) NScreens() int {
+	app.mu.Lock()
+	defer app.mu.Unlock()
 	return len(app.screens)
 }
 
 func (app *appImpl) Screen(scrN int) *oswin.Screen {
+	app.mu.Lock()
+	defer app.mu.Unlock()
 	sz := len(app.screens)
 	if scrN < sz {
 		return app.screens[scrN]
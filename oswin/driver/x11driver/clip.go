@@ -8,48 +8,88 @@ package x11driver
 
 import (
 	"log"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/BurntSushi/xgb"
 	"github.com/BurntSushi/xgb/xproto"
+	"github.com/goki/gi/oswin/clip"
 	"github.com/goki/gi/oswin/mimedata"
+	"github.com/goki/pi/filecat"
 )
 
 // implements clipboard support for X11
 // https://github.com/jtanx/libclipboard/blob/master/src/clipboard_x11.c
 // https://www.uninformativ.de/blog/postings/2017-04-02/0/POSTING-en.html
 // Qt source: qtbase/src/plugins/platforms/xcb/qxcbwindow.cpp
+// https://tronche.com/gui/x/icccm/sec-2.html (ICCCM selections, INCR)
 
-// favor CLIPBOARD for our writing and check it first -- standard explicit
-// cut/paste one PRIMARY is for mouse-selected text that usually pasted with
-// middle-mouse-button
+// the clipboard is the CLIPBOARD selection, for standard explicit
+// cut/paste -- PRIMARY is for mouse-selected text that is usually pasted with
+// middle-mouse-button -- it is available separately as thePrimary, through
+// the clip.PrimaryBoard interface.
+
+// each MIME type in the written data is offered as a target of the same
+// name (e.g., image/png, text/html), with text/plain also available as
+// UTF8_STRING -- if there are multiple elements, the multipart/mixed target
+// provides all of them, which is what other GoGi apps ask for first.  Large
+// data is transferred incrementally using the INCR protocol.
 
 type clipImpl struct {
+	primary   bool // PRIMARY instead of CLIPBOARD selection
 	lastWrite mimedata.Mimes
+	incrs     map[clipIncrKey]*clipIncr
+	mu        sync.Mutex
+}
+
+// clipIncrKey identifies an outgoing incremental transfer
+type clipIncrKey struct {
+	win  xproto.Window
+	prop xproto.Atom
+}
+
+// clipIncr is the state of an outgoing incremental transfer
+type clipIncr struct {
+	typ  xproto.Atom
+	data []byte
+	off  int
 }
 
 var theClip = clipImpl{}
 
+var thePrimary = clipImpl{primary: true}
+
 // ClipTransSize determines how much data in bytes to transfer per call, 1MB
 var ClipTransSize = uint32(1048576)
 
+// ClipIncrSize is the size above which data is sent to other apps
+// incrementally, and the size of each increment -- must be well below the
+// maximum request size of the X server
+var ClipIncrSize = 65536
+
 // ClipTimeOut determines how long to wait before timing out waiting for the
 // SelectionNotifyEvent
 var ClipTimeOut = 1 * time.Second
 
+// sel returns the selection atom for this clipboard
+func (ci *clipImpl) sel() xproto.Atom {
+	if ci.primary {
+		return theApp.atomPrimarySel
+	}
+	return theApp.atomClipboardSel
+}
+
+func (ci *clipImpl) Primary() clip.Board {
+	return &thePrimary
+}
+
 func (ci *clipImpl) IsEmpty() bool {
-	selown, err := xproto.GetSelectionOwner(theApp.xc, theApp.atomClipboardSel).Reply()
+	selown, err := xproto.GetSelectionOwner(theApp.xc, ci.sel()).Reply()
 	if err != nil {
 		log.Printf("X11 Clipboard Read error: %v\n", err)
 		return false
 	}
-	if selown.Owner == xproto.AtomNone {
-		selown, err = xproto.GetSelectionOwner(theApp.xc, theApp.atomPrimarySel).Reply()
-		if err != nil {
-			log.Printf("X11 Clipboard Read error: %v\n", err)
-			return false
-		}
-	}
 	if selown.Owner == xproto.AtomNone { // nothing there..
 		return true
 	}
@@ -60,89 +100,252 @@ func (ci *clipImpl) Read(types []string) mimedata.Mimes {
 	if types == nil {
 		return nil
 	}
-	// check for an owner on either the CLIPBOARD or PRIMARY selections
-	// owner info is not actually used under the XCB/XGB protocol -- just to see
-	// that it exists..
-	useSel := theApp.atomClipboardSel
-	selown, err := xproto.GetSelectionOwner(theApp.xc, theApp.atomClipboardSel).Reply()
+	// check for an owner on the selection -- owner info is not actually used
+	// under the XCB/XGB protocol -- just to see that it exists..
+	useSel := ci.sel()
+	selown, err := xproto.GetSelectionOwner(theApp.xc, useSel).Reply()
 	if err != nil {
 		log.Printf("X11 Clipboard Read error: %v\n", err)
 		return nil
 	}
-	if selown.Owner == xproto.AtomNone {
-		useSel = theApp.atomPrimarySel
-		selown, err = xproto.GetSelectionOwner(theApp.xc, theApp.atomPrimarySel).Reply()
-		if err != nil {
-			log.Printf("X11 Clipboard Read error: %v\n", err)
-			return nil
-		}
-	}
 	if selown.Owner == xproto.AtomNone { // nothing there..
 		return nil
 	}
 
 	if selown.Owner == theApp.window32 { // we are the owner -- just send our data
+		ci.mu.Lock()
+		defer ci.mu.Unlock()
 		return ci.lastWrite
 	}
 
-	wantText := mimedata.IsText(types[0])
+	// find out what the owner has to offer, and get the best match
+	targs := ci.targets(useSel)
+	if _, has := targs["multipart/mixed"]; has {
+		if b, ok := ci.convert(useSel, theApp.atomMultipart); ok {
+			if isMulti, _, boundary, body := mimedata.IsMultipart(b); isMulti {
+				return mimedata.FromMultipart(body, boundary)
+			}
+		}
+	}
+	for _, typ := range types {
+		tnm, targ := ci.matchTarget(typ, targs)
+		if targ == 0 {
+			continue
+		}
+		b, ok := ci.convert(useSel, targ)
+		if !ok {
+			continue
+		}
+		if mimedata.IsText(typ) {
+			return ci.textMimes(typ, b)
+		}
+		d := &mimedata.Data{Type: tnm, Data: b}
+		if tnm != typ && mimedata.IsImage(typ) {
+			if cd, err := mimedata.ConvertImage(d, typ); err == nil {
+				d = cd
+			} else {
+				log.Printf("X11 Clipboard Read image conversion error: %v\n", err)
+			}
+		}
+		return mimedata.Mimes{d}
+	}
+
+	// owners that don't report their targets generally support UTF8_STRING
+	if len(targs) == 0 && mimedata.IsText(types[0]) {
+		if b, ok := ci.convert(useSel, theApp.atomUTF8String); ok {
+			return ci.textMimes(types[0], b)
+		}
+	}
+	return nil
+}
 
-	if wantText {
-		// this is the main call requesting the selection -- there are no apparent
-		// docs for the xcb version of this call, in terms of the "Property" arg,
-		// but example from jtanx just uses the name of the selection again, so...
-		xproto.ConvertSelection(theApp.xc, theApp.window32, useSel, theApp.atomUTF8String, useSel, xproto.TimeCurrentTime)
+// textMimes returns the Mimes for text data of given type, which might be
+// a multipart MIME string encoding multiple elements
+func (ci *clipImpl) textMimes(typ string, b []byte) mimedata.Mimes {
+	isMulti, mediaType, boundary, body := mimedata.IsMultipart(b)
+	if isMulti {
+		return mimedata.FromMultipart(body, boundary)
+	}
+	if mediaType != "" { // found a mime type encoding
+		return mimedata.NewMime(mediaType, b)
+	}
+	// we can't really figure out type, so just assume..
+	return mimedata.NewMime(typ, b)
+}
 
-		// var ptyp xproto.Atom
-		b := make([]byte, 0, 1024)
+// targets returns the targets (types) supported by the current owner of
+// given selection, as a map from name to atom
+func (ci *clipImpl) targets(sel xproto.Atom) map[string]xproto.Atom {
+	b, ok := ci.convert(sel, theApp.atomTargets)
+	if !ok {
+		return nil
+	}
+	targs := make(map[string]xproto.Atom, len(b)/4)
+	for i := 0; i+4 <= len(b); i += 4 {
+		at := xproto.Atom(xgb.Get32(b[i:]))
+		if nm := atomName(at); nm != "" {
+			targs[nm] = at
+		}
+	}
+	return targs
+}
 
-		select {
-		case ev := <-theApp.selNotifyChan:
-			bytesAfter := uint32(1)
-			bufsz := uint32(0) // current buffer size
-			for bytesAfter > 0 {
-				// last two args are offset and amount to transfer, in 32bit "long" sizes
-				prop, err := xproto.GetProperty(theApp.xc, true, theApp.window32, ev.Property, xproto.AtomAny, bufsz/4, ClipTransSize/4).Reply()
-				if err != nil {
-					log.Printf("X11 Clipboard Read Property error: %v\n", err)
-					return nil
-				}
-				bytesAfter = prop.BytesAfter
-				sz := len(prop.Value)
-				if sz > 0 {
-					b = append(b, prop.Value...)
-					bufsz += uint32(sz)
-				}
-				// ptyp = prop.Type
+// matchTarget returns the name and atom of the target that best provides
+// given MIME type -- 0 if none
+func (ci *clipImpl) matchTarget(typ string, targs map[string]xproto.Atom) (string, xproto.Atom) {
+	if at, has := targs[typ]; has {
+		return typ, at
+	}
+	switch {
+	case typ == filecat.TextPlain:
+		for _, nm := range []string{"UTF8_STRING", "text/plain;charset=utf-8"} {
+			if at, has := targs[nm]; has {
+				return nm, at
+			}
+		}
+	case mimedata.IsImage(typ):
+		for _, nm := range []string{mimedata.ImagePNG, "image/jpeg", "image/gif"} {
+			if at, has := targs[nm]; has {
+				return nm, at
 			}
-		case <-time.After(ClipTimeOut):
-			log.Printf("X11 Clipboard Read: unexpected timeout on receipt of SelectionNotifyEvent\n")
-			return nil
 		}
+	}
+	return "", 0
+}
 
-		isMulti, mediaType, boundary, body := mimedata.IsMultipart(b)
-		if isMulti {
-			return mimedata.FromMultipart(body, boundary)
-		} else {
-			if mediaType != "" { // found a mime type encoding
-				return mimedata.NewMime(mediaType, b)
-			} else {
-				// we can't really figure out type, so just assume..
-				return mimedata.NewMime(types[0], b)
+// convert asks the owner of given selection to convert it to given target,
+// and returns the resulting data, reading it incrementally if needed
+func (ci *clipImpl) convert(sel, target xproto.Atom) ([]byte, bool) {
+	// discard anything left over from a previous request that timed out
+	for len(theApp.selNotifyChan) > 0 {
+		<-theApp.selNotifyChan
+	}
+
+	// this is the main call requesting the selection -- there are no apparent
+	// docs for the xcb version of this call, in terms of the "Property" arg,
+	// but example from jtanx just uses the name of the selection again, so...
+	xproto.ConvertSelection(theApp.xc, theApp.window32, sel, target, sel, xproto.TimeCurrentTime)
+
+	var ev xproto.SelectionNotifyEvent
+	select {
+	case ev = <-theApp.selNotifyChan:
+	case <-time.After(ClipTimeOut):
+		log.Printf("X11 Clipboard Read: unexpected timeout on receipt of SelectionNotifyEvent\n")
+		return nil, false
+	}
+	if ev.Property == xproto.AtomNone { // owner could not convert
+		return nil, false
+	}
+	// the owner setting the property comes before the notify, so any new
+	// value events pending now are not part of an incremental transfer
+	for len(theApp.propNotifyChan) > 0 {
+		<-theApp.propNotifyChan
+	}
+	b, ptyp, err := readProperty(theApp.window32, ev.Property)
+	if err != nil {
+		log.Printf("X11 Clipboard Read Property error: %v\n", err)
+		return nil, false
+	}
+	if ptyp != theApp.atomIncr {
+		return b, true
+	}
+
+	// incremental: deleting the INCR property (done by readProperty) starts
+	// the transfer, and each chunk is sent as a new value, ending with an
+	// empty one
+	b = b[:0]
+	for {
+		select {
+		case pev := <-theApp.propNotifyChan:
+			if pev.Atom != ev.Property {
+				continue
+			}
+			cb, _, err := readProperty(theApp.window32, ev.Property)
+			if err != nil {
+				log.Printf("X11 Clipboard Read Property error: %v\n", err)
+				return nil, false
+			}
+			if len(cb) == 0 {
+				return b, true
 			}
+			b = append(b, cb...)
+		case <-time.After(ClipTimeOut):
+			log.Printf("X11 Clipboard Read: timeout during incremental transfer\n")
+			return nil, false
 		}
-	} else {
-		// todo: deal with image formats etc
 	}
-	return nil
 }
 
 func (ci *clipImpl) Write(data mimedata.Mimes) error {
 	// we just advertise ourselves as clipboard owners and save the data until
 	// someone requests it..
+	ci.mu.Lock()
 	ci.lastWrite = data
-	useSel := theApp.atomClipboardSel
-	xproto.SetSelectionOwner(theApp.xc, theApp.window32, useSel, xproto.TimeCurrentTime)
+	ci.mu.Unlock()
+	xproto.SetSelectionOwner(theApp.xc, theApp.window32, ci.sel(), xproto.TimeCurrentTime)
+	return nil
+}
+
+// writeTargets returns the targets we offer for the last written data
+func (ci *clipImpl) writeTargets() []xproto.Atom {
+	targs := []xproto.Atom{theApp.atomTargets, theApp.atomTimestamp, theApp.atomUTF8String}
+	addTarg := func(at xproto.Atom) {
+		for _, t := range targs {
+			if t == at {
+				return
+			}
+		}
+		targs = append(targs, at)
+	}
+	if len(ci.lastWrite) > 1 {
+		addTarg(theApp.atomMultipart)
+	}
+	for _, d := range ci.lastWrite {
+		if d.Type == filecat.TextPlain {
+			addTarg(theApp.atomTextPlain)
+			addTarg(theApp.atomTextPlainUTF8)
+			continue
+		}
+		if at, err := theApp.internAtom(d.Type); err == nil {
+			addTarg(at)
+		}
+	}
+	return targs
+}
+
+// writeData returns the data of the last write for given target -- nil if
+// not available
+func (ci *clipImpl) writeData(target xproto.Atom) []byte {
+	switch target {
+	case theApp.atomMultipart:
+		return ci.lastWrite.ToMultipart()
+	case theApp.atomUTF8String, theApp.atomTextPlain, theApp.atomTextPlainUTF8:
+		if d := ci.lastWrite.TypeData(filecat.TextPlain); d != nil {
+			return d
+		}
+		if target != theApp.atomUTF8String {
+			return nil
+		}
+		// other GoGi apps without TARGETS support read everything as text
+		if len(ci.lastWrite) > 1 {
+			return ci.lastWrite.ToMultipart()
+		}
+		return ci.lastWrite[0].Data
+	}
+	typ := atomName(target)
+	if idx := strings.IndexByte(typ, ';'); idx > 0 { // strip any parameters
+		typ = typ[:idx]
+	}
+	if d := ci.lastWrite.TypeData(typ); d != nil {
+		return d
+	}
+	if mimedata.CanDecodeImage(typ) {
+		if id := ci.lastWrite.ImageData(); id != nil {
+			if cd, err := mimedata.ConvertImage(id, typ); err == nil {
+				return cd.Data
+			}
+		}
+	}
 	return nil
 }
 
@@ -155,47 +358,103 @@ func (ci *clipImpl) SendLastWrite(ev xproto.SelectionRequestEvent) {
 		Property:  xproto.AtomNone,
 	}
 
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
 	mask := xproto.EventMaskNoEvent
 	if ci.lastWrite != nil {
-		reply.Property = ev.Property
-		if reply.Property == xproto.AtomNone {
-			reply.Property = reply.Target
+		prop := ev.Property
+		if prop == xproto.AtomNone {
+			prop = ev.Target
 		}
-		switch reply.Target {
+		switch ev.Target {
 		case theApp.atomTargets: // requesting to know what targets we support
 			mask = xproto.EventMaskPropertyChange
-			targs := make([]byte, 4*3)
-			bi := 0
-			xgb.Put32(targs[bi:], uint32(theApp.atomUTF8String))
-			bi += 4
-			xgb.Put32(targs[bi:], uint32(theApp.atomTimestamp))
-			bi += 4
-			xgb.Put32(targs[bi:], uint32(theApp.atomTargets))
-			xproto.ChangeProperty(theApp.xc, xproto.PropModeReplace, reply.Requestor,
-				reply.Property, xproto.AtomAtom, 32, 3, targs)
+			theApp.setProperty(ev.Requestor, prop, ci.writeTargets()...)
+			reply.Property = prop
 		case theApp.atomTimestamp:
 			mask = xproto.EventMaskPropertyChange
 			targs := make([]byte, 4*1)
 			xgb.Put32(targs, uint32(xproto.TimeCurrentTime))
-			xproto.ChangeProperty(theApp.xc, xproto.PropModeReplace, reply.Requestor,
-				reply.Property, xproto.AtomInteger, 32, 1, targs)
-		case theApp.atomUTF8String:
-			mask = xproto.EventMaskPropertyChange
-			if len(ci.lastWrite) > 1 {
-				mpd := ci.lastWrite.ToMultipart()
-				xproto.ChangeProperty(theApp.xc, xproto.PropModeReplace, reply.Requestor,
-					reply.Property, reply.Target, 8, uint32(len(mpd)), mpd)
-			} else {
-				d := ci.lastWrite[0]
-				xproto.ChangeProperty(theApp.xc, xproto.PropModeReplace, reply.Requestor,
-					reply.Property, reply.Target, 8, uint32(len(d.Data)), d.Data)
+			xproto.ChangeProperty(theApp.xc, xproto.PropModeReplace, ev.Requestor,
+				prop, xproto.AtomInteger, 32, 1, targs)
+			reply.Property = prop
+		case theApp.atomMultiple: // not supported
+		default:
+			if d := ci.writeData(ev.Target); d != nil {
+				mask = xproto.EventMaskPropertyChange
+				ci.sendProperty(ev.Requestor, prop, ev.Target, d)
+				reply.Property = prop
 			}
 		}
 	}
 	xproto.SendEvent(theApp.xc, false, reply.Requestor, uint32(mask), string(reply.Bytes()))
 }
 
+// sendProperty sets given property on the requestor to the data, starting
+// an incremental transfer if it is too large -- must be called under mutex
+func (ci *clipImpl) sendProperty(win xproto.Window, prop, typ xproto.Atom, data []byte) {
+	if len(data) <= ClipIncrSize {
+		xproto.ChangeProperty(theApp.xc, xproto.PropModeReplace, win, prop, typ, 8, uint32(len(data)), data)
+		return
+	}
+	if ci.incrs == nil {
+		ci.incrs = make(map[clipIncrKey]*clipIncr)
+	}
+	ci.incrs[clipIncrKey{win, prop}] = &clipIncr{typ: typ, data: data}
+	// we need to know when the requestor deletes the property to send more
+	xproto.ChangeWindowAttributes(theApp.xc, win, xproto.CwEventMask, []uint32{xproto.EventMaskPropertyChange})
+	sz := make([]byte, 4)
+	xgb.Put32(sz, uint32(len(data)))
+	xproto.ChangeProperty(theApp.xc, xproto.PropModeReplace, win, prop, theApp.atomIncr, 32, 1, sz)
+}
+
+// clipPropertyNotify handles property changes for incremental transfers:
+// new values on our own window for reading, and deletions on requestor
+// windows for writing
+func clipPropertyNotify(ev xproto.PropertyNotifyEvent) {
+	if ev.Window == theApp.window32 {
+		if ev.State == xproto.PropertyNewValue {
+			select {
+			case theApp.propNotifyChan <- ev:
+			default: // nobody is reading
+			}
+		}
+		return
+	}
+	if ev.State != xproto.PropertyDelete {
+		return
+	}
+	theClip.sendIncr(ev)
+	thePrimary.sendIncr(ev)
+}
+
+// sendIncr sends the next chunk of an incremental transfer, if the property
+// deleted in given event is one of ours
+func (ci *clipImpl) sendIncr(ev xproto.PropertyNotifyEvent) {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	key := clipIncrKey{ev.Window, ev.Atom}
+	inc, has := ci.incrs[key]
+	if !has {
+		return
+	}
+	n := len(inc.data) - inc.off
+	if n > ClipIncrSize {
+		n = ClipIncrSize
+	}
+	chunk := inc.data[inc.off : inc.off+n]
+	inc.off += n
+	xproto.ChangeProperty(theApp.xc, xproto.PropModeReplace, ev.Window, ev.Atom, inc.typ, 8, uint32(len(chunk)), chunk)
+	if n == 0 { // empty chunk signals the end
+		delete(ci.incrs, key)
+		xproto.ChangeWindowAttributes(theApp.xc, ev.Window, xproto.CwEventMask, []uint32{xproto.EventMaskNoEvent})
+	}
+}
+
 func (ci *clipImpl) Clear() {
+	ci.mu.Lock()
 	ci.lastWrite = nil
-	xproto.SetSelectionOwner(theApp.xc, xproto.AtomNone, theApp.atomClipboardSel, xproto.TimeCurrentTime)
+	ci.mu.Unlock()
+	xproto.SetSelectionOwner(theApp.xc, xproto.AtomNone, ci.sel(), xproto.TimeCurrentTime)
 }
@@ -0,0 +1,230 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build linux,!android dragonfly openbsd
+
+package x11driver
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// decoding of X11 COMPOUND_TEXT, which is the default encoding for strings in
+// the XIM protocol -- this is ISO 2022 based, with escape sequences that
+// designate the character sets used for the left (GL) and right (GR) halves
+// of the 8-bit range: https://www.x.org/releases/X11R7.7/doc/xorg-docs/ctext/ctext.html
+// we support the character sets used by the main input methods: the ISO 8859
+// right halves, the 94x94 CJK sets, and UTF-8 extended segments.
+
+// ctCharsets are the character sets that can be designated to GL or GR
+type ctCharsets int
+
+const (
+	ctASCII ctCharsets = iota
+	ctLatin            // ISO 8859 right half -- charmap in ctState
+	ctKana             // JIS X 0201 katakana
+	ctGB2312
+	ctJISX0208
+	ctKSC5601
+	ctUnknown
+)
+
+// ctState is the state of a COMPOUND_TEXT decoder
+type ctState struct {
+	gl, gr  ctCharsets
+	glLatin *charmap.Charmap
+	grLatin *charmap.Charmap
+	mbSet   ctCharsets // character set of pending multi-byte chars
+	mb      []byte     // pending multi-byte chars, in EUC form (high bit set)
+	sb      strings.Builder
+}
+
+// ctLatinCharmaps are the ISO 8859 charmaps by their 96-set final byte
+var ctLatinCharmaps = map[byte]*charmap.Charmap{
+	'A': charmap.ISO8859_1,
+	'B': charmap.ISO8859_2,
+	'C': charmap.ISO8859_3,
+	'D': charmap.ISO8859_4,
+	'F': charmap.ISO8859_7,
+	'G': charmap.ISO8859_6,
+	'H': charmap.ISO8859_8,
+	'L': charmap.ISO8859_5,
+	'M': charmap.ISO8859_9,
+	'V': charmap.ISO8859_10,
+	'Y': charmap.ISO8859_13,
+	'_': charmap.ISO8859_14,
+	'b': charmap.ISO8859_15,
+	'f': charmap.ISO8859_16,
+}
+
+// ctMultiByteEncs are the EUC encodings for the 94x94 character sets
+var ctMultiByteEncs = map[ctCharsets]encoding.Encoding{
+	ctGB2312:   simplifiedchinese.GBK,
+	ctJISX0208: japanese.EUCJP,
+	ctKSC5601:  korean.EUCKR,
+}
+
+// decodeCompoundText decodes given COMPOUND_TEXT string to UTF-8
+func decodeCompoundText(b []byte) string {
+	cs := &ctState{gl: ctASCII, gr: ctLatin, grLatin: charmap.ISO8859_1}
+	for i := 0; i < len(b); {
+		c := b[i]
+		switch {
+		case c == 0x1b:
+			cs.flush()
+			i += cs.escape(b[i:])
+			continue
+		case c < 0x20 || c == 0x7f:
+			if c == '\n' || c == '\t' {
+				cs.flush()
+				cs.sb.WriteByte(c)
+			}
+			i++
+			continue
+		case c >= 0x80 && c < 0xa0: // C1 controls
+			i++
+			continue
+		}
+		set, latin := cs.gl, cs.glLatin
+		if c >= 0x80 {
+			set, latin = cs.gr, cs.grLatin
+		}
+		if _, mb := ctMultiByteEncs[set]; mb {
+			if i+1 >= len(b) {
+				break
+			}
+			if cs.mbSet != set {
+				cs.flush()
+			}
+			cs.mbSet = set
+			cs.mb = append(cs.mb, c|0x80, b[i+1]|0x80)
+			i += 2
+			continue
+		}
+		cs.flush()
+		switch set {
+		case ctASCII:
+			cs.sb.WriteByte(c & 0x7f)
+		case ctLatin:
+			if latin != nil {
+				cs.sb.WriteRune(latin.DecodeByte(c | 0x80))
+			}
+		case ctKana:
+			if kc := c | 0x80; kc >= 0xa1 && kc <= 0xdf {
+				cs.sb.WriteRune(0xff61 + rune(kc-0xa1))
+			}
+		}
+		i++
+	}
+	cs.flush()
+	return cs.sb.String()
+}
+
+// flush decodes any pending multi-byte chars
+func (cs *ctState) flush() {
+	if len(cs.mb) == 0 {
+		return
+	}
+	if dec, err := ctMultiByteEncs[cs.mbSet].NewDecoder().Bytes(cs.mb); err == nil {
+		cs.sb.Write(dec)
+	}
+	cs.mb = cs.mb[:0]
+}
+
+// escape processes the escape sequence at the start of b, returning its
+// length, including any extended segment that follows
+func (cs *ctState) escape(b []byte) int {
+	n := 1 // intermediate bytes are 0x20-0x2f, followed by final byte
+	for n < len(b) && b[n] >= 0x20 && b[n] <= 0x2f {
+		n++
+	}
+	if n >= len(b) {
+		return len(b)
+	}
+	fin := b[n]
+	seq := string(b[1:n])
+	n++
+	switch seq {
+	case "(": // 94 set to GL
+		cs.gl, cs.glLatin = cs.charset94(fin), nil
+	case ")": // 94 set to GR
+		cs.gr, cs.grLatin = cs.charset94(fin), nil
+	case "-": // 96 set to GR
+		cs.gr, cs.grLatin = ctLatin, ctLatinCharmaps[fin]
+	case "$", "$(": // 94^N set to GL
+		cs.gl, cs.glLatin = cs.charset94N(fin), nil
+	case "$)": // 94^N set to GR
+		cs.gr, cs.grLatin = cs.charset94N(fin), nil
+	case "%":
+		if fin == 'G' { // UTF-8 until ESC % @
+			end := bytes.Index(b[n:], []byte("\x1b%@"))
+			if end < 0 {
+				end = len(b) - n
+			}
+			cs.writeUTF8(b[n : n+end])
+			n += end
+		}
+	case "%/": // extended segment: M L name STX data
+		if n+2 > len(b) {
+			return len(b)
+		}
+		sz := int(b[n]&0x7f)*128 + int(b[n+1]&0x7f)
+		n += 2
+		end := n + sz
+		if end > len(b) {
+			end = len(b)
+		}
+		seg := b[n:end]
+		if stx := bytes.IndexByte(seg, 0x02); stx >= 0 {
+			if strings.EqualFold(string(seg[:stx]), "utf-8") {
+				cs.writeUTF8(seg[stx+1:])
+			}
+		}
+		n = end
+	}
+	return n
+}
+
+// charset94 returns the 94 character set with given final byte
+func (cs *ctState) charset94(fin byte) ctCharsets {
+	switch fin {
+	case 'B', 'J':
+		return ctASCII
+	case 'I':
+		return ctKana
+	}
+	return ctUnknown
+}
+
+// charset94N returns the 94x94 character set with given final byte
+func (cs *ctState) charset94N(fin byte) ctCharsets {
+	switch fin {
+	case 'A':
+		return ctGB2312
+	case '@', 'B':
+		return ctJISX0208
+	case 'C':
+		return ctKSC5601
+	}
+	return ctUnknown
+}
+
+// writeUTF8 writes given UTF-8 text, skipping any invalid bytes
+func (cs *ctState) writeUTF8(b []byte) {
+	for len(b) > 0 {
+		r, sz := utf8.DecodeRune(b)
+		if r != utf8.RuneError || sz > 1 {
+			cs.sb.WriteRune(r)
+		}
+		b = b[sz:]
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build linux,!android dragonfly openbsd
+
+package x11driver
+
+import "testing"
+
+func TestDecodeCompoundText(t *testing.T) {
+	tests := []struct {
+		ct   string
+		want string
+	}{
+		{"plain ascii", "plain ascii"},
+		{"caf\xe9", "café"},
+		{"\x1b$)A\xd6\xd0\xce\xc4", "中文"},
+		{"\x1b$(B\x46\x7c\x4b\x5c\x1b(Bgo", "日本go"},
+		{"\x1b$)C\xc7\xd1\xb1\xdb", "한글"},
+		{"\x1b-L\xbf\x1b-A\xe9", "Пé"},
+		{"a\x1b%G€\x1b%@b", "a€b"},
+		{"\x1b%/1\x80\x89utf-8\x02€", "€"},
+	}
+	for _, ts := range tests {
+		got := decodeCompoundText([]byte(ts.ct))
+		if got != ts.want {
+			t.Errorf("decodeCompoundText(%q) = %q, want %q", ts.ct, got, ts.want)
+		}
+	}
+}
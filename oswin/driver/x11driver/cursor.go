@@ -10,8 +10,10 @@ import (
 	"log"
 	"sync"
 
+	"github.com/BurntSushi/xgb/render"
 	"github.com/BurntSushi/xgb/xproto"
 	"github.com/goki/gi/oswin/cursor"
+	"github.com/goki/gi/oswin/driver/internal/swizzle"
 )
 
 // https://xcb.freedesktop.org/tutorial/mousecursors/
@@ -40,10 +42,17 @@ var cursorMap = map[cursor.Shapes]int{
 type cursorImpl struct {
 	cursor.CursorBase
 	cursors  map[cursor.Shapes]xproto.Cursor
+	customs  map[customCursorKey]xproto.Cursor
 	cursFont xproto.Font
 	mu       sync.Mutex
 }
 
+// customCursorKey identifies a custom cursor rendered for a given DPI
+type customCursorKey struct {
+	sh  cursor.Shapes
+	dpi float32
+}
+
 var theCursor = cursorImpl{CursorBase: cursor.CursorBase{Vis: true}}
 
 func (c *cursorImpl) openCursorFont() {
@@ -79,6 +88,45 @@ func (c *cursorImpl) createCursor(curid int) xproto.Cursor {
 	return cur
 }
 
+// createImageCursor creates an ARGB cursor from custom cursor image at given
+// DPI, using the RENDER extension
+func (c *cursorImpl) createImageCursor(cu *cursor.Custom, dpi float32) xproto.Cursor {
+	img, hot := cu.ImageForDPI(dpi)
+	sz := img.Bounds().Size()
+	if sz.X == 0 || sz.Y == 0 {
+		return 0
+	}
+	xc := theApp.xc
+	pix, err := xproto.NewPixmapId(xc)
+	if err != nil {
+		log.Printf("x11driver: xproto.NewPixmapId failed: %v", err)
+		return 0
+	}
+	xproto.CreatePixmap(xc, 32, pix, xproto.Drawable(theApp.window32), uint16(sz.X), uint16(sz.Y))
+	defer xproto.FreePixmap(xc, pix)
+	swizzle.BGRA(img.Pix) // RGBA is already premultiplied, as RENDER needs
+	xproto.PutImage(xc, xproto.ImageFormatZPixmap, xproto.Drawable(pix), theApp.gcontext32,
+		uint16(sz.X), uint16(sz.Y), 0, 0, 0, 32, img.Pix)
+	pic, err := render.NewPictureId(xc)
+	if err != nil {
+		log.Printf("x11driver: render.NewPictureId failed: %v", err)
+		return 0
+	}
+	render.CreatePicture(xc, pic, xproto.Drawable(pix), theApp.pictformat32, 0, nil)
+	defer render.FreePicture(xc, pic)
+	cur, err := xproto.NewCursorId(xc)
+	if err != nil {
+		log.Printf("x11driver: xproto.NewCursorId failed: %v", err)
+		return 0
+	}
+	err = render.CreateCursorChecked(xc, cur, pic, uint16(hot.X), uint16(hot.Y)).Check()
+	if err != nil {
+		log.Printf("x11driver: render.CreateCursor for custom cursor %v failed: %v", cu.Name, err)
+		return 0
+	}
+	return cur
+}
+
 func (c *cursorImpl) setCursor(cur xproto.Cursor) {
 	fw := theApp.ctxtwin
 	vallist := []uint32{uint32(cur)}
@@ -89,6 +137,9 @@ func (c *cursorImpl) setCursor(cur xproto.Cursor) {
 }
 
 func (c *cursorImpl) cursorHandle(sh cursor.Shapes) xproto.Cursor {
+	if cu := cursor.CustomShape(sh); cu != nil {
+		return c.customHandle(sh, cu)
+	}
 	c.mu.Lock()
 	if c.cursors == nil {
 		c.cursors = make(map[cursor.Shapes]xproto.Cursor, cursor.ShapesN)
@@ -104,6 +155,27 @@ func (c *cursorImpl) cursorHandle(sh cursor.Shapes) xproto.Cursor {
 	return ch
 }
 
+// customHandle returns the cursor for given custom shape, at the DPI of the
+// current window
+func (c *cursorImpl) customHandle(sh cursor.Shapes, cu *cursor.Custom) xproto.Cursor {
+	dpi := float32(cursor.BaseDPI)
+	if fw := theApp.ctxtwin; fw != nil {
+		dpi = fw.LogicalDPI()
+	}
+	key := customCursorKey{sh, dpi}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.customs == nil {
+		c.customs = make(map[customCursorKey]xproto.Cursor)
+	}
+	ch, ok := c.customs[key]
+	if !ok {
+		ch = c.createImageCursor(cu, dpi)
+		c.customs[key] = ch
+	}
+	return ch
+}
+
 func (c *cursorImpl) setImpl(sh cursor.Shapes) {
 	c.setCursor(c.cursorHandle(sh))
 }
@@ -0,0 +1,555 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build linux,!android dragonfly openbsd
+
+package x11driver
+
+import (
+	"image"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/goki/gi/oswin/dnd"
+	"github.com/goki/gi/oswin/mimedata"
+	"github.com/goki/pi/filecat"
+)
+
+// implements drag-n-drop with other applications using the XDND protocol:
+// https://www.freedesktop.org/wiki/Specifications/XDND/
+// drags within a window are handled entirely by gi.Window -- we only take
+// over when the pointer is over another XdndAware window, or when another
+// app drags something over one of our windows.
+
+// XdndVersion is the version of the XDND protocol that we support
+const XdndVersion = 5
+
+// xdndTarget is the state of a drag from another application over one of
+// our windows
+type xdndTarget struct {
+	win     *windowImpl
+	source  xproto.Window
+	version uint32
+	types   []xproto.Atom
+	where   image.Point
+	mods    int32
+	action  xproto.Atom
+	getType xproto.Atom // type requested from source on drop -- 0 if not dropped
+}
+
+// xdndSource is the state of a drag of our data out to other applications
+type xdndSource struct {
+	win      *windowImpl
+	data     mimedata.Mimes
+	types    []xproto.Atom
+	target   xproto.Window // current XdndAware window under the pointer -- 0 if none
+	version  uint32
+	waiting  bool // waiting for XdndStatus from target
+	accepted bool
+	action   xproto.Atom
+	dropped  bool // drop was sent, waiting for XdndFinished
+}
+
+type xdndImpl struct {
+	mu  sync.Mutex
+	tgt xdndTarget
+	src xdndSource
+}
+
+var theXdnd = xdndImpl{}
+
+func (app *appImpl) initXdndAtoms() (err error) {
+	atoms := []struct {
+		atom *xproto.Atom
+		name string
+	}{
+		{&app.atomXdndAware, "XdndAware"},
+		{&app.atomXdndEnter, "XdndEnter"},
+		{&app.atomXdndPosition, "XdndPosition"},
+		{&app.atomXdndStatus, "XdndStatus"},
+		{&app.atomXdndLeave, "XdndLeave"},
+		{&app.atomXdndDrop, "XdndDrop"},
+		{&app.atomXdndFinished, "XdndFinished"},
+		{&app.atomXdndSelection, "XdndSelection"},
+		{&app.atomXdndTypeList, "XdndTypeList"},
+		{&app.atomXdndActionCopy, "XdndActionCopy"},
+		{&app.atomXdndActionMove, "XdndActionMove"},
+		{&app.atomXdndActionLink, "XdndActionLink"},
+		{&app.atomTextURIList, mimedata.TextURIList},
+		{&app.atomTextPlain, filecat.TextPlain},
+		{&app.atomTextPlainUTF8, "text/plain;charset=utf-8"},
+	}
+	for _, a := range atoms {
+		*a.atom, err = app.internAtom(a.name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setXdndAware marks the window as accepting drops from other apps
+func (app *appImpl) setXdndAware(xw xproto.Window) {
+	app.setProperty(xw, app.atomXdndAware, xproto.Atom(XdndVersion))
+}
+
+// actionToMod converts an XDND action atom to a dnd.DropMods
+func (xd *xdndImpl) actionToMod(action xproto.Atom) dnd.DropMods {
+	switch action {
+	case theApp.atomXdndActionMove:
+		return dnd.DropMove
+	case theApp.atomXdndActionLink:
+		return dnd.DropLink
+	case xproto.AtomNone:
+		return dnd.DropIgnore
+	}
+	return dnd.DropCopy
+}
+
+// modToAction converts a dnd.DropMods to an XDND action atom
+func (xd *xdndImpl) modToAction(mod dnd.DropMods) xproto.Atom {
+	switch mod {
+	case dnd.DropMove:
+		return theApp.atomXdndActionMove
+	case dnd.DropLink:
+		return theApp.atomXdndActionLink
+	}
+	return theApp.atomXdndActionCopy
+}
+
+// sendMessage sends an Xdnd client message of given type to given window
+func (xd *xdndImpl) sendMessage(to xproto.Window, typ xproto.Atom, data ...uint32) {
+	var d [5]uint32
+	copy(d[:], data)
+	ev := xproto.ClientMessageEvent{
+		Format: 32,
+		Window: to,
+		Type:   typ,
+		Data:   xproto.ClientMessageDataUnionData32New(d[:]),
+	}
+	xproto.SendEvent(theApp.xc, false, to, xproto.EventMaskNoEvent, string(ev.Bytes()))
+}
+
+// handleClientMessage processes Xdnd client messages -- returns false if
+// not an Xdnd message
+func (xd *xdndImpl) handleClientMessage(ev xproto.ClientMessageEvent) bool {
+	if ev.Format != 32 {
+		return false
+	}
+	dat := ev.Data.Data32
+	switch ev.Type {
+	case theApp.atomXdndEnter:
+		if w := theApp.findWindow(ev.Window); w != nil {
+			xd.targetEnter(w, xproto.Window(dat[0]), dat[1], dat[2:5])
+		}
+	case theApp.atomXdndPosition:
+		xd.targetPosition(xproto.Window(dat[0]), int16(dat[2]>>16), int16(dat[2]&0xFFFF), xproto.Atom(dat[4]))
+	case theApp.atomXdndLeave:
+		xd.targetLeave(xproto.Window(dat[0]))
+	case theApp.atomXdndDrop:
+		xd.targetDrop(xproto.Window(dat[0]), xproto.Timestamp(dat[2]))
+	case theApp.atomXdndStatus:
+		xd.sourceStatus(xproto.Window(dat[0]), dat[1]&1 != 0, xproto.Atom(dat[4]))
+	case theApp.atomXdndFinished:
+		xd.sourceFinished(xproto.Window(dat[0]), dat[1]&1 != 0, xproto.Atom(dat[2]))
+	default:
+		return false
+	}
+	return true
+}
+
+// readProperty reads the full contents of given property on given window,
+// deleting it afterward, returning the data and its type
+func readProperty(win xproto.Window, prop xproto.Atom) ([]byte, xproto.Atom, error) {
+	var b []byte
+	var ptyp xproto.Atom
+	bytesAfter := uint32(1)
+	bufsz := uint32(0)
+	for bytesAfter > 0 {
+		pr, err := xproto.GetProperty(theApp.xc, true, win, prop, xproto.AtomAny, bufsz/4, ClipTransSize/4).Reply()
+		if err != nil {
+			return nil, 0, err
+		}
+		bytesAfter = pr.BytesAfter
+		b = append(b, pr.Value...)
+		bufsz += uint32(len(pr.Value))
+		ptyp = pr.Type
+	}
+	return b, ptyp, nil
+}
+
+// atomName returns the name of given atom, "" if unknown
+func atomName(atom xproto.Atom) string {
+	an, err := xproto.GetAtomName(theApp.xc, atom).Reply()
+	if err != nil {
+		return ""
+	}
+	return an.Name
+}
+
+////////////////////////////////////////////////////////////////////////////
+//   Target: drags from other apps into our windows
+
+// targetEnter starts a drag from source over our window
+func (xd *xdndImpl) targetEnter(w *windowImpl, source xproto.Window, flags uint32, types []uint32) {
+	xd.mu.Lock()
+	defer xd.mu.Unlock()
+	xd.tgt = xdndTarget{win: w, source: source, version: flags >> 24, where: image.Point{-1, -1}}
+	if flags&1 != 0 { // more than 3 types -- get full list
+		prop, err := xproto.GetProperty(theApp.xc, false, source, theApp.atomXdndTypeList, xproto.AtomAtom, 0, 1024).Reply()
+		if err != nil {
+			log.Printf("X11 XdndTypeList Read Property error: %v\n", err)
+			return
+		}
+		for i := 0; i+4 <= len(prop.Value); i += 4 {
+			xd.tgt.types = append(xd.tgt.types, xproto.Atom(xgb.Get32(prop.Value[i:])))
+		}
+		return
+	}
+	for _, t := range types {
+		if t != 0 {
+			xd.tgt.types = append(xd.tgt.types, xproto.Atom(t))
+		}
+	}
+}
+
+// targetType returns the best type to request from the source, in order of
+// preference: uri-list, then utf8 text, then plain text -- 0 if none usable
+func (xd *xdndImpl) targetType() xproto.Atom {
+	for _, want := range []xproto.Atom{theApp.atomTextURIList, theApp.atomUTF8String, theApp.atomTextPlainUTF8, theApp.atomTextPlain} {
+		for _, t := range xd.tgt.types {
+			if t == want {
+				return t
+			}
+		}
+	}
+	return 0
+}
+
+// targetPosition is a move of the drag over our window, in root coordinates
+func (xd *xdndImpl) targetPosition(source xproto.Window, rootX, rootY int16, action xproto.Atom) {
+	xd.mu.Lock()
+	tg := &xd.tgt
+	if tg.win == nil || tg.source != source {
+		xd.mu.Unlock()
+		return
+	}
+	w := tg.win
+	from := tg.where
+	if tc, err := xproto.TranslateCoordinates(theApp.xc, theApp.xsci.Root, w.xw, rootX, rootY).Reply(); err == nil {
+		tg.where = image.Point{int(tc.DstX), int(tc.DstY)}
+	}
+	if qp, err := xproto.QueryPointer(theApp.xc, w.xw).Reply(); err == nil {
+		tg.mods = KeyModifiers(qp.Mask)
+	}
+	accept := uint32(0)
+	tg.action = xproto.AtomNone
+	if xd.targetType() != 0 {
+		accept = 1 | 2 // accept, and send positions even within the same rect
+		tg.action = action
+		if action != theApp.atomXdndActionMove && action != theApp.atomXdndActionLink {
+			tg.action = theApp.atomXdndActionCopy
+		}
+	}
+	de := &dnd.MoveEvent{
+		Event: dnd.Event{Where: tg.where, Action: dnd.Move, Modifiers: tg.mods, Mod: xd.actionToMod(tg.action)},
+		From:  from,
+	}
+	tgtAction := tg.action
+	xd.mu.Unlock()
+	sendEvent(w, de)
+	xd.sendMessage(source, theApp.atomXdndStatus, uint32(w.xw), accept, 0, 0, uint32(tgtAction))
+}
+
+// targetLeave is the drag leaving our window without dropping
+func (xd *xdndImpl) targetLeave(source xproto.Window) {
+	xd.mu.Lock()
+	w := xd.tgt.win
+	if w == nil || xd.tgt.source != source {
+		xd.mu.Unlock()
+		return
+	}
+	xd.tgt = xdndTarget{}
+	xd.mu.Unlock()
+	xd.sendExit(w)
+}
+
+// sendExit sends a dnd.MoveEvent with Action = dnd.Exit and a position
+// outside the window, to signal that an external drag has left the window
+func (xd *xdndImpl) sendExit(w *windowImpl) {
+	de := &dnd.MoveEvent{Event: dnd.Event{Where: image.Point{-1, -1}, Action: dnd.Exit}}
+	sendEvent(w, de)
+}
+
+// targetDrop is the drop onto our window -- we request the data, which
+// arrives in a SelectionNotifyEvent handled by selectionNotify
+func (xd *xdndImpl) targetDrop(source xproto.Window, tm xproto.Timestamp) {
+	xd.mu.Lock()
+	tg := &xd.tgt
+	if tg.win == nil || tg.source != source {
+		xd.mu.Unlock()
+		return
+	}
+	w := tg.win
+	tg.getType = xd.targetType()
+	if tg.getType == 0 || tg.action == xproto.AtomNone {
+		xd.tgt = xdndTarget{}
+		xd.mu.Unlock()
+		xd.sendMessage(source, theApp.atomXdndFinished, uint32(w.xw), 0, uint32(xproto.AtomNone))
+		xd.sendExit(w)
+		return
+	}
+	getType := tg.getType
+	xd.mu.Unlock()
+	xproto.ConvertSelection(theApp.xc, w.xw, theApp.atomXdndSelection, getType, theApp.atomXdndSelection, tm)
+}
+
+// selectionNotify receives the dropped data from the source
+func (xd *xdndImpl) selectionNotify(ev xproto.SelectionNotifyEvent) {
+	xd.mu.Lock()
+	tg := xd.tgt
+	xd.tgt = xdndTarget{}
+	xd.mu.Unlock()
+	if tg.win == nil || tg.getType == 0 || ev.Requestor != tg.win.xw {
+		return
+	}
+	accepted := uint32(0)
+	action := xproto.Atom(xproto.AtomNone)
+	if ev.Property != xproto.AtomNone {
+		b, _, err := readProperty(tg.win.xw, ev.Property)
+		if err != nil {
+			log.Printf("X11 XdndSelection Read Property error: %v\n", err)
+		} else {
+			typ := filecat.TextPlain
+			if tg.getType == theApp.atomTextURIList {
+				typ = mimedata.TextURIList
+			}
+			de := &dnd.Event{
+				Where:     tg.where,
+				Action:    dnd.DropOnTarget,
+				Modifiers: tg.mods,
+				Mod:       xd.actionToMod(tg.action),
+				Data:      mimedata.NewMime(typ, b),
+			}
+			sendEvent(tg.win, de)
+			accepted = 1
+			action = tg.action
+		}
+	}
+	if accepted == 0 {
+		xd.sendExit(tg.win)
+	}
+	xd.sendMessage(tg.source, theApp.atomXdndFinished, uint32(tg.win.xw), accepted, uint32(action))
+}
+
+////////////////////////////////////////////////////////////////////////////
+//   Source: drags from our windows out to other apps
+
+// startDrag starts a drag of our data, which goes out to other apps once
+// the pointer is over another XdndAware window
+func (xd *xdndImpl) startDrag(w *windowImpl, data mimedata.Mimes) {
+	var types []xproto.Atom
+	addType := func(nm string) {
+		if at, err := theApp.internAtom(nm); err == nil {
+			for _, t := range types {
+				if t == at {
+					return
+				}
+			}
+			types = append(types, at)
+		}
+	}
+	for _, d := range data {
+		addType(d.Type)
+		if d.Type == filecat.TextPlain {
+			addType("UTF8_STRING")
+			addType("text/plain;charset=utf-8")
+		}
+	}
+	xd.mu.Lock()
+	xd.src = xdndSource{win: w, data: data, types: types}
+	xd.mu.Unlock()
+	if len(types) > 3 {
+		theApp.setProperty(w.xw, theApp.atomXdndTypeList, types...)
+	}
+	xproto.SetSelectionOwner(theApp.xc, w.xw, theApp.atomXdndSelection, xproto.TimeCurrentTime)
+}
+
+// awareVersion returns the XdndAware version of given window, 0 if not aware
+func (xd *xdndImpl) awareVersion(win xproto.Window) uint32 {
+	prop, err := xproto.GetProperty(theApp.xc, false, win, theApp.atomXdndAware, xproto.AtomAtom, 0, 1).Reply()
+	if err != nil || prop.Format != 32 || len(prop.Value) < 4 {
+		return 0
+	}
+	return xgb.Get32(prop.Value)
+}
+
+// findTarget returns the XdndAware window at given root position, and its
+// XDND version -- 0 if none
+func (xd *xdndImpl) findTarget(rootX, rootY int16) (xproto.Window, uint32) {
+	root := theApp.xsci.Root
+	win := root
+	for depth := 0; depth < 16; depth++ {
+		tc, err := xproto.TranslateCoordinates(theApp.xc, root, win, rootX, rootY).Reply()
+		if err != nil || tc.Child == xproto.WindowNone {
+			return 0, 0
+		}
+		win = tc.Child
+		if ver := xd.awareVersion(win); ver > 0 {
+			return win, ver
+		}
+	}
+	return 0, 0
+}
+
+// sourceLeave sends XdndLeave to current target if any -- must be called
+// under mutex
+func (xd *xdndImpl) sourceLeave() {
+	sr := &xd.src
+	if sr.target != 0 {
+		xd.sendMessage(sr.target, theApp.atomXdndLeave, uint32(sr.win.xw))
+	}
+	sr.target = 0
+	sr.version = 0
+	sr.waiting = false
+	sr.accepted = false
+}
+
+// sourceMotion handles mouse motion while dragging our data
+func (xd *xdndImpl) sourceMotion(w *windowImpl, rootX, rootY int16, state uint16, tm xproto.Timestamp) {
+	xd.mu.Lock()
+	defer xd.mu.Unlock()
+	sr := &xd.src
+	if sr.data == nil || sr.win != w || sr.dropped {
+		return
+	}
+	if ButtonFromState(state) == 0 { // drag ended without us seeing the release
+		xd.sourceLeave()
+		xd.src = xdndSource{}
+		return
+	}
+	tw, ver := xd.findTarget(rootX, rootY)
+	if tw == w.xw { // internal dnd
+		tw = 0
+	}
+	if tw != sr.target {
+		xd.sourceLeave()
+		if tw == 0 {
+			return
+		}
+		sr.target = tw
+		sr.version = ver
+		if sr.version > XdndVersion {
+			sr.version = XdndVersion
+		}
+		flags := sr.version << 24
+		if len(sr.types) > 3 {
+			flags |= 1
+		}
+		tdat := []uint32{uint32(w.xw), flags, 0, 0, 0}
+		for i := 0; i < len(sr.types) && i < 3; i++ {
+			tdat[2+i] = uint32(sr.types[i])
+		}
+		xd.sendMessage(tw, theApp.atomXdndEnter, tdat...)
+	}
+	if sr.target == 0 || sr.waiting {
+		return
+	}
+	sr.action = xd.modToAction(dnd.DefaultModBits(KeyModifiers(state)))
+	xd.sendMessage(sr.target, theApp.atomXdndPosition, uint32(w.xw), 0, uint32(rootX)<<16|uint32(uint16(rootY)), uint32(tm), uint32(sr.action))
+	sr.waiting = true
+}
+
+// sourceRelease handles the mouse button release at end of dragging our
+// data, sending the drop if the current target accepted it
+func (xd *xdndImpl) sourceRelease(w *windowImpl, tm xproto.Timestamp) {
+	xd.mu.Lock()
+	defer xd.mu.Unlock()
+	sr := &xd.src
+	if sr.data == nil || sr.win != w || sr.dropped {
+		return
+	}
+	if sr.target == 0 || !sr.accepted {
+		xd.sourceLeave()
+		xd.src = xdndSource{}
+		return
+	}
+	sr.dropped = true
+	xd.sendMessage(sr.target, theApp.atomXdndDrop, uint32(w.xw), 0, uint32(tm))
+}
+
+// sourceStatus is the response of the target to our XdndPosition
+func (xd *xdndImpl) sourceStatus(target xproto.Window, accept bool, action xproto.Atom) {
+	xd.mu.Lock()
+	defer xd.mu.Unlock()
+	sr := &xd.src
+	if sr.target != target {
+		return
+	}
+	sr.waiting = false
+	sr.accepted = accept
+	if accept && action != xproto.AtomNone {
+		sr.action = action
+	}
+}
+
+// sourceFinished is the target telling us that the drop is complete, which
+// we pass on to the source window as a dnd.DropFmSource event
+func (xd *xdndImpl) sourceFinished(target xproto.Window, accepted bool, action xproto.Atom) {
+	xd.mu.Lock()
+	sr := xd.src
+	if sr.target != target || !sr.dropped {
+		xd.mu.Unlock()
+		return
+	}
+	xd.src = xdndSource{}
+	xd.mu.Unlock()
+	mod := xd.actionToMod(sr.action)
+	if sr.version >= 5 { // accepted flag and action only in version 5
+		mod = dnd.DropIgnore
+		if accepted {
+			mod = xd.actionToMod(action)
+		}
+	}
+	de := &dnd.Event{Action: dnd.DropFmSource, Mod: mod, Data: sr.data}
+	sendEvent(sr.win, de)
+}
+
+// sendData sends our drag data to the target that requested it
+func (xd *xdndImpl) sendData(ev xproto.SelectionRequestEvent) {
+	xd.mu.Lock()
+	sr := xd.src
+	xd.mu.Unlock()
+	reply := xproto.SelectionNotifyEvent{
+		Time:      ev.Time,
+		Requestor: ev.Requestor,
+		Selection: ev.Selection,
+		Target:    ev.Target,
+		Property:  xproto.AtomNone,
+	}
+	prop := ev.Property
+	if prop == xproto.AtomNone {
+		prop = ev.Target
+	}
+	if sr.data != nil {
+		if ev.Target == theApp.atomTargets {
+			targs := append([]xproto.Atom{theApp.atomTargets}, sr.types...)
+			theApp.setProperty(ev.Requestor, prop, targs...)
+			reply.Property = prop
+		} else {
+			typ := atomName(ev.Target)
+			if ev.Target == theApp.atomUTF8String || strings.HasPrefix(typ, filecat.TextPlain) {
+				typ = filecat.TextPlain
+			}
+			if d := sr.data.TypeData(typ); d != nil {
+				xproto.ChangeProperty(theApp.xc, xproto.PropModeReplace, ev.Requestor, prop, ev.Target, 8, uint32(len(d)), d)
+				reply.Property = prop
+			}
+		}
+	}
+	xproto.SendEvent(theApp.xc, false, ev.Requestor, xproto.EventMaskNoEvent, string(reply.Bytes()))
+}
@@ -0,0 +1,176 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build linux,!android dragonfly openbsd
+
+package x11driver
+
+import (
+	"image"
+
+	"github.com/BurntSushi/xgb/randr"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/window"
+)
+
+// screens are obtained from the RandR extension, with one screen per active
+// monitor, each with its own geometry and physical DPI -- if RandR is not
+// available, we fall back on the core X11 screens.  RandR notifies us when
+// monitors are added, removed or reconfigured, and windows are sent a
+// window.ScreenUpdate event when their screen changes as a result, or when
+// they move to a different screen.
+
+// initRandR initializes the RandR extension and selects screen change
+// events -- returns false if not available (version 1.3 is required)
+func (app *appImpl) initRandR() bool {
+	if err := randr.Init(app.xc); err != nil {
+		return false
+	}
+	vr, err := randr.QueryVersion(app.xc, 1, 3).Reply()
+	if err != nil || vr.MajorVersion < 1 || (vr.MajorVersion == 1 && vr.MinorVersion < 3) {
+		return false
+	}
+	randr.SelectInput(app.xc, app.xsci.Root, randr.NotifyMaskScreenChange|randr.NotifyMaskCrtcChange|randr.NotifyMaskOutputChange)
+	return true
+}
+
+// randrScreens returns a screen for each active monitor, with the primary
+// one first -- nil if none could be found
+func (app *appImpl) randrScreens() []*oswin.Screen {
+	res, err := randr.GetScreenResourcesCurrent(app.xc, app.xsci.Root).Reply()
+	if err != nil {
+		return nil
+	}
+	var primary randr.Output
+	if pr, err := randr.GetOutputPrimary(app.xc, app.xsci.Root).Reply(); err == nil {
+		primary = pr.Output
+	}
+	crtcs := make(map[randr.Crtc]bool) // cloned outputs share a crtc
+	var scs []*oswin.Screen
+	for _, op := range res.Outputs {
+		oi, err := randr.GetOutputInfo(app.xc, op, res.ConfigTimestamp).Reply()
+		if err != nil || oi.Connection != randr.ConnectionConnected || oi.Crtc == 0 || crtcs[oi.Crtc] {
+			continue
+		}
+		ci, err := randr.GetCrtcInfo(app.xc, oi.Crtc, res.ConfigTimestamp).Reply()
+		if err != nil || ci.Width == 0 || ci.Height == 0 {
+			continue
+		}
+		crtcs[oi.Crtc] = true
+		sc := &oswin.Screen{
+			Geometry:         image.Rect(int(ci.X), int(ci.Y), int(ci.X)+int(ci.Width), int(ci.Y)+int(ci.Height)),
+			Depth:            int(app.xsci.RootDepth),
+			PhysicalSize:     image.Point{int(oi.MmWidth), int(oi.MmHeight)},
+			DevicePixelRatio: 1,
+			Name:             string(oi.Name),
+		}
+		if ci.Rotation&(randr.RotationRotate90|randr.RotationRotate270) != 0 {
+			sc.PhysicalSize.X, sc.PhysicalSize.Y = sc.PhysicalSize.Y, sc.PhysicalSize.X
+		}
+		if sc.PhysicalSize.X > 0 {
+			sc.PhysicalDPI = 25.4 * float32(ci.Width) / float32(sc.PhysicalSize.X)
+		} else { // projectors etc often don't report a size
+			sc.PhysicalDPI = 25.4 * float32(app.xsci.WidthInPixels) / float32(app.xsci.WidthInMillimeters)
+		}
+		sc.LogicalDPI = sc.PhysicalDPI
+		if ci.Width >= ci.Height {
+			sc.Orientation = oswin.Landscape
+		} else {
+			sc.Orientation = oswin.Portrait
+		}
+		sc.NativeOrientation = sc.Orientation
+		sc.PrimaryOrientation = sc.Orientation
+		for _, md := range res.Modes {
+			if md.Id == uint32(ci.Mode) && md.Htotal > 0 && md.Vtotal > 0 {
+				sc.RefreshRate = float32(md.DotClock) / (float32(md.Htotal) * float32(md.Vtotal))
+			}
+		}
+		if op == primary {
+			scs = append([]*oswin.Screen{sc}, scs...)
+		} else {
+			scs = append(scs, sc)
+		}
+	}
+	for i, sc := range scs {
+		sc.ScreenNumber = i
+	}
+	return scs
+}
+
+// refreshScreens updates the list of screens after a RandR change
+// notification, and sends a window.ScreenUpdate event to all windows whose
+// screen has changed as a result.  The existing Screen is updated in place
+// for monitors that are still present, so that pointers to it remain valid.
+func (app *appImpl) refreshScreens() {
+	scs := app.randrScreens()
+	if len(scs) == 0 {
+		return
+	}
+	app.mu.Lock()
+	old := make(map[string]*oswin.Screen, len(app.screens))
+	for _, sc := range app.screens {
+		old[sc.Name] = sc
+	}
+	changed := make(map[*oswin.Screen]bool)
+	for i, sc := range scs {
+		osc, ok := old[sc.Name]
+		if !ok {
+			continue
+		}
+		if osc.Geometry != sc.Geometry || osc.PhysicalDPI != sc.PhysicalDPI || osc.Orientation != sc.Orientation {
+			*osc = *sc
+			changed[osc] = true
+		} else {
+			osc.ScreenNumber = sc.ScreenNumber
+			osc.RefreshRate = sc.RefreshRate
+		}
+		scs[i] = osc
+	}
+	app.screens = scs
+	wins := make([]*windowImpl, len(app.winlist))
+	copy(wins, app.winlist)
+	app.mu.Unlock()
+
+	for _, w := range wins {
+		w.mu.Lock()
+		r := image.Rectangle{Min: w.Pos, Max: w.Pos.Add(w.Sz)}
+		w.mu.Unlock()
+		w.updateScreen(app.screenForRect(r), changed)
+	}
+}
+
+// screenForRect returns the screen that contains most of given rectangle
+// (in root window coordinates) -- the first screen if none
+func (app *appImpl) screenForRect(r image.Rectangle) *oswin.Screen {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	if len(app.screens) == 0 {
+		return nil
+	}
+	best := app.screens[0]
+	bestArea := 0
+	for _, sc := range app.screens {
+		isz := sc.Geometry.Intersect(r).Size()
+		if area := isz.X * isz.Y; area > bestArea {
+			best, bestArea = sc, area
+		}
+	}
+	return best
+}
+
+// updateScreen sets the window's screen, sending a window.ScreenUpdate
+// event if it is different from before, or has changed
+func (w *windowImpl) updateScreen(sc *oswin.Screen, changed map[*oswin.Screen]bool) {
+	if sc == nil {
+		return
+	}
+	w.mu.Lock()
+	upd := w.Scrn != sc || changed[sc]
+	w.Scrn = sc
+	w.PhysDPI = sc.PhysicalDPI
+	w.mu.Unlock()
+	if upd {
+		sendWindowEvent(w, window.ScreenUpdate)
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/goki/gi/oswin/driver/internal/drawer"
 	"github.com/goki/gi/oswin/driver/internal/event"
 	"github.com/goki/gi/oswin/key"
+	"github.com/goki/gi/oswin/mimedata"
 	"github.com/goki/gi/oswin/mouse"
 	"github.com/goki/gi/oswin/window"
 	"github.com/goki/ki/bitflag"
@@ -116,7 +117,9 @@ func (w *windowImpl) Publish() oswin.PublishResult {
 	// server can serve.
 	w.app.xc.Sync()
 
-	return oswin.PublishResult{}
+	// there is no back buffer: drawing goes directly to the window, whose
+	// contents are preserved until an expose, which sends a paint event
+	return oswin.PublishResult{BackImagePreserved: true}
 }
 
 func (w *windowImpl) getFrameSizes() [4]int {
@@ -166,10 +169,6 @@ func AbsInt(a int) int {
 }
 
 func (w *windowImpl) handleConfigureNotify(ev xproto.ConfigureNotifyEvent) {
-	// todo: support multiple screens
-	sc := oswin.TheApp.Screen(0)
-	dpi := sc.PhysicalDPI
-
 	sz := image.Point{int(ev.Width), int(ev.Height)}
 	ps := image.Point{int(ev.X), int(ev.Y)}
 
@@ -191,6 +190,12 @@ func (w *windowImpl) handleConfigureNotify(ev xproto.ConfigureNotifyEvent) {
 	}
 
 	// fmt.Printf("event geom, pos: %v size: %v  cur: %v  posdif: %v  border: %v\n", orgPos, sz, cpos, posdif, borderWidth)
+	sc := w.app.screenForRect(image.Rectangle{Min: ps, Max: ps.Add(sz)})
+	if sc == nil {
+		sc = w.Scrn
+	}
+	dpi := sc.PhysicalDPI
+	scChanged := w.Scrn != sc
 	act := window.Resize
 
 	if w.Sz != sz || w.PhysDPI != dpi {
@@ -204,13 +209,14 @@ func (w *windowImpl) handleConfigureNotify(ev xproto.ConfigureNotifyEvent) {
 	w.Sz = sz
 	w.PhysDPI = dpi
 
-	// if scrno > 0 && len(theApp.screens) > int(scrno) {
 	w.Scrn = sc
-	// }
 	w.mu.Unlock()
 
 	// fmt.Printf("sending window event: %v: sz: %v pos: %v\n", act, sz, ps)
 	sendWindowEvent(w, act)
+	if scChanged {
+		sendWindowEvent(w, window.ScreenUpdate)
+	}
 }
 
 func (w *windowImpl) handleExpose() {
@@ -449,6 +455,14 @@ func (w *windowImpl) Minimize() {
 	xproto.SendEvent(w.app.xc, true, w.xw, uint32(mask), string(minmsg.Bytes()))
 }
 
+func (w *windowImpl) StartDragNDrop(data mimedata.Mimes) {
+	theXdnd.startDrag(w, data)
+}
+
+func (w *windowImpl) SetIMECursor(r image.Rectangle) {
+	theXim.setCursor(w, r)
+}
+
 func (w *windowImpl) AddTexture(t *textureImpl) {
 	if w.textures == nil {
 		w.textures = make(map[*textureImpl]struct{})
@@ -0,0 +1,223 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build linux,!android dragonfly openbsd
+
+package x11driver
+
+import (
+	"image"
+	"log"
+
+	"github.com/BurntSushi/xgb"
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/goki/gi/oswin"
+	"github.com/goki/gi/oswin/window"
+	"github.com/goki/ki/bitflag"
+)
+
+// implements the window state (maximized, fullscreen, above), decorations,
+// opacity and moving of frameless windows using the EWMH spec:
+// https://specifications.freedesktop.org/wm-spec/wm-spec-latest.html
+// the state is requested from the window manager, and the window flags are
+// only updated when the window manager changes the _NET_WM_STATE property.
+
+const (
+	netWMStateRemove = 0
+	netWMStateAdd    = 1
+
+	netWMMoveResizeMove = 8
+
+	motifHintsDecorations = 2
+)
+
+func (app *appImpl) initWMStateAtoms() (err error) {
+	atoms := []struct {
+		atom *xproto.Atom
+		name string
+	}{
+		{&app.atomNetWMState, "_NET_WM_STATE"},
+		{&app.atomNetWMStateMaxVert, "_NET_WM_STATE_MAXIMIZED_VERT"},
+		{&app.atomNetWMStateMaxHorz, "_NET_WM_STATE_MAXIMIZED_HORZ"},
+		{&app.atomNetWMStateFullscreen, "_NET_WM_STATE_FULLSCREEN"},
+		{&app.atomNetWMStateAbove, "_NET_WM_STATE_ABOVE"},
+		{&app.atomNetWMStateHidden, "_NET_WM_STATE_HIDDEN"},
+		{&app.atomNetWMMoveResize, "_NET_WM_MOVERESIZE"},
+		{&app.atomNetWMOpacity, "_NET_WM_WINDOW_OPACITY"},
+		{&app.atomMotifWMHints, "_MOTIF_WM_HINTS"},
+	}
+	for _, a := range atoms {
+		*a.atom, err = app.internAtom(a.name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stateAtoms returns the _NET_WM_STATE atoms for given window state flag
+func (app *appImpl) stateAtoms(flag oswin.WindowFlags) []xproto.Atom {
+	switch flag {
+	case oswin.Maximized:
+		return []xproto.Atom{app.atomNetWMStateMaxVert, app.atomNetWMStateMaxHorz}
+	case oswin.Fullscreen:
+		return []xproto.Atom{app.atomNetWMStateFullscreen}
+	case oswin.AlwaysOnTop:
+		return []xproto.Atom{app.atomNetWMStateAbove}
+	}
+	return nil
+}
+
+// initWMState sets the initial state, decorations and opacity properties
+// of a new window according to the options -- must be called prior to
+// mapping the window
+func (w *windowImpl) initWMState(opts *oswin.NewWindowOptions) {
+	app := w.app
+	var sts []xproto.Atom
+	for _, fl := range []oswin.WindowFlags{oswin.Maximized, oswin.Fullscreen, oswin.AlwaysOnTop} {
+		if bitflag.Has(opts.Flags, int(fl)) {
+			sts = append(sts, app.stateAtoms(fl)...)
+		}
+	}
+	if len(sts) > 0 {
+		app.setProperty(w.xw, app.atomNetWMState, sts...)
+	}
+	if bitflag.Has(opts.Flags, int(oswin.Frameless)) {
+		hints := make([]byte, 5*4) // flags, functions, decorations, input mode, status
+		xgb.Put32(hints, motifHintsDecorations)
+		xproto.ChangeProperty(app.xc, xproto.PropModeReplace, w.xw, app.atomMotifWMHints, app.atomMotifWMHints, 32, 5, hints)
+	}
+	if opts.Opacity > 0 && opts.Opacity < 1 {
+		w.setOpacityProp(opts.Opacity)
+	}
+}
+
+// sendWMState asks the window manager to add or remove given state
+func (w *windowImpl) sendWMState(flag oswin.WindowFlags, on bool) {
+	app := w.app
+	vdat := []uint32{netWMStateRemove, 0, 0, 1, 0} // 1 = normal application source
+	if on {
+		vdat[0] = netWMStateAdd
+	}
+	for i, a := range app.stateAtoms(flag) {
+		vdat[1+i] = uint32(a)
+	}
+	msg := xproto.ClientMessageEvent{
+		Format: 32,
+		Window: w.xw,
+		Type:   app.atomNetWMState,
+		Data:   xproto.ClientMessageDataUnionData32New(vdat),
+	}
+	mask := xproto.EventMaskSubstructureRedirect | xproto.EventMaskSubstructureNotify
+	xproto.SendEvent(app.xc, false, app.xsci.Root, uint32(mask), string(msg.Bytes()))
+}
+
+func (w *windowImpl) SetMaximized(max bool) {
+	w.sendWMState(oswin.Maximized, max)
+}
+
+func (w *windowImpl) SetFullscreen(fs bool) {
+	w.sendWMState(oswin.Fullscreen, fs)
+}
+
+func (w *windowImpl) SetAlwaysOnTop(top bool) {
+	w.sendWMState(oswin.AlwaysOnTop, top)
+}
+
+// setOpacityProp sets the opacity property, read by the compositing manager
+func (w *windowImpl) setOpacityProp(op float32) {
+	app := w.app
+	if op <= 0 || op >= 1 {
+		xproto.DeleteProperty(app.xc, w.xw, app.atomNetWMOpacity)
+		return
+	}
+	b := make([]byte, 4)
+	xgb.Put32(b, uint32(float64(op)*0xffffffff))
+	xproto.ChangeProperty(app.xc, xproto.PropModeReplace, w.xw, app.atomNetWMOpacity, xproto.AtomCardinal, 32, 1, b)
+}
+
+func (w *windowImpl) SetOpacity(op float32) {
+	w.mu.Lock()
+	w.Opac = op
+	w.mu.Unlock()
+	w.setOpacityProp(op)
+}
+
+func (w *windowImpl) Opacity() float32 {
+	w.mu.Lock()
+	op := w.WindowBase.Opacity()
+	w.mu.Unlock()
+	return op
+}
+
+func (w *windowImpl) DragRegions() []image.Rectangle {
+	w.mu.Lock()
+	regs := w.DragRgs
+	w.mu.Unlock()
+	return regs
+}
+
+func (w *windowImpl) SetDragRegions(regs []image.Rectangle) {
+	w.mu.Lock()
+	w.DragRgs = regs
+	w.mu.Unlock()
+}
+
+func (w *windowImpl) InDragRegion(pos image.Point) bool {
+	w.mu.Lock()
+	in := w.WindowBase.InDragRegion(pos)
+	w.mu.Unlock()
+	return in
+}
+
+// handleWMStateNotify reads the new _NET_WM_STATE set by the window manager,
+// updating the window flags and sending a StateChange event if changed
+func (w *windowImpl) handleWMStateNotify() {
+	app := w.app
+	pr, err := xproto.GetProperty(app.xc, false, w.xw, app.atomNetWMState, xproto.AtomAtom, 0, 64).Reply()
+	if err != nil {
+		log.Printf("x11driver: xproto.GetProperty for _NET_WM_STATE failed: %v", err)
+		return
+	}
+	has := make(map[xproto.Atom]bool)
+	for i := 0; i+4 <= len(pr.Value); i += 4 {
+		has[xproto.Atom(xgb.Get32(pr.Value[i:]))] = true
+	}
+	w.mu.Lock()
+	oflag := w.Flag
+	wasMin := w.IsMinimized()
+	bitflag.SetStateAtomic(&w.Flag, has[app.atomNetWMStateMaxVert] && has[app.atomNetWMStateMaxHorz], int(oswin.Maximized))
+	bitflag.SetStateAtomic(&w.Flag, has[app.atomNetWMStateFullscreen], int(oswin.Fullscreen))
+	bitflag.SetStateAtomic(&w.Flag, has[app.atomNetWMStateAbove], int(oswin.AlwaysOnTop))
+	bitflag.SetStateAtomic(&w.Flag, has[app.atomNetWMStateHidden], int(oswin.Minimized))
+	changed := w.Flag != oflag
+	isMin := w.IsMinimized()
+	w.mu.Unlock()
+	if !changed {
+		return
+	}
+	if isMin && !wasMin {
+		sendWindowEvent(w, window.Minimize)
+	}
+	sendWindowEvent(w, window.StateChange)
+}
+
+// startMoveDrag asks the window manager to move the window interactively,
+// starting from given root coordinates -- used for DragRegions of frameless
+// windows
+func (w *windowImpl) startMoveDrag(rootX, rootY int16, tm xproto.Timestamp) {
+	app := w.app
+	// release the implicit grab from the button press, so the window manager
+	// can grab the pointer -- then request a move with button 1, normal source
+	xproto.UngrabPointer(app.xc, tm)
+	vdat := []uint32{uint32(rootX), uint32(rootY), netWMMoveResizeMove, 1, 1}
+	msg := xproto.ClientMessageEvent{
+		Format: 32,
+		Window: w.xw,
+		Type:   app.atomNetWMMoveResize,
+		Data:   xproto.ClientMessageDataUnionData32New(vdat),
+	}
+	mask := xproto.EventMaskSubstructureRedirect | xproto.EventMaskSubstructureNotify
+	xproto.SendEvent(app.xc, false, app.xsci.Root, uint32(mask), string(msg.Bytes()))
+}
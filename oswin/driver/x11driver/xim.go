@@ -0,0 +1,772 @@
+// Copyright 2018 The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build linux,!android dragonfly openbsd
+
+package x11driver
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/goki/gi/oswin/key"
+)
+
+// implements input method (IME) support using the XIM protocol, which is
+// supported by all the main X11 input methods (IBus, fcitx, etc):
+// https://www.x.org/releases/X11R7.7/doc/libX11/XIM/xim.html
+// we use on-the-spot (callback) preedit, so the text being composed is sent
+// as key.IMEEvent's and drawn in place by the widget with focus, and we only
+// implement the parts of the protocol needed for that, over the X transport
+// (ClientMessages and properties), asynchronously within the main event loop.
+
+// UseXIM determines whether to connect to the XIM input method server
+// specified by the XMODIFIERS environment variable (e.g., @im=ibus) --
+// must be set before the first window is created
+var UseXIM = true
+
+// XIM protocol message opcodes
+const (
+	ximConnect                  = 1
+	ximConnectReply             = 2
+	ximError                    = 20
+	ximOpen                     = 30
+	ximOpenReply                = 31
+	ximSetEventMask             = 37
+	ximEncodingNegotiation      = 38
+	ximEncodingNegotiationReply = 39
+	ximCreateIC                 = 50
+	ximCreateICReply            = 51
+	ximDestroyIC                = 52
+	ximSetICValues              = 54
+	ximSetICFocus               = 58
+	ximUnsetICFocus             = 59
+	ximForwardEvent             = 60
+	ximSync                     = 61
+	ximSyncReply                = 62
+	ximCommit                   = 63
+	ximPreeditStart             = 73
+	ximPreeditStartReply        = 74
+	ximPreeditDraw              = 75
+	ximPreeditCaret             = 76
+	ximPreeditCaretReply        = 77
+	ximPreeditDone              = 78
+)
+
+// XIM input styles
+const (
+	ximPreeditCallbacks = 0x0002
+	ximPreeditNothing   = 0x0008
+	ximStatusNothing    = 0x0400
+)
+
+// ximEncodings are the string encodings we offer the server, in order of
+// preference -- COMPOUND_TEXT is the default
+var ximEncodings = []string{"UTF-8", "COMPOUND_TEXT"}
+
+// ximStates are the states of the connection to the input method server
+type ximStates int
+
+const (
+	ximNone       ximStates = iota // not yet started
+	ximConnecting                  // waiting for the server to open the input method
+	ximReady                       // input method is open, input contexts can be created
+	ximFailed                      // no input method available
+)
+
+// ximIC is the input context for one of our windows
+type ximIC struct {
+	win     *windowImpl
+	id      uint16 // assigned by server -- 0 until created
+	active  bool   // text input is active in the window -- see SetIMECursor
+	focused bool   // input context has focus on the server
+	spot    image.Point
+	preedit []rune
+	caret   int
+}
+
+type ximImpl struct {
+	mu        sync.Mutex
+	state     ximStates
+	comm      xproto.Window // our communication window
+	srvComm   xproto.Window // server communication window
+	atomXConn xproto.Atom
+	atomProto xproto.Atom
+	atomMore  xproto.Atom
+	propAtoms []xproto.Atom // properties for sending large messages, used in rotation
+	propIdx   int
+	imID      uint16
+	icAttrs   map[string]uint16 // ids of input context attributes, by name
+	style     uint32
+	utf8      bool   // server sends UTF-8 strings instead of COMPOUND_TEXT
+	fwdMask   uint32 // key events to forward to the server
+	buf       []byte // accumulates multi-part messages
+	ics       map[xproto.Window]*ximIC
+	creating  []*ximIC // waiting for XIM_CREATE_IC_REPLY, in order
+}
+
+var theXim = ximImpl{}
+
+// ximServerName returns the name of the input method server from the
+// XMODIFIERS environment variable -- "" if not specified
+func ximServerName() string {
+	mods := os.Getenv("XMODIFIERS")
+	i := strings.Index(mods, "@im=")
+	if i < 0 {
+		return ""
+	}
+	nm := mods[i+4:]
+	if j := strings.IndexByte(nm, '@'); j >= 0 {
+		nm = nm[:j]
+	}
+	return nm
+}
+
+// ximLocale returns the locale name to open the input method with
+func ximLocale() string {
+	for _, ev := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if lc := os.Getenv(ev); lc != "" {
+			if i := strings.IndexAny(lc, ".@"); i >= 0 {
+				lc = lc[:i]
+			}
+			return lc
+		}
+	}
+	return "C"
+}
+
+// start connects to the input method server, if not already done -- returns
+// false if no input method is available.  Must be called with mu locked.
+func (xi *ximImpl) start() bool {
+	if xi.state != ximNone {
+		return xi.state != ximFailed
+	}
+	xi.state = ximFailed
+	nm := ximServerName()
+	if !UseXIM || nm == "none" {
+		return false
+	}
+	app := theApp
+	atomServers, err := app.internAtom("XIM_SERVERS")
+	if err != nil {
+		return false
+	}
+	pr, err := xproto.GetProperty(app.xc, false, app.xsci.Root, atomServers, xproto.AtomAtom, 0, 1024).Reply()
+	if err != nil || pr == nil {
+		return false
+	}
+	var server xproto.Window
+	for i := 0; i+4 <= len(pr.Value); i += 4 {
+		sa := xproto.Atom(binary.LittleEndian.Uint32(pr.Value[i:]))
+		if nm != "" && atomName(sa) != "@server="+nm {
+			continue
+		}
+		so, err := xproto.GetSelectionOwner(app.xc, sa).Reply()
+		if err == nil && so.Owner != 0 {
+			server = so.Owner
+			break
+		}
+	}
+	if server == 0 {
+		return false
+	}
+	names := []string{"_XIM_XCONNECT", "_XIM_PROTOCOL", "_XIM_MOREDATA"}
+	for i := 0; i < 4; i++ {
+		names = append(names, fmt.Sprintf("_GOGI_XIM_DATA%d", i))
+	}
+	atoms := make([]xproto.Atom, len(names))
+	for i, an := range names {
+		if atoms[i], err = app.internAtom(an); err != nil {
+			return false
+		}
+	}
+	xi.atomXConn, xi.atomProto, xi.atomMore = atoms[0], atoms[1], atoms[2]
+	xi.propAtoms = atoms[3:]
+	xi.comm, err = xproto.NewWindowId(app.xc)
+	if err != nil {
+		return false
+	}
+	xproto.CreateWindow(app.xc, 0, xi.comm, app.xsci.Root, 0, 0, 1, 1, 0,
+		xproto.WindowClassInputOnly, 0, 0, nil)
+	xi.style = ximPreeditCallbacks | ximStatusNothing
+	xi.fwdMask = xproto.EventMaskKeyPress
+	xi.icAttrs = make(map[string]uint16)
+	xi.ics = make(map[xproto.Window]*ximIC)
+	xi.state = ximConnecting
+	xi.sendClientMessage32(server, xi.atomXConn, uint32(xi.comm), 0, 0)
+	return true
+}
+
+// addWindow creates an input context for given window
+func (xi *ximImpl) addWindow(w *windowImpl) {
+	xi.mu.Lock()
+	defer xi.mu.Unlock()
+	if !xi.start() {
+		return
+	}
+	ic := &ximIC{win: w}
+	xi.ics[w.xw] = ic
+	if xi.state == ximReady {
+		xi.createIC(ic)
+	}
+}
+
+// removeWindow destroys the input context for given window
+func (xi *ximImpl) removeWindow(w *windowImpl) {
+	xi.mu.Lock()
+	defer xi.mu.Unlock()
+	ic, ok := xi.ics[w.xw]
+	if !ok {
+		return
+	}
+	delete(xi.ics, w.xw)
+	if ic.id != 0 {
+		xi.sendIC(ximDestroyIC, ic)
+	}
+}
+
+// setCursor sets the text cursor position for given window -- empty rect
+// means that text input is not active
+func (xi *ximImpl) setCursor(w *windowImpl, r image.Rectangle) {
+	xi.mu.Lock()
+	defer xi.mu.Unlock()
+	ic, ok := xi.ics[w.xw]
+	if !ok {
+		return
+	}
+	spot := image.Point{r.Min.X, r.Max.Y}
+	moved := spot != ic.spot
+	ic.spot = spot
+	wasFocused := ic.focused
+	ic.active = !r.Empty()
+	xi.updateFocus(ic)
+	if wasFocused && ic.focused && moved {
+		xi.setSpot(ic)
+	}
+}
+
+// windowFocus updates the input context focus when the focus of given
+// window changes
+func (xi *ximImpl) windowFocus(w *windowImpl) {
+	xi.mu.Lock()
+	defer xi.mu.Unlock()
+	if ic, ok := xi.ics[w.xw]; ok {
+		xi.updateFocus(ic)
+	}
+}
+
+// updateFocus sets the input context focus on the server according to
+// whether text input is active and the window has focus
+func (xi *ximImpl) updateFocus(ic *ximIC) {
+	if ic.id == 0 {
+		return
+	}
+	focus := ic.active && ic.win.IsFocus()
+	if focus == ic.focused {
+		return
+	}
+	ic.focused = focus
+	if focus {
+		xi.setSpot(ic)
+		xi.sendIC(ximSetICFocus, ic)
+	} else {
+		xi.sendIC(ximUnsetICFocus, ic)
+	}
+}
+
+// forwardKey forwards given key event (in wire format) to the server if
+// text input is active in the window, returning true if so -- unfiltered
+// events are sent back to us by the server, and handled in the usual way
+func (xi *ximImpl) forwardKey(w *windowImpl, evb []byte, seq uint16, mask uint32) bool {
+	xi.mu.Lock()
+	defer xi.mu.Unlock()
+	ic, ok := xi.ics[w.xw]
+	if !ok || !ic.focused || xi.fwdMask&mask == 0 {
+		return false
+	}
+	binary.LittleEndian.PutUint16(evb[2:], seq)
+	mw := &ximWriter{}
+	mw.card16(xi.imID)
+	mw.card16(ic.id)
+	mw.card16(1) // synchronous
+	mw.card16(0) // high bits of serial
+	mw.bytes(evb)
+	xi.send(ximForwardEvent, mw)
+	return true
+}
+
+////////////////////////////////////////////////////////////////////////////
+//   Sending
+
+// ximWriter builds the body of an XIM message, in little-endian byte order
+type ximWriter struct {
+	b []byte
+}
+
+func (mw *ximWriter) card8(v uint8) {
+	mw.b = append(mw.b, v)
+}
+
+func (mw *ximWriter) card16(v uint16) {
+	mw.b = append(mw.b, byte(v), byte(v>>8))
+}
+
+func (mw *ximWriter) card32(v uint32) {
+	mw.b = append(mw.b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func (mw *ximWriter) bytes(b []byte) {
+	mw.b = append(mw.b, b...)
+}
+
+// pad pads to a multiple of 4 bytes
+func (mw *ximWriter) pad() {
+	for len(mw.b)%4 != 0 {
+		mw.b = append(mw.b, 0)
+	}
+}
+
+// send sends an XIM message with given opcode and body to the server --
+// small messages go in a single ClientMessage, and larger ones via a
+// property on the server communication window
+func (xi *ximImpl) send(op uint8, mw *ximWriter) {
+	mw.pad()
+	msg := make([]byte, 4, 4+len(mw.b))
+	msg[0] = op
+	binary.LittleEndian.PutUint16(msg[2:], uint16(len(mw.b)/4))
+	msg = append(msg, mw.b...)
+	if len(msg) <= 20 {
+		var d [20]byte
+		copy(d[:], msg)
+		ev := xproto.ClientMessageEvent{
+			Format: 8,
+			Window: xi.srvComm,
+			Type:   xi.atomProto,
+			Data:   xproto.ClientMessageDataUnionData8New(d[:]),
+		}
+		xproto.SendEvent(theApp.xc, false, xi.srvComm, xproto.EventMaskNoEvent, string(ev.Bytes()))
+		return
+	}
+	prop := xi.propAtoms[xi.propIdx]
+	xi.propIdx = (xi.propIdx + 1) % len(xi.propAtoms)
+	xproto.ChangeProperty(theApp.xc, xproto.PropModeAppend, xi.srvComm, prop, xproto.AtomString, 8, uint32(len(msg)), msg)
+	xi.sendClientMessage32(xi.srvComm, xi.atomProto, uint32(len(msg)), uint32(prop))
+}
+
+// sendClientMessage32 sends a format 32 client message to given window
+func (xi *ximImpl) sendClientMessage32(to xproto.Window, typ xproto.Atom, data ...uint32) {
+	var d [5]uint32
+	copy(d[:], data)
+	ev := xproto.ClientMessageEvent{
+		Format: 32,
+		Window: to,
+		Type:   typ,
+		Data:   xproto.ClientMessageDataUnionData32New(d[:]),
+	}
+	xproto.SendEvent(theApp.xc, false, to, xproto.EventMaskNoEvent, string(ev.Bytes()))
+}
+
+// sendIC sends a message whose body is just the input method and context ids
+func (xi *ximImpl) sendIC(op uint8, ic *ximIC) {
+	mw := &ximWriter{}
+	mw.card16(xi.imID)
+	mw.card16(ic.id)
+	xi.send(op, mw)
+}
+
+// icAttr adds an input context attribute with given name and value, if
+// supported by the server
+func (xi *ximImpl) icAttr(mw *ximWriter, name string, val []byte) {
+	id, ok := xi.icAttrs[name]
+	if !ok {
+		return
+	}
+	mw.card16(id)
+	mw.card16(uint16(len(val)))
+	mw.bytes(val)
+	mw.pad()
+}
+
+// icAttr32 adds a 32 bit input context attribute
+func (xi *ximImpl) icAttr32(mw *ximWriter, name string, val uint32) {
+	vw := &ximWriter{}
+	vw.card32(val)
+	xi.icAttr(mw, name, vw.b)
+}
+
+// createIC asks the server to create given input context
+func (xi *ximImpl) createIC(ic *ximIC) {
+	attrs := &ximWriter{}
+	xi.icAttr32(attrs, "inputStyle", xi.style)
+	xi.icAttr32(attrs, "clientWindow", uint32(ic.win.xw))
+	xi.icAttr32(attrs, "focusWindow", uint32(ic.win.xw))
+	mw := &ximWriter{}
+	mw.card16(xi.imID)
+	mw.card16(uint16(len(attrs.b)))
+	mw.bytes(attrs.b)
+	xi.send(ximCreateIC, mw)
+	xi.creating = append(xi.creating, ic)
+}
+
+// setSpot tells the server the text cursor position, for positioning the
+// candidate window
+func (xi *ximImpl) setSpot(ic *ximIC) {
+	spot := &ximWriter{}
+	spot.card16(uint16(int16(ic.spot.X)))
+	spot.card16(uint16(int16(ic.spot.Y)))
+	nest := &ximWriter{}
+	xi.icAttr(nest, "spotLocation", spot.b)
+	attrs := &ximWriter{}
+	xi.icAttr(attrs, "preeditAttributes", nest.b)
+	if len(nest.b) == 0 || len(attrs.b) == 0 {
+		return
+	}
+	mw := &ximWriter{}
+	mw.card16(xi.imID)
+	mw.card16(ic.id)
+	mw.card16(uint16(len(attrs.b)))
+	mw.card16(0)
+	mw.bytes(attrs.b)
+	xi.send(ximSetICValues, mw)
+}
+
+////////////////////////////////////////////////////////////////////////////
+//   Receiving
+
+// ximReader reads the body of an XIM message -- reading past the end
+// returns zero values
+type ximReader struct {
+	b []byte
+}
+
+func (mr *ximReader) bytes(n int) []byte {
+	if n > len(mr.b) {
+		n = len(mr.b)
+	}
+	b := mr.b[:n]
+	mr.b = mr.b[n:]
+	return b
+}
+
+func (mr *ximReader) card16() uint16 {
+	b := mr.bytes(2)
+	if len(b) < 2 {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(b)
+}
+
+func (mr *ximReader) card32() uint32 {
+	b := mr.bytes(4)
+	if len(b) < 4 {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(b)
+}
+
+// skipPad skips the padding after n bytes
+func (mr *ximReader) skipPad(n int) {
+	mr.bytes((4 - n%4) % 4)
+}
+
+// handleClientMessage processes client messages sent to our communication
+// window -- returns false if not for us
+func (xi *ximImpl) handleClientMessage(ev xproto.ClientMessageEvent) bool {
+	xi.mu.Lock()
+	defer xi.mu.Unlock()
+	if xi.comm == 0 || ev.Window != xi.comm {
+		return false
+	}
+	switch {
+	case ev.Type == xi.atomXConn && ev.Format == 32:
+		xi.srvComm = xproto.Window(ev.Data.Data32[0])
+		mw := &ximWriter{}
+		mw.card8('l') // little endian
+		mw.card8(0)
+		mw.card16(1) // protocol version 1.0
+		mw.card16(0)
+		mw.card16(0) // no authentication
+		xi.send(ximConnect, mw)
+	case ev.Type == xi.atomMore && ev.Format == 8:
+		xi.buf = append(xi.buf, ev.Data.Data8...)
+	case ev.Type == xi.atomProto && ev.Format == 8:
+		xi.buf = append(xi.buf, ev.Data.Data8...)
+		xi.handleMessages(xi.buf)
+		xi.buf = nil
+	case ev.Type == xi.atomProto && ev.Format == 32:
+		sz, prop := ev.Data.Data32[0], xproto.Atom(ev.Data.Data32[1])
+		pr, err := xproto.GetProperty(theApp.xc, true, xi.comm, prop, xproto.AtomAny, 0, (sz+3)/4).Reply()
+		if err != nil {
+			log.Printf("x11driver: XIM message property read error: %v\n", err)
+			break
+		}
+		xi.handleMessages(pr.Value)
+	}
+	return true
+}
+
+// handleMessages processes the XIM messages in given data
+func (xi *ximImpl) handleMessages(b []byte) {
+	for len(b) >= 4 {
+		op := b[0]
+		n := 4 + 4*int(binary.LittleEndian.Uint16(b[2:]))
+		if op == 0 || n > len(b) {
+			return
+		}
+		xi.handleMessage(op, &ximReader{b: b[4:n]})
+		b = b[n:]
+	}
+}
+
+// handleMessage processes one XIM message from the server
+func (xi *ximImpl) handleMessage(op uint8, mr *ximReader) {
+	switch op {
+	case ximConnectReply:
+		loc := ximLocale()
+		mw := &ximWriter{}
+		mw.card8(uint8(len(loc)))
+		mw.bytes([]byte(loc))
+		xi.send(ximOpen, mw)
+	case ximOpenReply:
+		xi.imID = mr.card16()
+		n := int(mr.card16())
+		mr.bytes(n) // im attributes
+		n = int(mr.card16())
+		mr.card16()
+		attrs := &ximReader{b: mr.bytes(n)}
+		for len(attrs.b) >= 6 {
+			id := attrs.card16()
+			attrs.card16() // type
+			nl := int(attrs.card16())
+			xi.icAttrs[string(attrs.bytes(nl))] = id
+			attrs.skipPad(2 + nl)
+		}
+		encs := &ximWriter{}
+		for _, enc := range ximEncodings {
+			encs.card8(uint8(len(enc)))
+			encs.bytes([]byte(enc))
+		}
+		mw := &ximWriter{}
+		mw.card16(xi.imID)
+		mw.card16(uint16(len(encs.b)))
+		mw.bytes(encs.b)
+		mw.pad()
+		mw.card16(0) // no detailed encoding info
+		mw.card16(0)
+		xi.send(ximEncodingNegotiation, mw)
+	case ximEncodingNegotiationReply:
+		mr.card16()
+		mr.card16() // category
+		idx := int16(mr.card16())
+		xi.utf8 = idx >= 0 && int(idx) < len(ximEncodings) && ximEncodings[idx] == "UTF-8"
+		xi.state = ximReady
+		for _, ic := range xi.ics {
+			xi.createIC(ic)
+		}
+	case ximSetEventMask:
+		mr.card16()
+		mr.card16()
+		xi.fwdMask = mr.card32()
+	case ximCreateICReply:
+		mr.card16()
+		id := mr.card16()
+		if len(xi.creating) == 0 {
+			break
+		}
+		ic := xi.creating[0]
+		xi.creating = xi.creating[1:]
+		ic.id = id
+		if xi.ics[ic.win.xw] != ic { // window closed in the meantime
+			xi.sendIC(ximDestroyIC, ic)
+			break
+		}
+		xi.updateFocus(ic)
+	case ximError:
+		mr.card16()
+		mr.card16()
+		flag := mr.card16()
+		code := mr.card16()
+		n := int(mr.card16())
+		mr.card16()
+		log.Printf("x11driver: XIM error %v: %v\n", code, string(mr.bytes(n)))
+		if flag&2 == 0 && len(xi.creating) > 0 && xi.style&ximPreeditCallbacks != 0 {
+			// on-the-spot preedit not supported -- let the server show the preedit
+			ic := xi.creating[0]
+			xi.creating = xi.creating[1:]
+			xi.style = ximPreeditNothing | ximStatusNothing
+			xi.createIC(ic)
+		}
+	case ximForwardEvent:
+		mr.card16()
+		ic := xi.icByID(mr.card16())
+		flag := mr.card16()
+		mr.card16()
+		evb := mr.bytes(32)
+		if ic != nil && len(evb) == 32 {
+			switch ev := xproto.KeyPressEventNew(evb).(type) {
+			case xproto.KeyPressEvent:
+				if evb[0]&0x7f == xproto.KeyRelease {
+					ic.win.handleKey(ev.Detail, ev.State, key.Release)
+				} else {
+					ic.win.handleKey(ev.Detail, ev.State, key.Press)
+				}
+			}
+		}
+		if flag&1 != 0 && ic != nil {
+			xi.sendIC(ximSyncReply, ic)
+		}
+	case ximSync:
+		mr.card16()
+		if ic := xi.icByID(mr.card16()); ic != nil {
+			xi.sendIC(ximSyncReply, ic)
+		}
+	case ximCommit:
+		mr.card16()
+		ic := xi.icByID(mr.card16())
+		flag := mr.card16()
+		txt := ""
+		if flag&4 != 0 { // keysym
+			mr.card16()
+			if r := keysymRune(mr.card32()); r > 0 {
+				txt = string(r)
+			}
+		}
+		if flag&2 != 0 { // chars
+			n := int(mr.card16())
+			txt += xi.decode(mr.bytes(n))
+		}
+		if ic == nil {
+			break
+		}
+		ic.preedit = nil
+		ic.caret = 0
+		if txt != "" {
+			xi.sendIME(ic, key.IMECommit, txt)
+		}
+		if flag&1 != 0 {
+			xi.sendIC(ximSyncReply, ic)
+		}
+	case ximPreeditStart:
+		mr.card16()
+		if ic := xi.icByID(mr.card16()); ic != nil {
+			mw := &ximWriter{}
+			mw.card16(xi.imID)
+			mw.card16(ic.id)
+			mw.card32(0xffffffff) // no length limit
+			xi.send(ximPreeditStartReply, mw)
+		}
+	case ximPreeditDraw:
+		mr.card16()
+		ic := xi.icByID(mr.card16())
+		caret := int(int32(mr.card32()))
+		first := int(int32(mr.card32()))
+		chlen := int(int32(mr.card32()))
+		status := mr.card32()
+		n := int(mr.card16())
+		var txt []rune
+		if status&1 == 0 {
+			txt = []rune(xi.decode(mr.bytes(n)))
+		}
+		if ic == nil {
+			break
+		}
+		first = clampInt(first, 0, len(ic.preedit))
+		last := clampInt(first+chlen, first, len(ic.preedit))
+		pe := append([]rune{}, ic.preedit[:first]...)
+		pe = append(pe, txt...)
+		ic.preedit = append(pe, ic.preedit[last:]...)
+		ic.caret = clampInt(caret, 0, len(ic.preedit))
+		xi.sendIME(ic, key.IMEPreedit, string(ic.preedit))
+	case ximPreeditCaret:
+		mr.card16()
+		ic := xi.icByID(mr.card16())
+		pos := int(int32(mr.card32()))
+		dir := mr.card32()
+		if ic == nil {
+			break
+		}
+		switch dir {
+		case 0: // forward char
+			ic.caret++
+		case 1: // backward char
+			ic.caret--
+		case 8: // line start
+			ic.caret = 0
+		case 9: // line end
+			ic.caret = len(ic.preedit)
+		case 10: // absolute
+			ic.caret = pos
+		}
+		ic.caret = clampInt(ic.caret, 0, len(ic.preedit))
+		mw := &ximWriter{}
+		mw.card16(xi.imID)
+		mw.card16(ic.id)
+		mw.card32(uint32(ic.caret))
+		xi.send(ximPreeditCaretReply, mw)
+		xi.sendIME(ic, key.IMEPreedit, string(ic.preedit))
+	case ximPreeditDone:
+		mr.card16()
+		if ic := xi.icByID(mr.card16()); ic != nil && len(ic.preedit) > 0 {
+			ic.preedit = nil
+			ic.caret = 0
+			xi.sendIME(ic, key.IMEPreedit, "")
+		}
+	}
+}
+
+// icByID returns the input context with given id -- nil if none
+func (xi *ximImpl) icByID(id uint16) *ximIC {
+	for _, ic := range xi.ics {
+		if ic.id == id {
+			return ic
+		}
+	}
+	return nil
+}
+
+// decode decodes a string from the server
+func (xi *ximImpl) decode(b []byte) string {
+	if xi.utf8 {
+		return string(b)
+	}
+	return decodeCompoundText(b)
+}
+
+// sendIME sends a key.IMEEvent to the window of given input context
+func (xi *ximImpl) sendIME(ic *ximIC, act key.IMEActions, txt string) {
+	event := &key.IMEEvent{
+		Action: act,
+		Text:   txt,
+		Cursor: ic.caret,
+	}
+	event.Init()
+	ic.win.Send(event)
+}
+
+// keysymRune returns the unicode rune for given keysym -- 0 if none
+func keysymRune(ks uint32) rune {
+	switch {
+	case ks >= 0x20 && ks < 0x7f, ks >= 0xa0 && ks <= 0xff:
+		return rune(ks)
+	case ks&0xff000000 == 0x01000000:
+		return rune(ks & 0xffffff)
+	}
+	return 0
+}
+
+func clampInt(v, mn, mx int) int {
+	if v < mn {
+		return mn
+	}
+	if v > mx {
+		return mx
+	}
+	return v
+}
@@ -5,6 +5,7 @@
 package oswin
 
 import (
+	"bytes"
 	"fmt"
 	"image"
 	"time"
@@ -118,9 +119,18 @@ const (
 	// DNDFocusEvent is for Enter / Exit events of the DND into / out of a given widget
 	DNDFocusEvent
 
+	// IMEEvent is for composed text input from the input method (IME):
+	// preedit text being composed, and the final committed text
+	IMEEvent
+
 	// CustomEventType is a user-defined event with a data interface{} field
 	CustomEventType
 
+	// WindowStateEvent is a synthetic event sent to widget consumers,
+	// sent when the Minimized, Maximized, Fullscreen and / or AlwaysOnTop
+	// state of the window changes (action is StateChange)
+	WindowStateEvent
+
 	// number of event types
 	EventTypeN
 )
@@ -129,6 +139,16 @@ const (
 
 var KiT_EventType = kit.Enums.AddEnum(EventTypeN, false, nil)
 
+// MarshalJSON saves the event type by name, so saved values do not depend
+// on the order of the types
+func (ev EventType) MarshalJSON() ([]byte, error) { return kit.EnumMarshalJSON(ev) }
+
+// UnmarshalJSON sets the event type from its name, returning an error if it
+// is not a known type
+func (ev *EventType) UnmarshalJSON(b []byte) error {
+	return ev.FromString(string(bytes.Trim(b, "\"")))
+}
+
 // Event is the interface for oswin GUI events.  also includes Stringer
 // to get a string description of the event
 type Event interface {
@@ -0,0 +1,78 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package key
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/goki/gi/oswin"
+	"github.com/goki/ki/kit"
+)
+
+// key.IMEEvent reports composed text input from the input method (IME),
+// e.g., for entering Chinese, Japanese, or Korean text, or accented
+// characters via dead keys.  While text is being composed, a series of
+// IMEPreedit events are sent with the current (uncommitted) preedit text,
+// which should be shown in place at the cursor, and then a final IMECommit
+// event gives the text to actually insert.
+type IMEEvent struct {
+	oswin.EventBase
+
+	// Action is the IME action: IMEPreedit or IMECommit
+	Action IMEActions
+
+	// Text is the current preedit text (empty to clear the preedit), or the
+	// text to commit
+	Text string
+
+	// Cursor is the position of the cursor within the preedit text, in runes
+	Cursor int
+}
+
+// IMEActions are the different actions for IMEEvent
+type IMEActions int32
+
+const (
+	// IMEPreedit means that Text is the current preedit text, replacing any
+	// previous preedit text -- empty Text ends the preedit
+	IMEPreedit IMEActions = iota
+
+	// IMECommit means that Text is the final composed text to insert, and
+	// any preedit text should be removed
+	IMECommit
+
+	IMEActionsN
+)
+
+//go:generate stringer -type=IMEActions
+
+var KiT_IMEActions = kit.Enums.AddEnum(IMEActionsN, false, nil)
+
+func (ev IMEEvent) String() string {
+	return fmt.Sprintf("Type: %v  Action: %v  Text: %q  Cursor: %v  Time: %v", ev.Type(), ev.Action, ev.Text, ev.Cursor, ev.Time())
+}
+
+/////////////////////////////
+// oswin.Event interface
+
+func (ev IMEEvent) Type() oswin.EventType {
+	return oswin.IMEEvent
+}
+
+func (ev IMEEvent) HasPos() bool {
+	return false
+}
+
+func (ev IMEEvent) Pos() image.Point {
+	return image.ZP
+}
+
+func (ev IMEEvent) OnFocus() bool {
+	return true
+}
+
+// check for interface implementation
+var _ oswin.Event = &IMEEvent{}
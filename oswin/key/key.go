@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"image"
 	"strings"
+	"sync"
 	"unicode"
 
 	"github.com/goki/gi/oswin"
@@ -162,6 +163,53 @@ func (ch Chord) Decode() (r rune, mods int32, err error) {
 	return
 }
 
+// ChordEvent returns a new ChordEvent that generates this chord -- the
+// inverse of Event.Chord -- e.g., for synthesizing keyboard input
+func (ch Chord) ChordEvent() (*ChordEvent, error) {
+	mods, cs := ModsFmString(string(ch))
+	ce := &ChordEvent{}
+	ce.Modifiers = mods
+	ce.Action = Press
+	rs := ([]rune)(cs)
+	switch {
+	case len(rs) == 1:
+		ce.Rune = rs[0]
+		if mods != 0 { // modded keys are uppercase in chords
+			ce.Rune = unicode.ToLower(ce.Rune)
+		}
+	case cs == "Spacebar":
+		ce.Rune = ' '
+		ce.Code = CodeSpacebar
+	default:
+		ce.Code = CodeFmName(cs)
+		if ce.Code == CodeUnknown {
+			return nil, fmt.Errorf("gi.oswin.key.Chord: unknown key name: %v in chord: %v", cs, ch)
+		}
+	}
+	return ce, nil
+}
+
+// codeNames maps code names (without the Code prefix) to codes -- built on
+// first use by CodeFmName
+var codeNames map[string]Codes
+
+var codeNamesOnce sync.Once
+
+// CodeFmName returns the key code with given name, without the "Code"
+// prefix, as used in chords (e.g., "UpArrow") -- CodeUnknown if not found
+func CodeFmName(nm string) Codes {
+	codeNamesOnce.Do(func() {
+		codeNames = make(map[string]Codes)
+		for c := CodeUnknown; c <= CodeCompose; c++ {
+			cs := c.String()
+			if strings.HasPrefix(cs, "Code") {
+				codeNames[strings.TrimPrefix(cs, "Code")] = c
+			}
+		}
+	})
+	return codeNames[nm]
+}
+
 // Shortcut transforms chord string into short form suitable for display to users
 func (ch Chord) Shortcut() string {
 	cs := strings.Replace(string(ch), "Control+", "^", -1) // ⌃ doesn't look as good
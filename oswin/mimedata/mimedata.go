@@ -16,12 +16,18 @@ import (
 	"bytes"
 	"encoding/base64"
 	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
 	"io"
 	"io/ioutil"
 	"log"
 	"mime"
 	"mime/multipart"
 	"net/textproto"
+	"net/url"
+	"path/filepath"
 	"strings"
 
 	"github.com/goki/pi/filecat"
@@ -33,6 +39,18 @@ const (
 	ContentTransferEncoding = "Content-Transfer-Encoding"
 )
 
+// TextHTML is the MIME type for HTML-formatted text, e.g., syntax-highlighted
+// code or rich text
+const TextHTML = "text/html"
+
+// ImagePNG is the MIME type for PNG images -- this is the standard format
+// for exchanging images on the clipboard
+const ImagePNG = "image/png"
+
+// TextURIList is the standard MIME type for a list of URIs, one per line --
+// this is how files are exchanged in drag-n-drop with other applications
+const TextURIList = "text/uri-list"
+
 var MIMEVersion1B = ([]byte)(MIMEVersion1)
 var ContentTypeB = ([]byte)(ContentType)
 var ContentTransferEncodingB = ([]byte)(ContentTransferEncoding)
@@ -58,6 +76,34 @@ func NewTextDataBytes(text []byte) *Data {
 	return &Data{filecat.TextPlain, text}
 }
 
+// NewFileURIs returns a text/uri-list Data representation of the given file
+// paths, as file:// URIs -- relative paths are made absolute
+func NewFileURIs(paths []string) *Data {
+	var b strings.Builder
+	for _, p := range paths {
+		if ap, err := filepath.Abs(p); err == nil {
+			p = ap
+		}
+		u := url.URL{Scheme: "file", Path: filepath.ToSlash(p)}
+		b.WriteString(u.String() + "\r\n")
+	}
+	return &Data{TextURIList, []byte(b.String())}
+}
+
+// NewHTMLData returns a text/html Data representation of the given HTML
+func NewHTMLData(html string) *Data {
+	return &Data{TextHTML, []byte(html)}
+}
+
+// NewImageData returns an image/png Data representation of the given image
+func NewImageData(img image.Image) (*Data, error) {
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return nil, err
+	}
+	return &Data{ImagePNG, b.Bytes()}, nil
+}
+
 // IsText returns true if type is any of the text/ types (literally looks for that at start of Type) or is another known text type (e.g., AppJSON, XML)
 func IsText(typ string) bool {
 	if strings.HasPrefix(typ, "text/") {
@@ -69,6 +115,58 @@ func IsText(typ string) bool {
 	return false
 }
 
+// IsImage returns true if type is any of the image/ types
+func IsImage(typ string) bool {
+	return strings.HasPrefix(typ, "image/")
+}
+
+// CanDecodeImage returns true if type is an image type that can be decoded
+// by DecodeImage (png, jpeg, gif)
+func CanDecodeImage(typ string) bool {
+	switch typ {
+	case ImagePNG, "image/jpeg", "image/gif":
+		return true
+	}
+	return false
+}
+
+// DecodeImage decodes the image in given Data, which must be of one of the
+// types supported by CanDecodeImage
+func DecodeImage(d *Data) (image.Image, error) {
+	if !CanDecodeImage(d.Type) {
+		return nil, fmt.Errorf("mimedata.DecodeImage: unsupported image type: %v", d.Type)
+	}
+	img, _, err := image.Decode(bytes.NewReader(d.Data))
+	return img, err
+}
+
+// ConvertImage returns the image in given Data converted to given image
+// type (png, jpeg, gif) -- returns d itself if it is already of that type
+func ConvertImage(d *Data, typ string) (*Data, error) {
+	if d.Type == typ {
+		return d, nil
+	}
+	img, err := DecodeImage(d)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	switch typ {
+	case ImagePNG:
+		err = png.Encode(&b, img)
+	case "image/jpeg":
+		err = jpeg.Encode(&b, img, nil)
+	case "image/gif":
+		err = gif.Encode(&b, img, nil)
+	default:
+		err = fmt.Errorf("mimedata.ConvertImage: unsupported image type: %v", typ)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Data{typ, b.Bytes()}, nil
+}
+
 // Mimes is a slice of mime data, potentially encoding the same data in
 // different formats -- this is used for all oswin API's for maximum
 // flexibility
@@ -107,6 +205,22 @@ func NewMime(typ string, data []byte) Mimes {
 	return mi
 }
 
+// NewTextHTML returns a Mimes representation of rich text as a text/plain
+// version plus the text/html version
+func NewTextHTML(text, html string) Mimes {
+	return Mimes{NewTextData(text), NewHTMLData(html)}
+}
+
+// NewImage returns a Mimes representation of the image as a single
+// image/png Data
+func NewImage(img image.Image) (Mimes, error) {
+	md, err := NewImageData(img)
+	if err != nil {
+		return nil, err
+	}
+	return Mimes{md}, nil
+}
+
 // HasType returns true if Mimes has given type of data available
 func (mi Mimes) HasType(typ string) bool {
 	for _, d := range mi {
@@ -127,6 +241,65 @@ func (mi Mimes) TypeData(typ string) []byte {
 	return nil
 }
 
+// ImageData returns the first image element that can be decoded by
+// DecodeImage -- nil if none
+func (mi Mimes) ImageData() *Data {
+	for _, d := range mi {
+		if CanDecodeImage(d.Type) {
+			return d
+		}
+	}
+	return nil
+}
+
+// Image returns the decoded image from the first image element that can be
+// decoded -- nil if none
+func (mi Mimes) Image() image.Image {
+	d := mi.ImageData()
+	if d == nil {
+		return nil
+	}
+	img, err := DecodeImage(d)
+	if err != nil {
+		log.Printf("mimedata.Image: %v\n", err)
+		return nil
+	}
+	return img
+}
+
+// URIs returns all the URIs in any text/uri-list elements, skipping
+// comment lines
+func (mi Mimes) URIs() []string {
+	var uris []string
+	for _, d := range mi {
+		if d.Type != TextURIList {
+			continue
+		}
+		for _, ln := range strings.Split(string(d.Data), "\n") {
+			ln = strings.TrimSpace(ln)
+			if ln == "" || ln[0] == '#' {
+				continue
+			}
+			uris = append(uris, ln)
+		}
+	}
+	return uris
+}
+
+// FilePaths returns the local file paths for all the file:// URIs in any
+// text/uri-list elements
+func (mi Mimes) FilePaths() []string {
+	var paths []string
+	for _, us := range mi.URIs() {
+		u, err := url.Parse(us)
+		if err != nil || u.Scheme != "file" {
+			continue
+		}
+		paths = append(paths, filepath.FromSlash(u.Path))
+	}
+	return paths
+}
+
 // Text extracts all the text elements of given type as a string
 func (mi Mimes) Text(typ string) string {
 	str := ""
@@ -243,5 +416,3 @@ func FromMultipart(body []byte, boundary string) Mimes {
 	}
 	return mi
 }
-
-// todo: image, etc extractors
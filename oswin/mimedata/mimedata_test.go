@@ -0,0 +1,60 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mimedata
+
+import (
+	"image"
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestFileURIs(t *testing.T) {
+	paths := []string{"/home/user/a file.txt", "/tmp/b.go"}
+	d := NewFileURIs(paths)
+	if d.Type != TextURIList {
+		t.Errorf("type: %v != %v\n", d.Type, TextURIList)
+	}
+	ex := "file:///home/user/a%20file.txt\r\nfile:///tmp/b.go\r\n"
+	if string(d.Data) != ex {
+		t.Errorf("uri list: %q != %q\n", string(d.Data), ex)
+	}
+	mi := Mimes{d, NewTextData("other"), &Data{TextURIList, []byte("# comment\nhttp://example.com/\n")}}
+	if fp := mi.FilePaths(); !reflect.DeepEqual(fp, paths) {
+		t.Errorf("file paths: %v != %v\n", fp, paths)
+	}
+	if us := mi.URIs(); len(us) != 3 || us[2] != "http://example.com/" {
+		t.Errorf("uris: %v\n", us)
+	}
+}
+
+func TestImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 2, color.RGBA{255, 0, 0, 255})
+	d, err := NewImageData(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mi := Mimes{NewTextData("img"), d}
+	isMulti, _, boundary, body := IsMultipart(mi.ToMultipart())
+	if !isMulti {
+		t.Fatalf("not multipart\n")
+	}
+	mi = FromMultipart(body, boundary)
+	got := mi.Image()
+	if got == nil {
+		t.Fatalf("no image after multipart round trip\n")
+	}
+	if r, _, _, _ := got.At(1, 2).RGBA(); r != 0xffff || got.Bounds() != img.Bounds() {
+		t.Errorf("image mismatch: %v %v\n", got.At(1, 2), got.Bounds())
+	}
+	jd, err := ConvertImage(mi.ImageData(), "image/jpeg")
+	if err != nil || jd.Type != "image/jpeg" {
+		t.Fatalf("convert to jpeg: %v\n", err)
+	}
+	if pd, err := ConvertImage(jd, ImagePNG); err != nil || pd.Type != ImagePNG {
+		t.Errorf("convert back to png: %v\n", err)
+	}
+}
@@ -7,9 +7,9 @@ import (
 	"strconv"
 )
 
-const _Platforms_name = "MacOSLinuxX11WindowsPlatformsN"
+const _Platforms_name = "MacOSLinuxX11WindowsOffscreenPlatformsN"
 
-var _Platforms_index = [...]uint8{0, 5, 13, 20, 30}
+var _Platforms_index = [...]uint8{0, 5, 13, 20, 29, 39}
 
 func (i Platforms) String() string {
 	if i < 0 || i >= Platforms(len(_Platforms_index)-1) {
@@ -13,6 +13,7 @@ import (
 	"image"
 	"unicode/utf8"
 
+	"github.com/goki/gi/oswin/mimedata"
 	"github.com/goki/ki/bitflag"
 	"github.com/goki/ki/kit"
 )
@@ -118,6 +119,55 @@ type Window interface {
 	// input etc).
 	IsFocus() bool
 
+	// IsMaximized returns true if this window is maximized.
+	IsMaximized() bool
+
+	// IsAlwaysOnTop returns true if this window is kept above all other
+	// windows.
+	IsAlwaysOnTop() bool
+
+	// IsFrameless returns true if this window has no OS-level decorations
+	// (title bar, borders) -- the app draws its own title bar, and sets
+	// DragRegions to allow the user to move the window.
+	IsFrameless() bool
+
+	// SetMaximized requests that the window be maximized, or restored to
+	// its previous geometry if max is false.  A window.StateChange event is
+	// sent when the state actually changes, where supported by the driver.
+	SetMaximized(max bool)
+
+	// SetFullscreen requests that the window occupy the entire screen, or
+	// be restored to its previous geometry if fs is false.  A
+	// window.StateChange event is sent when the state actually changes, where
+	// supported by the driver.
+	SetFullscreen(fs bool)
+
+	// SetAlwaysOnTop requests that the window be kept above all other
+	// windows, or not.  A window.StateChange event is sent when the state
+	// actually changes, where supported by the driver.
+	SetAlwaysOnTop(top bool)
+
+	// Opacity returns the opacity of the whole window, 0-1 (1 = opaque).
+	Opacity() float32
+
+	// SetOpacity sets the opacity of the whole window, 0-1 (1 = opaque),
+	// where supported by the driver (typically requires a compositing window
+	// manager).
+	SetOpacity(op float32)
+
+	// DragRegions returns the regions of a frameless window, in window
+	// coordinates, where pressing the left mouse button starts moving the
+	// window.
+	DragRegions() []image.Rectangle
+
+	// SetDragRegions sets the regions of a frameless window, in window
+	// coordinates, where pressing the left mouse button starts moving the
+	// window, as in the title bar of a regular window -- typically the
+	// app-drawn title bar, excluding any buttons within it.  Mouse presses
+	// in these regions are not delivered as mouse events, where supported
+	// by the driver.
+	SetDragRegions(regs []image.Rectangle)
+
 	// SetCloseReqFunc sets the function that is called whenever there is a
 	// request to close the window (via a OS or a call to CloseReq() method).  That
 	// function can then adjudicate whether and when to actually call Close.
@@ -143,6 +193,21 @@ type Window interface {
 	// is undefined.  See App.Quit methods to quit overall app.
 	Close()
 
+	// StartDragNDrop tells the window that a drag-n-drop of given data has
+	// started within it, so that the data can be dragged out to other
+	// applications if the mouse leaves the window, where supported by the
+	// driver.  When the drop is completed by another application, a
+	// dnd.Event with Action = dnd.DropFmSource is sent to the window.  Drops
+	// from other applications arrive as regular dnd events with a nil Source.
+	StartDragNDrop(data mimedata.Mimes)
+
+	// SetIMECursor tells the input method (IME) where the text cursor of the
+	// widget with keyboard focus is, in window coordinates, so it can
+	// position its candidate window next to it -- an empty rectangle means
+	// that there is no text input, disabling the IME.  Composed text input
+	// is delivered as key.IMEEvent events, where supported by the driver.
+	SetIMECursor(r image.Rectangle)
+
 	EventDeque
 
 	Uploader
@@ -173,6 +238,8 @@ type WindowBase struct {
 	Scrn    *Screen
 	Par     interface{}
 	Flag    int64
+	Opac    float32
+	DragRgs []image.Rectangle
 }
 
 func (w WindowBase) Name() string {
@@ -223,6 +290,80 @@ func (w *WindowBase) IsFocus() bool {
 	return bitflag.HasAtomic(&w.Flag, int(Focus))
 }
 
+func (w *WindowBase) IsMaximized() bool {
+	return bitflag.HasAtomic(&w.Flag, int(Maximized))
+}
+
+func (w *WindowBase) IsAlwaysOnTop() bool {
+	return bitflag.HasAtomic(&w.Flag, int(AlwaysOnTop))
+}
+
+func (w *WindowBase) IsFrameless() bool {
+	return bitflag.HasAtomic(&w.Flag, int(Frameless))
+}
+
+// SetMaximized does nothing by default -- drivers that support changing
+// the window state override it
+func (w *WindowBase) SetMaximized(max bool) {
+}
+
+// SetFullscreen does nothing by default -- drivers that support changing
+// the window state override it
+func (w *WindowBase) SetFullscreen(fs bool) {
+}
+
+// SetAlwaysOnTop does nothing by default -- drivers that support changing
+// the window state override it
+func (w *WindowBase) SetAlwaysOnTop(top bool) {
+}
+
+// Opacity returns the Opac value, where 0 means not set, i.e., opaque --
+// drivers that set Opac or DragRgs outside of the app goroutine guard these
+// methods with their window mutex
+func (w *WindowBase) Opacity() float32 {
+	if w.Opac == 0 {
+		return 1
+	}
+	return w.Opac
+}
+
+// SetOpacity does nothing by default -- drivers that support window
+// opacity override it
+func (w *WindowBase) SetOpacity(op float32) {
+}
+
+func (w *WindowBase) DragRegions() []image.Rectangle {
+	return w.DragRgs
+}
+
+func (w *WindowBase) SetDragRegions(regs []image.Rectangle) {
+	w.DragRgs = regs
+}
+
+// InDragRegion returns true if given window position is within one of the
+// DragRegions of a frameless window
+func (w *WindowBase) InDragRegion(pos image.Point) bool {
+	if !w.IsFrameless() {
+		return false
+	}
+	for _, r := range w.DragRgs {
+		if pos.In(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// StartDragNDrop does nothing by default -- drivers that support drag-n-drop
+// with other applications override it
+func (w *WindowBase) StartDragNDrop(data mimedata.Mimes) {
+}
+
+// SetIMECursor does nothing by default -- drivers that support input
+// methods override it
+func (w *WindowBase) SetIMECursor(r image.Rectangle) {
+}
+
 ////////////////////////////////////////////////////////////////////////////
 // WindowOptions
 
@@ -255,6 +396,17 @@ const (
 	// Focus indicates that the window has the focus.
 	Focus
 
+	// Maximized indicates a window that is maximized to fill the available
+	// space on the screen, while still showing its decorations.
+	Maximized
+
+	// AlwaysOnTop indicates a window that is kept above all other windows.
+	AlwaysOnTop
+
+	// Frameless indicates a window without any OS-level decorations -- the
+	// app draws its own title bar etc, and sets DragRegions.
+	Frameless
+
 	WindowFlagsN
 )
 
@@ -283,6 +435,10 @@ type NewWindowOptions struct {
 
 	// Flags can be set using WindowFlags to request different types of windows
 	Flags int64
+
+	// Opacity is the opacity of the whole window, 0-1 -- 0 means the
+	// default of fully opaque
+	Opacity float32
 }
 
 func (o *NewWindowOptions) SetDialog() {
@@ -301,6 +457,18 @@ func (o *NewWindowOptions) SetFullscreen() {
 	bitflag.Set(&o.Flags, int(Fullscreen))
 }
 
+func (o *NewWindowOptions) SetMaximized() {
+	bitflag.Set(&o.Flags, int(Maximized))
+}
+
+func (o *NewWindowOptions) SetAlwaysOnTop() {
+	bitflag.Set(&o.Flags, int(AlwaysOnTop))
+}
+
+func (o *NewWindowOptions) SetFrameless() {
+	bitflag.Set(&o.Flags, int(Frameless))
+}
+
 func WindowFlagsToBool(flags int64) (dialog, modal, tool, fullscreen bool) {
 	dialog = bitflag.Has(flags, int(Dialog))
 	modal = bitflag.Has(flags, int(Modal))
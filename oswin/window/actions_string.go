@@ -7,9 +7,9 @@ import (
 	"strconv"
 )
 
-const _Actions_name = "CloseMinimizeResizeMoveFocusDeFocusPaintShowActionsN"
+const _Actions_name = "CloseMinimizeResizeMoveFocusDeFocusPaintShowScreenUpdateStateChangeActionsN"
 
-var _Actions_index = [...]uint8{0, 5, 13, 19, 23, 28, 35, 40, 44, 52}
+var _Actions_index = [...]uint8{0, 5, 13, 19, 23, 28, 35, 40, 44, 56, 67, 75}
 
 func (i Actions) String() string {
 	if i < 0 || i >= Actions(len(_Actions_index)-1) {
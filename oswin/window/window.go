@@ -68,6 +68,19 @@ const (
 	// Show is for the WindowShow event
 	Show
 
+	// ScreenUpdate means that the screen the window is on has changed, either
+	// because the window moved to a different screen, or because the screens
+	// were reconfigured (added, removed, resized, or changed resolution) --
+	// the window Screen() and PhysicalDPI() have the new values, and the
+	// logical DPI typically needs to be updated, requiring a full re-render.
+	ScreenUpdate
+
+	// StateChange means that the Minimized, Maximized, Fullscreen and / or
+	// AlwaysOnTop state of the window has changed -- consult the window
+	// flags for the new state.  Any change in size is sent separately as a
+	// Resize event.
+	StateChange
+
 	ActionsN
 )
 
@@ -125,3 +138,15 @@ type FocusEvent struct {
 func (ev FocusEvent) Type() oswin.EventType {
 	return oswin.WindowFocusEvent
 }
+
+// window.StateEvent is for synthetic window state event that is sent to widget
+// consumers when the Minimized, Maximized, Fullscreen and / or AlwaysOnTop
+// state of the window changes (action is StateChange) -- consult the window
+// flags for the new state.
+type StateEvent struct {
+	Event
+}
+
+func (ev StateEvent) Type() oswin.EventType {
+	return oswin.WindowStateEvent
+}
@@ -48,8 +48,24 @@ func (g *Group) Render2D() {
 	rs := &g.Viewport.Render
 	rs.PushXFormLock(pc.XForm)
 
+	layer := g.HasLayer()
+	if layer {
+		rs.PushLayer(rs.Bounds, pc.FontStyle.Opacity, pc.FontStyle.Blend, gi.CompSrcOver)
+	}
 	g.Render2DChildren()
+	if layer {
+		rs.PopLayer()
+	}
 	g.ComputeBBoxSVG()
 
 	rs.PopXFormLock()
 }
+
+// HasLayer returns true if the group is rendered as a whole into a
+// compositing layer, for its opacity or mix-blend-mode -- its children do
+// not inherit its opacity, so overlapping children are not seen through
+// each other
+func (g *Group) HasLayer() bool {
+	pc := &g.Pnt
+	return pc.FontStyle.Opacity < 1 || pc.FontStyle.Blend != gi.BlendNormal
+}
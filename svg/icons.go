@@ -15,6 +15,7 @@ import (
 	"strings"
 
 	"github.com/goki/gi/gi"
+	"github.com/goki/gi/oswin/cursor"
 	"github.com/goki/gi/units"
 	"github.com/goki/ki"
 	"github.com/goki/ki/dirs"
@@ -92,7 +93,7 @@ func (ic *Icon) Render2D() {
 		ic.FullRender2DTree()
 		return
 	}
-	if ic.NeedsReRender() {
+	if ic.NeedsReRender() || ic.IsRecording() {
 		if ic.PushBounds() {
 			rs := &ic.Render
 			if ic.Fill {
@@ -110,6 +111,33 @@ func (ic *Icon) Render2D() {
 	ic.RenderViewport2D() // update our parent image
 }
 
+// AddCursor registers a custom cursor with given name rendered from given
+// icon, with given nominal size and hot spot (in pixels at cursor.BaseDPI)
+// -- the icon is re-rendered at full resolution for each DPI, filled black
+// with a white outline.  Returns the cursor shape to use with Push etc.
+func AddCursor(name string, ic *Icon, size, hotSpot image.Point) cursor.Shapes {
+	return cursor.AddCustomRender(name, size, hotSpot, func(sz image.Point) image.Image {
+		cic := &Icon{}
+		cic.InitName(cic, name)
+		cic.CopyFromIcon(ic)
+		cic.Resize(sz)
+		if cic.Pixels == nil {
+			return nil
+		}
+		cic.SetProp("fill", "black")
+		cic.SetProp("stroke", "white")
+		cic.Norm = true        // standalone icon renders as a plain normalized SVG
+		cic.FullRender2DTree() // init, style and layout
+		cic.SVG.Render2D()
+		img := image.NewRGBA(cic.Pixels.Bounds())
+		copy(img.Pix, cic.Pixels.Pix)
+		if cic.OSImage != nil {
+			cic.OSImage.Release()
+		}
+		return img
+	})
+}
+
 ////////////////////////////////////////////////////////////////////////////////////////
 // IconMgr
 
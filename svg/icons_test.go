@@ -0,0 +1,39 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/gitest"
+	"github.com/goki/gi/oswin/cursor"
+	"github.com/goki/gi/svg"
+)
+
+func TestMain(m *testing.M) {
+	gitest.Main(m)
+}
+
+func TestAddCursor(t *testing.T) {
+	ic := &svg.Icon{}
+	ic.InitName(ic, "box")
+	ic.ViewBox.Size = gi.NewVec2D(1, 1)
+	bx := ic.AddNewChild(svg.KiT_Rect, "bx").(*svg.Rect)
+	bx.Pos.Set(0.1, 0.1)
+	bx.Size.Set(0.8, 0.8)
+	sh := svg.AddCursor("test-box", ic, image.Point{16, 16}, image.Point{8, 8})
+	if sh2 := svg.AddCursor("test-box", ic, image.Point{16, 16}, image.Point{8, 8}); sh2 != sh {
+		t.Errorf("re-registered cursor shape: %v != %v\n", sh2, sh)
+	}
+	img, hot := cursor.CustomShape(sh).ImageForDPI(2 * cursor.BaseDPI)
+	if img.Bounds().Size() != (image.Point{32, 32}) || hot != (image.Point{16, 16}) {
+		t.Errorf("cursor size: %v, hot spot: %v at 2x DPI\n", img.Bounds().Size(), hot)
+	}
+	if c := img.RGBAAt(16, 16); c.A == 0 {
+		t.Errorf("cursor center pixel not rendered: %v\n", c)
+	}
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/goki/gi/gi"
 	"github.com/goki/gi/units"
+	"github.com/srwiley/rasterx"
 	"golang.org/x/net/html/charset"
 )
 
@@ -544,6 +545,59 @@ func (svg *SVG) UnmarshalXML(decoder *xml.Decoder, se xml.StartElement) error {
 				}
 				mrk.RefPos.Set(rx, ry)
 				mrk.Size.Set(szx, szy)
+			case nm == "pattern":
+				curPar = curPar.AddNewChild(KiT_Pattern, "pattern").(gi.Node2D)
+				pat := curPar.(*Pattern)
+				pat.Pat.Units = rasterx.ObjectBoundingBox
+				pat.Pat.XForm = gi.Identity2D()
+				pat.Pat.Repeat = gi.BgRepeat
+				for _, attr := range se.Attr {
+					if pat.SetStdXMLAttr(attr.Name.Local, attr.Value) {
+						continue
+					}
+					switch attr.Name.Local {
+					case "x":
+						pat.Pat.Pos.X, err = gi.ParseFloat32(attr.Value)
+					case "y":
+						pat.Pat.Pos.Y, err = gi.ParseFloat32(attr.Value)
+					case "width":
+						pat.Pat.Size.X, err = gi.ParseFloat32(attr.Value)
+					case "height":
+						pat.Pat.Size.Y, err = gi.ParseFloat32(attr.Value)
+					case "patternUnits":
+						if attr.Value == "userSpaceOnUse" {
+							pat.Pat.Units = rasterx.UserSpaceOnUse
+						} else {
+							pat.Pat.Units = rasterx.ObjectBoundingBox
+						}
+					case "patternTransform":
+						err = pat.Pat.XForm.SetString(attr.Value)
+					case "viewBox":
+						pts := gi.ReadPoints(attr.Value)
+						if len(pts) != 4 {
+							return paramMismatchError
+						}
+						pat.ViewBox.Min.X = pts[0]
+						pat.ViewBox.Min.Y = pts[1]
+						pat.ViewBox.Size.X = pts[2]
+						pat.ViewBox.Size.Y = pts[3]
+					case "href":
+						nm := strings.TrimPrefix(attr.Value, "#")
+						hr, ok := curPar.Parent().ChildByName(nm, 0)
+						if hrp, isp := hr.(*Pattern); ok && isp {
+							pat.Pat.Units = hrp.Pat.Units
+							pat.Pat.Pos = hrp.Pat.Pos
+							pat.Pat.Size = hrp.Pat.Size
+							pat.Pat.XForm = hrp.Pat.XForm
+							pat.ViewBox = hrp.ViewBox
+						}
+					default:
+						pat.SetProp(attr.Name.Local, attr.Value)
+					}
+					if err != nil {
+						return err
+					}
+				}
 			case nm == "use":
 				link := gi.XMLAttr("href", se.Attr)
 				itm := curPar.FindNamedElement(link)
@@ -72,6 +72,10 @@ func StyleSVG(gii gi.Node2D) {
 	pp := g.ParentPaint()
 	if pp != nil {
 		pc.CopyStyleFrom(pp)
+		if _, ok := g.Par.(*Group); ok { // the group layer applies these
+			pc.FontStyle.Opacity = 1
+			pc.FontStyle.Blend = gi.BlendNormal
+		}
 		pc.SetStyleProps(pp, *gii.Properties(), g.Viewport)
 	} else {
 		pc.SetStyleProps(nil, *gii.Properties(), g.Viewport)
@@ -97,10 +101,6 @@ func StyleSVG(gii gi.Node2D) {
 // ApplyCSSSVG applies css styles to given node, using key to select sub-props
 // from overall properties list
 func ApplyCSSSVG(node gi.Node2D, key string, css ki.Props) bool {
-	pntr, ok := node.(gi.Painter)
-	if !ok {
-		return false
-	}
 	pp, got := css[key]
 	if !got {
 		return false
@@ -109,6 +109,15 @@ func ApplyCSSSVG(node gi.Node2D, key string, css ki.Props) bool {
 	if !ok {
 		return false
 	}
+	return applyPropsSVG(node, pmap)
+}
+
+// applyPropsSVG sets the paint style of given node from given style props
+func applyPropsSVG(node gi.Node2D, pmap ki.Props) bool {
+	pntr, ok := node.(gi.Painter)
+	if !ok {
+		return false
+	}
 	nb := node.AsNode2D()
 	pc := pntr.Paint()
 
@@ -124,15 +133,13 @@ func ApplyCSSSVG(node gi.Node2D, key string, css ki.Props) bool {
 	return true
 }
 
-// StyleCSS applies css style properties to given SVG node, parsing
-// out type, .class, and #name selectors
+// StyleCSS applies css style properties to given SVG node, for all the
+// rules whose selectors match the node, in order of specificity -- see
+// gi.MatchCSS
 func StyleCSS(node gi.Node2D, css ki.Props) {
-	tyn := strings.ToLower(node.Type().Name()) // type is most general, first
-	ApplyCSSSVG(node, tyn, css)
-	cln := "." + strings.ToLower(node.AsNode2D().Class) // then class
-	ApplyCSSSVG(node, cln, css)
-	idnm := "#" + strings.ToLower(node.Name()) // then name
-	ApplyCSSSVG(node, idnm, css)
+	for _, pmap := range gi.MatchCSS(node, css, "") {
+		applyPropsSVG(node, pmap)
+	}
 }
 
 func (g *NodeBase) Style2D() {
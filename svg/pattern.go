@@ -0,0 +1,54 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package svg
+
+import (
+	"github.com/goki/gi/gi"
+	"github.com/goki/ki/kit"
+)
+
+// Pattern is the SVG pattern element, which paints by tiling the plane with
+// its children -- it is defined in the Defs and referenced as url(#name) in
+// fill or stroke properties, and is not rendered itself
+type Pattern struct {
+	NodeBase
+	Pat     gi.Pattern `desc:"the pattern paint server, with the position, size, units and transform of the tiles"`
+	ViewBox ViewBox    `desc:"viewbox defines the coordinate system for the children within a tile, if set"`
+}
+
+var KiT_Pattern = kit.Types.AddType(&Pattern{}, nil)
+
+// Pattern satisfies the gi.Patterner interface, returning the pattern that
+// draws our children
+func (p *Pattern) Pattern() *gi.Pattern {
+	p.Pat.Drawer = p
+	return &p.Pat
+}
+
+// DrawPattern satisfies the gi.PatternDrawer interface, rendering our
+// children into a tile of given size -- they render into the render state
+// of our viewport, so the pattern can only be used within its svg
+func (p *Pattern) DrawPattern(rs *gi.RenderState, size gi.Vec2D) {
+	if p.Viewport == nil || rs != &p.Viewport.Render {
+		return
+	}
+	xf := gi.Identity2D()
+	if !p.ViewBox.Size.IsZero() {
+		xf = gi.Scale2D(size.X/p.ViewBox.Size.X, size.Y/p.ViewBox.Size.Y).Translate(-p.ViewBox.Min.X, -p.ViewBox.Min.Y)
+	}
+	rs.PushXForm(xf)
+	p.Render2DChildren()
+	rs.PopXForm()
+}
+
+func (p *Pattern) Style2D() {
+	StyleSVG(p.This().(gi.Node2D))
+	p.Pat.Update() // children may have changed
+}
+
+// Render2D does nothing -- the children are only rendered as tiles of the
+// pattern, when painting elements that use it
+func (p *Pattern) Render2D() {
+}
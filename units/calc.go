@@ -0,0 +1,264 @@
+// Copyright (c) 2018, The GoKi Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package units
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Calc is a CSS calc() expression of lengths, e.g., calc(100% - 2em) --
+// all units convert linearly into dots, so any valid expression (sums of
+// lengths, multiplied or divided by numbers) reduces to a sum of one term
+// per unit, which is converted when the unit context is known.
+type Calc struct {
+	Terms []Value `desc:"the terms of the sum, at most one per unit"`
+}
+
+// ToDots returns the value of the expression in dots, for given context
+func (c *Calc) ToDots(ctxt *Context) float32 {
+	var dots float32
+	for _, t := range c.Terms {
+		dots += ctxt.ToDots(t.Val, t.Un)
+	}
+	return dots
+}
+
+// String returns the expression in CSS form, e.g., calc(100pct - 2em)
+func (c *Calc) String() string {
+	var sb strings.Builder
+	sb.WriteString("calc(")
+	for i, t := range c.Terms {
+		val := t.Val
+		if i > 0 {
+			if val < 0 {
+				sb.WriteString(" - ")
+				val = -val
+			} else {
+				sb.WriteString(" + ")
+			}
+		}
+		sb.WriteString(strconv.FormatFloat(float64(val), 'g', -1, 32) + UnitNames[t.Un])
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// IsCalc returns true if the string is a calc() expression
+func IsCalc(str string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(str)), "calc(")
+}
+
+// ParseCalc parses a calc() expression of lengths in any units, numbers
+// (which are in px when added to lengths, and can have exponents, e.g.,
+// 1e3), + - * / and parentheses (which can also be nested calc()'s), into
+// a Value with the expression in Calc -- as in CSS, + and - must have white
+// space on both sides
+func ParseCalc(str string) (Value, error) {
+	str = strings.TrimSpace(str)
+	if !IsCalc(str) {
+		return Value{}, fmt.Errorf("units.ParseCalc: not a calc() expression: %v", str)
+	}
+	cp := &calcParser{str: str}
+	cv, err := cp.expr()
+	if err == nil && cp.pos < len(cp.str) {
+		err = fmt.Errorf("unexpected: %v", cp.str[cp.pos:])
+	}
+	if err != nil {
+		return Value{}, fmt.Errorf("units.ParseCalc: %v in: %v", err, str)
+	}
+	c := &Calc{}
+	for un, val := range cv.terms {
+		if val != 0 {
+			c.Terms = append(c.Terms, NewValue(val, Unit(un)))
+		}
+	}
+	var uc Context
+	uc.Defaults()
+	v := Value{Calc: c}
+	v.ToDots(&uc) // an approximate value until converted in context
+	return v, nil
+}
+
+// calcVal is a value in a calc() expression: a sum of terms per unit, or a
+// number
+type calcVal struct {
+	terms [UnitN]float32
+	num   bool
+}
+
+// scale multiplies all the terms by f
+func (cv *calcVal) scale(f float32) {
+	for i := range cv.terms {
+		cv.terms[i] *= f
+	}
+}
+
+// calcParser is a recursive-descent parser for calc() expressions: the
+// whole expression is parsed as a factor, so calc( starts a parenthesized
+// expression
+type calcParser struct {
+	str string
+	pos int
+}
+
+// isSpace returns true if the byte at given position is white space
+func (cp *calcParser) isSpace(pos int) bool {
+	return pos >= 0 && pos < len(cp.str) && strings.IndexByte(" \t\n\r", cp.str[pos]) >= 0
+}
+
+// isDigit returns true if the byte at given position is a digit
+func (cp *calcParser) isDigit(pos int) bool {
+	return pos < len(cp.str) && cp.str[pos] >= '0' && cp.str[pos] <= '9'
+}
+
+// skipSpace advances past any white space
+func (cp *calcParser) skipSpace() {
+	for cp.isSpace(cp.pos) {
+		cp.pos++
+	}
+}
+
+// peek returns the next non-space byte, or 0 at the end
+func (cp *calcParser) peek() byte {
+	cp.skipSpace()
+	if cp.pos >= len(cp.str) {
+		return 0
+	}
+	return cp.str[cp.pos]
+}
+
+// expr parses a sum of terms -- as in CSS, the + and - operators must be
+// surrounded by white space, so they are not taken as signs of the numbers
+func (cp *calcParser) expr() (calcVal, error) {
+	cv, err := cp.term()
+	if err != nil {
+		return cv, err
+	}
+	for {
+		op := cp.peek()
+		if op != '+' && op != '-' {
+			return cv, nil
+		}
+		if !cp.isSpace(cp.pos-1) || !cp.isSpace(cp.pos+1) {
+			return cv, fmt.Errorf("%c must have white space on both sides", op)
+		}
+		cp.pos++
+		rv, err := cp.term()
+		if err != nil {
+			return cv, err
+		}
+		if op == '-' {
+			rv.scale(-1)
+		}
+		for i := range cv.terms {
+			cv.terms[i] += rv.terms[i]
+		}
+		cv.num = cv.num && rv.num
+	}
+}
+
+// term parses a product of factors
+func (cp *calcParser) term() (calcVal, error) {
+	cv, err := cp.factor()
+	if err != nil {
+		return cv, err
+	}
+	for {
+		op := cp.peek()
+		if op != '*' && op != '/' {
+			return cv, nil
+		}
+		cp.pos++
+		rv, err := cp.factor()
+		if err != nil {
+			return cv, err
+		}
+		switch {
+		case op == '/':
+			if !rv.num {
+				return cv, fmt.Errorf("can only divide by numbers")
+			}
+			if rv.terms[Px] == 0 {
+				return cv, fmt.Errorf("division by zero")
+			}
+			cv.scale(1 / rv.terms[Px])
+		case rv.num:
+			cv.scale(rv.terms[Px])
+		case cv.num:
+			rv.scale(cv.terms[Px])
+			cv = rv
+		default:
+			return cv, fmt.Errorf("can only multiply by numbers")
+		}
+	}
+}
+
+// factor parses a number or length, or a parenthesized expression
+func (cp *calcParser) factor() (calcVal, error) {
+	var cv calcVal
+	c := cp.peek()
+	switch {
+	case c == 0:
+		return cv, fmt.Errorf("unexpected end")
+	case c == '(' || strings.HasPrefix(strings.ToLower(cp.str[cp.pos:]), "calc("):
+		cp.pos = strings.IndexByte(cp.str[cp.pos:], '(') + cp.pos + 1
+		cv, err := cp.expr()
+		if err != nil {
+			return cv, err
+		}
+		if cp.peek() != ')' {
+			return cv, fmt.Errorf("missing )")
+		}
+		cp.pos++
+		return cv, nil
+	case c == '-' || c == '+':
+		cp.pos++
+		cv, err := cp.factor()
+		if c == '-' {
+			cv.scale(-1)
+		}
+		return cv, err
+	}
+	st := cp.pos
+	for cp.pos < len(cp.str) && (cp.str[cp.pos] == '.' || cp.isDigit(cp.pos)) {
+		cp.pos++
+	}
+	if cp.pos < len(cp.str) && cp.str[cp.pos]|0x20 == 'e' { // exponent, not a unit such as em
+		ep := cp.pos + 1
+		if ep < len(cp.str) && (cp.str[ep] == '-' || cp.str[ep] == '+') {
+			ep++
+		}
+		if cp.isDigit(ep) {
+			for cp.pos = ep; cp.isDigit(cp.pos); cp.pos++ {
+			}
+		}
+	}
+	val, err := strconv.ParseFloat(cp.str[st:cp.pos], 32)
+	if err != nil {
+		return cv, fmt.Errorf("bad number: %v", cp.str[st:])
+	}
+	ust := cp.pos
+	for cp.pos < len(cp.str) && (cp.str[cp.pos] == '%' || (cp.str[cp.pos]|0x20 >= 'a' && cp.str[cp.pos]|0x20 <= 'z')) {
+		cp.pos++
+	}
+	unm := strings.ToLower(cp.str[ust:cp.pos])
+	if unm == "" {
+		cv.num = true
+		cv.terms[Px] = float32(val)
+		return cv, nil
+	}
+	if unm == "%" {
+		unm = "pct"
+	}
+	for un, nm := range UnitNames {
+		if nm == unm {
+			cv.terms[un] = float32(val)
+			return cv, nil
+		}
+	}
+	return cv, fmt.Errorf("unknown unit: %v", unm)
+}
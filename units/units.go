@@ -269,11 +269,14 @@ func (uc *Context) DotsToPx(val float32) float32 {
 ////////////////////////////////////////////////////////////////////////
 //   Value
 
-// Value and units, and converted value into raw pixels (dots in DPI)
+// Value and units, and converted value into raw pixels (dots in DPI) --
+// values from calc() expressions have the terms in Calc, and Val and Un are
+// then the value in Dot units, as of the last ToDots
 type Value struct {
 	Val  float32
 	Un   Unit
 	Dots float32
+	Calc *Calc
 }
 
 var KiT_Value = kit.Types.AddType(&Value{}, ValueProps)
@@ -284,18 +287,24 @@ var ValueProps = ki.Props{
 
 // NewValue creates a new value with given units
 func NewValue(val float32, un Unit) Value {
-	return Value{val, un, 0.0}
+	return Value{Val: val, Un: un}
 }
 
 // Set sets value and units of an existing value
 func (v *Value) Set(val float32, un Unit) {
 	v.Val = val
 	v.Un = un
+	v.Calc = nil
 }
 
 // ToDots converts value to raw display pixels (dots as in DPI), setting also
 // the Dots field
 func (v *Value) ToDots(ctxt *Context) float32 {
+	if v.Calc != nil {
+		v.Dots = v.Calc.ToDots(ctxt)
+		v.Val, v.Un = v.Dots, Dot
+		return v.Dots
+	}
 	v.Dots = ctxt.ToDots(v.Val, v.Un)
 	return v.Dots
 }
@@ -309,16 +318,27 @@ func (v *Value) ToDotsFixed(ctxt *Context) fixed.Int26_6 {
 // Convert converts value to the given units, given unit context
 func (v *Value) Convert(to Unit, ctxt *Context) Value {
 	dots := v.ToDots(ctxt)
-	return Value{dots / ctxt.ToDotsFactor(to), to, dots}
+	return Value{Val: dots / ctxt.ToDotsFactor(to), Un: to, Dots: dots}
 }
 
 // String implements the fmt.Stringer interface.
 func (v *Value) String() string {
+	if v.Calc != nil {
+		return v.Calc.String()
+	}
 	return fmt.Sprintf("%f%s", v.Val, UnitNames[v.Un])
 }
 
-// SetString sets value from a string
+// SetString sets value from a string, which can also be a calc() expression
+// (see ParseCalc)
 func (v *Value) SetString(str string) {
+	if IsCalc(str) {
+		if cv, err := ParseCalc(str); err == nil {
+			*v = cv
+			return
+		}
+	}
+	v.Calc = nil
 	trstr := strings.TrimSpace(strings.Replace(str, "%", "pct", -1))
 	sz := len(trstr)
 	if sz < 2 {
@@ -31,3 +31,36 @@ func TestValCvt(t *testing.T) {
 		t.Errorf("strings don't match: %v != %v\n", s1, s2)
 	}
 }
+
+func TestCalc(t *testing.T) {
+	var ctxt Context
+	ctxt.Defaults()
+	ctxt.SetSizes(800, 600, 200, 100)
+	for _, tst := range []struct {
+		str  string
+		dots float32
+	}{
+		{"calc(100% - 2em)", 200 - 24},
+		{"calc(50% + 10px)", 110},
+		{"calc((100% - 20px) / 3)", 60},
+		{"calc(2 * 1in - calc(1in / 2))", 144},
+		{"CALC(-1em + 30)", 18},
+		{"calc(1vw*10 + 4)", 84},
+		{"calc(1e3px / 1e1)", 100},
+		{"calc(2.5E-1in * 4 - 2e+1)", 76},
+	} {
+		v := StringToValue(tst.str)
+		if v.Calc == nil {
+			t.Errorf("%v did not parse as calc\n", tst.str)
+			continue
+		}
+		if dots := v.ToDots(&ctxt); dots < tst.dots-0.01 || dots > tst.dots+0.01 {
+			t.Errorf("%v = %v (%v) dots, not %v\n", tst.str, dots, v.Calc, tst.dots)
+		}
+	}
+	for _, bad := range []string{"calc(1em * 2px)", "calc(1px / 0)", "calc(1px + )", "calc(2foo)", "calc(1px", "calc(10px+2px)", "calc(10px -2px)", "calc(1e3.5px)"} {
+		if _, err := ParseCalc(bad); err == nil {
+			t.Errorf("ParseCalc(%q) did not fail\n", bad)
+		}
+	}
+}